@@ -0,0 +1,264 @@
+package tea
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// accessibleInterval is how often the accessible renderer prints what
+// changed. Changes in between are printed together, so that a screen reader
+// isn't flooded by animations.
+const accessibleInterval = 250 * time.Millisecond
+
+// accessibleFromEnv reports whether the ACCESSIBLE environment variable asks
+// for the accessible renderer, by being set to a true value as parsed by
+// strconv.ParseBool, such as 1 or true.
+func accessibleFromEnv(getenv func(string) string) bool {
+	on, err := strconv.ParseBool(getenv("ACCESSIBLE"))
+	return err == nil && on
+}
+
+// LiveRegion is a part of a view which screen readers should announce when
+// it changes, such as a status line or the result of a search.
+type LiveRegion struct {
+	// Name identifies the region from one frame to the next.
+	Name string
+
+	// Content is what's announced. Escape sequences are stripped from it.
+	Content string
+}
+
+// LiveRegionModel is an optional interface models can implement to tell the
+// accessible renderer what to announce. LiveRegions is called after every
+// View when the accessible renderer is in use, and the regions whose content
+// changed since the last frame are announced, in order. The rest of the view
+// isn't announced.
+//
+//	func (m model) LiveRegions() []tea.LiveRegion {
+//	    return []tea.LiveRegion{
+//	        {Name: "status", Content: m.status},
+//	        {Name: "selection", Content: m.list.SelectedItem().Title()},
+//	    }
+//	}
+type LiveRegionModel interface {
+	Model
+
+	// LiveRegions returns the regions of the view to announce when they
+	// change.
+	LiveRegions() []LiveRegion
+}
+
+type announceMsg string
+
+// Announce is a command which makes the accessible renderer announce a
+// message, such as "File saved". Other renderers ignore it.
+func Announce(message string) Cmd {
+	return func() Msg {
+		return announceMsg(message)
+	}
+}
+
+// accessibleRenderer is a renderer for screen readers. Rather than drawing
+// frames with cursor movements, which screen readers can't make sense of, it
+// prints what changed as plain lines of text, one after the other: the lines
+// of the view which changed, or the live regions which changed if the model
+// has any, along with announcements and the lines printed with Println and
+// Printf.
+type accessibleRenderer struct {
+	mtx  sync.Mutex
+	out  io.Writer
+	done chan struct{}
+	once sync.Once
+
+	view       string
+	viewDirty  bool
+	lastLines  []string
+	regions    []LiveRegion
+	hasRegions bool
+	lastRegion map[string]string
+
+	// lines waiting to be printed: announcements, and lines printed with
+	// Println and Printf
+	pending []string
+}
+
+func newAccessibleRenderer(out io.Writer) *accessibleRenderer {
+	return &accessibleRenderer{
+		out:        out,
+		done:       make(chan struct{}),
+		lastRegion: make(map[string]string),
+	}
+}
+
+func (r *accessibleRenderer) start() {
+	r.done = make(chan struct{})
+	r.once = sync.Once{}
+	go r.listen()
+}
+
+// stop prints what's changed one last time.
+func (r *accessibleRenderer) stop() {
+	r.once.Do(func() {
+		close(r.done)
+	})
+	r.flush()
+}
+
+func (r *accessibleRenderer) kill() {
+	r.once.Do(func() {
+		close(r.done)
+	})
+}
+
+func (r *accessibleRenderer) listen() {
+	ticker := time.NewTicker(accessibleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-ticker.C:
+			r.flush()
+		}
+	}
+}
+
+// flush prints what changed since the last flush.
+func (r *accessibleRenderer) flush() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	lines := r.pending
+	r.pending = nil
+
+	if r.viewDirty {
+		r.viewDirty = false
+		if r.hasRegions {
+			lines = append(lines, r.changedRegions()...)
+		} else {
+			lines = append(lines, r.changedLines()...)
+		}
+	}
+
+	for _, line := range lines {
+		// The terminal is in raw mode, so line feeds don't return the
+		// cursor to the start of the line.
+		_, _ = io.WriteString(r.out, line+"\r\n")
+	}
+}
+
+// changedLines returns the lines of the view which changed since the last
+// frame, leaving out blank ones.
+func (r *accessibleRenderer) changedLines() []string {
+	newLines := plainLines(r.view)
+	var changed []string
+	for i, line := range newLines {
+		if line == "" || (i < len(r.lastLines) && r.lastLines[i] == line) {
+			continue
+		}
+		changed = append(changed, line)
+	}
+	r.lastLines = newLines
+	return changed
+}
+
+// changedRegions returns the content of the live regions which changed since
+// the last frame.
+func (r *accessibleRenderer) changedRegions() []string {
+	var changed []string
+	for _, region := range r.regions {
+		content := strings.Join(plainLines(region.Content), " ")
+		if last, ok := r.lastRegion[region.Name]; ok && last == content {
+			continue
+		}
+		r.lastRegion[region.Name] = content
+		if content != "" {
+			changed = append(changed, content)
+		}
+	}
+	return changed
+}
+
+// plainLines returns the lines of a view stripped of escape sequences and
+// trailing spaces.
+func plainLines(view string) []string {
+	lines := strings.Split(stripANSI(view), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \r")
+	}
+	return lines
+}
+
+func (r *accessibleRenderer) write(s string) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if s != r.view {
+		r.view = s
+		r.viewDirty = true
+	}
+}
+
+// setLiveRegions sets the live regions of the latest frame.
+func (r *accessibleRenderer) setLiveRegions(regions []LiveRegion) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.regions = regions
+	r.hasRegions = true
+	r.viewDirty = true
+}
+
+// handleMessages queues announcements and the lines printed with Println
+// and Printf.
+func (r *accessibleRenderer) handleMessages(msg Msg) {
+	var line string
+	switch msg := msg.(type) {
+	case announceMsg:
+		line = string(msg)
+	case printLineMessage:
+		line = msg.messageBody
+	default:
+		return
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.pending = append(r.pending, plainLines(line)...)
+}
+
+func (r *accessibleRenderer) repaint() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.lastLines = nil
+	r.lastRegion = make(map[string]string)
+	r.viewDirty = true
+}
+
+func (r *accessibleRenderer) clearScreen()                             {}
+func (r *accessibleRenderer) altScreen() bool                          { return false }
+func (r *accessibleRenderer) enterAltScreen()                          {}
+func (r *accessibleRenderer) exitAltScreen()                           {}
+func (r *accessibleRenderer) showCursor()                              {}
+func (r *accessibleRenderer) hideCursor()                              {}
+func (r *accessibleRenderer) setCursor(_ *Cursor)                      {}
+func (r *accessibleRenderer) enableMouseCellMotion()                   {}
+func (r *accessibleRenderer) disableMouseCellMotion()                  {}
+func (r *accessibleRenderer) enableMouseAllMotion()                    {}
+func (r *accessibleRenderer) disableMouseAllMotion()                   {}
+func (r *accessibleRenderer) enableMouseSGRMode()                      {}
+func (r *accessibleRenderer) disableMouseSGRMode()                     {}
+func (r *accessibleRenderer) enableMousePixelsMode()                   {}
+func (r *accessibleRenderer) disableMousePixelsMode()                  {}
+func (r *accessibleRenderer) queryCellSize()                           {}
+func (r *accessibleRenderer) enableBracketedPaste()                    {}
+func (r *accessibleRenderer) disableBracketedPaste()                   {}
+func (r *accessibleRenderer) bracketedPasteActive() bool               { return false }
+func (r *accessibleRenderer) enableKittyKeyboard(_ KittyKeyboardFlags) {}
+func (r *accessibleRenderer) disableKittyKeyboard()                    {}
+func (r *accessibleRenderer) enableModifyOtherKeys()                   {}
+func (r *accessibleRenderer) disableModifyOtherKeys()                  {}
+func (r *accessibleRenderer) setClipboard(_ string)                    {}
+func (r *accessibleRenderer) readClipboard()                           {}
+func (r *accessibleRenderer) setSynchronizedOutput(_ bool)             {}
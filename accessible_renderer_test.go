@@ -0,0 +1,93 @@
+package tea
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestAccessibleRenderer(t *testing.T) {
+	var buf bytes.Buffer
+	m := initCmdModel{testModel: &testModel{}, init: Sequence(
+		Println("\x1b[1mhello\x1b[0m"),
+		Announce("saved"),
+		Quit,
+	)}
+	p := NewProgram(m, WithInput(nil), WithOutput(&buf), WithAccessibleRenderer())
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "hello\r\nsaved\r\nsuccess\r\n"; buf.String() != expected {
+		t.Errorf("expected output %q, got %q", expected, buf.String())
+	}
+}
+
+func TestAccessibleRendererChanges(t *testing.T) {
+	var buf bytes.Buffer
+	r := newAccessibleRenderer(&buf)
+
+	r.write("\x1b[1mTasks\x1b[0m\n  > one   \n    two\n")
+	r.flush()
+	if expected := "Tasks\r\n  > one\r\n    two\r\n"; buf.String() != expected {
+		t.Errorf("expected the whole view, got %q", buf.String())
+	}
+
+	// Only the lines which changed are printed.
+	buf.Reset()
+	r.write("\x1b[1mTasks\x1b[0m\n    one\n  > two\n")
+	r.flush()
+	if expected := "    one\r\n  > two\r\n"; buf.String() != expected {
+		t.Errorf("expected the changed lines, got %q", buf.String())
+	}
+
+	// Nothing is printed when nothing changed.
+	buf.Reset()
+	r.write("\x1b[1mTasks\x1b[0m\n    one\n  > two\n")
+	r.flush()
+	if buf.Len() != 0 {
+		t.Errorf("expected nothing, got %q", buf.String())
+	}
+}
+
+func TestAccessibleRendererLiveRegions(t *testing.T) {
+	var buf bytes.Buffer
+	r := newAccessibleRenderer(&buf)
+
+	var printed []string
+	frame := func(view string, regions ...LiveRegion) {
+		buf.Reset()
+		r.write(view)
+		r.setLiveRegions(regions)
+		r.flush()
+		printed = append(printed, buf.String())
+	}
+	frame("spinner 1\nstatus: idle",
+		LiveRegion{Name: "status", Content: "idle"})
+	frame("spinner 2\nstatus: idle",
+		LiveRegion{Name: "status", Content: "idle"})
+	frame("spinner 3\nstatus: \x1b[32mdone\x1b[0m",
+		LiveRegion{Name: "status", Content: "\x1b[32mdone\x1b[0m"})
+
+	// Only the regions are announced, when they change.
+	if expected := []string{"idle\r\n", "", "done\r\n"}; !reflect.DeepEqual(printed, expected) {
+		t.Errorf("expected %q, got %q", expected, printed)
+	}
+}
+
+func TestAccessibleFromEnv(t *testing.T) {
+	for value, expected := range map[string]bool{
+		"":      false,
+		"1":     true,
+		"true":  true,
+		"TRUE":  true,
+		"0":     false,
+		"false": false,
+		"yes":   false,
+	} {
+		getenv := func(string) string { return value }
+		if got := accessibleFromEnv(getenv); got != expected {
+			t.Errorf("expected ACCESSIBLE=%q to give %v, got %v", value, expected, got)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package tea
+
+import "github.com/charmbracelet/bubbletea/input"
+
+// AltKeys is how keys typed after an escape character are reported, since
+// terminals report alt with an escape character in front of keys, which
+// can't be told apart from esc typed before them.
+type AltKeys = input.AltKeys
+
+// Ways of reporting keys typed after an escape character. See WithAltKeys.
+const (
+	AltKeysPrefix   = input.AltKeysPrefix
+	AltKeysSeparate = input.AltKeysSeparate
+	AltKeysAuto     = input.AltKeysAuto
+)
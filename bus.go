@@ -0,0 +1,206 @@
+package tea
+
+import "reflect"
+
+// PublishedMsg is delivered to the subscribers of a topic when a message is
+// published on it with Publish.
+type PublishedMsg struct {
+	Topic string
+	Msg   Msg
+}
+
+// publishMsg is an internal message used to publish a message on a topic.
+type publishMsg struct {
+	topic string
+	msg   Msg
+}
+
+// Publish produces a command that publishes a message on a topic of the
+// program's message bus. Every subscriber of the topic receives it as a
+// PublishedMsg. Publishing on a topic nobody subscribed to is a no-op.
+//
+// The bus lets sibling components talk to each other without their parent
+// having to route every message type between them:
+//
+//	// In the file list component:
+//	return m, tea.Publish("file-selected", path)
+//
+//	// In the preview component:
+//	func (m preview) Init() tea.Cmd {
+//	    return tea.Subscribe("file-selected")
+//	}
+//
+//	func (m preview) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+//	    switch msg := msg.(type) {
+//	    case tea.PublishedMsg:
+//	        if msg.Topic == "file-selected" {
+//	            return m, m.load(msg.Msg.(string))
+//	        }
+//	    }
+//	    return m, nil
+//	}
+func Publish(topic string, msg Msg) Cmd {
+	return func() Msg {
+		return publishMsg{topic: topic, msg: msg}
+	}
+}
+
+// subscribeMsg is an internal message used to subscribe to a topic.
+type subscribeMsg struct {
+	topic string
+}
+
+// Subscribe produces a command that subscribes to a topic of the program's
+// message bus. See Publish.
+//
+// When Subscribe is returned from within a Component, or any command wrapped
+// with Wrap, the subscription is addressed to that component: messages
+// published on the topic are wrapped the same way, so they are routed back to
+// the subscribing component only. Otherwise they're delivered to the top-level
+// model.
+//
+// Subscriptions belong to the program and end when it exits. Subscribing
+// twice to the same topic from the same component has no effect, and neither
+// does subscribing from within a command wrapped with IDs that aren't
+// comparable, such as slices, as no component could be routed the messages.
+func Subscribe(topic string) Cmd {
+	return func() Msg {
+		return subscribeMsg{topic: topic}
+	}
+}
+
+// unsubscribeMsg is an internal message used to unsubscribe from a topic.
+type unsubscribeMsg struct {
+	topic string
+}
+
+// Unsubscribe produces a command that cancels a subscription made with
+// Subscribe. Like Subscribe, it applies to the component it's returned from.
+func Unsubscribe(topic string) Cmd {
+	return func() Msg {
+		return unsubscribeMsg{topic: topic}
+	}
+}
+
+// subscriber is the address of a subscription: the IDs of the wrapped
+// messages the subscription arrived in, outermost first.
+type subscriber []interface{}
+
+func (s subscriber) equals(o subscriber) bool {
+	if len(s) != len(o) {
+		return false
+	}
+	for i := range s {
+		if s[i] != o[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// comparable reports whether the IDs of the subscriber can be compared with
+// ==, which panics otherwise. Only comparable subscribers make it onto the
+// bus, so that they can be told apart.
+func (s subscriber) comparable() bool {
+	for _, id := range s {
+		if !comparableValue(reflect.ValueOf(id)) {
+			return false
+		}
+	}
+	return true
+}
+
+// comparableValue reports whether a value can be compared with == without
+// panicking, looking into the values held by interfaces, arrays and structs.
+func comparableValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Slice, reflect.Map, reflect.Func:
+		return false
+	case reflect.Interface:
+		return v.IsNil() || comparableValue(v.Elem())
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if !comparableValue(v.Index(i)) {
+				return false
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !comparableValue(v.Field(i)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// bus holds the subscriptions of a program's message bus. It's only accessed
+// from the event loop.
+type bus map[string][]subscriber
+
+// unwrapBusMsg peels off any WrappedMsg layers around msg and, if they
+// contained a bus message, returns it with the IDs it was wrapped with.
+//
+// Bus messages are deliberately wrapped by Wrap, unlike other messages handled
+// by the event loop, as the wrapping tells us who subscribed.
+func unwrapBusMsg(msg Msg) (Msg, subscriber) {
+	var ids subscriber
+	for {
+		switch m := msg.(type) {
+		case WrappedMsg:
+			ids = append(ids, m.ID)
+			msg = m.Msg
+		case publishMsg, subscribeMsg, unsubscribeMsg:
+			return m, ids
+		default:
+			return nil, nil
+		}
+	}
+}
+
+// handle processes a bus message and returns the commands delivering
+// published messages to their subscribers, if any.
+func (b bus) handle(msg Msg, from subscriber) []Cmd {
+	switch msg := msg.(type) {
+	case subscribeMsg:
+		if !from.comparable() {
+			return nil
+		}
+		for _, s := range b[msg.topic] {
+			if s.equals(from) {
+				return nil
+			}
+		}
+		b[msg.topic] = append(b[msg.topic], from)
+
+	case unsubscribeMsg:
+		if !from.comparable() {
+			return nil
+		}
+		subs := b[msg.topic]
+		for i, s := range subs {
+			if s.equals(from) {
+				b[msg.topic] = append(subs[:i:i], subs[i+1:]...)
+				break
+			}
+		}
+		if len(b[msg.topic]) == 0 {
+			delete(b, msg.topic)
+		}
+
+	case publishMsg:
+		subs := b[msg.topic]
+		cmds := make([]Cmd, 0, len(subs))
+		for _, s := range subs {
+			var m Msg = PublishedMsg{Topic: msg.topic, Msg: msg.msg}
+			for i := len(s) - 1; i >= 0; i-- {
+				m = WrappedMsg{ID: s[i], Msg: m}
+			}
+			cmds = append(cmds, func() Msg { return m })
+		}
+		return cmds
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package tea
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBus(t *testing.T) {
+	b := bus{}
+	publish := func(topic string) []Msg {
+		var msgs []Msg
+		for _, cmd := range b.handle(publishMsg{topic: topic, msg: "hi"}, nil) {
+			msgs = append(msgs, cmd())
+		}
+		return msgs
+	}
+
+	if msgs := publish("files"); len(msgs) != 0 {
+		t.Fatalf("expected no subscribers, got %#v", msgs)
+	}
+
+	// Subscribe from the top-level model, and from a nested component, twice.
+	for _, cmd := range []Cmd{
+		Subscribe("files"),
+		Wrap("sidebar", Wrap(3, Subscribe("files"))),
+		Wrap("sidebar", Wrap(3, Subscribe("files"))),
+	} {
+		msg, from := unwrapBusMsg(cmd())
+		if msg == nil {
+			t.Fatal("expected a bus message")
+		}
+		b.handle(msg, from)
+	}
+
+	expected := []Msg{
+		PublishedMsg{Topic: "files", Msg: "hi"},
+		WrappedMsg{ID: "sidebar", Msg: WrappedMsg{ID: 3, Msg: PublishedMsg{Topic: "files", Msg: "hi"}}},
+	}
+	if msgs := publish("files"); !reflect.DeepEqual(msgs, expected) {
+		t.Fatalf("expected %#v, got %#v", expected, msgs)
+	}
+	if msgs := publish("other"); len(msgs) != 0 {
+		t.Fatalf("expected no subscribers, got %#v", msgs)
+	}
+
+	msg, from := unwrapBusMsg(Wrap("sidebar", Wrap(3, Unsubscribe("files")))())
+	b.handle(msg, from)
+	if msgs := publish("files"); !reflect.DeepEqual(msgs, expected[:1]) {
+		t.Fatalf("expected %#v, got %#v", expected[:1], msgs)
+	}
+
+	msg, from = unwrapBusMsg(Unsubscribe("files")())
+	b.handle(msg, from)
+	if len(b) != 0 {
+		t.Fatalf("expected no subscriptions left, got %#v", b)
+	}
+}
+
+func TestBusUncomparableID(t *testing.T) {
+	b := bus{}
+	handle := func(cmd Cmd) []Cmd {
+		msg, from := unwrapBusMsg(cmd())
+		if msg == nil {
+			t.Fatal("expected a bus message")
+		}
+		return b.handle(msg, from)
+	}
+
+	handle(Subscribe("files"))
+	for _, cmd := range []Cmd{
+		WrapPath(Subscribe("files"), "sidebar", []int{3}),
+		WrapPath(Subscribe("files"), "sidebar", struct{ id interface{} }{[]int{3}}),
+		WrapPath(Unsubscribe("files"), "sidebar", []int{3}),
+	} {
+		handle(cmd)
+	}
+	if subs := b["files"]; len(subs) != 1 || len(subs[0]) != 0 {
+		t.Fatalf("expected the top-level subscription only, got %#v", subs)
+	}
+
+	// Components with such IDs can still publish.
+	if cmds := handle(WrapPath(Publish("files", "hi"), []int{3})); len(cmds) != 1 {
+		t.Fatalf("expected the message to be published, got %d commands", len(cmds))
+	}
+}
+
+func TestUnwrapBusMsg(t *testing.T) {
+	if msg, _ := unwrapBusMsg(WrappedMsg{ID: 1, Msg: "hi"}); msg != nil {
+		t.Fatalf("expected no bus message, got %#v", msg)
+	}
+	if msg, _ := unwrapBusMsg(QuitMsg{}); msg != nil {
+		t.Fatalf("expected no bus message, got %#v", msg)
+	}
+}
@@ -0,0 +1,158 @@
+package tea
+
+import (
+	"strings"
+
+	"github.com/muesli/termenv"
+)
+
+// termCaps are the sequences the renderer controls the terminal with. They
+// come from the terminfo entry of the terminal, so that programs behave on
+// terminals which aren't compatible with xterm, such as the Linux console.
+type termCaps struct {
+	// clearLine clears the line the cursor is on.
+	clearLine string
+
+	// clearLineRight clears the line from the cursor to its end.
+	clearLineRight string
+
+	// clearScreen clears the screen and moves the cursor to its top-left
+	// corner.
+	clearScreen string
+
+	// cursorAddress moves the cursor to a position, given as a 0-based row
+	// and column.
+	cursorAddress string
+
+	up, down, forward, back cursorMove
+
+	// scrollRegionSeq sets the scrolling region, given as 0-based rows.
+	scrollRegionSeq string
+
+	// insert inserts lines above the cursor.
+	insert cursorMove
+
+	hideCursor, showCursor        string
+	enterAltScreen, exitAltScreen string
+}
+
+// cursorMove moves the cursor in a direction, by any number of cells with
+// parm, or by one cell with step. It's also used for inserting lines.
+type cursorMove struct {
+	parm, step string
+}
+
+func (m cursorMove) seq(n int) string {
+	if m.parm != "" {
+		return tparm(m.parm, n)
+	}
+	return strings.Repeat(tparm(m.step), n)
+}
+
+// xtermCaps are the built-in capabilities, used when the terminal has no
+// terminfo entry, or when the output isn't a terminal.
+var xtermCaps = &termCaps{
+	clearLine:       termenv.CSI + "2K",
+	clearLineRight:  termenv.CSI + "0K",
+	clearScreen:     termenv.CSI + "2J" + termenv.CSI + "1;1H",
+	cursorAddress:   termenv.CSI + "%i%p1%d;%p2%dH",
+	up:              cursorMove{parm: termenv.CSI + "%p1%dA"},
+	down:            cursorMove{parm: termenv.CSI + "%p1%dB"},
+	forward:         cursorMove{parm: termenv.CSI + "%p1%dC"},
+	back:            cursorMove{parm: termenv.CSI + "%p1%dD"},
+	scrollRegionSeq: termenv.CSI + "%i%p1%d;%p2%dr",
+	insert:          cursorMove{parm: termenv.CSI + "%p1%dL"},
+	hideCursor:      termenv.CSI + "?25l",
+	showCursor:      termenv.CSI + "?25h",
+	enterAltScreen:  termenv.CSI + "?1049h",
+	exitAltScreen:   termenv.CSI + "?1049l",
+}
+
+// loadTermCaps returns the capabilities of a terminal type, falling back to
+// the built-in ones if it has no terminfo entry. Terminals which can't hide
+// the cursor or have no alternate screen get no sequences for them, but the
+// built-in sequences are used for the cursor movements missing from the
+// entry, as the renderer can't do without them.
+func loadTermCaps(term string) *termCaps {
+	ti, err := loadTerminfo(term)
+	if err != nil {
+		return xtermCaps
+	}
+	return terminfoCaps(ti)
+}
+
+func terminfoCaps(ti *terminfo) *termCaps {
+	// Expand the capabilities without parameters right away, to drop their
+	// delays.
+	str := func(i int) (string, bool) {
+		s, ok := ti.str(i)
+		return tparm(s), ok
+	}
+
+	caps := &termCaps{}
+	caps.hideCursor, _ = str(tiCursorHide)
+	caps.showCursor, _ = str(tiCursorShow)
+	caps.enterAltScreen, _ = str(tiEnterCAMode)
+	caps.exitAltScreen, _ = str(tiExitCAMode)
+
+	// Clear the line on both sides of the cursor.
+	el, hasEL := str(tiClrEOL)
+	el1, hasEL1 := str(tiClrBOL)
+	caps.clearLineRight = el
+	if !hasEL {
+		caps.clearLineRight = xtermCaps.clearLineRight
+	}
+	switch {
+	case hasEL && hasEL1:
+		caps.clearLine = el1 + el
+	case hasEL:
+		// The renderer clears lines from their start.
+		caps.clearLine = el
+	default:
+		caps.clearLine = xtermCaps.clearLine
+	}
+
+	var ok bool
+	if caps.clearScreen, ok = str(tiClearScreen); !ok {
+		caps.clearScreen = xtermCaps.clearScreen
+	}
+	if caps.cursorAddress, ok = ti.str(tiCursorAddress); !ok {
+		caps.cursorAddress = xtermCaps.cursorAddress
+	}
+	if caps.scrollRegionSeq, ok = ti.str(tiScrollRegion); !ok {
+		caps.scrollRegionSeq = xtermCaps.scrollRegionSeq
+	}
+
+	move := func(parm, step int, fallback cursorMove) cursorMove {
+		var m cursorMove
+		m.parm, _ = ti.str(parm)
+		m.step, _ = ti.str(step)
+		if m.parm == "" && m.step == "" {
+			return fallback
+		}
+		return m
+	}
+	caps.up = move(tiParmUp, tiCursorUp, xtermCaps.up)
+	caps.down = move(tiParmDown, tiCursorDown, xtermCaps.down)
+	caps.forward = move(tiParmRight, tiCursorRight, xtermCaps.forward)
+	caps.back = move(tiParmLeft, tiCursorLeft, xtermCaps.back)
+	caps.insert = move(tiParmInsert, tiInsertLine, xtermCaps.insert)
+	return caps
+}
+
+// moveCursor moves the cursor to a position, given as a 1-based row and
+// column.
+func (c *termCaps) moveCursor(row, col int) string {
+	return tparm(c.cursorAddress, row-1, col-1)
+}
+
+// scrollRegion sets the scrolling region, given as 1-based rows.
+func (c *termCaps) scrollRegion(top, bottom int) string {
+	return tparm(c.scrollRegionSeq, top-1, bottom-1)
+}
+
+func (c *termCaps) insertLines(n int) string   { return c.insert.seq(n) }
+func (c *termCaps) cursorUp(n int) string      { return c.up.seq(n) }
+func (c *termCaps) cursorDown(n int) string    { return c.down.seq(n) }
+func (c *termCaps) cursorForward(n int) string { return c.forward.seq(n) }
+func (c *termCaps) cursorBack(n int) string    { return c.back.seq(n) }
@@ -0,0 +1,97 @@
+package tea
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/muesli/termenv"
+)
+
+func TestXtermCaps(t *testing.T) {
+	// The built-in capabilities match the sequences of termenv.
+	var buf bytes.Buffer
+	out := termenv.NewOutput(&buf)
+	check := func(name, seq string, fn func()) {
+		t.Helper()
+		buf.Reset()
+		fn()
+		if seq != buf.String() {
+			t.Errorf("%s: expected %q, got %q", name, buf.String(), seq)
+		}
+	}
+	c := xtermCaps
+	check("clearLine", c.clearLine, out.ClearLine)
+	check("clearLineRight", c.clearLineRight, out.ClearLineRight)
+	check("clearScreen", c.clearScreen, out.ClearScreen)
+	check("moveCursor", c.moveCursor(3, 0), func() { out.MoveCursor(3, 0) })
+	check("cursorUp", c.cursorUp(2), func() { out.CursorUp(2) })
+	check("cursorDown", c.cursorDown(2), func() { out.CursorDown(2) })
+	check("cursorForward", c.cursorForward(2), func() { out.CursorForward(2) })
+	check("cursorBack", c.cursorBack(80), func() { out.CursorBack(80) })
+	check("scrollRegion", c.scrollRegion(2, 20), func() { out.ChangeScrollingRegion(2, 20) })
+	check("insertLines", c.insertLines(3), func() { out.InsertLines(3) })
+	check("hideCursor", c.hideCursor, out.HideCursor)
+	check("showCursor", c.showCursor, out.ShowCursor)
+	check("enterAltScreen", c.enterAltScreen, out.AltScreen)
+	check("exitAltScreen", c.exitAltScreen, out.ExitAltScreen)
+}
+
+func TestTerminfoCaps(t *testing.T) {
+	ti, err := parseTerminfo(compileTerminfo("vt", map[int]string{
+		tiClearScreen: "\x1b[H\x1b[J$<50>",
+		tiClrEOL:      "\x1b[K",
+		tiCursorUp:    "\x1bM",
+		tiParmRight:   "\x1b[%p1%dC",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := terminfoCaps(ti)
+
+	tests := []struct {
+		name     string
+		seq      string
+		expected string
+	}{
+		{"clearLine", c.clearLine, "\x1b[K"},
+		{"clearLineRight", c.clearLineRight, "\x1b[K"},
+		{"clearScreen", c.clearScreen, "\x1b[H\x1b[J"},
+		{"cursorUp", c.cursorUp(3), "\x1bM\x1bM\x1bM"},
+		{"cursorForward", c.cursorForward(3), "\x1b[3C"},
+		// Missing movements fall back to xterm.
+		{"cursorDown", c.cursorDown(3), "\x1b[3B"},
+		{"moveCursor", c.moveCursor(2, 5), "\x1b[2;5H"},
+		// Missing features are left out.
+		{"hideCursor", c.hideCursor, ""},
+		{"enterAltScreen", c.enterAltScreen, ""},
+	}
+	for _, test := range tests {
+		if test.seq != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.name, test.expected, test.seq)
+		}
+	}
+}
+
+func TestRendererCaps(t *testing.T) {
+	ti, err := parseTerminfo(compileTerminfo("vt", map[int]string{
+		tiClrEOL:   "<el>",
+		tiCursorUp: "<up>",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	r := newRenderer(termenv.NewOutput(&buf), false, 60).(*standardRenderer)
+	r.caps = terminfoCaps(ti)
+
+	r.write("a\nb")
+	r.flush()
+	r.write("c\nd")
+	r.flush()
+
+	if out := buf.String(); !strings.Contains(out, "<up>\r<el>c") {
+		t.Errorf("expected the terminal's sequences to be used, got %q", out)
+	}
+}
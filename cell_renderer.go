@@ -0,0 +1,430 @@
+package tea
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/muesli/termenv"
+)
+
+// cellAttrs are the text attributes of a cell, as a set of bits.
+type cellAttrs uint8
+
+const (
+	attrBold cellAttrs = 1 << iota
+	attrFaint
+	attrItalic
+	attrUnderline
+	attrBlink
+	attrReverse
+	attrConceal
+	attrStrikethrough
+)
+
+// cellAttrCodes are the SGR parameters turning each attribute on and off.
+var cellAttrCodes = []struct {
+	attr    cellAttrs
+	on, off int
+}{
+	{attrBold, 1, 22},
+	{attrFaint, 2, 22},
+	{attrItalic, 3, 23},
+	{attrUnderline, 4, 24},
+	{attrBlink, 5, 25},
+	{attrReverse, 7, 27},
+	{attrConceal, 8, 28},
+	{attrStrikethrough, 9, 29},
+}
+
+// cellStyle is the style of a cell. Colors are kept as the SGR parameters
+// setting them, such as "31" or "38;5;208", and are empty for the terminal's
+// default colors.
+type cellStyle struct {
+	attrs  cellAttrs
+	fg, bg string
+}
+
+// apply returns the style resulting from the parameters of an SGR sequence,
+// and whether they were all understood.
+func (s cellStyle) apply(params string) (cellStyle, bool) {
+	if strings.ContainsRune(params, ':') {
+		// Subparameters, such as underline styles, aren't supported.
+		return s, false
+	}
+
+	// Split the parameters without allocating, keeping where each starts so
+	// that colors can be sliced out of them.
+	var (
+		buf    [16]string
+		starts [16]int
+	)
+	ps, offsets := buf[:0], starts[:0]
+	for start := 0; ; {
+		end := strings.IndexByte(params[start:], ';')
+		if end < 0 {
+			ps, offsets = append(ps, params[start:]), append(offsets, start)
+			break
+		}
+		ps, offsets = append(ps, params[start:start+end]), append(offsets, start)
+		start += end + 1
+	}
+
+	for i := 0; i < len(ps); i++ {
+		n := 0
+		if ps[i] != "" {
+			var err error
+			if n, err = strconv.Atoi(ps[i]); err != nil {
+				return s, false
+			}
+		}
+
+		switch {
+		case n == 0:
+			s = cellStyle{}
+		case n >= 30 && n <= 37, n >= 90 && n <= 97:
+			s.fg = ps[i]
+		case n == 39:
+			s.fg = ""
+		case n >= 40 && n <= 47, n >= 100 && n <= 107:
+			s.bg = ps[i]
+		case n == 49:
+			s.bg = ""
+		case n == 38 || n == 48:
+			// 256 colors, with 5;n, or true colors, with 2;r;g;b.
+			size := 0
+			if i+2 < len(ps) && ps[i+1] == "5" {
+				size = 3
+			} else if i+4 < len(ps) && ps[i+1] == "2" {
+				size = 5
+			} else {
+				return s, false
+			}
+			last := i + size - 1
+			color := params[offsets[i] : offsets[last]+len(ps[last])]
+			if n == 38 {
+				s.fg = color
+			} else {
+				s.bg = color
+			}
+			i += size - 1
+		default:
+			known := false
+			for _, c := range cellAttrCodes {
+				switch n {
+				case c.on:
+					s.attrs |= c.attr
+					known = true
+				case c.off:
+					s.attrs &^= c.attr
+					known = true
+				}
+			}
+			if !known {
+				return s, false
+			}
+		}
+	}
+	return s, true
+}
+
+// sgr returns the SGR sequence changing the style of the terminal from
+// another style to this one.
+func (s cellStyle) sgr(from cellStyle) string {
+	if s == from {
+		return ""
+	}
+	if s == (cellStyle{}) {
+		return termenv.CSI + "m"
+	}
+
+	var params []string
+	if from.attrs&^s.attrs != 0 {
+		// Turning attributes off one by one is more trouble than it's worth,
+		// as bold and faint are turned off together.
+		params = append(params, "0")
+		from = cellStyle{}
+	}
+	for _, c := range cellAttrCodes {
+		if s.attrs&c.attr != 0 && from.attrs&c.attr == 0 {
+			params = append(params, strconv.Itoa(c.on))
+		}
+	}
+	if s.fg != from.fg {
+		if s.fg == "" {
+			params = append(params, "39")
+		} else {
+			params = append(params, s.fg)
+		}
+	}
+	if s.bg != from.bg {
+		if s.bg == "" {
+			params = append(params, "49")
+		} else {
+			params = append(params, s.bg)
+		}
+	}
+	return termenv.CSI + strings.Join(params, ";") + "m"
+}
+
+// cell is a cell of the terminal's screen. A wide character takes up two
+// cells, the second of which has no content and no width.
+type cell struct {
+	content string
+	width   int
+	style   cellStyle
+}
+
+// blankCell is what the cells of a cleared line hold.
+var blankCell = cell{content: " ", width: 1}
+
+// cellAt returns a cell of a row, which is blank past the end of the row.
+func cellAt(row []cell, col int) cell {
+	if col < len(row) {
+		return row[col]
+	}
+	return blankCell
+}
+
+// parseCells parses the lines of a frame into rows of cells, cut at the given
+// width if it's known. Trailing blank cells are left out. It returns nil if a
+// line holds something else than text and SGR sequences, such as cursor
+// movements, hyperlinks or control characters, since there's no telling how
+// the terminal would draw it.
+func (m cellWidths) parseCells(lines []string, width int) [][]cell {
+	grid := make([][]cell, len(lines))
+	for i, line := range lines {
+		row, ok := m.parseCellRow(line, width)
+		if !ok {
+			return nil
+		}
+		grid[i] = row
+	}
+	return grid
+}
+
+func (m cellWidths) parseCellRow(line string, width int) ([]cell, bool) {
+	var (
+		row   []cell
+		style cellStyle
+
+		// where the content of the last character starts and ends in the line
+		leadStart, leadEnd int
+	)
+	for i := 0; i < len(line); {
+		if line[i] == '\x1b' {
+			if i+1 >= len(line) || line[i+1] != '[' {
+				return nil, false
+			}
+			j := i + 2
+			for j < len(line) && (line[j] < 0x40 || line[j] > 0x7e) {
+				j++
+			}
+			if j >= len(line) || line[j] != 'm' {
+				return nil, false
+			}
+			var ok bool
+			if style, ok = style.apply(line[i+2 : j]); !ok {
+				return nil, false
+			}
+			i = j + 1
+			continue
+		}
+
+		start := i
+		cluster, w := m.firstGrapheme(line[i:])
+		i += len(cluster)
+		if r, size := utf8.DecodeRuneInString(cluster); unicode.IsControl(r) || (r == utf8.RuneError && size == 1) {
+			return nil, false
+		}
+
+		if w == 0 {
+			// Combining marks separated from their character by a sequence
+			// join it all the same.
+			lead := len(row) - 1
+			if lead > 0 && row[lead].width == 0 {
+				lead--
+			}
+			if lead >= 0 {
+				if lead == len(row)-1 && leadEnd == start {
+					// Slice the line rather than allocating, when the
+					// rune follows the character right away.
+					row[lead].content = line[leadStart:i]
+				} else {
+					row[lead].content += cluster
+				}
+				leadEnd = i
+			}
+			continue
+		}
+		if width > 0 && len(row)+w > width {
+			break
+		}
+		if row == nil {
+			n := len(line)
+			if width > 0 && width < n {
+				n = width
+			}
+			row = make([]cell, 0, n)
+		}
+		leadStart, leadEnd = start, i
+		row = append(row, cell{content: line[start:i], width: w, style: style})
+		for k := 1; k < w; k++ {
+			row = append(row, cell{style: style})
+		}
+	}
+
+	for len(row) > 0 && row[len(row)-1] == blankCell {
+		row = row[:len(row)-1]
+	}
+	return row, true
+}
+
+// cellPainter paints the cells of a frame, keeping track of the cursor and of
+// the style of the terminal to keep the sequences it writes to a minimum.
+type cellPainter struct {
+	r   *standardRenderer
+	out *termenv.Output
+
+	// the cursor position in the frame; col is -1 when it's unknown, such as
+	// after writing to the last column of the window
+	row, col int
+
+	// the style of the terminal, if known
+	pen      cellStyle
+	penKnown bool
+}
+
+// paintCells paints the cells of a frame which changed since the last one.
+// The cursor must be at the start of the last rendered line, and is left on
+// the last line of the new frame.
+func (r *standardRenderer) paintCells(out *termenv.Output, newCells [][]cell) {
+	oldCells := r.lastCells
+	p := &cellPainter{r: r, out: out, row: r.linesRendered - 1}
+
+	for i := 0; i < len(oldCells); i++ {
+		if _, skip := r.ignoreLines[i]; skip {
+			continue
+		}
+		if i < len(newCells) {
+			p.paintRow(i, oldCells[i], newCells[i])
+			continue
+		}
+		// The frame got shorter: clear the lines left below it.
+		if len(oldCells[i]) > 0 {
+			p.move(i, 0)
+			p.setStyle(cellStyle{})
+			_, _ = out.WriteString(r.caps.clearLine)
+		}
+	}
+
+	if len(newCells) > len(oldCells) {
+		// The frame got taller: add lines below the last one, scrolling the
+		// terminal if need be.
+		p.move(len(oldCells)-1, 0)
+		for i := len(oldCells); i < len(newCells); i++ {
+			p.setStyle(cellStyle{})
+			_, _ = out.WriteString("\r\n")
+			p.row, p.col = i, 0
+			p.paintRow(i, nil, newCells[i])
+		}
+	}
+
+	if p.penKnown {
+		p.setStyle(cellStyle{})
+	}
+	if !r.altScreenActive {
+		p.move(len(newCells)-1, 0)
+	}
+}
+
+// paintRow paints the cells of a row which changed.
+func (p *cellPainter) paintRow(row int, oldRow, newRow []cell) {
+	for col := 0; col < len(newRow); {
+		c := newRow[col]
+		if c.width == 0 {
+			col++
+			continue
+		}
+
+		changed := false
+		for k := col; k < col+c.width; k++ {
+			if cellAt(oldRow, k) != cellAt(newRow, k) {
+				changed = true
+			}
+		}
+		if changed {
+			p.moveOver(row, col, newRow)
+			p.paint(c)
+		}
+		col += c.width
+	}
+
+	if len(oldRow) > len(newRow) {
+		p.move(row, len(newRow))
+		p.setStyle(cellStyle{})
+		_, _ = p.out.WriteString(p.r.caps.clearLineRight)
+	}
+}
+
+// moveOver moves the cursor forward to a column of the row it's on by
+// painting the cells in between again, if that's shorter than a cursor
+// movement and they're in the current style, and moves it otherwise.
+func (p *cellPainter) moveOver(row, col int, cells []cell) {
+	const maxGap = 3
+	if row == p.row && p.col >= 0 && p.col < col && col-p.col <= maxGap && p.penKnown {
+		gap := cells[p.col:col]
+		rewrite := gap[0].width > 0
+		for _, c := range gap {
+			if c.style != p.pen {
+				rewrite = false
+			}
+		}
+		if rewrite {
+			for _, c := range gap {
+				if c.width > 0 {
+					p.paint(c)
+				}
+			}
+			return
+		}
+	}
+	p.move(row, col)
+}
+
+// move moves the cursor to a column of a line of the frame.
+func (p *cellPainter) move(row, col int) {
+	switch {
+	case row == p.row && col == p.col:
+	case row == p.row && p.col >= 0 && col > p.col:
+		_, _ = p.out.WriteString(p.r.caps.cursorForward(col - p.col))
+	case row == p.row && p.col >= 0 && col > 0:
+		_, _ = p.out.WriteString(p.r.caps.cursorBack(p.col - col))
+	default:
+		p.r.moveTo(p.out, p.row, row, col)
+	}
+	p.row, p.col = row, col
+}
+
+// setStyle sets the style of the terminal. The first time around the style is
+// reset, as text painted before may have left any style behind.
+func (p *cellPainter) setStyle(s cellStyle) {
+	if !p.penKnown {
+		_, _ = p.out.WriteString(termenv.CSI + "m")
+		p.pen, p.penKnown = cellStyle{}, true
+	}
+	_, _ = p.out.WriteString(s.sgr(p.pen))
+	p.pen = s
+}
+
+// paint paints a cell at the cursor.
+func (p *cellPainter) paint(c cell) {
+	p.setStyle(c.style)
+	_, _ = p.out.WriteString(c.content)
+	p.col += c.width
+	if p.r.width > 0 && p.col >= p.r.width {
+		// The cursor stays in the last column, waiting to wrap.
+		p.col = -1
+	}
+}
@@ -0,0 +1,156 @@
+package tea
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/muesli/termenv"
+)
+
+func TestCellStyleApply(t *testing.T) {
+	tests := []struct {
+		params   string
+		from     cellStyle
+		expected cellStyle
+		ok       bool
+	}{
+		{"1;31", cellStyle{}, cellStyle{attrs: attrBold, fg: "31"}, true},
+		{"", cellStyle{attrs: attrBold, fg: "31"}, cellStyle{}, true},
+		{"22;39", cellStyle{attrs: attrBold | attrFaint | attrItalic, fg: "31"}, cellStyle{attrs: attrItalic}, true},
+		{"38;5;208;48;2;1;2;3", cellStyle{}, cellStyle{fg: "38;5;208", bg: "48;2;1;2;3"}, true},
+		{"4;102", cellStyle{bg: "41"}, cellStyle{attrs: attrUnderline, bg: "102"}, true},
+		{"4:3", cellStyle{}, cellStyle{}, false},
+		{"38;5", cellStyle{}, cellStyle{}, false},
+		{"53", cellStyle{}, cellStyle{}, false},
+	}
+	for _, test := range tests {
+		s, ok := test.from.apply(test.params)
+		if ok != test.ok {
+			t.Errorf("%q: expected ok to be %v", test.params, test.ok)
+			continue
+		}
+		if ok && s != test.expected {
+			t.Errorf("%q: expected %+v, got %+v", test.params, test.expected, s)
+		}
+	}
+}
+
+func TestCellStyleSGR(t *testing.T) {
+	tests := []struct {
+		from, to cellStyle
+		expected string
+	}{
+		{cellStyle{}, cellStyle{}, ""},
+		{cellStyle{fg: "31"}, cellStyle{}, "\x1b[m"},
+		{cellStyle{}, cellStyle{attrs: attrBold | attrItalic, fg: "31"}, "\x1b[1;3;31m"},
+		{cellStyle{attrs: attrBold, fg: "31"}, cellStyle{attrs: attrBold, fg: "32"}, "\x1b[32m"},
+		{cellStyle{fg: "31", bg: "41"}, cellStyle{fg: "31"}, "\x1b[49m"},
+		{cellStyle{attrs: attrBold, fg: "31"}, cellStyle{fg: "31"}, "\x1b[0;31m"},
+	}
+	for _, test := range tests {
+		if s := test.to.sgr(test.from); s != test.expected {
+			t.Errorf("%+v to %+v: expected %q, got %q", test.from, test.to, test.expected, s)
+		}
+	}
+}
+
+func TestParseCells(t *testing.T) {
+	red := cellStyle{fg: "31"}
+	grid := cellWidths(0).parseCells([]string{"a\x1b[31mb\x1b[m  ", "世e\u0301", ""}, 0)
+	expected := [][]cell{
+		{{"a", 1, cellStyle{}}, {"b", 1, red}},
+		{{"世", 2, cellStyle{}}, {"", 0, cellStyle{}}, {"e\u0301", 1, cellStyle{}}},
+		nil,
+	}
+	if !reflect.DeepEqual(grid, expected) {
+		t.Errorf("expected %+v, got %+v", expected, grid)
+	}
+
+	// Lines are cut at the width, without splitting wide characters.
+	grid = cellWidths(0).parseCells([]string{"ab世"}, 3)
+	if len(grid[0]) != 2 {
+		t.Errorf("expected 2 cells, got %+v", grid[0])
+	}
+
+	// Frames with other sequences can't be parsed.
+	for _, line := range []string{"\x1b]8;;http://example.com\x1b\\link", "a\x1b[2Cb", "a\tb"} {
+		if grid := cellWidths(0).parseCells([]string{line}, 0); grid != nil {
+			t.Errorf("%q: expected no cells, got %+v", line, grid)
+		}
+	}
+}
+
+func TestCellRenderer(t *testing.T) {
+	tests := []struct {
+		name      string
+		altScreen bool
+		first     string
+		second    string
+		expected  string
+	}{
+		{
+			name:     "only changed cells are painted",
+			first:    "cpu: 10%\nmem: 20%",
+			second:   "cpu: 12%\nmem: 20%",
+			expected: "\x1b[1A\r\x1b[6C\x1b[m2\x1b[1B\r\x1b[0D",
+		},
+		{
+			name:     "short gaps are painted over",
+			first:    "a1b2c",
+			second:   "a3b4c",
+			expected: "\x1b[1C\x1b[m3b4\r\x1b[0D",
+		},
+		{
+			name:     "styles are changed as little as possible",
+			first:    "\x1b[1;31mab\x1b[m",
+			second:   "\x1b[1;31ma\x1b[32mc\x1b[m",
+			expected: "\x1b[1C\x1b[m\x1b[1;32mc\x1b[m\r\x1b[0D",
+		},
+		{
+			name:     "shorter lines are cleared",
+			first:    "abcd",
+			second:   "ab",
+			expected: "\x1b[2C\x1b[m\x1b[0K\r\x1b[0D",
+		},
+		{
+			name:     "wide characters",
+			first:    "a世b",
+			second:   "a界b",
+			expected: "\x1b[1C\x1b[m界\r\x1b[0D",
+		},
+		{
+			name:      "alt screen",
+			altScreen: true,
+			first:     "a\nb",
+			second:    "a\nc",
+			expected:  "\x1b[mc\x1b[2;0H",
+		},
+		{
+			name:     "frames with other sequences are painted line by line",
+			first:    "ab",
+			second:   "a\x1b]8;;x\x1b\\c",
+			expected: "\r\x1b[1C\x1b[0K\x1b]8;;x\x1b\\c\x1b]8;;\x1b\\\r\x1b[0D",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			r := newRenderer(termenv.NewOutput(&buf), false, 0).(*standardRenderer)
+			r.altScreenActive = test.altScreen
+			r.paintSuffixes = true
+			r.cellDiffing = true
+
+			r.write(test.first)
+			r.flush()
+			buf.Reset()
+
+			r.write(test.second)
+			r.flush()
+			if got := buf.String(); got != test.expected {
+				t.Errorf("expected:\n%q\ngot:\n%q", test.expected, got)
+			}
+		})
+	}
+}
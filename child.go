@@ -0,0 +1,107 @@
+package tea
+
+// ChildLens embeds a child model in a parent model of type P, given how to get
+// the child out of the parent and how to set it back. It does the forwarding a
+// parent would write by hand for each child, like a Component, but leaves the
+// child's fields as they are, so that children can be plain models:
+//
+//	type model struct {
+//	    sidebar sidebar.Model
+//	    editor  editor.Model
+//	}
+//
+//	var (
+//	    sidebarChild = tea.Child(
+//	        func(m model) sidebar.Model { return m.sidebar },
+//	        func(m model, c sidebar.Model) model { m.sidebar = c; return m },
+//	        "sidebar",
+//	    )
+//	    editorChild = tea.Child(
+//	        func(m model) editor.Model { return m.editor },
+//	        func(m model, c editor.Model) model { m.editor = c; return m },
+//	        "editor",
+//	    )
+//	)
+//
+//	func (m model) Init() tea.Cmd {
+//	    return tea.InitChildren(m, sidebarChild, editorChild)
+//	}
+//
+//	func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+//	    return tea.UpdateChildren(m, msg, sidebarChild, editorChild)
+//	}
+type ChildLens[P any] struct {
+	id     interface{}
+	init   func(P) Cmd
+	update func(P, Msg) (P, Cmd)
+	view   func(P) string
+}
+
+// Child returns a lens embedding a child model of type C in a parent model of
+// type P. Commands returned by the child are wrapped with the given ID, which
+// should be unique among its siblings.
+//
+// The child's Update function must return a model of the same type it was
+// called on. If it doesn't, the child is left as it was and updating it
+// returns an ErrMsg for the parent along with the child's command.
+func Child[P any, C Model, ID comparable](get func(P) C, set func(P, C) P, id ID) ChildLens[P] {
+	return ChildLens[P]{
+		id: id,
+		init: func(parent P) Cmd {
+			return wrap(id, get(parent).Init())
+		},
+		update: func(parent P, msg Msg) (P, Cmd) {
+			child, cmd := forward(id, get(parent), msg)
+			return set(parent, child), cmd
+		},
+		view: func(parent P) string {
+			return get(parent).View()
+		},
+	}
+}
+
+// ID returns the ID the child's commands are wrapped with.
+func (l ChildLens[P]) ID() interface{} {
+	return l.id
+}
+
+// Init calls the child's Init function and wraps the resulting command.
+func (l ChildLens[P]) Init(parent P) Cmd {
+	return l.init(parent)
+}
+
+// Update forwards the message to the child if it's addressed to it, or to no
+// one in particular, and returns the parent with the updated child along with
+// the wrapped command. Messages addressed to other children are ignored.
+func (l ChildLens[P]) Update(parent P, msg Msg) (P, Cmd) {
+	return l.update(parent, msg)
+}
+
+// View returns the child's view.
+func (l ChildLens[P]) View(parent P) string {
+	return l.view(parent)
+}
+
+// InitChildren collects the initial commands of the children of a parent
+// into a single batch.
+func InitChildren[P any](parent P, children ...ChildLens[P]) Cmd {
+	cmds := make([]Cmd, 0, len(children))
+	for _, c := range children {
+		cmds = append(cmds, c.Init(parent))
+	}
+	return Batch(cmds...)
+}
+
+// UpdateChildren forwards a message to each of the children of a parent in
+// turn, and returns the updated parent along with the children's commands in
+// a single batch. The parent is returned as a Model, so that UpdateChildren
+// can be returned from its Update function as is.
+func UpdateChildren[P Model](parent P, msg Msg, children ...ChildLens[P]) (Model, Cmd) {
+	cmds := make([]Cmd, 0, len(children))
+	for _, c := range children {
+		var cmd Cmd
+		parent, cmd = c.Update(parent, msg)
+		cmds = append(cmds, cmd)
+	}
+	return parent, Batch(cmds...)
+}
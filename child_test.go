@@ -0,0 +1,77 @@
+package tea
+
+import "testing"
+
+type parentModel struct {
+	left, right counterModel
+}
+
+func (m parentModel) Init() Cmd {
+	return InitChildren(m, leftChild, rightChild)
+}
+
+func (m parentModel) Update(msg Msg) (Model, Cmd) {
+	return UpdateChildren(m, msg, leftChild, rightChild)
+}
+
+func (m parentModel) View() string {
+	return leftChild.View(m) + rightChild.View(m)
+}
+
+var (
+	leftChild = Child(
+		func(m parentModel) counterModel { return m.left },
+		func(m parentModel, c counterModel) parentModel { m.left = c; return m },
+		"left",
+	)
+	rightChild = Child(
+		func(m parentModel) counterModel { return m.right },
+		func(m parentModel, c counterModel) parentModel { m.right = c; return m },
+		"right",
+	)
+)
+
+func TestChild(t *testing.T) {
+	var m parentModel
+
+	batch, ok := m.Init()().(BatchMsg)
+	if !ok || len(batch) != 2 {
+		t.Fatalf("expected a batch of 2 commands, got %#v", batch)
+	}
+	for i, id := range []string{"left", "right"} {
+		if w, ok := run(batch[i]).(WrappedMsg); !ok || w.ID != id {
+			t.Errorf("expected a message wrapped with %q, got %#v", id, w)
+		}
+	}
+
+	// Addressed to a child.
+	next, cmd := m.Update(WrappedMsg{ID: "left", Msg: counterMsg{}})
+	m = next.(parentModel)
+	if m.left.count != 1 || m.right.count != 0 {
+		t.Errorf("expected only the left child to be updated, got %+v", m)
+	}
+	if cmd == nil {
+		t.Fatal("expected a command")
+	}
+	batch, ok = cmd().(BatchMsg)
+	if !ok || len(batch) != 2 {
+		t.Fatalf("expected the child's batch, got %#v", batch)
+	}
+	if w, ok := run(batch[0]).(WrappedMsg); !ok || w.ID != "left" || w.Msg != "done" {
+		t.Errorf("expected the child's message to be wrapped, got %#v", w)
+	}
+	if _, ok := run(batch[1]).(QuitMsg); !ok {
+		t.Error("expected QuitMsg to be left unwrapped")
+	}
+
+	// Addressed to no one in particular.
+	next, _ = m.Update(KeyMsg{Type: KeyEnter})
+	m = next.(parentModel)
+	if m.left.count != 11 || m.right.count != 10 {
+		t.Errorf("expected both children to be updated, got %+v", m)
+	}
+
+	if leftChild.ID() != "left" {
+		t.Errorf("expected ID %q, got %v", "left", leftChild.ID())
+	}
+}
@@ -0,0 +1,160 @@
+package tea
+
+import (
+	"strings"
+	"time"
+)
+
+// defaultKeySequenceTimeout is how long the program waits for the next key of
+// a key sequence by default.
+const defaultKeySequenceTimeout = time.Second
+
+// KeySequenceMsg is sent when the keys of a key sequence registered with
+// WithKeySequences were pressed one after the other, in place of the keys
+// themselves.
+type KeySequenceMsg struct {
+	// Sequence is the sequence as it was registered, such as "g g".
+	Sequence string
+
+	// Keys are the keys making up the sequence.
+	Keys []Key
+}
+
+// String returns the sequence, as it was registered.
+func (k KeySequenceMsg) String() string {
+	return k.Sequence
+}
+
+// keySequenceTimeoutMsg is sent when the timeout for the next key of a key
+// sequence passed. It's handled internally.
+type keySequenceTimeoutMsg struct {
+	gen int
+}
+
+// keySequences recognizes key sequences. Keys starting a sequence are held
+// back until the sequence is complete, they're followed by a key that doesn't
+// continue it, or the timeout passes, in which case they're delivered as
+// they are.
+type keySequences struct {
+	sequences map[string]string // normalized sequences to sequences
+	prefixes  map[string]bool   // normalized prefixes of sequences
+	timeout   time.Duration
+
+	held  []Key
+	timer Timer
+	gen   int
+}
+
+// newKeySequences returns a recognizer for the given sequences, of keys
+// separated by spaces, as returned by Key.String.
+func newKeySequences(sequences []string, timeout time.Duration) *keySequences {
+	s := &keySequences{
+		sequences: map[string]string{},
+		prefixes:  map[string]bool{},
+		timeout:   timeout,
+	}
+	for _, seq := range sequences {
+		keys := strings.Fields(seq)
+		if len(keys) == 0 {
+			continue
+		}
+		s.sequences[strings.Join(keys, " ")] = seq
+		for i := 1; i < len(keys); i++ {
+			s.prefixes[strings.Join(keys[:i], " ")] = true
+		}
+	}
+	return s
+}
+
+// handle returns the messages to deliver in place of a message: none for a
+// key held back, the keys held back once they turn out not to make up a
+// sequence, and a KeySequenceMsg once they do.
+func (s *keySequences) handle(p *Program, msg Msg) []Msg {
+	switch msg := msg.(type) {
+	case KeyMsg:
+		if msg.Paste || msg.IsRelease {
+			break
+		}
+		k := Key(msg)
+		keys := append(s.held, k) //nolint:gocritic
+		name := keysString(keys)
+		if _, ok := s.sequences[name]; ok && !s.prefixes[name] {
+			s.reset()
+			return []Msg{KeySequenceMsg{Sequence: s.sequences[name], Keys: keys}}
+		}
+		if s.prefixes[name] {
+			s.hold(p, keys)
+			return nil
+		}
+		if len(s.held) == 0 {
+			break
+		}
+
+		// The key breaks the sequence held back, and may start another.
+		msgs := s.flush()
+		return append(msgs, s.handle(p, msg)...)
+
+	case keySequenceTimeoutMsg:
+		if msg.gen != s.gen {
+			return nil
+		}
+		return s.flush()
+	}
+	return []Msg{msg}
+}
+
+// hold holds back keys, for the timeout.
+func (s *keySequences) hold(p *Program, keys []Key) {
+	s.held = keys
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	s.gen++
+	gen, t := s.gen, p.clock.NewTimer(s.timeout)
+	s.timer = t
+	go func() {
+		select {
+		case <-t.C():
+			p.Send(keySequenceTimeoutMsg{gen: gen})
+		case <-p.ctx.Done():
+		}
+	}()
+}
+
+// flush returns the messages for the keys held back: a KeySequenceMsg for the
+// longest complete sequence they start with, if any, and the keys left.
+func (s *keySequences) flush() []Msg {
+	held := s.held
+	s.reset()
+
+	var msgs []Msg
+	for n := len(held); n > 0; n-- {
+		if seq, ok := s.sequences[keysString(held[:n])]; ok {
+			msgs = append(msgs, KeySequenceMsg{Sequence: seq, Keys: held[:n]})
+			held = held[n:]
+			break
+		}
+	}
+	for _, k := range held {
+		msgs = append(msgs, KeyMsg(k))
+	}
+	return msgs
+}
+
+func (s *keySequences) reset() {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	s.held = nil
+	s.gen++
+}
+
+// keysString returns the keys of a sequence, separated by spaces.
+func keysString(keys []Key) string {
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		names[i] = k.String()
+	}
+	return strings.Join(names, " ")
+}
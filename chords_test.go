@@ -0,0 +1,57 @@
+package tea
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKeySequences(t *testing.T) {
+	clock := manualClock{timers: make(chan instantTimer, 64)}
+	p := NewProgram(nil, WithClock(clock), WithKeySequences("g g", "ctrl+x  ctrl+s", "z z", "z z z"))
+	defer p.cancel()
+	s := p.keySequences
+
+	g := KeyMsg{Type: KeyRunes, Runes: []rune("g")}
+	a := KeyMsg{Type: KeyRunes, Runes: []rune("a")}
+	z := KeyMsg{Type: KeyRunes, Runes: []rune("z")}
+	ctrlX, ctrlS := KeyMsg{Type: KeyCtrlX}, KeyMsg{Type: KeyCtrlS}
+
+	tests := []struct {
+		name string
+		msg  Msg
+		want []Msg
+	}{
+		{"other key", a, []Msg{a}},
+		{"start", g, nil},
+		{"complete", g, []Msg{KeySequenceMsg{Sequence: "g g", Keys: []Key{Key(g), Key(g)}}}},
+		{"start with modifier", ctrlX, nil},
+		{"complete with modifier", ctrlS, []Msg{KeySequenceMsg{Sequence: "ctrl+x  ctrl+s", Keys: []Key{Key(ctrlX), Key(ctrlS)}}}},
+		{"start again", ctrlX, nil},
+		{"break", a, []Msg{ctrlX, a}},
+		{"break and start", ctrlX, nil},
+		{"other start", g, []Msg{ctrlX}},
+		{"complete other", g, []Msg{KeySequenceMsg{Sequence: "g g", Keys: []Key{Key(g), Key(g)}}}},
+		{"prefix", z, nil},
+		{"complete prefix", z, nil},
+		{"break complete prefix", a, []Msg{KeySequenceMsg{Sequence: "z z", Keys: []Key{Key(z), Key(z)}}, a}},
+		{"paste", KeyMsg{Type: KeyRunes, Runes: []rune("g"), Paste: true}, []Msg{KeyMsg{Type: KeyRunes, Runes: []rune("g"), Paste: true}}},
+	}
+	for _, test := range tests {
+		if got := s.handle(p, test.msg); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: expected %#v, got %#v", test.name, test.want, got)
+		}
+	}
+
+	// Keys held back are delivered once the timeout passes, unless a later
+	// key restarted it.
+	s.handle(p, g)
+	stale := keySequenceTimeoutMsg{gen: s.gen}
+	s.handle(p, ctrlS)
+	s.handle(p, g)
+	if got := s.handle(p, stale); got != nil {
+		t.Errorf("expected a stale timeout to be ignored, got %#v", got)
+	}
+	if got := s.handle(p, keySequenceTimeoutMsg{gen: s.gen}); !reflect.DeepEqual(got, []Msg{g}) {
+		t.Errorf("expected the key held back to be delivered, got %#v", got)
+	}
+}
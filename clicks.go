@@ -0,0 +1,41 @@
+package tea
+
+import "time"
+
+// defaultMultiClickInterval is the longest time between the clicks of a
+// double or triple click by default, as on most desktops.
+const defaultMultiClickInterval = 500 * time.Millisecond
+
+// clickCounter counts the clicks in a row of a mouse button.
+type clickCounter struct {
+	at     time.Time
+	x, y   int
+	button MouseButton
+	clicks int
+}
+
+// count returns the number of clicks in a row a mouse event is part of, and
+// keeps track of presses to count the clicks of the ones that follow.
+func (c *clickCounter) count(m MouseEvent, now time.Time, interval time.Duration) int {
+	if m.IsWheel() {
+		return 0
+	}
+
+	switch m.Action {
+	case MouseActionPress:
+		again := m.Button == c.button && m.X == c.x && m.Y == c.y
+		if interval > 0 && c.clicks > 0 && again && now.Sub(c.at) <= interval {
+			c.clicks++
+		} else {
+			c.clicks = 1
+		}
+		c.at, c.x, c.y, c.button = now, m.X, m.Y, m.Button
+		return c.clicks
+	case MouseActionRelease:
+		// X10 releases don't tell which button was released.
+		if m.Button == c.button || m.Button == MouseButtonNone {
+			return c.clicks
+		}
+	}
+	return 0
+}
@@ -0,0 +1,45 @@
+package tea
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClickCounter(t *testing.T) {
+	var c clickCounter
+	start := time.Unix(0, 0)
+	press := MouseEvent{X: 1, Y: 2, Button: MouseButtonLeft, Action: MouseActionPress}
+	release := MouseEvent{X: 1, Y: 2, Button: MouseButtonLeft, Action: MouseActionRelease}
+
+	tests := []struct {
+		name   string
+		event  MouseEvent
+		after  time.Duration
+		clicks int
+	}{
+		{"first press", press, 0, 1},
+		{"release", release, 100 * time.Millisecond, 1},
+		{"double click", press, 200 * time.Millisecond, 2},
+		{"double click release", release, 250 * time.Millisecond, 2},
+		{"triple click", press, 400 * time.Millisecond, 3},
+		{"motion", MouseEvent{X: 1, Y: 2, Action: MouseActionMotion}, 450 * time.Millisecond, 0},
+		{"wheel", MouseEvent{X: 1, Y: 2, Button: MouseButtonWheelUp}, 450 * time.Millisecond, 0},
+		{"too late", press, 1000 * time.Millisecond, 1},
+		{"other cell", MouseEvent{X: 2, Y: 2, Button: MouseButtonLeft}, 1100 * time.Millisecond, 1},
+		{"other button", MouseEvent{X: 2, Y: 2, Button: MouseButtonRight}, 1200 * time.Millisecond, 1},
+		{"x10 release", MouseEvent{X: 2, Y: 2, Action: MouseActionRelease}, 1250 * time.Millisecond, 1},
+	}
+	for _, test := range tests {
+		if got := c.count(test.event, start.Add(test.after), defaultMultiClickInterval); got != test.clicks {
+			t.Errorf("%s: expected %d clicks, got %d", test.name, test.clicks, got)
+		}
+	}
+
+	// Without an interval, every click is a single click.
+	c = clickCounter{}
+	for i := 0; i < 2; i++ {
+		if got := c.count(press, start, 0); got != 1 {
+			t.Errorf("expected a single click, got %d", got)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package tea
+
+import "github.com/charmbracelet/bubbletea/input"
+
+// ClipboardMsg is sent to the program's update function when the terminal
+// reports the contents of the system clipboard, in response to ReadClipboard.
+type ClipboardMsg = input.ClipboardMsg
+
+// setClipboardMsg is an internal message used to set the system clipboard.
+type setClipboardMsg string
+
+// SetClipboard produces a command that copies the given string to the system
+// clipboard using the OSC 52 escape sequence. This works over SSH, as it's
+// the terminal emulator that sets the clipboard, not the machine the program
+// is running on.
+//
+// Note that not all terminals support OSC 52, and some only support it once
+// it's been explicitly enabled.
+//
+//	func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+//	    switch msg := msg.(type) {
+//	    case tea.KeyMsg:
+//	        if msg.String() == "y" {
+//	            return m, tea.SetClipboard(m.selection)
+//	        }
+//	    }
+//	    return m, nil
+//	}
+func SetClipboard(s string) Cmd {
+	return func() Msg {
+		return setClipboardMsg(s)
+	}
+}
+
+// readClipboardMsg is an internal message used to query the system
+// clipboard.
+type readClipboardMsg struct{}
+
+// ReadClipboard is a special command that asks the terminal to report the
+// contents of the system clipboard using the OSC 52 escape sequence. If the
+// terminal supports it, the contents will be delivered to the program's
+// update function as a ClipboardMsg.
+//
+// Many terminals don't allow reading the clipboard, or ask the user for
+// permission first, so programs shouldn't rely on a ClipboardMsg arriving.
+func ReadClipboard() Msg {
+	return readClipboardMsg{}
+}
@@ -0,0 +1,47 @@
+package tea
+
+import "time"
+
+// Clock is the source of time for the timers of a program, such as the ones
+// started by Tick, Every and Cron. By default, the program uses the system
+// clock; set another one with WithClock, for instance to drive animations and
+// timeouts deterministically in tests.
+//
+// Timer commands don't start their timer themselves: they have the program
+// running them start it, on its own clock, so programs running at the same
+// time each keep their own.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// NewTimer returns a timer that sends the current time on its channel
+	// once the given duration has elapsed.
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a timer created by a Clock.
+type Timer interface {
+	// C returns the channel on which the time is sent when the timer
+	// fires.
+	C() <-chan time.Time
+
+	// Stop prevents the timer from firing. It returns false if the timer
+	// has already fired or been stopped.
+	Stop() bool
+}
+
+// systemClock is the default Clock, which uses the system's time.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) NewTimer(d time.Duration) Timer {
+	return systemTimer{time.NewTimer(d)}
+}
+
+type systemTimer struct {
+	t *time.Timer
+}
+
+func (t systemTimer) C() <-chan time.Time { return t.t.C }
+func (t systemTimer) Stop() bool          { return t.t.Stop() }
@@ -0,0 +1,109 @@
+package tea
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// instantClock is a clock stuck at a given time, whose timers fire right
+// away.
+type instantClock struct {
+	now time.Time
+}
+
+func (c instantClock) Now() time.Time { return c.now }
+
+func (c instantClock) NewTimer(d time.Duration) Timer {
+	ch := make(chan time.Time, 1)
+	ch <- c.now.Add(d)
+	return instantTimer(ch)
+}
+
+type instantTimer chan time.Time
+
+func (t instantTimer) C() <-chan time.Time { return t }
+func (t instantTimer) Stop() bool          { return false }
+
+func TestWithClock(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 30, 0, time.UTC)
+	p := NewProgram(nil, WithClock(instantClock{now}))
+
+	tests := []struct {
+		name     string
+		cmd      Cmd
+		expected time.Time
+	}{
+		{
+			name:     "tick",
+			cmd:      Tick(time.Hour, func(t time.Time) Msg { return t }),
+			expected: now.Add(time.Hour),
+		},
+		{
+			name:     "every",
+			cmd:      Every(time.Minute, func(t time.Time) Msg { return t }),
+			expected: now.Add(30 * time.Second),
+		},
+		{
+			name:     "cron",
+			cmd:      MustCron("0 * * * *", func(t time.Time) Msg { return t }),
+			expected: now.Add(59*time.Minute + 30*time.Second),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			msg := p.runCmd(test.cmd)
+			if ts, ok := msg.(time.Time); !ok || !ts.Equal(test.expected) {
+				t.Fatalf("expected %v, got %v", test.expected, msg)
+			}
+		})
+	}
+}
+
+// clockModel quits with the time of the tick it starts.
+type clockModel struct {
+	tick time.Time
+}
+
+func (m clockModel) Init() Cmd {
+	return Tick(time.Second, func(t time.Time) Msg { return t })
+}
+
+func (m clockModel) Update(msg Msg) (Model, Cmd) {
+	if t, ok := msg.(time.Time); ok {
+		m.tick = t
+		return m, Quit
+	}
+	return m, nil
+}
+
+func (m clockModel) View() string {
+	return ""
+}
+
+func TestWithClockPerProgram(t *testing.T) {
+	starts := []time.Time{
+		time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+	}
+	done := make(chan struct{}, len(starts))
+	for _, start := range starts {
+		start := start
+		go func() {
+			var buf bytes.Buffer
+			var in bytes.Buffer
+			p := NewProgram(clockModel{}, WithInput(&in), WithOutput(&buf), WithClock(instantClock{start}))
+			m, err := p.Run()
+			if err != nil {
+				t.Error(err)
+			}
+			if tick := m.(clockModel).tick; !tick.Equal(start.Add(time.Second)) {
+				t.Errorf("expected a tick on the program's clock at %v, got %v", start.Add(time.Second), tick)
+			}
+			done <- struct{}{}
+		}()
+	}
+	for range starts {
+		<-done
+	}
+}
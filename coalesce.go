@@ -0,0 +1,94 @@
+package tea
+
+import "time"
+
+// isMotion reports whether a message is a mouse motion event, which can be
+// coalesced with the ones following it.
+func isMotion(msg Msg) bool {
+	m, ok := msg.(MouseMsg)
+	return ok && m.Action == MouseActionMotion
+}
+
+// frameInterval returns the time between frames at the framerate the program
+// renders at.
+func (p *Program) frameInterval() time.Duration {
+	fps := p.fps
+	if fps < 1 {
+		fps = defaultFPS
+	} else if fps > maxFPS {
+		fps = maxFPS
+	}
+	return time.Second / time.Duration(fps)
+}
+
+// coalesceMotion forwards the messages read from the input to the program,
+// keeping only the last of the mouse motion events received within a frame.
+// Other messages, presses and releases among them, are forwarded right away,
+// after the motion before them, so that the order of button transitions is
+// kept. It returns once in is closed, after forwarding the last motion.
+func (p *Program) coalesceMotion(in <-chan Msg) {
+	var (
+		pending Msg
+		timer   Timer
+	)
+	flush := func() bool {
+		if timer != nil {
+			timer.Stop()
+			timer = nil
+		}
+		if pending == nil {
+			return true
+		}
+		msg := pending
+		pending = nil
+		select {
+		case p.msgs <- msg:
+			return true
+		case <-p.ctx.Done():
+			return false
+		}
+	}
+
+	for {
+		var tick <-chan time.Time
+		if timer != nil {
+			tick = timer.C()
+		}
+
+		select {
+		case <-p.ctx.Done():
+			return
+
+		case msg, ok := <-in:
+			if !ok {
+				flush()
+				return
+			}
+			if isMotion(msg) {
+				// Motions with other buttons held are kept apart.
+				if pending != nil && pending.(MouseMsg).Button != msg.(MouseMsg).Button && !flush() {
+					return
+				}
+				pending = msg
+				if timer == nil {
+					timer = p.clock.NewTimer(p.frameInterval())
+				}
+				continue
+			}
+			if !flush() {
+				return
+			}
+			select {
+			case p.msgs <- msg:
+			case <-p.ctx.Done():
+				return
+			}
+
+		case <-tick:
+			timer = nil
+			if !flush() {
+				return
+			}
+		}
+	}
+}
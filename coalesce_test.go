@@ -0,0 +1,68 @@
+package tea
+
+import (
+	"testing"
+	"time"
+)
+
+// manualClock is a clock whose timers fire when told to.
+type manualClock struct {
+	timers chan instantTimer
+}
+
+func (c manualClock) Now() time.Time { return time.Time{} }
+
+func (c manualClock) NewTimer(time.Duration) Timer {
+	t := make(instantTimer, 1)
+	c.timers <- t
+	return t
+}
+
+func TestCoalesceMotion(t *testing.T) {
+	clock := manualClock{timers: make(chan instantTimer, 8)}
+	p := NewProgram(nil, WithClock(clock))
+
+	in := make(chan Msg)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p.coalesceMotion(in)
+	}()
+
+	motion := func(x int) MouseMsg {
+		return MouseMsg{X: x, Button: MouseButtonLeft, Action: MouseActionMotion}
+	}
+	expect := func(want Msg) {
+		t.Helper()
+		select {
+		case got := <-p.msgs:
+			if got != want {
+				t.Errorf("expected %#v, got %#v", want, got)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("expected %#v, got nothing", want)
+		}
+	}
+
+	// Motions within a frame are coalesced, up to the next press.
+	in <- motion(1)
+	in <- motion(2)
+	press := MouseMsg{X: 2, Button: MouseButtonLeft, Action: MouseActionPress}
+	in <- press
+	expect(motion(2))
+	expect(press)
+
+	// Motions are sent at the end of the frame.
+	in <- motion(3)
+	in <- motion(4)
+	<-clock.timers
+	timer := <-clock.timers
+	timer <- time.Time{}
+	expect(motion(4))
+
+	// The last motion is sent when the input ends.
+	in <- motion(5)
+	close(in)
+	expect(motion(5))
+	<-done
+}
@@ -1,6 +1,9 @@
 package tea
 
 import (
+	"context"
+	"math/rand"
+	"sync"
 	"time"
 )
 
@@ -36,6 +39,88 @@ func Batch(cmds ...Cmd) Cmd {
 // no ordering guarantees. You can send a BatchMsg with Batch.
 type BatchMsg []Cmd
 
+// BatchN is like Batch, but runs at most limit of the given commands at the
+// same time. Use it when fanning out many commands, such as network requests,
+// that would otherwise overwhelm an API or run out of file descriptors.
+//
+//	func (m model) Init() Cmd {
+//	    cmds := make([]tea.Cmd, len(m.urls))
+//	    for i, url := range m.urls {
+//	        cmds[i] = fetch(url)
+//	    }
+//	    return tea.BatchN(4, cmds...)
+//	}
+//
+// A command holds its slot until its message is produced, including the ones
+// produced later by the program, such as the messages of Retry and Tick, so
+// commands made with BatchN must be run by a program.
+//
+// If limit is less than 1, BatchN is equivalent to Batch.
+func BatchN(limit int, cmds ...Cmd) Cmd {
+	var validCmds []Cmd //nolint:prealloc
+	for _, c := range cmds {
+		if c == nil {
+			continue
+		}
+		validCmds = append(validCmds, c)
+	}
+	if limit < 1 || len(validCmds) <= limit {
+		return Batch(validCmds...)
+	}
+	return func() Msg {
+		sem := make(chan struct{}, limit)
+		limited := make(BatchMsg, len(validCmds))
+		for i, cmd := range validCmds {
+			cmd := cmd
+			limited[i] = func() Msg {
+				return limitedMsg{cmd: cmd, sem: sem}
+			}
+		}
+		return limited
+	}
+}
+
+// limitedMsg is an internal message used to have the program run a command of
+// BatchN once a slot of sem is free. The messages delivered go through final
+// and progress, if set, like the ones of the deferred messages it produces.
+type limitedMsg struct {
+	cmd             Cmd
+	sem             chan struct{}
+	final, progress func(Msg) Msg
+}
+
+func (l limitedMsg) run(p *Program, ctx context.Context) Msg {
+	select {
+	case l.sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil
+	case <-p.stopped:
+		return nil
+	}
+	defer func() { <-l.sem }()
+
+	msg := p.callOnWorker(l.cmd)
+	if later, ok := msg.(deferredMsg); ok {
+		// Hold the slot until the message is produced.
+		if l.final != nil || l.progress != nil {
+			msg = later.then(chain(l.final, identity), chain(l.progress, identity))
+		}
+		return p.settle(ctx, msg)
+	}
+	return through(l.final, msg)
+}
+
+func (l limitedMsg) then(final, progress func(Msg) Msg) Msg {
+	l.final = chain(l.final, final)
+	l.progress = chain(l.progress, progress)
+	return l
+}
+
+// identity returns a message as is.
+func identity(msg Msg) Msg {
+	return msg
+}
+
 // Sequence runs the given commands one at a time, in order. Contrast this with
 // Batch, which runs commands concurrently.
 func Sequence(cmds ...Cmd) Cmd {
@@ -47,6 +132,79 @@ func Sequence(cmds ...Cmd) Cmd {
 // sequenceMsg is used internally to run the given commands in order.
 type sequenceMsg []Cmd
 
+// SequenceDoneMsg is sent once all the steps of a SequenceUntil have run, or
+// it has been stopped early.
+type SequenceDoneMsg struct {
+	// Aborted reports whether the sequence was stopped before running all
+	// of its commands.
+	Aborted bool
+
+	// Msg is the message that stopped the sequence, if it was aborted.
+	Msg Msg
+}
+
+// SequenceUntil is like Sequence, but stops running the remaining commands as
+// soon as one of them returns a message for which stop returns true. Every
+// message produced up to that point, including the one that stopped the
+// sequence, is delivered as usual, followed by a SequenceDoneMsg.
+//
+//	cmd := tea.SequenceUntil(func(msg tea.Msg) bool {
+//	    _, failed := msg.(buildFailedMsg)
+//	    return failed
+//	}, fetch, build, deploy)
+//
+// Note that stop is called with the message each command returns; messages
+// from commands batched within a step, and the ones Stream jobs send along
+// the way, are not checked.
+func SequenceUntil(stop func(Msg) bool, cmds ...Cmd) Cmd {
+	return func() Msg {
+		// Steps run one at a time, so there's no need to synchronize.
+		var done SequenceDoneMsg
+
+		steps := make(sequenceMsg, 0, len(cmds)+1)
+		for _, cmd := range cmds {
+			if cmd == nil {
+				continue
+			}
+			cmd := cmd
+			steps = append(steps, func() Msg {
+				if done.Aborted {
+					return nil
+				}
+				check := func(msg Msg) Msg {
+					if msg != nil && stop(msg) {
+						done = SequenceDoneMsg{Aborted: true, Msg: msg}
+					}
+					return msg
+				}
+				msg := cmd()
+				if later, ok := msg.(deferredMsg); ok {
+					// Check the message once it's produced; the
+					// sequence waits for it before going on. The ones
+					// delivered along the way, such as RetryMsgs, don't
+					// stop the sequence.
+					return later.then(check, func(m Msg) Msg { return m })
+				}
+				return check(msg)
+			})
+		}
+		steps = append(steps, func() Msg {
+			return done
+		})
+		return steps
+	}
+}
+
+// SequenceAbortOnErr is like Sequence, but stops running the remaining
+// commands as soon as one of them returns a message that is an error. See
+// SequenceUntil.
+func SequenceAbortOnErr(cmds ...Cmd) Cmd {
+	return SequenceUntil(func(msg Msg) bool {
+		_, isErr := msg.(error)
+		return isErr
+	}, cmds...)
+}
+
 // Every is a command that ticks in sync with the system clock. So, if you
 // wanted to tick with the system clock every second, minute or hour you
 // could use this. It's also handy for having different things tick in sync.
@@ -93,23 +251,28 @@ type sequenceMsg []Cmd
 //	}
 //
 // Every is analogous to Tick in the Elm Architecture.
+//
+// The timer is run by the program, using its clock, which can be replaced
+// with WithClock. Such commands must therefore be run by a program, directly
+// or through Batch, Sequence and the like: calling them yourself returns an
+// internal message rather than waiting.
 func Every(duration time.Duration, fn func(time.Time) Msg) Cmd {
-	n := time.Now()
-	d := n.Truncate(duration).Add(duration).Sub(n)
-	t := time.NewTimer(d)
 	return func() Msg {
-		ts := <-t.C
-		t.Stop()
-		for len(t.C) > 0 {
-			<-t.C
-		}
-		return fn(ts)
+		return scheduleMsg{next: everyNext(duration), fn: fn}
+	}
+}
+
+// everyNext returns a function giving the next time that's a multiple of the
+// duration.
+func everyNext(duration time.Duration) func(time.Time) time.Time {
+	return func(now time.Time) time.Time {
+		return now.Truncate(duration).Add(duration)
 	}
 }
 
 // Tick produces a command at an interval independent of the system clock at
-// the given duration. That is, the timer begins precisely when invoked,
-// and runs for its entire duration.
+// the given duration. That is, the timer begins precisely when the command
+// is run, and runs for its entire duration.
 //
 // To produce the command, pass a duration and a function which returns
 // a message containing the time at which the tick occurred.
@@ -145,15 +308,103 @@ func Every(duration time.Duration, fn func(time.Time) Msg) Cmd {
 //	    }
 //	    return m, nil
 //	}
+//
+// Like with Every, the timer is run by the program, using its clock.
 func Tick(d time.Duration, fn func(time.Time) Msg) Cmd {
-	t := time.NewTimer(d)
 	return func() Msg {
-		ts := <-t.C
-		t.Stop()
-		for len(t.C) > 0 {
-			<-t.C
+		return scheduleMsg{next: tickNext(d), fn: fn}
+	}
+}
+
+// tickNext returns a function giving the time once the duration has elapsed.
+func tickNext(d time.Duration) func(time.Time) time.Time {
+	return func(now time.Time) time.Time {
+		return now.Add(d)
+	}
+}
+
+// EveryWithContext is like Every, but the timer is stopped when the given
+// context is done, in which case the command returns no message. Use it to
+// cancel a pending tick, for instance when the part of the UI it animates is
+// closed:
+//
+//	func tickEvery(ctx context.Context) tea.Cmd {
+//	    return tea.EveryWithContext(ctx, time.Second, func(t time.Time) tea.Msg {
+//	        return TickMsg(t)
+//	    })
+//	}
+//
+// Pending ticks, of Every and Tick too, are always dropped when the program
+// exits, so they don't hold it up; the context is for stopping them earlier.
+func EveryWithContext(ctx context.Context, duration time.Duration, fn func(time.Time) Msg) Cmd {
+	return func() Msg {
+		return scheduleMsg{ctx: ctx, next: everyNext(duration), fn: fn}
+	}
+}
+
+// EveryOptions shift the ticks of EveryWithOptions from the multiples of their
+// duration.
+type EveryOptions struct {
+	// Phase shifts ticks by a fixed offset, so that with a duration of a
+	// minute and a phase of 15 seconds, they happen at a quarter past every
+	// minute. Phases longer than the duration wrap around.
+	Phase time.Duration
+
+	// Jitter delays each tick by a random duration up to Jitter, so that
+	// programs ticking in sync, such as dashboards polling the same backend,
+	// spread their requests out rather than sending them all at once.
+	Jitter time.Duration
+}
+
+// EveryWithOptions is like Every, but ticks are shifted from the multiples of
+// the duration by the phase and the jitter of the options:
+//
+//	// Poll every 10 seconds, at 5 seconds past, give or take a second.
+//	func poll() tea.Cmd {
+//	    return tea.EveryWithOptions(10*time.Second, tea.EveryOptions{
+//	        Phase:  4 * time.Second,
+//	        Jitter: 2 * time.Second,
+//	    }, func(t time.Time) tea.Msg {
+//	        return pollMsg(t)
+//	    })
+//	}
+func EveryWithOptions(duration time.Duration, opts EveryOptions, fn func(time.Time) Msg) Cmd {
+	return func() Msg {
+		return scheduleMsg{next: everyShiftedNext(duration, opts), fn: fn}
+	}
+}
+
+// jitter is the source of the random delays of EveryWithOptions, seeded
+// apart from the global source so that programs don't share their delays.
+var jitter = struct {
+	sync.Mutex
+	*rand.Rand
+}{Rand: rand.New(rand.NewSource(time.Now().UnixNano()))} //nolint:gosec
+
+// everyShiftedNext returns a function giving the next time that's a multiple
+// of the duration shifted by the phase, delayed by a random jitter.
+func everyShiftedNext(duration time.Duration, opts EveryOptions) func(time.Time) time.Time {
+	phase := opts.Phase % duration
+	if phase < 0 {
+		phase += duration
+	}
+	return func(now time.Time) time.Time {
+		next := now.Add(-phase).Truncate(duration).Add(duration + phase)
+		if opts.Jitter > 0 {
+			jitter.Lock()
+			next = next.Add(time.Duration(jitter.Int63n(int64(opts.Jitter))))
+			jitter.Unlock()
 		}
-		return fn(ts)
+		return next
+	}
+}
+
+// TickWithContext is like Tick, but the timer is stopped when the given
+// context is done, in which case the command returns no message. See
+// EveryWithContext.
+func TickWithContext(ctx context.Context, d time.Duration, fn func(time.Time) Msg) Cmd {
+	return func() Msg {
+		return scheduleMsg{ctx: ctx, next: tickNext(d), fn: fn}
 	}
 }
 
@@ -185,6 +436,185 @@ func Sequentially(cmds ...Cmd) Cmd {
 	}
 }
 
+// Stream produces a command that runs a long job which can report progress
+// along the way. The job is given a send function that delivers intermediate
+// messages to the program's update function, like Program.Send does, and the
+// message it returns is delivered once it's done.
+//
+//	func download(url string) tea.Cmd {
+//	    return tea.Stream(func(send func(tea.Msg)) tea.Msg {
+//	        err := fetch(url, func(progress float64) {
+//	            send(progressMsg(progress))
+//	        })
+//	        if err != nil {
+//	            return tea.ErrMsg{Err: err}
+//	        }
+//	        return doneMsg{}
+//	    })
+//	}
+//
+// This saves capturing the Program in commands so they can call Program.Send.
+// It's safe to call send after the program has exited; the message is simply
+// dropped.
+func Stream(job func(send func(Msg)) Msg) Cmd {
+	return func() Msg {
+		return streamMsg{job: job}
+	}
+}
+
+// streamMsg is an internal message used to run a streaming job with access
+// to the program. You can send a streamMsg with Stream.
+type streamMsg struct {
+	job func(send func(Msg)) Msg
+}
+
+func (s streamMsg) run(p *Program, _ context.Context) Msg {
+	return s.job(p.Send)
+}
+
+func (s streamMsg) then(final, progress func(Msg) Msg) Msg {
+	job := s.job
+	s.job = func(send func(Msg)) Msg {
+		return final(job(func(msg Msg) {
+			if msg := progress(msg); msg != nil {
+				send(msg)
+			}
+		}))
+	}
+	return s
+}
+
+// ErrMsg is a message carrying an error returned by a command. Commands made
+// with Try report their errors as an ErrMsg, so programs and components can
+// handle errors from any source in one place:
+//
+//	func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+//	    switch msg := msg.(type) {
+//	    case tea.ErrMsg:
+//	        m.err = msg
+//	        return m, nil
+//	    }
+//	    return m, nil
+//	}
+//
+// ErrMsg implements the error interface, so it also stops a
+// SequenceAbortOnErr.
+type ErrMsg struct {
+	Err error
+}
+
+// Error implements the error interface.
+func (e ErrMsg) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error, for use with errors.Is and errors.As.
+func (e ErrMsg) Unwrap() error {
+	return e.Err
+}
+
+// CmdE is a command that can fail. Use Try to turn it into a Cmd.
+type CmdE func() (Msg, error)
+
+// Try produces a command that runs the given fallible command, delivering its
+// message if it succeeds, or an ErrMsg if it returns an error.
+//
+//	func fetch(url string) tea.Cmd {
+//	    return tea.Try(func() (tea.Msg, error) {
+//	        resp, err := http.Get(url)
+//	        if err != nil {
+//	            return nil, err
+//	        }
+//	        defer resp.Body.Close()
+//	        return statusMsg(resp.StatusCode), nil
+//	    })
+//	}
+func Try(cmd CmdE) Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() Msg {
+		msg, err := cmd()
+		if err != nil {
+			return ErrMsg{Err: err}
+		}
+		return msg
+	}
+}
+
+// Map produces a command that runs cmd and delivers its message as
+// transformed by fn, which can drop it by returning nil. The messages of
+// commands batched or sequenced by cmd are transformed each, as are the ones
+// sent by Stream jobs and those of scheduled commands once they're produced,
+// so reusable commands can be adapted to a model's own messages:
+//
+//	func (m model) save() tea.Cmd {
+//	    return tea.Map(store.Save(m.doc), func(msg tea.Msg) tea.Msg {
+//	        if err, ok := msg.(tea.ErrMsg); ok {
+//	            return statusMsg("couldn't save: " + err.Error())
+//	        }
+//	        return statusMsg("saved")
+//	    })
+//	}
+//
+// Messages for the program itself, such as the one of Quit, are left as is.
+func Map(cmd Cmd, fn func(Msg) Msg) Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() Msg {
+		return mapMsg(cmd(), fn)
+	}
+}
+
+// Discard produces a command that runs cmd and drops its message if discard
+// reports true for it. Like with Map, the messages of commands batched or
+// sequenced by cmd are checked each.
+//
+//	// Only report failures.
+//	cmd := tea.Discard(save(doc), func(msg tea.Msg) bool {
+//	    _, failed := msg.(error)
+//	    return !failed
+//	})
+func Discard(cmd Cmd, discard func(Msg) bool) Cmd {
+	return Map(cmd, func(msg Msg) Msg {
+		if discard(msg) {
+			return nil
+		}
+		return msg
+	})
+}
+
+// mapMsg transforms the message of a command with fn, recursing into the
+// commands of internal messages, and transforming the messages produced
+// later by the program once they're produced.
+func mapMsg(msg Msg, fn func(Msg) Msg) Msg {
+	switch msg := msg.(type) {
+	case nil:
+		return nil
+	case BatchMsg:
+		cmds := make(BatchMsg, len(msg))
+		for i, cmd := range msg {
+			cmds[i] = Map(cmd, fn)
+		}
+		return cmds
+	case sequenceMsg:
+		cmds := make(sequenceMsg, len(msg))
+		for i, cmd := range msg {
+			cmds[i] = Map(cmd, fn)
+		}
+		return cmds
+	case deferredMsg:
+		// Transform the messages produced later, all of them.
+		mapped := func(m Msg) Msg { return mapMsg(m, fn) }
+		return msg.then(mapped, mapped)
+	}
+	if isRuntimeMsg(msg) {
+		return msg
+	}
+	return fn(msg)
+}
+
 // setWindowTitleMsg is an internal message used to set the window title.
 type setWindowTitleMsg string
 
@@ -196,8 +626,46 @@ type setWindowTitleMsg string
 //	    // Set title.
 //	    return tea.SetWindowTitle("My App")
 //	}
+//
+// The original title will be restored when the program exits, provided the
+// terminal supports the title stack (XTWINOPS 22 and 23).
 func SetWindowTitle(title string) Cmd {
 	return func() Msg {
 		return setWindowTitleMsg(title)
 	}
 }
+
+// pushWindowTitleMsg is an internal message used to save the current terminal
+// title and set a new one.
+type pushWindowTitleMsg string
+
+// PushWindowTitle produces a command that saves the current terminal title on
+// the terminal's title stack and then sets a new title. Use PopWindowTitle to
+// restore the saved title. This is useful for nested views, or tools that
+// temporarily take over the terminal, that shouldn't clobber the title
+// permanently.
+//
+//	func (m model) openEditor() (tea.Model, tea.Cmd) {
+//	    return m, tea.PushWindowTitle("Editing " + m.filename)
+//	}
+//
+//	func (m model) closeEditor() (tea.Model, tea.Cmd) {
+//	    return m, tea.PopWindowTitle
+//	}
+//
+// Any titles still on the stack will be popped when the program exits.
+func PushWindowTitle(title string) Cmd {
+	return func() Msg {
+		return pushWindowTitleMsg(title)
+	}
+}
+
+// popWindowTitleMsg is an internal message used to restore the terminal title
+// saved with PushWindowTitle.
+type popWindowTitleMsg struct{}
+
+// PopWindowTitle is a special command that restores the terminal title saved
+// by the last PushWindowTitle. If there's no title to restore it's a no-op.
+func PopWindowTitle() Msg {
+	return popWindowTitleMsg{}
+}
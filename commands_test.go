@@ -1,29 +1,149 @@
 package tea
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
 
+// run runs a command as a program would, waiting for its message if it's
+// scheduled.
+func run(cmd Cmd) Msg {
+	return NewProgram(nil).runCmd(cmd)
+}
+
 func TestEvery(t *testing.T) {
 	expected := "every ms"
-	msg := Every(time.Millisecond, func(t time.Time) Msg {
+	msg := run(Every(time.Millisecond, func(t time.Time) Msg {
 		return expected
-	})()
+	}))
 	if expected != msg {
 		t.Fatalf("expected a msg %v but got %v", expected, msg)
 	}
 }
 
+func TestEveryWithOptions(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 10, 0, time.UTC)
+	tests := []struct {
+		name string
+		opts EveryOptions
+		want time.Time
+	}{
+		{"aligned", EveryOptions{}, now.Add(50 * time.Second)},
+		{"phase", EveryOptions{Phase: 15 * time.Second}, now.Add(5 * time.Second)},
+		{"phase passed", EveryOptions{Phase: 5 * time.Second}, now.Add(55 * time.Second)},
+		{"long phase", EveryOptions{Phase: 75 * time.Second}, now.Add(5 * time.Second)},
+		{"negative phase", EveryOptions{Phase: -45 * time.Second}, now.Add(5 * time.Second)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := everyShiftedNext(time.Minute, test.opts)(now); !got.Equal(test.want) {
+				t.Errorf("expected the next tick at %v, got %v", test.want, got)
+			}
+		})
+	}
+
+	t.Run("jitter", func(t *testing.T) {
+		next := everyShiftedNext(time.Minute, EveryOptions{Jitter: time.Second})
+		aligned := now.Add(50 * time.Second)
+		for i := 0; i < 100; i++ {
+			if got := next(now); got.Before(aligned) || !got.Before(aligned.Add(time.Second)) {
+				t.Fatalf("expected the next tick within a second of %v, got %v", aligned, got)
+			}
+		}
+	})
+
+	msg := run(EveryWithOptions(time.Millisecond, EveryOptions{Jitter: time.Millisecond}, func(time.Time) Msg {
+		return "tick"
+	}))
+	if msg != "tick" {
+		t.Fatalf("expected a msg %v but got %v", "tick", msg)
+	}
+}
+
 func TestTick(t *testing.T) {
 	expected := "tick"
-	msg := Tick(time.Millisecond, func(t time.Time) Msg {
+	msg := run(Tick(time.Millisecond, func(t time.Time) Msg {
+		return expected
+	}))
+	if expected != msg {
+		t.Fatalf("expected a msg %v but got %v", expected, msg)
+	}
+}
+
+func TestTickWithContext(t *testing.T) {
+	expected := "tick"
+	msg := run(TickWithContext(context.Background(), time.Millisecond, func(t time.Time) Msg {
+		return expected
+	}))
+	if expected != msg {
+		t.Fatalf("expected a msg %v but got %v", expected, msg)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cmd := TickWithContext(ctx, time.Hour, func(t time.Time) Msg {
+		return expected
+	})
+	cancel()
+	if msg := run(cmd); msg != nil {
+		t.Fatalf("expected no msg after cancellation but got %v", msg)
+	}
+}
+
+func TestEveryWithContext(t *testing.T) {
+	expected := "every ms"
+	msg := run(EveryWithContext(context.Background(), time.Millisecond, func(t time.Time) Msg {
 		return expected
-	})()
+	}))
 	if expected != msg {
 		t.Fatalf("expected a msg %v but got %v", expected, msg)
 	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	msg = run(EveryWithContext(ctx, time.Hour, func(t time.Time) Msg {
+		return expected
+	}))
+	if msg != nil {
+		t.Fatalf("expected no msg after cancellation but got %v", msg)
+	}
+}
+
+func TestTickQuit(t *testing.T) {
+	var buf bytes.Buffer
+	var in bytes.Buffer
+
+	clock := manualClock{timers: make(chan instantTimer, 2)}
+	p := NewProgram(&testModel{}, WithInput(&in), WithOutput(&buf), WithClock(clock))
+	tick := Tick(time.Hour, func(t time.Time) Msg { return t })
+	go p.Send(BatchMsg{tick, Sequence(tick)})
+	go func() {
+		// Quit once both ticks are pending.
+		<-clock.timers
+		<-clock.timers
+		p.Quit()
+	}()
+
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	go func() {
+		p.cmdsInFlight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected the pending ticks to be dropped once the program exited")
+	}
 }
 
 func TestSequentially(t *testing.T) {
@@ -105,3 +225,200 @@ func TestBatch(t *testing.T) {
 		}
 	})
 }
+
+func TestBatchN(t *testing.T) {
+	t.Run("nil cmds", func(t *testing.T) {
+		if b := BatchN(2, nil, nil); b != nil {
+			t.Fatalf("expected nil, got %+v", b)
+		}
+	})
+	t.Run("under limit", func(t *testing.T) {
+		b := BatchN(2, nil, Quit)()
+		if _, ok := b.(QuitMsg); !ok {
+			t.Fatalf("expected a QuitMsg, got %T", b)
+		}
+	})
+	t.Run("limited", func(t *testing.T) {
+		const limit = 3
+		var running, maxRunning int32
+		cmd := func() Msg {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				m := atomic.LoadInt32(&maxRunning)
+				if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			return nil
+		}
+
+		cmds := make([]Cmd, 10)
+		for i := range cmds {
+			cmds[i] = cmd
+		}
+		b, ok := BatchN(limit, cmds...)().(BatchMsg)
+		if !ok || len(b) != len(cmds) {
+			t.Fatalf("expected a BatchMsg with len %d, got %#v", len(cmds), b)
+		}
+
+		// Run the batch the way the program does: all at once.
+		p := NewProgram(nil)
+		var wg sync.WaitGroup
+		for _, cmd := range b {
+			wg.Add(1)
+			go func(cmd Cmd) {
+				defer wg.Done()
+				p.runCmd(cmd)
+			}(cmd)
+		}
+		wg.Wait()
+
+		if maxRunning > limit {
+			t.Fatalf("expected at most %d commands running at once, got %d", limit, maxRunning)
+		}
+	})
+	t.Run("deferred", func(t *testing.T) {
+		clock := manualClock{timers: make(chan instantTimer, 1)}
+		p := NewProgram(nil, WithClock(clock))
+
+		var ticking int32
+		tick := func() Msg {
+			atomic.StoreInt32(&ticking, 1)
+			return Tick(time.Hour, func(time.Time) Msg {
+				atomic.StoreInt32(&ticking, 0)
+				return "tick"
+			})()
+		}
+		other := func() Msg {
+			if atomic.LoadInt32(&ticking) == 1 {
+				return "overlap"
+			}
+			return "other"
+		}
+
+		results := make(chan Msg, 2)
+		for _, cmd := range BatchN(1, tick, other)().(BatchMsg) {
+			go func(cmd Cmd) {
+				results <- p.runCmd(cmd)
+			}(cmd)
+		}
+		timer := <-clock.timers
+		time.Sleep(10 * time.Millisecond)
+		timer <- time.Time{}
+
+		for i := 0; i < 2; i++ {
+			if msg := <-results; msg == "overlap" {
+				t.Fatal("expected the slot to be held until the tick was due")
+			}
+		}
+	})
+}
+
+func TestSequenceUntil(t *testing.T) {
+	run := func(cmd Cmd) []Msg {
+		var msgs []Msg
+		for _, step := range cmd().(sequenceMsg) {
+			if msg := step(); msg != nil {
+				msgs = append(msgs, msg)
+			}
+		}
+		return msgs
+	}
+
+	var ran []string
+	step := func(msg Msg) Cmd {
+		return func() Msg {
+			ran = append(ran, fmt.Sprint(msg))
+			return msg
+		}
+	}
+	errSomething := fmt.Errorf("something")
+
+	t.Run("completed", func(t *testing.T) {
+		ran = nil
+		msgs := run(SequenceAbortOnErr(step("a"), nil, step("b")))
+		expected := []Msg{"a", "b", SequenceDoneMsg{}}
+		if !reflect.DeepEqual(msgs, expected) {
+			t.Fatalf("expected %#v, got %#v", expected, msgs)
+		}
+	})
+
+	t.Run("aborted", func(t *testing.T) {
+		ran = nil
+		msgs := run(SequenceAbortOnErr(step("a"), step(errSomething), step("b")))
+		expected := []Msg{"a", errSomething, SequenceDoneMsg{Aborted: true, Msg: errSomething}}
+		if !reflect.DeepEqual(msgs, expected) {
+			t.Fatalf("expected %#v, got %#v", expected, msgs)
+		}
+		if len(ran) != 2 {
+			t.Fatalf("expected 2 steps to run, got %v", ran)
+		}
+	})
+}
+
+func TestTry(t *testing.T) {
+	if Try(nil) != nil {
+		t.Fatal("expected nil")
+	}
+
+	msg := Try(func() (Msg, error) { return "ok", nil })()
+	if msg != "ok" {
+		t.Fatalf("expected %q, got %#v", "ok", msg)
+	}
+
+	errSomething := fmt.Errorf("something")
+	msg = Try(func() (Msg, error) { return "ignored", errSomething })()
+	errMsg, ok := msg.(ErrMsg)
+	if !ok {
+		t.Fatalf("expected an ErrMsg, got %#v", msg)
+	}
+	if !errors.Is(errMsg, errSomething) || errMsg.Error() != "something" {
+		t.Fatalf("expected ErrMsg to wrap %v, got %v", errSomething, errMsg)
+	}
+}
+
+func TestMap(t *testing.T) {
+	if Map(nil, func(msg Msg) Msg { return msg }) != nil {
+		t.Fatal("expected nil")
+	}
+
+	upper := func(msg Msg) Msg {
+		if s, ok := msg.(string); ok {
+			return strings.ToUpper(s)
+		}
+		return msg
+	}
+	p := NewProgram(nil, WithClock(instantClock{}))
+
+	if msg := p.runCmd(Map(func() Msg { return "a" }, upper)); msg != "A" {
+		t.Errorf("expected %q, got %#v", "A", msg)
+	}
+	if msg := p.runCmd(Map(Quit, upper)); msg != (QuitMsg{}) {
+		t.Errorf("expected runtime messages to be left as is, got %#v", msg)
+	}
+	if msg := p.runCmd(Map(Tick(time.Second, func(time.Time) Msg { return "tick" }), upper)); msg != "TICK" {
+		t.Errorf("expected the scheduled message to be mapped, got %#v", msg)
+	}
+
+	batch := Map(Batch(func() Msg { return "a" }, func() Msg { return "b" }), upper)().(BatchMsg)
+	for i, want := range []Msg{"A", "B"} {
+		if msg := batch[i](); msg != want {
+			t.Errorf("expected command %d of the batch to return %q, got %#v", i, want, msg)
+		}
+	}
+}
+
+func TestDiscard(t *testing.T) {
+	cmd := Discard(Sequence(func() Msg { return "keep" }, func() Msg { return "drop" }), func(msg Msg) bool {
+		return msg == "drop"
+	})
+	var got []Msg
+	for _, step := range cmd().(sequenceMsg) {
+		got = append(got, step())
+	}
+	if !reflect.DeepEqual(got, []Msg{"keep", nil}) {
+		t.Errorf("expected the dropped message to be discarded, got %#v", got)
+	}
+}
@@ -0,0 +1,209 @@
+package tea
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WrappedMsg is a message produced by a command wrapped with Wrap. It carries
+// the ID of the component the message is addressed to, so that a parent can
+// route it to the right child.
+//
+// IDs can be of any comparable type, such as an int or a string. When
+// components are nested, so are wrapped messages: a message from a list
+// inside a sidebar arrives as a WrappedMsg for the sidebar whose Msg is a
+// WrappedMsg for the list.
+type WrappedMsg struct {
+	ID  interface{}
+	Msg Msg
+}
+
+// IDs returns the IDs of the nested wrapped messages, outermost first, as
+// they were wrapped, so that WrapPath(cmd, w.IDs()...) wraps a command the
+// same way.
+func (w WrappedMsg) IDs() []interface{} {
+	ids := []interface{}{w.ID}
+	for {
+		inner, ok := w.Msg.(WrappedMsg)
+		if !ok {
+			return ids
+		}
+		ids = append(ids, inner.ID)
+		w = inner
+	}
+}
+
+// Path returns the IDs of the nested wrapped messages, outermost first,
+// joined with slashes, e.g. "sidebar/list/3", for logging and debugging.
+// Slashes and backslashes within IDs are escaped with a backslash, so that
+// paths are never ambiguous. Use IDs to get the IDs themselves.
+func (w WrappedMsg) Path() string {
+	ids := w.IDs()
+	segments := make([]string, len(ids))
+	for i, id := range ids {
+		segments[i] = pathEscaper.Replace(fmt.Sprint(id))
+	}
+	return strings.Join(segments, "/")
+}
+
+// pathEscaper escapes the slashes within the segments of a path.
+var pathEscaper = strings.NewReplacer(`\`, `\\`, "/", `\/`)
+
+// Wrap wraps a command so that the message it produces is delivered as a
+// WrappedMsg with the given ID. Commands producing batches and sequences are
+// wrapped recursively, so every message they produce carries the ID.
+//
+// Messages handled by Bubble Tea itself, such as QuitMsg or WindowSizeMsg,
+// are never wrapped, so that commands like Quit keep working from within a
+// child.
+//
+// Most of the time you'll want to use a Component, which wraps commands for
+// you.
+func Wrap[ID comparable](id ID, cmd Cmd) Cmd {
+	return wrap(id, cmd)
+}
+
+// WrapPath wraps a command with a nested WrappedMsg for each of the given
+// IDs, outermost first, so that WrapPath(cmd, "sidebar", 3) is the same as
+// Wrap("sidebar", Wrap(3, cmd)). IDs keep their types: a component with the
+// ID 3 gets messages wrapped with 3, but not with "3".
+//
+// Like the ones of Wrap, IDs should be comparable. Messages wrapped with IDs
+// that aren't, such as slices, are never addressed to any component.
+func WrapPath(cmd Cmd, ids ...interface{}) Cmd {
+	for i := len(ids) - 1; i >= 0; i-- {
+		cmd = wrap(ids[i], cmd)
+	}
+	return cmd
+}
+
+// Unwrap dispatches a WrappedMsg to the handler registered for its ID, passing
+// it the inner message. It reports whether a handler was found. Messages that
+// aren't wrapped, or whose ID isn't of type ID, are not dispatched.
+//
+//	func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+//	    if cmd, ok := tea.Unwrap(msg, map[string]func(tea.Msg) tea.Cmd{
+//	        "sidebar": func(msg tea.Msg) tea.Cmd {
+//	            var cmd tea.Cmd
+//	            m.sidebar, cmd = m.sidebar.Update(msg)
+//	            return cmd
+//	        },
+//	    }); ok {
+//	        return m, cmd
+//	    }
+//	    // ...
+//	}
+func Unwrap[ID comparable](msg Msg, handlers map[ID]func(Msg) Cmd) (Cmd, bool) {
+	w, ok := msg.(WrappedMsg)
+	if !ok {
+		return nil, false
+	}
+	id, ok := w.ID.(ID)
+	if !ok {
+		return nil, false
+	}
+	h, ok := handlers[id]
+	if !ok {
+		return nil, false
+	}
+	return h(w.Msg), true
+}
+
+// wrap is the non-generic implementation of Wrap.
+func wrap(id interface{}, cmd Cmd) Cmd {
+	return Map(cmd, func(msg Msg) Msg {
+		return WrappedMsg{ID: id, Msg: msg}
+	})
+}
+
+// Component is a child model mounted inside a parent model. It takes care of
+// the plumbing every parent would otherwise write by hand for each child:
+// commands returned by the child are wrapped with the component's ID, and
+// messages are forwarded to the child only if they are addressed to it or
+// aren't addressed to anyone in particular (such as key presses and window
+// size changes).
+//
+//	type model struct {
+//	    sidebar tea.Component[sidebar.Model, string]
+//	    editor  tea.Component[editor.Model, string]
+//	}
+//
+//	func (m model) Init() tea.Cmd {
+//	    return tea.Batch(m.sidebar.Init(), m.editor.Init())
+//	}
+//
+//	func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+//	    var sidebarCmd, editorCmd tea.Cmd
+//	    m.sidebar, sidebarCmd = m.sidebar.Update(msg)
+//	    m.editor, editorCmd = m.editor.Update(msg)
+//	    return m, tea.Batch(sidebarCmd, editorCmd)
+//	}
+//
+// Each component should have an ID that's unique among its siblings.
+// Messages are addressed to a component if they're wrapped with an ID of the
+// same type that's equal to its own.
+type Component[M Model, ID comparable] struct {
+	ID    ID
+	Model M
+}
+
+// NewComponent mounts the given model as a component with the given ID.
+func NewComponent[M Model, ID comparable](id ID, model M) Component[M, ID] {
+	return Component[M, ID]{ID: id, Model: model}
+}
+
+// Init calls the child's Init function and wraps the resulting command.
+func (c Component[M, ID]) Init() Cmd {
+	return wrap(c.ID, c.Model.Init())
+}
+
+// Update forwards the message to the child if it's addressed to it, and wraps
+// the resulting command. Messages addressed to other components are ignored.
+//
+// The child's Update function must return a model of the same type it was
+// called on. If it doesn't, the child is left as it was and Update returns an
+// ErrMsg for the parent along with the child's command.
+func (c Component[M, ID]) Update(msg Msg) (Component[M, ID], Cmd) {
+	var cmd Cmd
+	c.Model, cmd = forward(c.ID, c.Model, msg)
+	return c, cmd
+}
+
+// forward forwards a message to a child model with the given ID if it's
+// addressed to it or to no one in particular, and wraps the resulting command.
+func forward[M Model, ID comparable](id ID, model M, msg Msg) (M, Cmd) {
+	if w, ok := msg.(WrappedMsg); ok {
+		if to, ok := w.ID.(ID); !ok || to != id {
+			return model, nil
+		}
+		msg = w.Msg
+	}
+
+	next, cmd := model.Update(msg)
+	m, ok := next.(M)
+	if !ok {
+		// Keep the child as it was, and let its parent know.
+		err := fmt.Errorf("child %v returned a %T from Update rather than a %T", id, next, model)
+		return model, Batch(wrap(id, cmd), func() Msg { return ErrMsg{Err: err} })
+	}
+	return m, wrap(id, cmd)
+}
+
+// View returns the child's view.
+func (c Component[M, ID]) View() string {
+	return c.Model.View()
+}
+
+// InitAll collects the initial commands of the given components (or any
+// other models) into a single batch. Use it in a parent's Init function.
+//
+//	func (m model) Init() tea.Cmd {
+//	    return tea.InitAll(m.sidebar, m.editor)
+//	}
+func InitAll(children ...interface{ Init() Cmd }) Cmd {
+	cmds := make([]Cmd, 0, len(children))
+	for _, c := range children {
+		cmds = append(cmds, c.Init())
+	}
+	return Batch(cmds...)
+}
@@ -0,0 +1,239 @@
+package tea
+
+import (
+	"testing"
+)
+
+type counterMsg struct{}
+
+type counterModel struct {
+	count int
+}
+
+func (m counterModel) Init() Cmd {
+	return func() Msg { return counterMsg{} }
+}
+
+func (m counterModel) Update(msg Msg) (Model, Cmd) {
+	switch msg.(type) {
+	case counterMsg:
+		m.count++
+		return m, Batch(func() Msg { return "done" }, Quit)
+	case KeyMsg:
+		m.count += 10
+	}
+	return m, nil
+}
+
+func (m counterModel) View() string {
+	return ""
+}
+
+func TestWrap(t *testing.T) {
+	if Wrap(1, nil) != nil {
+		t.Fatal("expected wrapping a nil command to return nil")
+	}
+
+	msg := Wrap(1, func() Msg { return "hi" })()
+	if w, ok := msg.(WrappedMsg); !ok || w.ID != 1 || w.Msg != "hi" {
+		t.Fatalf("expected wrapped message, got %#v", msg)
+	}
+
+	msg = Wrap(1, Quit)()
+	if _, ok := msg.(QuitMsg); !ok {
+		t.Fatalf("expected QuitMsg to be left unwrapped, got %#v", msg)
+	}
+
+	msg = Wrap(1, SetHeader("header"))()
+	if _, ok := msg.(setHeaderMsg); !ok {
+		t.Fatalf("expected setHeaderMsg to be left unwrapped, got %#v", msg)
+	}
+
+	msg = Wrap(1, Batch(func() Msg { return "a" }, func() Msg { return "b" }))()
+	batch, ok := msg.(BatchMsg)
+	if !ok || len(batch) != 2 {
+		t.Fatalf("expected a batch of 2 commands, got %#v", msg)
+	}
+	for _, cmd := range batch {
+		if w, ok := cmd().(WrappedMsg); !ok || w.ID != 1 {
+			t.Fatalf("expected batched message to be wrapped, got %#v", w)
+		}
+	}
+}
+
+func TestComponent(t *testing.T) {
+	a := NewComponent(1, counterModel{})
+	b := NewComponent(2, counterModel{})
+
+	// Init messages are addressed to their own component only.
+	msg := a.Init()()
+	a, _ = a.Update(msg)
+	b, _ = b.Update(msg)
+	if a.Model.count != 1 || b.Model.count != 0 {
+		t.Fatalf("expected counts 1 and 0, got %d and %d", a.Model.count, b.Model.count)
+	}
+
+	// Unaddressed messages are forwarded to every component.
+	a, _ = a.Update(KeyMsg{Type: KeyEnter})
+	b, _ = b.Update(KeyMsg{Type: KeyEnter})
+	if a.Model.count != 11 || b.Model.count != 10 {
+		t.Fatalf("expected counts 11 and 10, got %d and %d", a.Model.count, b.Model.count)
+	}
+
+	// Commands returned by the child are wrapped, except for Quit.
+	_, cmd := b.Update(WrappedMsg{ID: 2, Msg: counterMsg{}})
+	batch, ok := cmd().(BatchMsg)
+	if !ok || len(batch) != 2 {
+		t.Fatalf("expected a batch of 2 commands, got %#v", batch)
+	}
+	if w, ok := batch[0]().(WrappedMsg); !ok || w.ID != 2 || w.Msg != "done" {
+		t.Fatalf("expected wrapped message, got %#v", w)
+	}
+	if _, ok := batch[1]().(QuitMsg); !ok {
+		t.Fatal("expected QuitMsg to be left unwrapped")
+	}
+}
+
+func TestInitAll(t *testing.T) {
+	a := NewComponent(1, counterModel{})
+	b := NewComponent(2, counterModel{})
+
+	batch, ok := InitAll(a, b)().(BatchMsg)
+	if !ok || len(batch) != 2 {
+		t.Fatalf("expected a batch of 2 commands, got %#v", batch)
+	}
+	for i, cmd := range batch {
+		if w, ok := cmd().(WrappedMsg); !ok || w.ID != i+1 {
+			t.Fatalf("expected message wrapped with ID %d, got %#v", i+1, w)
+		}
+	}
+}
+
+func TestWrapPath(t *testing.T) {
+	msg := WrapPath(func() Msg { return "hi" }, "sidebar", "list", 3)()
+	w, ok := msg.(WrappedMsg)
+	if !ok {
+		t.Fatalf("expected wrapped message, got %#v", msg)
+	}
+	if ids := w.IDs(); len(ids) != 3 || ids[0] != "sidebar" || ids[1] != "list" || ids[2] != 3 {
+		t.Fatalf("expected IDs sidebar, list and 3, got %#v", ids)
+	}
+	if path := w.Path(); path != "sidebar/list/3" {
+		t.Fatalf("expected path %q, got %q", "sidebar/list/3", path)
+	}
+
+	// Non-string IDs reach their components.
+	c := NewComponent(3, counterModel{})
+	c, _ = c.Update(WrapPath(func() Msg { return counterMsg{} }, 3)())
+	c, _ = c.Update(WrapPath(func() Msg { return counterMsg{} }, "3")())
+	if c.Model.count != 1 {
+		t.Fatalf("expected count 1, got %d", c.Model.count)
+	}
+
+	// Slashes and backslashes within IDs are escaped.
+	msg = Wrap("a/b", Wrap(`c\`, func() Msg { return "hi" }))()
+	if path := msg.(WrappedMsg).Path(); path != `a\/b/c\\` {
+		t.Fatalf("expected path %q, got %q", `a\/b/c\\`, path)
+	}
+}
+
+func TestUnwrap(t *testing.T) {
+	var got []Msg
+	handlers := map[string]func(Msg) Cmd{
+		"sidebar": func(msg Msg) Cmd {
+			got = append(got, msg)
+			return nil
+		},
+	}
+
+	msg := WrapPath(func() Msg { return "hi" }, "sidebar", "list")()
+	if _, ok := Unwrap(msg, handlers); !ok {
+		t.Fatal("expected message to be dispatched")
+	}
+	if len(got) != 1 || got[0].(WrappedMsg).ID != "list" {
+		t.Fatalf("expected handler to receive the inner wrapped message, got %#v", got)
+	}
+
+	for _, msg := range []Msg{
+		"hi",
+		WrappedMsg{ID: "editor", Msg: "hi"},
+		WrappedMsg{ID: 1, Msg: "hi"},
+	} {
+		if _, ok := Unwrap(msg, handlers); ok {
+			t.Fatalf("expected %#v not to be dispatched", msg)
+		}
+	}
+}
+
+func TestComponentStringID(t *testing.T) {
+	c := NewComponent("counter", counterModel{})
+	c, _ = c.Update(c.Init()())
+	c, _ = c.Update(WrappedMsg{ID: "other", Msg: counterMsg{}})
+	if c.Model.count != 1 {
+		t.Fatalf("expected count 1, got %d", c.Model.count)
+	}
+}
+
+// swapModel returns a counterModel from its Update function.
+type swapModel struct{}
+
+func (m swapModel) Init() Cmd { return nil }
+
+func (m swapModel) Update(Msg) (Model, Cmd) {
+	return counterModel{}, func() Msg { return "swapped" }
+}
+
+func (m swapModel) View() string { return "" }
+
+func TestComponentUpdateType(t *testing.T) {
+	c := NewComponent("swap", swapModel{})
+	c, cmd := c.Update(KeyMsg{Type: KeyEnter})
+	if c.Model != (swapModel{}) {
+		t.Fatalf("expected the child to be left as it was, got %#v", c.Model)
+	}
+
+	batch, ok := cmd().(BatchMsg)
+	if !ok || len(batch) != 2 {
+		t.Fatalf("expected a batch of 2 commands, got %#v", batch)
+	}
+	if w, ok := batch[0]().(WrappedMsg); !ok || w.ID != "swap" || w.Msg != "swapped" {
+		t.Fatalf("expected the child's message to be wrapped, got %#v", w)
+	}
+	msg := batch[1]()
+	if _, ok := msg.(ErrMsg); !ok {
+		t.Fatalf("expected an ErrMsg, got %#v", msg)
+	}
+}
+
+func TestComponentUncomparableID(t *testing.T) {
+	c := NewComponent("counter", counterModel{})
+	c, _ = c.Update(WrappedMsg{ID: []string{"counter"}, Msg: counterMsg{}})
+	c, _ = c.Update(WrappedMsg{ID: 1, Msg: counterMsg{}})
+	if c.Model.count != 0 {
+		t.Fatalf("expected wrapped messages not to be forwarded, got count %d", c.Model.count)
+	}
+	c, _ = c.Update(counterMsg{})
+	if c.Model.count != 1 {
+		t.Fatalf("expected count 1, got %d", c.Model.count)
+	}
+}
+
+func TestWrapStream(t *testing.T) {
+	msg := Wrap(1, Stream(func(send func(Msg)) Msg {
+		send("progress")
+		return "done"
+	}))()
+	s, ok := msg.(streamMsg)
+	if !ok {
+		t.Fatalf("expected a streamMsg, got %#v", msg)
+	}
+
+	var sent []Msg
+	done := s.job(func(msg Msg) { sent = append(sent, msg) })
+	if len(sent) != 1 || sent[0] != (WrappedMsg{ID: 1, Msg: "progress"}) {
+		t.Fatalf("expected sent message to be wrapped, got %#v", sent)
+	}
+	if done != (WrappedMsg{ID: 1, Msg: "done"}) {
+		t.Fatalf("expected final message to be wrapped, got %#v", done)
+	}
+}
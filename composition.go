@@ -0,0 +1,27 @@
+package tea
+
+// CompositionMsg reports the text being composed with an input method, such
+// as the kana typed ahead of their conversion to kanji, or the jamo making up
+// a Hangul syllable, before it's committed. Text inputs can show it at their
+// cursor, so that it's seen in place, as it's typed.
+//
+// Each CompositionMsg replaces the one before it. Once the text is committed
+// or the composition is canceled, a CompositionEndMsg follows.
+//
+// Terminals don't report composition with escape sequences, leaving it to the
+// input method to show, so these messages are only received where the
+// terminal reports it some other way: xterm.js terminals run with package
+// xterm do, and other integrations can send them with Program.Send.
+type CompositionMsg struct {
+	Text string
+}
+
+// CompositionEndMsg reports the end of a composition: the text composed was
+// committed, or the composition was canceled, in which case Committed is
+// empty. Text inputs should stop showing the text composed.
+//
+// The text committed is received as a KeyMsg too, as if it were typed, so it
+// shouldn't be inserted again.
+type CompositionEndMsg struct {
+	Committed string
+}
@@ -0,0 +1,308 @@
+package tea
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/muesli/termenv"
+)
+
+// ansiCompressor is a writer removing redundant sequences from what's
+// written to it before passing it on:
+//
+//   - the SGR sequences written between two characters are coalesced into
+//     the one sequence changing the style of the terminal to what it should
+//     be, which is nothing at all when a style is reset only to be set again,
+//     as with adjacent spans of styled text;
+//   - adjacent cursor movements are merged, such as a carriage return
+//     followed by moves back, or moves up one after the other.
+//
+// Sequences are only held back within a call to Write, so that nothing is
+// left unwritten once a frame has been written. The style of the terminal is
+// tracked across calls, though.
+type ansiCompressor struct {
+	w   io.Writer
+	out bytes.Buffer
+
+	// the style of the terminal, if known, and the style the text written
+	// next should be in
+	pen, style cellStyle
+	penKnown   bool
+
+	// the cursor movements held back
+	move pendingMove
+
+	// the start of a sequence split across calls to Write
+	partial string
+}
+
+// pendingMove is a cursor movement held back, made of an absolute position,
+// then a vertical and a horizontal movement. A carriage return comes before
+// the horizontal movement. Directions are the final bytes of the sequences
+// moving the cursor, such as 'A' for up, and are 0 without a movement.
+type pendingMove struct {
+	abs      bool
+	row, col int
+
+	vdir byte
+	vn   int
+
+	cr   bool
+	hdir byte
+	hn   int
+}
+
+// newANSICompressor returns an ansiCompressor writing to w.
+func newANSICompressor(w io.Writer) *ansiCompressor {
+	return &ansiCompressor{w: w}
+}
+
+// Write compresses a chunk of output and writes it.
+func (c *ansiCompressor) Write(b []byte) (int, error) {
+	c.out.Reset()
+
+	// Sequences are sliced out of a string, which saves converting each of
+	// them.
+	data := c.partial + string(b)
+	c.partial = ""
+
+	for i := 0; i < len(data); {
+		switch ch := data[i]; {
+		case ch == '\x1b':
+			end, ok := sequenceEnd(data, i)
+			if !ok {
+				// Wait for the rest of the sequence.
+				c.partial = data[i:]
+				i = len(data)
+				continue
+			}
+			c.sequence(data[i:end])
+			i = end
+
+		case ch == '\r':
+			c.move.cr = true
+			c.move.hdir, c.move.hn = 0, 0
+			i++
+
+		case ch < 0x20 || ch == 0x7f:
+			// Other control characters, such as line feeds, may scroll
+			// the screen, which fills new lines with the current
+			// background color, or move the cursor in ways not tracked.
+			c.flushMove()
+			c.flushStyle()
+			c.out.WriteByte(ch)
+			i++
+
+		default:
+			j := i + 1
+			for j < len(data) && data[j] >= 0x20 && data[j] != 0x7f && data[j] != '\x1b' {
+				j++
+			}
+			c.flushMove()
+			c.flushStyle()
+			c.out.WriteString(data[i:j])
+			i = j
+		}
+	}
+
+	c.flushMove()
+	c.flushStyle()
+	if _, err := c.w.Write(c.out.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// sequence handles an escape sequence.
+func (c *ansiCompressor) sequence(seq string) {
+	if len(seq) >= 3 && seq[1] == '[' {
+		params, final := seq[2:len(seq)-1], seq[len(seq)-1]
+		if isPlainParams(params) {
+			switch final {
+			case 'm':
+				c.sgr(params)
+				return
+			case 'A', 'B', 'C', 'D', 'H', 'f':
+				c.cursorMove(params, final)
+				return
+			}
+		}
+	}
+
+	c.flushMove()
+	c.flushStyle()
+	c.out.WriteString(seq)
+	if restoresStyle(seq) {
+		c.penKnown = false
+	}
+}
+
+// sgr handles an SGR sequence.
+func (c *ansiCompressor) sgr(params string) {
+	if c.penKnown {
+		if style, ok := c.style.apply(params); ok {
+			c.style = style
+			return
+		}
+		c.flushStyle()
+		c.writeSGR(params)
+		c.penKnown = false
+		return
+	}
+
+	// Until the style is reset, there's no telling what it is.
+	c.writeSGR(params)
+	if first := params[:strings.IndexByte(params+";", ';')]; first == "" || first == "0" {
+		if style, ok := (cellStyle{}).apply(params); ok {
+			c.pen, c.style, c.penKnown = style, style, true
+		}
+	}
+}
+
+// writeSGR writes an SGR sequence as is.
+func (c *ansiCompressor) writeSGR(params string) {
+	c.out.WriteString(termenv.CSI)
+	c.out.WriteString(params)
+	c.out.WriteByte('m')
+}
+
+// flushStyle writes the sequence changing the style of the terminal to the
+// style of the text written next.
+func (c *ansiCompressor) flushStyle() {
+	if !c.penKnown {
+		return
+	}
+	c.out.WriteString(c.style.sgr(c.pen))
+	c.pen = c.style
+}
+
+// cursorMove handles a sequence moving the cursor.
+func (c *ansiCompressor) cursorMove(params string, final byte) {
+	m := &c.move
+	if final == 'H' || final == 'f' {
+		row, col := 1, 1
+		ps := strings.SplitN(params, ";", 2)
+		if n, err := strconv.Atoi(ps[0]); err == nil && n > 0 {
+			row = n
+		}
+		if len(ps) > 1 {
+			if n, err := strconv.Atoi(ps[1]); err == nil && n > 0 {
+				col = n
+			}
+		}
+		*m = pendingMove{abs: true, row: row, col: col}
+		return
+	}
+
+	// Terminals move the cursor by one cell when told to move it by 0.
+	n, err := strconv.Atoi(params)
+	if err != nil || n < 1 {
+		n = 1
+	}
+
+	switch final {
+	case 'A', 'B':
+		if m.vdir != 0 && m.vdir != final {
+			// Moves in opposite directions don't cancel out when the first
+			// one is stopped by a margin.
+			c.flushMove()
+		}
+		m.vdir = final
+		m.vn += n
+	case 'C', 'D':
+		if final == 'D' && m.cr && m.hdir == 0 {
+			// The cursor is in the first column already.
+			return
+		}
+		if m.hdir != 0 && m.hdir != final || final == 'D' && m.cr {
+			c.flushMove()
+		}
+		m.hdir = final
+		m.hn += n
+	}
+}
+
+// flushMove writes the cursor movements held back.
+func (c *ansiCompressor) flushMove() {
+	m := c.move
+	if m.abs {
+		c.out.WriteString(termenv.CSI + strconv.Itoa(m.row) + ";" + strconv.Itoa(m.col) + "H")
+	}
+	if m.vdir != 0 {
+		c.out.WriteString(termenv.CSI + strconv.Itoa(m.vn) + string(m.vdir))
+	}
+	if m.cr {
+		c.out.WriteByte('\r')
+	}
+	if m.hdir != 0 {
+		c.out.WriteString(termenv.CSI + strconv.Itoa(m.hn) + string(m.hdir))
+	}
+	c.move = pendingMove{}
+}
+
+// isPlainParams reports whether the parameters of a CSI sequence are only
+// numbers separated by semicolons, without private markers or intermediate
+// bytes.
+func isPlainParams(params string) bool {
+	for i := 0; i < len(params); i++ {
+		if (params[i] < '0' || params[i] > '9') && params[i] != ';' {
+			return false
+		}
+	}
+	return true
+}
+
+// restoresStyle reports whether a sequence may change the style of the
+// terminal behind our back, by restoring a saved cursor, which comes with a
+// style, switching screens or resetting the terminal.
+func restoresStyle(s string) bool {
+	switch {
+	case s == "\x1b8", s == "\x1bc", s == termenv.CSI+"u":
+		return true
+	case strings.HasPrefix(s, termenv.CSI+"?") && (strings.HasSuffix(s, "h") || strings.HasSuffix(s, "l")):
+		for _, mode := range strings.Split(s[3:len(s)-1], ";") {
+			if mode == "1048" || mode == "1049" || mode == "47" || mode == "1047" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sequenceEnd returns the index right after the escape sequence starting at
+// s[i], and whether the sequence is complete: a CSI sequence ends with its
+// final byte; OSC, DCS, APC, PM and SOS strings end with BEL or ST; other
+// escapes end with their first byte which isn't an intermediate one.
+func sequenceEnd(s string, i int) (int, bool) {
+	if i+1 >= len(s) {
+		return len(s), false
+	}
+	switch s[i+1] {
+	case '[':
+		for j := i + 2; j < len(s); j++ {
+			if s[j] >= 0x40 && s[j] <= 0x7e {
+				return j + 1, true
+			}
+		}
+		return len(s), false
+	case ']', 'P', '_', '^', 'X':
+		for j := i + 2; j < len(s); j++ {
+			if s[j] == '\a' {
+				return j + 1, true
+			}
+			if s[j] == '\x1b' && j+1 < len(s) && s[j+1] == '\\' {
+				return j + 2, true
+			}
+		}
+		return len(s), false
+	default:
+		for j := i + 1; j < len(s); j++ {
+			if s[j] < 0x20 || s[j] > 0x2f {
+				return j + 1, true
+			}
+		}
+		return len(s), false
+	}
+}
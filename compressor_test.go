@@ -0,0 +1,122 @@
+package tea
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestANSICompressor(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "adjacent spans of the same style",
+			input:    "\x1b[m\x1b[1;31mfoo\x1b[0m\x1b[1;31m bar\x1b[0m",
+			expected: "\x1b[m\x1b[1;31mfoo bar\x1b[m",
+		},
+		{
+			name:     "styles set one after the other",
+			input:    "\x1b[m\x1b[1m\x1b[31m\x1b[4mfoo",
+			expected: "\x1b[m\x1b[1;4;31mfoo",
+		},
+		{
+			name:     "resets without a style",
+			input:    "\x1b[mfoo\x1b[0m\x1b[m bar",
+			expected: "\x1b[mfoo bar",
+		},
+		{
+			name:     "styles dropped before the line is cleared",
+			input:    "\x1b[m\x1b[41mfoo\x1b[0m\x1b[2K",
+			expected: "\x1b[m\x1b[41mfoo\x1b[m\x1b[2K",
+		},
+		{
+			name:     "moves in the same direction",
+			input:    "\x1b[1A\x1b[2A\x1b[3C\x1b[1Cfoo",
+			expected: "\x1b[3A\x1b[4Cfoo",
+		},
+		{
+			name:     "moves back after a carriage return",
+			input:    "\r\x1b[80D\x1b[2Cfoo",
+			expected: "\r\x1b[2Cfoo",
+		},
+		{
+			name:     "moves in opposite directions",
+			input:    "\x1b[2A\x1b[1Bfoo",
+			expected: "\x1b[2A\x1b[1Bfoo",
+		},
+		{
+			name:     "moves before an absolute position",
+			input:    "\x1b[2A\r\x1b[5;1Hfoo",
+			expected: "\x1b[5;1Hfoo",
+		},
+		{
+			name:     "moves by 0",
+			input:    "\x1b[0A\x1b[Afoo",
+			expected: "\x1b[2Afoo",
+		},
+		{
+			name:     "other sequences",
+			input:    "\x1b[m\x1b[31m\x1b]8;;http://example.com\x1b\\foo\x1b]8;;\x1b\\",
+			expected: "\x1b[m\x1b[31m\x1b]8;;http://example.com\x1b\\foo\x1b]8;;\x1b\\",
+		},
+		{
+			name:     "style unknown until reset",
+			input:    "\x1b[31mfoo\x1b[31mbar",
+			expected: "\x1b[31mfoo\x1b[31mbar",
+		},
+		{
+			name:     "style unknown after restoring the cursor",
+			input:    "\x1b[m\x1b[31m\x1b8\x1b[31mfoo",
+			expected: "\x1b[m\x1b[31m\x1b8\x1b[31mfoo",
+		},
+		{
+			name:     "unsupported style",
+			input:    "\x1b[m\x1b[4:3mfoo\x1b[4:3mbar",
+			expected: "\x1b[m\x1b[4:3mfoo\x1b[4:3mbar",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			c := newANSICompressor(&buf)
+			if _, err := c.Write([]byte(test.input)); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != test.expected {
+				t.Errorf("expected:\n%q\ngot:\n%q", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestANSICompressorSplitSequences(t *testing.T) {
+	var buf bytes.Buffer
+	c := newANSICompressor(&buf)
+
+	// Sequences split across writes are put back together, and the style is
+	// remembered between writes.
+	for _, s := range []string{"\x1b[m\x1b[1;3", "1mfoo", "\x1b[0m\x1b[1;31mbar\x1b[m"} {
+		_, _ = c.Write([]byte(s))
+	}
+	if got, want := buf.String(), "\x1b[m\x1b[1;31mfoobar\x1b[m"; got != want {
+		t.Errorf("expected:\n%q\ngot:\n%q", want, got)
+	}
+}
+
+func BenchmarkANSICompressor(b *testing.B) {
+	frame := []byte(strings.Repeat("\x1b[1;38;5;205mH\x1b[0m\x1b[1;38;5;205mi\x1b[0m \x1b[1A\x1b[1A\r", 200))
+
+	var buf bytes.Buffer
+	c := newANSICompressor(&buf)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		_, _ = c.Write(frame)
+	}
+	b.ReportMetric(float64(len(frame))/float64(buf.Len()), "ratio")
+}
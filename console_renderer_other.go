@@ -0,0 +1,10 @@
+//go:build !windows
+// +build !windows
+
+package tea
+
+// legacyConsoleRenderer returns a renderer for legacy Windows consoles, which
+// only exist on Windows.
+func (p *Program) legacyConsoleRenderer() renderer {
+	return nil
+}
@@ -0,0 +1,647 @@
+//go:build windows
+// +build windows
+
+package tea
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	kernel32                         = windows.NewLazySystemDLL("kernel32.dll")
+	procWriteConsoleOutputW          = kernel32.NewProc("WriteConsoleOutputW")
+	procGetConsoleCursorInfo         = kernel32.NewProc("GetConsoleCursorInfo")
+	procSetConsoleCursorInfo         = kernel32.NewProc("SetConsoleCursorInfo")
+	procCreateConsoleScreenBuffer    = kernel32.NewProc("CreateConsoleScreenBuffer")
+	procSetConsoleActiveScreenBuffer = kernel32.NewProc("SetConsoleActiveScreenBuffer")
+)
+
+// Console character attributes.
+const (
+	consoleForegroundBlue      = 0x0001
+	consoleForegroundGreen     = 0x0002
+	consoleForegroundRed       = 0x0004
+	consoleForegroundIntensity = 0x0008
+	consoleLeadingByte         = 0x0100
+	consoleTrailingByte        = 0x0200
+
+	consoleForegroundMask = 0x000f
+	consoleBackgroundMask = 0x00f0
+
+	consoleTextModeBuffer = 1
+)
+
+// charInfo is a CHAR_INFO: a cell of a console screen buffer.
+type charInfo struct {
+	char uint16
+	attr uint16
+}
+
+// consoleCursorInfo is a CONSOLE_CURSOR_INFO.
+type consoleCursorInfo struct {
+	size    uint32
+	visible int32
+}
+
+// legacyConsoleRenderer returns a renderer drawing with the Windows Console
+// API if the output is a console which doesn't support virtual terminal
+// sequences, as is the case before Windows 10. Otherwise, it returns nil.
+func (p *Program) legacyConsoleRenderer() renderer {
+	if !p.noVirtualTerminal {
+		return nil
+	}
+	f, ok := p.output.TTY().(*os.File)
+	if !ok {
+		return nil
+	}
+	var info windows.ConsoleScreenBufferInfo
+	if err := windows.GetConsoleScreenBufferInfo(windows.Handle(f.Fd()), &info); err != nil {
+		return nil
+	}
+	return newConsoleRenderer(windows.Handle(f.Fd()), info.Attributes, p.fps)
+}
+
+// consoleRenderer is a renderer for legacy Windows consoles, which don't
+// understand escape sequences. It draws frames by writing cells directly to
+// the console's screen buffer with WriteConsoleOutput, translating colors
+// and text attributes to console attributes, and moves the cursor with
+// SetConsoleCursorPosition. The alternate screen is a second screen buffer.
+//
+// Escape sequences other than colors and text attributes are dropped, and so
+// are colors that have no equivalent among the 16 colors of the console.
+type consoleRenderer struct {
+	mtx sync.Mutex
+
+	// the main screen buffer, the alternate one when active, and the
+	// attributes of text before the program started
+	main        windows.Handle
+	alt         windows.Handle
+	defaultAttr uint16
+
+	framerate time.Duration
+	ticker    *time.Ticker
+	done      chan struct{}
+	once      sync.Once
+
+	// the first row of the frame in the main screen buffer
+	origin int16
+
+	view          string
+	dirty         bool
+	linesRendered int
+	queuedLines   []string
+
+	cursor       *Cursor
+	cursorHidden bool
+}
+
+func newConsoleRenderer(out windows.Handle, attr uint16, fps int) *consoleRenderer {
+	if fps < 1 {
+		fps = defaultFPS
+	} else if fps > maxFPS {
+		fps = maxFPS
+	}
+	return &consoleRenderer{
+		main:        out,
+		defaultAttr: attr,
+		framerate:   time.Second / time.Duration(fps),
+		done:        make(chan struct{}),
+	}
+}
+
+// active returns the screen buffer being drawn to.
+func (r *consoleRenderer) active() windows.Handle {
+	if r.alt != 0 {
+		return r.alt
+	}
+	return r.main
+}
+
+func (r *consoleRenderer) start() {
+	r.mtx.Lock()
+	var info windows.ConsoleScreenBufferInfo
+	if err := windows.GetConsoleScreenBufferInfo(r.main, &info); err == nil {
+		// Start drawing at the cursor, on a line of our own.
+		r.origin = info.CursorPosition.Y
+		if info.CursorPosition.X > 0 {
+			r.origin++
+		}
+	}
+	r.mtx.Unlock()
+
+	if r.ticker == nil {
+		r.ticker = time.NewTicker(r.framerate)
+	} else {
+		r.ticker.Reset(r.framerate)
+	}
+	r.once = sync.Once{}
+	go r.listen()
+}
+
+func (r *consoleRenderer) listen() {
+	for {
+		select {
+		case <-r.done:
+			r.ticker.Stop()
+			return
+		case <-r.ticker.C:
+			r.flush()
+		}
+	}
+}
+
+// stop draws the final frame and moves the cursor below it.
+func (r *consoleRenderer) stop() {
+	r.flush()
+
+	r.mtx.Lock()
+	_ = windows.SetConsoleCursorPosition(r.main, windows.Coord{X: 0, Y: r.origin + int16(r.linesRendered)})
+	r.mtx.Unlock()
+
+	r.once.Do(func() {
+		r.done <- struct{}{}
+	})
+}
+
+func (r *consoleRenderer) kill() {
+	r.once.Do(func() {
+		r.done <- struct{}{}
+	})
+}
+
+func (r *consoleRenderer) write(s string) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if s != r.view {
+		r.view = s
+		r.dirty = true
+	}
+}
+
+func (r *consoleRenderer) repaint() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.dirty = true
+}
+
+// flush draws the current frame, if it changed.
+func (r *consoleRenderer) flush() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if !r.dirty {
+		return
+	}
+	r.dirty = false
+
+	out := r.active()
+	var info windows.ConsoleScreenBufferInfo
+	if err := windows.GetConsoleScreenBufferInfo(out, &info); err != nil {
+		return
+	}
+	width := int(info.Size.X)
+	height := int(info.Window.Bottom-info.Window.Top) + 1
+	origin := r.origin
+	if r.alt != 0 {
+		origin = info.Window.Top
+	}
+
+	// Print the lines queued with Println above the frame. There's no such
+	// thing as above the frame in the alternate screen.
+	if len(r.queuedLines) > 0 && r.alt == 0 {
+		n := len(r.queuedLines)
+		writeConsoleLines(out, r.queuedLines, width, origin, r.defaultAttr)
+		r.queuedLines = nil
+		origin += int16(n)
+		r.origin = origin
+		if r.linesRendered > n {
+			r.linesRendered -= n
+		} else {
+			r.linesRendered = 0
+		}
+	}
+
+	lines := strings.Split(r.view, "\n")
+	if len(lines) > height {
+		// Keep the bottom of frames taller than the window, as the
+		// standard renderer does.
+		lines = lines[len(lines)-height:]
+	}
+
+	// Make sure the frame fits in the screen buffer.
+	if int(origin)+len(lines) > int(info.Size.Y) {
+		origin = info.Size.Y - int16(len(lines))
+		if origin < 0 {
+			origin = 0
+		}
+		if r.alt == 0 {
+			r.origin = origin
+		}
+	}
+
+	// Clear the lines left over from a taller frame.
+	rows := lines
+	for i := len(lines); i < r.linesRendered; i++ {
+		rows = append(rows, "")
+	}
+	writeConsoleLines(out, rows, width, origin, r.defaultAttr)
+	r.linesRendered = len(lines)
+
+	// Place the cursor, which also has the console scroll to it.
+	pos := windows.Coord{X: 0, Y: origin + int16(len(lines)) - 1}
+	if r.cursor != nil {
+		pos = windows.Coord{X: int16(r.cursor.X), Y: origin + int16(r.cursor.Y)}
+	}
+	_ = windows.SetConsoleCursorPosition(out, pos)
+}
+
+// writeConsoleLines writes lines to a console screen buffer, from the given
+// row on, padding them to the width of the buffer.
+func writeConsoleLines(out windows.Handle, lines []string, width int, row int16, attr uint16) {
+	if width <= 0 || len(lines) == 0 {
+		return
+	}
+	cells := make([]charInfo, 0, width*len(lines))
+	for _, line := range lines {
+		cells = append(cells, consoleCells(line, width, attr)...)
+	}
+
+	size := windows.Coord{X: int16(width), Y: int16(len(lines))}
+	region := windows.SmallRect{
+		Left:   0,
+		Top:    row,
+		Right:  int16(width - 1),
+		Bottom: row + int16(len(lines)) - 1,
+	}
+	_, _, _ = procWriteConsoleOutputW.Call(
+		uintptr(out),
+		uintptr(unsafe.Pointer(&cells[0])),
+		uintptr(packCoord(size)),
+		uintptr(packCoord(windows.Coord{})),
+		uintptr(unsafe.Pointer(&region)),
+	)
+}
+
+// packCoord packs a COORD passed by value to a system call.
+func packCoord(c windows.Coord) uint32 {
+	return uint32(uint16(c.X)) | uint32(uint16(c.Y))<<16
+}
+
+// consoleCells converts a line of text, which may hold SGR sequences, to
+// exactly width console cells, using attr as the default attributes.
+func consoleCells(line string, width int, attr uint16) []charInfo {
+	cells := make([]charInfo, 0, width)
+	state := sgrState{attr: attr, defaultAttr: attr}
+
+	for i := 0; i < len(line) && len(cells) < width; {
+		if line[i] == '\x1b' {
+			i = state.skipEscape(line, i)
+			continue
+		}
+
+		cluster, w := firstGrapheme(line[i:])
+		i += len(cluster)
+		if w == 0 {
+			continue
+		}
+
+		// Cells hold a single character, without the marks combining with
+		// it, and a single UTF-16 code unit.
+		r, _ := utf8.DecodeRuneInString(cluster)
+		if r > 0xffff {
+			r = '?'
+		}
+		a := state.cellAttr()
+		if w == 1 {
+			cells = append(cells, charInfo{char: uint16(r), attr: a})
+			continue
+		}
+		if len(cells)+2 > width {
+			break
+		}
+		cells = append(cells,
+			charInfo{char: uint16(r), attr: a | consoleLeadingByte},
+			charInfo{char: uint16(r), attr: a | consoleTrailingByte},
+		)
+	}
+
+	for len(cells) < width {
+		cells = append(cells, charInfo{char: ' ', attr: attr})
+	}
+	return cells
+}
+
+// sgrState keeps track of the text attributes set by SGR sequences.
+type sgrState struct {
+	attr        uint16
+	defaultAttr uint16
+	reverse     bool
+}
+
+// cellAttr returns the attributes of the next cell.
+func (s *sgrState) cellAttr() uint16 {
+	if !s.reverse {
+		return s.attr
+	}
+	fg := s.attr & consoleForegroundMask
+	bg := (s.attr & consoleBackgroundMask) >> 4
+	return s.attr&^(consoleForegroundMask|consoleBackgroundMask) | bg | fg<<4
+}
+
+// skipEscape skips the escape sequence starting at line[i], applying it if
+// it's an SGR sequence, and returns the index following it.
+func (s *sgrState) skipEscape(line string, i int) int {
+	if i+1 >= len(line) {
+		return len(line)
+	}
+	switch line[i+1] {
+	case '[':
+		j := i + 2
+		for j < len(line) && (line[j] < 0x40 || line[j] > 0x7e) {
+			j++
+		}
+		if j < len(line) && line[j] == 'm' {
+			s.apply(line[i+2 : j])
+		}
+		return j + 1
+	case ']':
+		j := i + 2
+		for j < len(line) {
+			if line[j] == '\a' {
+				return j + 1
+			}
+			if line[j] == '\x1b' && j+1 < len(line) && line[j+1] == '\\' {
+				return j + 2
+			}
+			j++
+		}
+		return j
+	default:
+		return i + 2
+	}
+}
+
+// apply applies the parameters of an SGR sequence.
+func (s *sgrState) apply(params string) {
+	if params == "" {
+		params = "0"
+	}
+	ps := strings.Split(params, ";")
+	for i := 0; i < len(ps); i++ {
+		n, err := strconv.Atoi(ps[i])
+		if err != nil {
+			continue
+		}
+		switch {
+		case n == 0:
+			s.attr = s.defaultAttr
+			s.reverse = false
+		case n == 1:
+			s.attr |= consoleForegroundIntensity
+		case n == 22:
+			s.attr &^= consoleForegroundIntensity
+		case n == 7:
+			s.reverse = true
+		case n == 27:
+			s.reverse = false
+		case n >= 30 && n <= 37:
+			s.setForeground(consoleColor(n - 30))
+		case n == 39:
+			s.setForeground(s.defaultAttr & consoleForegroundMask)
+		case n >= 40 && n <= 47:
+			s.setBackground(consoleColor(n - 40))
+		case n == 49:
+			s.setBackground((s.defaultAttr & consoleBackgroundMask) >> 4)
+		case n >= 90 && n <= 97:
+			s.setForeground(consoleColor(n-90) | consoleForegroundIntensity)
+		case n >= 100 && n <= 107:
+			s.setBackground(consoleColor(n-100) | consoleForegroundIntensity)
+		case n == 38 || n == 48:
+			// Extended colors: 5;n for 256 colors, 2;r;g;b for true
+			// color.
+			c, ok, skip := extendedConsoleColor(ps[i+1:])
+			i += skip
+			if !ok {
+				continue
+			}
+			if n == 38 {
+				s.setForeground(c)
+			} else {
+				s.setBackground(c)
+			}
+		}
+	}
+}
+
+func (s *sgrState) setForeground(c uint16) {
+	s.attr = s.attr&^consoleForegroundMask | c
+}
+
+func (s *sgrState) setBackground(c uint16) {
+	s.attr = s.attr&^consoleBackgroundMask | c<<4
+}
+
+// consoleColor converts one of the 8 ANSI colors, in which bit 0 is red and
+// bit 2 is blue, to a console color, in which it's the other way around.
+func consoleColor(c int) uint16 {
+	var a uint16
+	if c&1 != 0 {
+		a |= consoleForegroundRed
+	}
+	if c&2 != 0 {
+		a |= consoleForegroundGreen
+	}
+	if c&4 != 0 {
+		a |= consoleForegroundBlue
+	}
+	return a
+}
+
+// extendedConsoleColor converts the parameters of an extended color to the
+// closest console color, if any. It returns how many parameters it
+// consumed.
+func extendedConsoleColor(ps []string) (uint16, bool, int) {
+	if len(ps) == 0 {
+		return 0, false, 0
+	}
+	switch ps[0] {
+	case "5":
+		if len(ps) < 2 { //nolint:gomnd
+			return 0, false, len(ps)
+		}
+		n, err := strconv.Atoi(ps[1])
+		if err != nil || n > 15 || n < 0 { //nolint:gomnd
+			return 0, false, 2 //nolint:gomnd
+		}
+		c := consoleColor(n % 8) //nolint:gomnd
+		if n >= 8 {              //nolint:gomnd
+			c |= consoleForegroundIntensity
+		}
+		return c, true, 2 //nolint:gomnd
+	case "2":
+		if len(ps) < 4 { //nolint:gomnd
+			return 0, false, len(ps)
+		}
+		var c int
+		bright := false
+		for i, p := range ps[1:4] {
+			v, _ := strconv.Atoi(p)
+			if v >= 128 { //nolint:gomnd
+				c |= 1 << i
+			}
+			if v >= 192 { //nolint:gomnd
+				bright = true
+			}
+		}
+		a := consoleColor(c)
+		if bright {
+			a |= consoleForegroundIntensity
+		}
+		return a, true, 4 //nolint:gomnd
+	default:
+		return 0, false, 0
+	}
+}
+
+func (r *consoleRenderer) clearScreen() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	var info windows.ConsoleScreenBufferInfo
+	if err := windows.GetConsoleScreenBufferInfo(r.active(), &info); err != nil {
+		return
+	}
+	height := int(info.Window.Bottom-info.Window.Top) + 1
+	writeConsoleLines(r.active(), make([]string, height), int(info.Size.X), info.Window.Top, r.defaultAttr)
+	if r.alt == 0 {
+		r.origin = info.Window.Top
+	}
+	r.linesRendered = 0
+	r.dirty = true
+}
+
+func (r *consoleRenderer) altScreen() bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.alt != 0
+}
+
+func (r *consoleRenderer) enterAltScreen() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.alt != 0 {
+		return
+	}
+	h, _, _ := procCreateConsoleScreenBuffer.Call(
+		uintptr(windows.GENERIC_READ|windows.GENERIC_WRITE),
+		uintptr(windows.FILE_SHARE_READ|windows.FILE_SHARE_WRITE),
+		0,
+		consoleTextModeBuffer,
+		0,
+	)
+	if windows.Handle(h) == windows.InvalidHandle || h == 0 {
+		return
+	}
+	if ok, _, _ := procSetConsoleActiveScreenBuffer.Call(h); ok == 0 {
+		_ = windows.CloseHandle(windows.Handle(h))
+		return
+	}
+	r.alt = windows.Handle(h)
+	r.setCursorVisible(r.alt, !r.cursorHidden)
+	r.dirty = true
+}
+
+func (r *consoleRenderer) exitAltScreen() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.alt == 0 {
+		return
+	}
+	_, _, _ = procSetConsoleActiveScreenBuffer.Call(uintptr(r.main))
+	_ = windows.CloseHandle(r.alt)
+	r.alt = 0
+	r.dirty = true
+}
+
+func (r *consoleRenderer) showCursor() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.cursorHidden = false
+	r.setCursorVisible(r.active(), true)
+}
+
+func (r *consoleRenderer) hideCursor() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.cursorHidden = true
+	r.setCursorVisible(r.active(), false)
+}
+
+func (r *consoleRenderer) setCursorVisible(out windows.Handle, visible bool) {
+	var info consoleCursorInfo
+	if ok, _, _ := procGetConsoleCursorInfo.Call(uintptr(out), uintptr(unsafe.Pointer(&info))); ok == 0 {
+		return
+	}
+	info.visible = 0
+	if visible {
+		info.visible = 1
+	}
+	_, _, _ = procSetConsoleCursorInfo.Call(uintptr(out), uintptr(unsafe.Pointer(&info)))
+}
+
+func (r *consoleRenderer) setCursor(c *Cursor) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if (c == nil) != (r.cursor == nil) || (c != nil && *c != *r.cursor) {
+		r.dirty = true
+	}
+	r.cursor = c
+}
+
+// handleMessages queues the lines printed with Println and Printf.
+func (r *consoleRenderer) handleMessages(msg Msg) {
+	switch msg := msg.(type) {
+	case printLineMessage:
+		r.mtx.Lock()
+		defer r.mtx.Unlock()
+		if r.alt == 0 {
+			r.queuedLines = append(r.queuedLines, strings.Split(msg.messageBody, "\n")...)
+			r.dirty = true
+		}
+	case WindowSizeMsg:
+		r.repaint()
+	}
+}
+
+// Mouse tracking, bracketed paste and the other terminal modes don't apply
+// to legacy consoles: mouse events are read from the console input.
+func (r *consoleRenderer) enableMouseCellMotion()                   {}
+func (r *consoleRenderer) disableMouseCellMotion()                  {}
+func (r *consoleRenderer) enableMouseAllMotion()                    {}
+func (r *consoleRenderer) disableMouseAllMotion()                   {}
+func (r *consoleRenderer) enableMouseSGRMode()                      {}
+func (r *consoleRenderer) disableMouseSGRMode()                     {}
+func (r *consoleRenderer) enableMousePixelsMode()                   {}
+func (r *consoleRenderer) disableMousePixelsMode()                  {}
+func (r *consoleRenderer) queryCellSize()                           {}
+func (r *consoleRenderer) enableBracketedPaste()                    {}
+func (r *consoleRenderer) disableBracketedPaste()                   {}
+func (r *consoleRenderer) bracketedPasteActive() bool               { return false }
+func (r *consoleRenderer) enableKittyKeyboard(_ KittyKeyboardFlags) {}
+func (r *consoleRenderer) disableKittyKeyboard()                    {}
+func (r *consoleRenderer) enableModifyOtherKeys()                   {}
+func (r *consoleRenderer) disableModifyOtherKeys()                  {}
+func (r *consoleRenderer) setClipboard(_ string)                    {}
+func (r *consoleRenderer) readClipboard()                           {}
+func (r *consoleRenderer) setSynchronizedOutput(_ bool)             {}
@@ -0,0 +1,68 @@
+//go:build windows
+// +build windows
+
+package tea
+
+import "testing"
+
+func TestConsoleCells(t *testing.T) {
+	const def = consoleForegroundRed | consoleForegroundGreen | consoleForegroundBlue
+
+	tests := []struct {
+		name  string
+		line  string
+		chars string
+		attrs []uint16
+	}{
+		{
+			name:  "plain",
+			line:  "ab",
+			chars: "ab  ",
+			attrs: []uint16{def, def, def, def},
+		},
+		{
+			name:  "colors",
+			line:  "\x1b[31ma\x1b[0mb",
+			chars: "ab  ",
+			attrs: []uint16{consoleForegroundRed, def, def, def},
+		},
+		{
+			name:  "bright background",
+			line:  "\x1b[104ma",
+			chars: "a   ",
+			attrs: []uint16{def | (consoleForegroundBlue|consoleForegroundIntensity)<<4, def, def, def},
+		},
+		{
+			name:  "reverse",
+			line:  "\x1b[7ma",
+			chars: "a   ",
+			attrs: []uint16{def << 4, def, def, def},
+		},
+		{
+			name:  "truncated",
+			line:  "abcdef",
+			chars: "abcd",
+			attrs: []uint16{def, def, def, def},
+		},
+		{
+			name:  "other escapes",
+			line:  "\x1b[2Ka\x1b]0;title\ab",
+			chars: "ab  ",
+			attrs: []uint16{def, def, def, def},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cells := consoleCells(test.line, 4, def)
+			if len(cells) != 4 {
+				t.Fatalf("expected 4 cells, got %d", len(cells))
+			}
+			for i, c := range cells {
+				if rune(c.char) != rune(test.chars[i]) || c.attr != test.attrs[i] {
+					t.Errorf("cell %d: expected %q/%#x, got %q/%#x",
+						i, test.chars[i], test.attrs[i], rune(c.char), c.attr)
+				}
+			}
+		})
+	}
+}
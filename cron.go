@@ -0,0 +1,310 @@
+package tea
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron produces a command that sends a single message at the next time
+// matching the given cron expression. To produce the message, pass a function
+// which returns a message containing the time at which it was scheduled.
+//
+//	type RefreshMsg time.Time
+//
+//	// Refresh on the schedule set by the user.
+//	func (m model) refresh() tea.Cmd {
+//	    cmd, err := tea.Cron(m.config.Schedule, func(t time.Time) tea.Msg {
+//	        return RefreshMsg(t)
+//	    })
+//	    if err != nil {
+//	        return tea.Println("invalid schedule:", err)
+//	    }
+//	    return cmd
+//	}
+//
+// Like Every, Cron sends a single message; return the same Cron command again
+// after receiving your message to keep the schedule going.
+//
+// The expression uses the standard five fields: minute (0-59), hour (0-23),
+// day of the month (1-31), month (1-12) and day of the week (0-6, with both 0
+// and 7 meaning Sunday). Each field is either *, a number, a range such as
+// 1-5, or a comma-separated list of those, each optionally followed by a
+// step, such as */15 or 0-30/10. As in most cron implementations, when both
+// the day of the month and the day of the week are restricted, a time matches
+// if either of them does. The shorthands @yearly, @monthly, @weekly, @daily
+// and @hourly are also supported. Times are in the local time zone.
+//
+// The pending message is dropped when the program exits, so a scheduled Cron
+// won't hold up shutdown.
+//
+// Cron returns an error if the expression is invalid. See MustCron for
+// expressions known to be valid.
+func Cron(spec string, fn func(time.Time) Msg) (Cmd, error) {
+	s, err := parseCron(spec)
+	if err != nil {
+		return nil, err
+	}
+	return func() Msg {
+		return scheduleMsg{next: s.next, fn: fn}
+	}, nil
+}
+
+// MustCron is like Cron but panics if the expression is invalid. It simplifies
+// using expressions written in the program:
+//
+//	// Refresh at the top of every fifth minute.
+//	func refresh() tea.Cmd {
+//	    return tea.MustCron("*/5 * * * *", func(t time.Time) tea.Msg {
+//	        return RefreshMsg(t)
+//	    })
+//	}
+func MustCron(spec string, fn func(time.Time) Msg) Cmd {
+	cmd, err := Cron(spec, fn)
+	if err != nil {
+		panic(err)
+	}
+	return cmd
+}
+
+// scheduleMsg is an internal message used to have the program send a message
+// at a later time, as told by its clock, unless it has exited by then. The
+// time is given by next, relative to the current time; a zero time means the
+// message is never sent. If ctx is set, the message is dropped once it's
+// done.
+type scheduleMsg struct {
+	ctx  context.Context
+	next func(now time.Time) time.Time
+	fn   func(time.Time) Msg
+}
+
+func (s scheduleMsg) run(p *Program, _ context.Context) Msg {
+	return p.wait(s)
+}
+
+func (s scheduleMsg) then(final, _ func(Msg) Msg) Msg {
+	fn := s.fn
+	s.fn = func(t time.Time) Msg {
+		return final(fn(t))
+	}
+	return s
+}
+
+// wait waits for a scheduled command to be due and returns its message. It
+// returns nil if the program exits or stops running commands first, or the
+// command's context is done, so that pending timers don't hold up Shutdown.
+func (p *Program) wait(s scheduleMsg) Msg {
+	now := p.clock.Now()
+	at := s.next(now)
+	if at.IsZero() {
+		// The schedule never matches, e.g. February 30th.
+		return nil
+	}
+
+	t := p.clock.NewTimer(at.Sub(now))
+	defer t.Stop()
+
+	var done <-chan struct{}
+	if s.ctx != nil {
+		done = s.ctx.Done()
+	}
+	select {
+	case <-p.ctx.Done():
+		return nil
+	case <-p.stopped:
+		return nil
+	case <-done:
+		return nil
+	case ts := <-t.C():
+		return s.fn(ts)
+	}
+}
+
+// runCmd runs a command and returns its message. Scheduled, retried,
+// cancelable and streaming commands, such as Tick, Retry, WithTimeout and
+// Stream, are waited for, so that they complete like any other command, and
+// watches are started.
+func (p *Program) runCmd(cmd Cmd) Msg {
+	return p.settle(p.ctx, p.callOnWorker(cmd))
+}
+
+// deferredMsg is implemented by the internal messages telling the program to
+// run a command itself and deliver its messages later, such as the ones of
+// Tick, Stream and Retry.
+type deferredMsg interface {
+	// run runs the command and returns its message, delivering the ones it
+	// produces along the way. Commands made with Cancelable are given ctx.
+	run(p *Program, ctx context.Context) Msg
+
+	// then returns the message with final applied to the messages the
+	// command returns once they're produced, and progress to the ones it
+	// delivers along the way.
+	then(final, progress func(Msg) Msg) Msg
+}
+
+// runDeferred runs a command the program runs itself in the background,
+// sending its message once it's done, unless the program exits first.
+func (p *Program) runDeferred(msg deferredMsg) {
+	p.cmdsInFlight.Add(1)
+	go func() {
+		defer p.cmdsInFlight.Done()
+		defer p.recoverFromGoPanic()
+
+		if msg := msg.run(p, p.ctx); msg != nil {
+			p.Send(msg)
+		}
+	}()
+}
+
+// settle waits for the message of a command, if it's one of the internal
+// messages telling the program to produce it later, such as a scheduled
+// command, with the given context for commands made with Cancelable.
+func (p *Program) settle(ctx context.Context, msg Msg) Msg {
+	if later, ok := msg.(deferredMsg); ok {
+		return later.run(p, ctx)
+	}
+	return msg
+}
+
+// cronSchedule is a parsed cron expression. Each field is a bitset of the
+// values it matches.
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+
+	// domStar and dowStar report whether the day of the month and the day
+	// of the week are unrestricted.
+	domStar, dowStar bool
+}
+
+var cronShorthands = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// parseCron parses a cron expression. See Cron for the supported syntax.
+func parseCron(spec string) (cronSchedule, error) {
+	if s, ok := cronShorthands[strings.TrimSpace(spec)]; ok {
+		spec = s
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 { //nolint:gomnd
+		return cronSchedule{}, fmt.Errorf("cron: expected 5 fields in %q, got %d", spec, len(fields))
+	}
+
+	var s cronSchedule
+	var err error
+	if s.minute, err = parseCronField(fields[0], 0, 59); err != nil { //nolint:gomnd
+		return cronSchedule{}, err
+	}
+	if s.hour, err = parseCronField(fields[1], 0, 23); err != nil { //nolint:gomnd
+		return cronSchedule{}, err
+	}
+	if s.dom, err = parseCronField(fields[2], 1, 31); err != nil { //nolint:gomnd
+		return cronSchedule{}, err
+	}
+	if s.month, err = parseCronField(fields[3], 1, 12); err != nil { //nolint:gomnd
+		return cronSchedule{}, err
+	}
+	if s.dow, err = parseCronField(fields[4], 0, 7); err != nil { //nolint:gomnd
+		return cronSchedule{}, err
+	}
+
+	// 7 is Sunday, too.
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domStar = fields[2] == "*"
+	s.dowStar = fields[4] == "*"
+
+	return s, nil
+}
+
+// parseCronField parses a single field of a cron expression into a bitset.
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			var err error
+			rng = part[:i]
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step < 1 {
+				return 0, fmt.Errorf("cron: invalid step in %q", part)
+			}
+		}
+
+		lo, hi := min, max
+		if rng != "*" {
+			var err error
+			bounds := strings.SplitN(rng, "-", 2) //nolint:gomnd
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("cron: invalid value in %q", part)
+			}
+			hi = lo
+			if len(bounds) == 2 { //nolint:gomnd
+				if hi, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, fmt.Errorf("cron: invalid value in %q", part)
+				}
+			} else if step > 1 {
+				// A step after a single value runs to the end of the
+				// range, e.g. 5/15 is 5,20,35,50.
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("cron: %q out of range %d-%d", part, min, max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// next returns the first time after t matching the schedule, or the zero
+// time if there's none in the next few years.
+func (s cronSchedule) next(t time.Time) time.Time {
+	loc := t.Location()
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, loc)
+	limit := t.AddDate(5, 0, 0) //nolint:gomnd
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, loc)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches reports whether the day of t matches the schedule.
+func (s cronSchedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
@@ -0,0 +1,123 @@
+package tea
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	for _, spec := range []string{
+		"* * * * *",
+		"*/5 * * * *",
+		"0,30 9-17 * * 1-5",
+		"5/15 0 1 1 7",
+		"@daily",
+	} {
+		if _, err := parseCron(spec); err != nil {
+			t.Errorf("%q: unexpected error: %v", spec, err)
+		}
+	}
+
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"@sometimes",
+	} {
+		if _, err := parseCron(spec); err == nil {
+			t.Errorf("%q: expected an error", spec)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	// A Wednesday.
+	from := time.Date(2023, time.March, 15, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		spec     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2023, time.March, 15, 10, 8, 0, 0, time.UTC)},
+		{"*/5 * * * *", time.Date(2023, time.March, 15, 10, 10, 0, 0, time.UTC)},
+		{"0 * * * *", time.Date(2023, time.March, 15, 11, 0, 0, 0, time.UTC)},
+		{"30 9 * * *", time.Date(2023, time.March, 16, 9, 30, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2023, time.April, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 0", time.Date(2023, time.March, 19, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2023, time.March, 19, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		// Day of month or day of week: the 20th, or any Friday.
+		{"0 0 20 * 5", time.Date(2023, time.March, 17, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, test := range tests {
+		s, err := parseCron(test.spec)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", test.spec, err)
+		}
+		if next := s.next(from); !next.Equal(test.expected) {
+			t.Errorf("%q: expected %v, got %v", test.spec, test.expected, next)
+		}
+	}
+}
+
+func TestCron(t *testing.T) {
+	msg := MustCron("* * * * *", func(t time.Time) Msg { return t })()
+	s, ok := msg.(scheduleMsg)
+	if !ok {
+		t.Fatalf("expected a scheduleMsg, got %#v", msg)
+	}
+	now := time.Now()
+	if at := s.next(now); at.Second() != 0 || !at.After(now) {
+		t.Fatalf("expected a time at the start of a future minute, got %v", at)
+	}
+
+	if cmd, err := Cron("nope", func(t time.Time) Msg { return t }); cmd != nil || err == nil {
+		t.Fatal("expected an error for an invalid expression")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected MustCron to panic on an invalid expression")
+		}
+	}()
+	MustCron("nope", func(t time.Time) Msg { return t })
+}
+
+func TestCronShutdown(t *testing.T) {
+	var buf bytes.Buffer
+	var in bytes.Buffer
+
+	p := NewProgram(&testModel{}, WithInput(&in), WithOutput(&buf))
+	yearly := MustCron("@yearly", func(t time.Time) Msg { return t })
+	started := make(chan struct{})
+	go p.Send(BatchMsg{yearly})
+	go p.Send(sequenceMsg{func() Msg {
+		close(started)
+		return nil
+	}, yearly})
+
+	errs := make(chan error, 1)
+	go func() {
+		<-started
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		errs <- p.Shutdown(ctx)
+	}()
+
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("expected pending crons not to hold up shutdown, got %v", err)
+	}
+}
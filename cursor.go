@@ -0,0 +1,119 @@
+package tea
+
+import "time"
+
+// Cursor describes where the terminal's hardware cursor should be placed,
+// relative to the top-left corner of the view. X is the column and Y is the
+// line, both starting at zero.
+type Cursor struct {
+	X int
+	Y int
+}
+
+// CursorModel is an optional interface models can implement to place the
+// terminal's real cursor inside their view, rather than drawing a fake one
+// with reverse video. A real cursor is important for input method editors,
+// which draw their composition window at the cursor, and for screen readers,
+// which follow it.
+//
+// Cursor is called after every View. Return nil to keep the cursor hidden.
+// The cursor is shown as it's placed in each frame, and hidden again
+// before the next one is drawn. Layers and frames can place the cursor too,
+// with Layer.Cursor and Frame.SetCursor, and a Split places the cursor of
+// the first pane which does.
+//
+//	func (m model) Cursor() *tea.Cursor {
+//	    if !m.input.Focused() {
+//	        return nil
+//	    }
+//	    return &tea.Cursor{X: len(m.prompt) + m.input.Position(), Y: 2}
+//	}
+type CursorModel interface {
+	Model
+
+	// Cursor returns the position of the cursor within the view, or nil if
+	// the cursor should be hidden.
+	Cursor() *Cursor
+}
+
+// render sends the model's view, with its layers, and its cursor, if it
+// declares them, to the renderer, along with the header and the footer.
+func (p *Program) render(model Model) {
+	model = p.timeTravel.shown(model)
+
+	start := time.Now()
+	view, cursor := p.composedView(model)
+	v, headerLines := p.regions.pin(view, p.renderer.altScreen())
+	v, p.zoneMap = scanZones(v)
+	p.debug.renderTime = time.Since(start)
+
+	var overlay []string
+	if p.debug.enabled {
+		overlay = p.debugOverlayLines()
+	}
+	if p.timeTravel.traveling() {
+		overlay = append(overlay, p.timeTravel.overlayLines()...)
+	}
+	if len(overlay) > 0 {
+		v = p.withOverlay(v, overlay)
+	}
+	p.renderer.write(v)
+
+	p.lastFrameMtx.Lock()
+	p.lastFrame, p.lastFrameWidth, p.hasLastFrame = v, p.width, true
+	p.lastFrameMtx.Unlock()
+
+	if cursor != nil && headerLines > 0 {
+		cursor = &Cursor{X: cursor.X, Y: cursor.Y + headerLines}
+	}
+	p.renderer.setCursor(cursor)
+
+	// Images are placed relative to the model's view, below the header.
+	if r, ok := p.renderer.(*standardRenderer); ok {
+		r.setImageOffset(headerLines)
+	}
+
+	// Live regions are only of use to the accessible renderer.
+	if r, ok := p.renderer.(*accessibleRenderer); ok {
+		if m, ok := model.(LiveRegionModel); ok {
+			r.setLiveRegions(m.LiveRegions())
+		}
+	}
+}
+
+// composedView returns the view of a model, or the frame it draws, with its
+// layers composited over it, along with where the cursor should be placed:
+// in the topmost layer which places it, or where the model or its frame
+// places it.
+func (p *Program) composedView(model Model) (string, *Cursor) {
+	var (
+		v      string
+		cursor *Cursor
+	)
+	if m, ok := model.(FrameModel); ok {
+		width, height := 80, 24
+		if p.width > 0 && p.regions.height > 0 {
+			width, height = p.width, p.regions.viewHeight()
+		}
+		f := NewFrame(width, height)
+		f.profile = p.output.Profile
+		m.ViewFrame(f)
+		v, cursor = f.String(), f.cursor
+	} else {
+		v = model.View()
+	}
+
+	// Cursor is called after View.
+	if m, ok := model.(CursorModel); ok && cursor == nil {
+		cursor = m.Cursor()
+	}
+
+	if m, ok := model.(LayerModel); ok {
+		layers := m.Layers()
+		v = Composite(v, layers...)
+		if c := layersCursor(layers); c != nil {
+			cursor = c
+		}
+	}
+	return v, cursor
+}
@@ -0,0 +1,48 @@
+package tea
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/muesli/termenv"
+)
+
+func TestRendererCursor(t *testing.T) {
+	var buf bytes.Buffer
+	r := newRenderer(termenv.NewOutput(&buf), false, 0).(*standardRenderer)
+	r.cursorHidden = true
+
+	r.write("first\nsecond\nthird")
+	r.setCursor(&Cursor{X: 3, Y: 1})
+	r.flush()
+
+	// The frame is followed by moving the cursor up one line and forward
+	// three columns, then showing it.
+	if got, want := buf.String(), "\rfirst\r\nsecond\r\nthird\x1b[0D\x1b[1A\x1b[3C\x1b[?25h"; got != want {
+		t.Errorf("expected:\n%q\ngot:\n%q", want, got)
+	}
+
+	// Moving the cursor without changing the frame only moves the cursor.
+	buf.Reset()
+	r.setCursor(&Cursor{X: 0, Y: 0})
+	r.flush()
+	if got, want := buf.String(), "\x1b[?25l\x1b[1B\r\x1b[2A\x1b[?25h"; got != want {
+		t.Errorf("expected:\n%q\ngot:\n%q", want, got)
+	}
+
+	// Removing the cursor hides it again.
+	buf.Reset()
+	r.setCursor(nil)
+	r.flush()
+	if got, want := buf.String(), "\x1b[?25l\x1b[2B\r"; got != want {
+		t.Errorf("expected:\n%q\ngot:\n%q", want, got)
+	}
+
+	// Setting the same cursor twice is a no-op.
+	buf.Reset()
+	r.setCursor(nil)
+	r.flush()
+	if buf.Len() != 0 {
+		t.Errorf("expected no output, got %q", buf.String())
+	}
+}
@@ -0,0 +1,156 @@
+package tea
+
+import "sync"
+
+// Renderer is the interface for custom renderers, which draw a program's
+// views somewhere other than the terminal: to a file, to a buffer in memory,
+// over a remote protocol, and so on. Set one with WithRenderer.
+//
+// Beyond the methods below, a renderer can implement any of the following
+// ones, which are called when the program needs them:
+//
+//	// SetCursor is called after each update with the position of the
+//	// cursor set by a CursorModel, or nil if the model doesn't place it.
+//	SetCursor(*Cursor)
+//
+//	// EnterAltScreen and ExitAltScreen are called when the program enters
+//	// and exits the alternate screen buffer.
+//	EnterAltScreen()
+//	ExitAltScreen()
+//
+//	// ClearScreen is called when the program asks for the screen to be
+//	// cleared, and Repaint when the next frame should be drawn in full.
+//	ClearScreen()
+//	Repaint()
+//
+//	// HandleMessage is called with each message the program receives,
+//	// such as a WindowSizeMsg, before it's passed to the model.
+//	HandleMessage(Msg)
+//
+// Terminal features which have no meaning outside of a terminal, such as
+// mouse tracking modes and bracketed paste, aren't passed on to custom
+// renderers.
+type Renderer interface {
+	// Start is called when the program starts, before the first frame is
+	// written.
+	Start()
+
+	// Stop is called when the program exits. The renderer should draw the
+	// last frame written, if it didn't already.
+	Stop()
+
+	// Kill is called when the program is killed. The last frame doesn't
+	// need to be drawn.
+	Kill()
+
+	// Write is called with the program's view each time it's rendered. It's
+	// called from the program's event loop, so it should return quickly:
+	// renderers are free to draw frames at their own pace, skipping some.
+	Write(view string)
+}
+
+// customRenderer adapts a Renderer to the program's renderer interface.
+type customRenderer struct {
+	r Renderer
+
+	mtx         sync.Mutex
+	altScreenOn bool
+	bracketed   bool
+}
+
+func newCustomRenderer(r Renderer) *customRenderer {
+	return &customRenderer{r: r}
+}
+
+func (c *customRenderer) start()         { c.r.Start() }
+func (c *customRenderer) stop()          { c.r.Stop() }
+func (c *customRenderer) kill()          { c.r.Kill() }
+func (c *customRenderer) write(s string) { c.r.Write(s) }
+
+func (c *customRenderer) repaint() {
+	if r, ok := c.r.(interface{ Repaint() }); ok {
+		r.Repaint()
+	}
+}
+
+func (c *customRenderer) clearScreen() {
+	if r, ok := c.r.(interface{ ClearScreen() }); ok {
+		r.ClearScreen()
+	}
+}
+
+func (c *customRenderer) altScreen() bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.altScreenOn
+}
+
+func (c *customRenderer) enterAltScreen() {
+	c.mtx.Lock()
+	c.altScreenOn = true
+	c.mtx.Unlock()
+
+	if r, ok := c.r.(interface{ EnterAltScreen() }); ok {
+		r.EnterAltScreen()
+	}
+}
+
+func (c *customRenderer) exitAltScreen() {
+	c.mtx.Lock()
+	c.altScreenOn = false
+	c.mtx.Unlock()
+
+	if r, ok := c.r.(interface{ ExitAltScreen() }); ok {
+		r.ExitAltScreen()
+	}
+}
+
+func (c *customRenderer) setCursor(cur *Cursor) {
+	if r, ok := c.r.(interface{ SetCursor(*Cursor) }); ok {
+		r.SetCursor(cur)
+	}
+}
+
+func (c *customRenderer) handleMessages(msg Msg) {
+	if r, ok := c.r.(interface{ HandleMessage(Msg) }); ok {
+		r.HandleMessage(msg)
+	}
+}
+
+func (c *customRenderer) showCursor()             {}
+func (c *customRenderer) hideCursor()             {}
+func (c *customRenderer) enableMouseCellMotion()  {}
+func (c *customRenderer) disableMouseCellMotion() {}
+func (c *customRenderer) enableMouseAllMotion()   {}
+func (c *customRenderer) disableMouseAllMotion()  {}
+func (c *customRenderer) enableMouseSGRMode()     {}
+func (c *customRenderer) disableMouseSGRMode()    {}
+func (c *customRenderer) enableMousePixelsMode()  {}
+func (c *customRenderer) disableMousePixelsMode() {}
+func (c *customRenderer) queryCellSize()          {}
+
+func (c *customRenderer) enableBracketedPaste() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.bracketed = true
+}
+
+func (c *customRenderer) disableBracketedPaste() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.bracketed = false
+}
+
+func (c *customRenderer) bracketedPasteActive() bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.bracketed
+}
+
+func (c *customRenderer) enableKittyKeyboard(_ KittyKeyboardFlags) {}
+func (c *customRenderer) disableKittyKeyboard()                    {}
+func (c *customRenderer) enableModifyOtherKeys()                   {}
+func (c *customRenderer) disableModifyOtherKeys()                  {}
+func (c *customRenderer) setClipboard(_ string)                    {}
+func (c *customRenderer) readClipboard()                           {}
+func (c *customRenderer) setSynchronizedOutput(_ bool)             {}
@@ -0,0 +1,69 @@
+package tea
+
+import (
+	"sync"
+	"testing"
+)
+
+type recordingRenderer struct {
+	mtx    sync.Mutex
+	calls  []string
+	frames []string
+	sizes  []WindowSizeMsg
+}
+
+func (r *recordingRenderer) record(call string) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.calls = append(r.calls, call)
+}
+
+func (r *recordingRenderer) Start()          { r.record("start") }
+func (r *recordingRenderer) Stop()           { r.record("stop") }
+func (r *recordingRenderer) Kill()           { r.record("kill") }
+func (r *recordingRenderer) EnterAltScreen() { r.record("enter alt screen") }
+func (r *recordingRenderer) ExitAltScreen()  { r.record("exit alt screen") }
+
+func (r *recordingRenderer) Write(view string) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.frames = append(r.frames, view)
+}
+
+func (r *recordingRenderer) HandleMessage(msg Msg) {
+	if msg, ok := msg.(WindowSizeMsg); ok {
+		r.mtx.Lock()
+		defer r.mtx.Unlock()
+		r.sizes = append(r.sizes, msg)
+	}
+}
+
+func TestWithRenderer(t *testing.T) {
+	r := &recordingRenderer{}
+
+	resize := func() Msg { return WindowSizeMsg{Width: 80, Height: 24} }
+	m := initCmdModel{testModel: &testModel{}, init: Sequence(resize, Quit)}
+	p := NewProgram(m, WithRenderer(r), WithInput(nil), WithAltScreen())
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	expected := []string{"enter alt screen", "start", "stop", "exit alt screen"}
+	if len(r.calls) != len(expected) {
+		t.Fatalf("expected calls %q, got %q", expected, r.calls)
+	}
+	for i := range expected {
+		if r.calls[i] != expected[i] {
+			t.Fatalf("expected calls %q, got %q", expected, r.calls)
+		}
+	}
+	if len(r.frames) == 0 || r.frames[len(r.frames)-1] != "success\n" {
+		t.Errorf("expected last frame %q, got %q", "success\n", r.frames)
+	}
+	if len(r.sizes) != 1 || r.sizes[0] != (WindowSizeMsg{Width: 80, Height: 24}) {
+		t.Errorf("expected one 80x24 window size, got %v", r.sizes)
+	}
+}
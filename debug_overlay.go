@@ -0,0 +1,147 @@
+package tea
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/muesli/ansi"
+	"github.com/muesli/reflow/truncate"
+	"github.com/muesli/termenv"
+)
+
+// Width of the terminal assumed for the debug overlay until the program gets
+// a WindowSizeMsg.
+const defaultDebugOverlayWidth = 80
+
+// ToggleDebugOverlay is a special command that shows or hides the debug
+// overlay: a small box drawn over the top-right corner of the view, showing
+// the frame rate, how long View takes, how many messages are waiting to be
+// processed, the last message received and what the terminal supports. It's
+// meant to help diagnose sluggish programs, for instance bound to a key:
+//
+//	case tea.KeyMsg:
+//	    if msg.String() == "f12" {
+//	        return m, tea.ToggleDebugOverlay
+//	    }
+func ToggleDebugOverlay() Msg {
+	return toggleDebugOverlayMsg{}
+}
+
+// toggleDebugOverlayMsg is an internal message that shows or hides the debug
+// overlay. You can send a toggleDebugOverlayMsg with ToggleDebugOverlay.
+type toggleDebugOverlayMsg struct{}
+
+// debugOverlay keeps the statistics shown in the debug overlay. Except for
+// frames, which are counted by the renderer, it's only used from the event
+// loop.
+type debugOverlay struct {
+	enabled    bool
+	width      int
+	lastMsg    string
+	renderTime time.Duration
+
+	// the times at which frames were drawn within the last second
+	mtx    sync.Mutex
+	frames []time.Time
+}
+
+// frameRendered counts a frame drawn by the renderer.
+func (d *debugOverlay) frameRendered() {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	now := time.Now()
+	d.frames = append(d.frames, now)
+	for len(d.frames) > 0 && now.Sub(d.frames[0]) > time.Second {
+		d.frames = d.frames[1:]
+	}
+}
+
+// fps returns the number of frames drawn within the last second.
+func (d *debugOverlay) fps() int {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	n := 0
+	for _, t := range d.frames {
+		if time.Since(t) <= time.Second {
+			n++
+		}
+	}
+	return n
+}
+
+// debugOverlayLines returns the lines of the debug overlay.
+func (p *Program) debugOverlayLines() []string {
+	term := []string{profileName(p.output.Profile)}
+	if p.outputIsTerminal() {
+		term = append(term, "tty")
+	} else {
+		term = append(term, "no tty")
+	}
+	if p.kittyKeyboardFlags != 0 {
+		term = append(term, "kitty keyboard")
+	}
+
+	return []string{
+		fmt.Sprintf("fps: %d", p.debug.fps()),
+		fmt.Sprintf("view: %s", p.debug.renderTime.Round(time.Microsecond)),
+		fmt.Sprintf("queue: %d", p.queueDepth()),
+		fmt.Sprintf("last: %s", p.debug.lastMsg),
+		fmt.Sprintf("term: %s", strings.Join(term, ", ")),
+	}
+}
+
+// withOverlay draws a box with the given lines, such as the ones of the debug
+// overlay, over the top-right corner of the view.
+func (p *Program) withOverlay(view string, overlay []string) string {
+	width := p.debug.width
+	if width <= 0 {
+		width = defaultDebugOverlayWidth
+	}
+
+	boxWidth := 0
+	for _, l := range overlay {
+		if w := ansi.PrintableRuneWidth(l); w > boxWidth {
+			boxWidth = w
+		}
+	}
+	boxWidth += 2 // padding
+	if boxWidth > width {
+		boxWidth = width
+	}
+
+	lines := strings.Split(view, "\n")
+	for len(lines) < len(overlay) {
+		lines = append(lines, "")
+	}
+	for i, l := range overlay {
+		box := " " + l + strings.Repeat(" ", boxWidth-ansi.PrintableRuneWidth(l)-1)
+		box = truncate.String(box, uint(boxWidth))
+		box = termenv.String(box).Reverse().String()
+
+		// Keep the left part of the line, padded up to the box.
+		line := truncateLine(lines[i], width-boxWidth)
+		if w := StringWidth(line); w < width-boxWidth {
+			line += strings.Repeat(" ", width-boxWidth-w)
+		}
+		lines[i] = line + termenv.CSI + termenv.ResetSeq + "m" + box
+	}
+	return strings.Join(lines, "\n")
+}
+
+// profileName returns the name of a color profile.
+func profileName(p termenv.Profile) string {
+	switch p {
+	case termenv.TrueColor:
+		return "true color"
+	case termenv.ANSI256:
+		return "256 colors"
+	case termenv.ANSI:
+		return "16 colors"
+	default:
+		return "no color"
+	}
+}
@@ -0,0 +1,52 @@
+package tea
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/muesli/ansi"
+)
+
+func TestDebugOverlay(t *testing.T) {
+	r := NewHeadlessRenderer()
+	m := initCmdModel{testModel: &testModel{}, init: Sequence(
+		ToggleDebugOverlay,
+		func() Msg { return WindowSizeMsg{Width: 40, Height: 10} },
+		Quit,
+	)}
+	p := NewProgram(m, WithHeadlessRenderer(r), WithInput(nil))
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(r.LastFrame(), "\n")
+	if !strings.HasPrefix(lines[0], "success") {
+		t.Errorf("expected the view under the overlay, got %q", lines[0])
+	}
+	for i, want := range []string{"fps:", "view:", "queue:", "last: tea.QuitMsg", "term: no color, no tty"} {
+		if !strings.Contains(lines[i], want) {
+			t.Errorf("expected line %d to contain %q, got %q", i, want, lines[i])
+		}
+		if w := ansi.PrintableRuneWidth(lines[i]); w != 40 {
+			t.Errorf("expected line %d to be 40 cells wide, got %d", i, w)
+		}
+	}
+}
+
+func TestDebugOverlayLayout(t *testing.T) {
+	p := NewProgram(nil, WithOutput(io.Discard))
+	p.debug.width = 30
+
+	view := p.withOverlay("a very long line that goes under the overlay\nshort", p.debugOverlayLines())
+	lines := strings.Split(view, "\n")
+	if len(lines) != 5 {
+		t.Fatalf("expected the view to grow to the height of the overlay, got %d lines", len(lines))
+	}
+	if !strings.HasPrefix(lines[0], "a very") || strings.Contains(lines[0], "overlay") {
+		t.Errorf("expected the line to be cut by the overlay, got %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "short") {
+		t.Errorf("unexpected second line %q", lines[1])
+	}
+}
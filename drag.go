@@ -0,0 +1,51 @@
+package tea
+
+// dragTracker follows the presses, motions and releases of the mouse to tell
+// the events making up drags.
+type dragTracker struct {
+	pressed  bool
+	dragging bool
+	button   MouseButton
+	x, y     int
+	lastX    int
+	lastY    int
+}
+
+// track sets the part of a drag a mouse event is, if any.
+func (d *dragTracker) track(m MouseEvent) MouseEvent {
+	if m.IsWheel() {
+		return m
+	}
+
+	switch m.Action {
+	case MouseActionPress:
+		*d = dragTracker{pressed: true, button: m.Button, x: m.X, y: m.Y, lastX: m.X, lastY: m.Y}
+	case MouseActionMotion:
+		if m.Button == MouseButtonNone {
+			// The button was released without the release being reported.
+			*d = dragTracker{}
+			return m
+		}
+		if !d.pressed || m.Button != d.button {
+			// The press happened before the mouse was enabled, or
+			// another button was pressed in the meantime.
+			*d = dragTracker{pressed: true, button: m.Button, x: m.X, y: m.Y, lastX: m.X, lastY: m.Y}
+		}
+		m.Drag = MouseDragMove
+		if !d.dragging {
+			m.Drag = MouseDragStart
+			d.dragging = true
+		}
+		m.DragX, m.DragY = d.x, d.y
+		m.DeltaX, m.DeltaY = m.X-d.lastX, m.Y-d.lastY
+		d.lastX, d.lastY = m.X, m.Y
+	case MouseActionRelease:
+		if d.dragging {
+			m.Drag = MouseDragEnd
+			m.DragX, m.DragY = d.x, d.y
+			m.DeltaX, m.DeltaY = m.X-d.lastX, m.Y-d.lastY
+		}
+		*d = dragTracker{}
+	}
+	return m
+}
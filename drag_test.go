@@ -0,0 +1,38 @@
+package tea
+
+import "testing"
+
+func TestDragTracker(t *testing.T) {
+	var d dragTracker
+	tests := []struct {
+		name   string
+		event  MouseEvent
+		drag   MouseDrag
+		dx, dy int
+	}{
+		{"press", MouseEvent{X: 1, Y: 1, Button: MouseButtonLeft, Action: MouseActionPress}, MouseDragNone, 0, 0},
+		{"start", MouseEvent{X: 2, Y: 1, Button: MouseButtonLeft, Action: MouseActionMotion}, MouseDragStart, 1, 0},
+		{"move", MouseEvent{X: 4, Y: 3, Button: MouseButtonLeft, Action: MouseActionMotion}, MouseDragMove, 2, 2},
+		{"end", MouseEvent{X: 4, Y: 4, Action: MouseActionRelease}, MouseDragEnd, 0, 1},
+		{"hover", MouseEvent{X: 5, Y: 4, Action: MouseActionMotion}, MouseDragNone, 0, 0},
+		{"click", MouseEvent{X: 5, Y: 4, Button: MouseButtonLeft, Action: MouseActionPress}, MouseDragNone, 0, 0},
+		{"click release", MouseEvent{X: 5, Y: 4, Button: MouseButtonLeft, Action: MouseActionRelease}, MouseDragNone, 0, 0},
+	}
+	for _, test := range tests {
+		m := d.track(test.event)
+		if m.Drag != test.drag || m.DeltaX != test.dx || m.DeltaY != test.dy {
+			t.Errorf("%s: expected drag %v by %d,%d, got %v by %d,%d", test.name, test.drag, test.dx, test.dy, m.Drag, m.DeltaX, m.DeltaY)
+		}
+		if m.Drag != MouseDragNone && (m.DragX != 1 || m.DragY != 1) {
+			t.Errorf("%s: expected the drag to start at 1,1, got %d,%d", test.name, m.DragX, m.DragY)
+		}
+	}
+
+	// Drags starting before the mouse was enabled start where they're first
+	// seen.
+	d = dragTracker{}
+	m := d.track(MouseEvent{X: 3, Y: 3, Button: MouseButtonRight, Action: MouseActionMotion})
+	if m.Drag != MouseDragStart || m.DragX != 3 || m.DragY != 3 {
+		t.Errorf("expected a drag starting at 3,3, got %v at %d,%d", m.Drag, m.DragX, m.DragY)
+	}
+}
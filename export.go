@@ -0,0 +1,282 @@
+package tea
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+
+	"github.com/muesli/termenv"
+)
+
+// ExportFormat is a format frames can be exported to with ExportView and
+// Program.ExportFrame.
+type ExportFormat int
+
+// Export formats.
+const (
+	// ExportHTML is a pre element, which can be embedded in a page.
+	ExportHTML ExportFormat = iota
+
+	// ExportSVG is a standalone SVG image.
+	ExportSVG
+)
+
+// ErrNoFrame is returned by Program.ExportFrame when no frame was rendered
+// yet.
+var ErrNoFrame = errors.New("no frame was rendered yet")
+
+// The colors frames are exported with where the terminal's default colors
+// are used, and the size of their cells in SVG images, in pixels.
+const (
+	exportForeground = "#d0d0d0"
+	exportBackground = "#1c1c1c"
+	exportCellWidth  = 9
+	exportCellHeight = 18
+)
+
+// ExportFrame returns the latest frame rendered by the program, with its
+// colors and styles, as an HTML snippet or an SVG image, such as for
+// documentation or bug reports. It's safe to call from any goroutine, and
+// returns ErrNoFrame if no frame was rendered yet.
+func (p *Program) ExportFrame(format ExportFormat) (string, error) {
+	frame, ok := p.latestFrame()
+	if !ok {
+		return "", ErrNoFrame
+	}
+	return ExportView(frame, format)
+}
+
+// ExportView converts a view, with its colors and styles, to an HTML snippet
+// or an SVG image. Colors are exported as they're defined by xterm, and
+// hyperlinks are kept. Escape sequences other than those setting styles and
+// hyperlinks are left out.
+func ExportView(view string, format ExportFormat) (string, error) {
+	var rows [][]exportSpan
+	for _, line := range strings.Split(view, "\n") {
+		rows = append(rows, exportSpans(line))
+	}
+
+	switch format {
+	case ExportHTML:
+		return exportHTML(rows), nil
+	case ExportSVG:
+		return exportSVG(rows), nil
+	default:
+		return "", fmt.Errorf("unknown export format %d", format)
+	}
+}
+
+// exportSpan is a run of text in the same style and hyperlink, and the
+// column it starts at.
+type exportSpan struct {
+	text  string
+	col   int
+	width int
+	style cellStyle
+	link  string
+}
+
+// exportSpans splits a line into spans of text in the same style and
+// hyperlink, with tabs expanded.
+func exportSpans(line string) []exportSpan {
+	if strings.IndexByte(line, '\t') >= 0 {
+		line = expandTabs(line, defaultTabWidth)
+	}
+
+	var (
+		spans []exportSpan
+		style cellStyle
+		link  string
+		col   int
+	)
+	for i := 0; i < len(line); {
+		if line[i] == '\x1b' {
+			end, _ := sequenceEnd(line, i)
+			seq := line[i:end]
+			switch {
+			case strings.HasPrefix(seq, termenv.CSI) && strings.HasSuffix(seq, "m"):
+				// What's understood of the sequence is kept.
+				style, _ = style.apply(seq[len(termenv.CSI) : len(seq)-1])
+			case strings.HasPrefix(seq, "\x1b]8;"):
+				link = ""
+				if hyperlinkOpen(seq) {
+					params := strings.TrimSuffix(strings.TrimSuffix(seq[4:], "\a"), "\x1b\\")
+					link = params[strings.IndexByte(params, ';')+1:]
+				}
+			}
+			i = end
+			continue
+		}
+
+		cluster, w := firstGrapheme(line[i:])
+		i += len(cluster)
+		if w == 0 && (cluster[0] < 0x20 || cluster[0] == 0x7f) {
+			continue
+		}
+		if n := len(spans) - 1; n >= 0 && spans[n].style == style && spans[n].link == link {
+			spans[n].text += cluster
+			spans[n].width += w
+		} else {
+			spans = append(spans, exportSpan{text: cluster, col: col, width: w, style: style, link: link})
+		}
+		col += w
+	}
+	return spans
+}
+
+// exportColors returns the foreground and background colors of a style, as
+// CSS colors.
+func exportColors(s cellStyle) (fg, bg string) {
+	fg, bg = exportColor(s.fg, exportForeground), exportColor(s.bg, exportBackground)
+	if s.attrs&attrReverse != 0 {
+		fg, bg = bg, fg
+	}
+	if s.attrs&attrConceal != 0 {
+		fg = bg
+	}
+	return fg, bg
+}
+
+// exportColor converts the SGR parameters setting a color, such as "31",
+// "38;5;208" or "48;2;255;0;0", to a CSS color. Empty parameters stand for
+// the default color given.
+func exportColor(params, def string) string {
+	if params == "" {
+		return def
+	}
+	ps := strings.Split(params, ";")
+	n, _ := strconv.Atoi(ps[0])
+	switch {
+	case n >= 30 && n <= 37:
+		return termenv.ANSIColor(n - 30).String()
+	case n >= 40 && n <= 47:
+		return termenv.ANSIColor(n - 40).String()
+	case n >= 90 && n <= 97:
+		return termenv.ANSIColor(n - 90 + 8).String()
+	case n >= 100 && n <= 107:
+		return termenv.ANSIColor(n - 100 + 8).String()
+	case len(ps) == 3 && ps[1] == "5":
+		if c, err := strconv.Atoi(ps[2]); err == nil && c >= 0 && c < 256 {
+			return termenv.ANSI256Color(c).String()
+		}
+	case len(ps) == 5 && ps[1] == "2":
+		var rgb [3]int
+		for i := range rgb {
+			rgb[i], _ = strconv.Atoi(ps[i+2])
+		}
+		return fmt.Sprintf("#%02x%02x%02x", rgb[0]&0xff, rgb[1]&0xff, rgb[2]&0xff)
+	}
+	return def
+}
+
+// exportCSS returns the CSS declarations styling a span.
+func exportCSS(s cellStyle, svg bool) string {
+	var decls []string
+	fg, bg := exportColors(s)
+	if svg {
+		if fg != exportForeground {
+			decls = append(decls, "fill:"+fg)
+		}
+	} else {
+		if fg != exportForeground {
+			decls = append(decls, "color:"+fg)
+		}
+		if bg != exportBackground {
+			decls = append(decls, "background-color:"+bg)
+		}
+	}
+	if s.attrs&attrBold != 0 {
+		decls = append(decls, "font-weight:bold")
+	}
+	if s.attrs&attrFaint != 0 {
+		decls = append(decls, "opacity:0.5")
+	}
+	if s.attrs&attrItalic != 0 {
+		decls = append(decls, "font-style:italic")
+	}
+	var lines []string
+	if s.attrs&attrUnderline != 0 {
+		lines = append(lines, "underline")
+	}
+	if s.attrs&attrStrikethrough != 0 {
+		lines = append(lines, "line-through")
+	}
+	if len(lines) > 0 {
+		decls = append(decls, "text-decoration:"+strings.Join(lines, " "))
+	}
+	return strings.Join(decls, ";")
+}
+
+// exportHTML exports rows of spans as a pre element.
+func exportHTML(rows [][]exportSpan) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<pre style="color:%s;background-color:%s;font-family:monospace">`, exportForeground, exportBackground)
+	for i, row := range rows {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		for _, span := range row {
+			text := html.EscapeString(span.text)
+			if css := exportCSS(span.style, false); css != "" {
+				text = `<span style="` + css + `">` + text + `</span>`
+			}
+			if span.link != "" {
+				text = `<a href="` + html.EscapeString(span.link) + `">` + text + `</a>`
+			}
+			b.WriteString(text)
+		}
+	}
+	b.WriteString("</pre>")
+	return b.String()
+}
+
+// exportSVG exports rows of spans as an SVG image. Each span is stretched to
+// the cells it takes up, so that columns line up whatever the font.
+func exportSVG(rows [][]exportSpan) string {
+	cols := 0
+	for _, row := range rows {
+		if n := len(row); n > 0 && row[n-1].col+row[n-1].width > cols {
+			cols = row[n-1].col + row[n-1].width
+		}
+	}
+	width, height := cols*exportCellWidth, len(rows)*exportCellHeight
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`,
+		width, height, width, height)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="%s"/>`, exportBackground)
+
+	// Backgrounds first, so that they don't cover the text.
+	for y, row := range rows {
+		for _, span := range row {
+			if _, bg := exportColors(span.style); bg != exportBackground {
+				fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`,
+					span.col*exportCellWidth, y*exportCellHeight, span.width*exportCellWidth, exportCellHeight, bg)
+			}
+		}
+	}
+
+	fmt.Fprintf(&b, `<g font-family="monospace" font-size="%d" fill="%s" xml:space="preserve">`,
+		exportCellHeight*5/6, exportForeground)
+	for y, row := range rows {
+		for _, span := range row {
+			if strings.TrimSpace(span.text) == "" && span.style.attrs&(attrUnderline|attrStrikethrough) == 0 {
+				continue
+			}
+			text := fmt.Sprintf(`<text x="%d" y="%d" textLength="%d" lengthAdjust="spacingAndGlyphs"`,
+				span.col*exportCellWidth, y*exportCellHeight+exportCellHeight*4/5, span.width*exportCellWidth)
+			if css := exportCSS(span.style, true); css != "" {
+				text += ` style="` + css + `"`
+			}
+			text += ">" + html.EscapeString(span.text) + "</text>"
+			if span.link != "" {
+				text = `<a href="` + html.EscapeString(span.link) + `">` + text + `</a>`
+			}
+			b.WriteString(text)
+		}
+	}
+	b.WriteString("</g></svg>")
+	return b.String()
+}
@@ -0,0 +1,85 @@
+package tea
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestExportColor(t *testing.T) {
+	tests := []struct {
+		params   string
+		expected string
+	}{
+		{"", exportForeground},
+		{"31", "#800000"},
+		{"91", "#ff0000"},
+		{"44", "#000080"},
+		{"38;5;208", "#ff8700"},
+		{"48;2;1;2;3", "#010203"},
+	}
+	for _, test := range tests {
+		if got := exportColor(test.params, exportForeground); got != test.expected {
+			t.Errorf("%q: expected %s, got %s", test.params, test.expected, got)
+		}
+	}
+}
+
+func TestExportViewHTML(t *testing.T) {
+	view := "\x1b[1;31m<a>\x1b[0m b\n" + Hyperlink("https://example.com/?a&b", "link")
+	got, err := ExportView(view, ExportHTML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<pre style="color:#d0d0d0;background-color:#1c1c1c;font-family:monospace">` +
+		`<span style="color:#800000;font-weight:bold">&lt;a&gt;</span> b` + "\n" +
+		`<a href="https://example.com/?a&amp;b">link</a></pre>`
+	if got != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestExportViewSVG(t *testing.T) {
+	got, err := ExportView("a\x1b[7mbc\x1b[0m\n世", ExportSVG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<svg xmlns="http://www.w3.org/2000/svg" width="27" height="36"`,
+		// The reversed span's background is the default foreground.
+		`<rect x="9" y="0" width="18" height="18" fill="#d0d0d0"/>`,
+		`<text x="9" y="14" textLength="18" lengthAdjust="spacingAndGlyphs" style="fill:#1c1c1c">bc</text>`,
+		// Wide characters take up two cells.
+		`<text x="0" y="32" textLength="18" lengthAdjust="spacingAndGlyphs">世</text>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %s in:\n%s", want, got)
+		}
+	}
+
+	if _, err := ExportView("", ExportFormat(-1)); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestProgramExportFrame(t *testing.T) {
+	var buf bytes.Buffer
+	var in bytes.Buffer
+	in.Write([]byte("q"))
+	p := NewProgram(&testModel{}, WithInput(&in), WithOutput(&buf))
+	if _, err := p.ExportFrame(ExportHTML); !errors.Is(err, ErrNoFrame) {
+		t.Errorf("expected ErrNoFrame, got %v", err)
+	}
+
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+	got, err := p.ExportFrame(ExportHTML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, ">success\n</pre>") {
+		t.Errorf("expected the last frame, got %s", got)
+	}
+}
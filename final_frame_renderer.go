@@ -0,0 +1,142 @@
+package tea
+
+import (
+	"io"
+	"strings"
+	"sync"
+)
+
+// finalFrameRenderer is the renderer used when the program's output isn't a
+// terminal, e.g. when it's piped to another program or redirected to a file.
+// Rather than drawing each frame with escape sequences, it prints the final
+// frame once the program exits, stripped of escape sequences, along with the
+// lines printed with Println and Printf, so that the output is plain text.
+type finalFrameRenderer struct {
+	mtx  sync.Mutex
+	out  io.Writer
+	view string
+	done bool
+}
+
+func newFinalFrameRenderer(out io.Writer) *finalFrameRenderer {
+	return &finalFrameRenderer{out: out}
+}
+
+func (r *finalFrameRenderer) start() {}
+
+// stop prints the final frame.
+func (r *finalFrameRenderer) stop() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.done {
+		return
+	}
+	r.done = true
+
+	view := stripANSI(r.view)
+	if view == "" {
+		return
+	}
+	if !strings.HasSuffix(view, "\n") {
+		view += "\n"
+	}
+	_, _ = io.WriteString(r.out, view)
+}
+
+// kill stops the renderer without printing the final frame.
+func (r *finalFrameRenderer) kill() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.done = true
+}
+
+func (r *finalFrameRenderer) write(s string) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.view = s
+}
+
+// handleMessages prints the lines printed with Println and Printf right
+// away.
+func (r *finalFrameRenderer) handleMessages(msg Msg) {
+	if msg, ok := msg.(printLineMessage); ok {
+		r.mtx.Lock()
+		defer r.mtx.Unlock()
+		_, _ = io.WriteString(r.out, stripANSI(msg.messageBody)+"\n")
+	}
+}
+
+func (r *finalFrameRenderer) repaint()                                 {}
+func (r *finalFrameRenderer) clearScreen()                             {}
+func (r *finalFrameRenderer) altScreen() bool                          { return false }
+func (r *finalFrameRenderer) enterAltScreen()                          {}
+func (r *finalFrameRenderer) exitAltScreen()                           {}
+func (r *finalFrameRenderer) showCursor()                              {}
+func (r *finalFrameRenderer) hideCursor()                              {}
+func (r *finalFrameRenderer) setCursor(_ *Cursor)                      {}
+func (r *finalFrameRenderer) enableMouseCellMotion()                   {}
+func (r *finalFrameRenderer) disableMouseCellMotion()                  {}
+func (r *finalFrameRenderer) enableMouseAllMotion()                    {}
+func (r *finalFrameRenderer) disableMouseAllMotion()                   {}
+func (r *finalFrameRenderer) enableMouseSGRMode()                      {}
+func (r *finalFrameRenderer) disableMouseSGRMode()                     {}
+func (r *finalFrameRenderer) enableMousePixelsMode()                   {}
+func (r *finalFrameRenderer) disableMousePixelsMode()                  {}
+func (r *finalFrameRenderer) queryCellSize()                           {}
+func (r *finalFrameRenderer) enableBracketedPaste()                    {}
+func (r *finalFrameRenderer) disableBracketedPaste()                   {}
+func (r *finalFrameRenderer) bracketedPasteActive() bool               { return false }
+func (r *finalFrameRenderer) enableKittyKeyboard(_ KittyKeyboardFlags) {}
+func (r *finalFrameRenderer) disableKittyKeyboard()                    {}
+func (r *finalFrameRenderer) enableModifyOtherKeys()                   {}
+func (r *finalFrameRenderer) disableModifyOtherKeys()                  {}
+func (r *finalFrameRenderer) setClipboard(_ string)                    {}
+func (r *finalFrameRenderer) readClipboard()                           {}
+func (r *finalFrameRenderer) setSynchronizedOutput(_ bool)             {}
+
+// stripANSI removes escape sequences from s: CSI sequences, such as colors
+// and cursor movements, OSC sequences, such as hyperlinks and window titles,
+// and other two-character escapes.
+func stripANSI(s string) string {
+	if !strings.ContainsRune(s, '\x1b') {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\x1b' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+1 >= len(s) {
+			break
+		}
+
+		switch s[i+1] {
+		case '[':
+			// CSI: parameters and intermediates, up to a final byte.
+			i += 2
+			for i < len(s) && (s[i] < 0x40 || s[i] > 0x7e) {
+				i++
+			}
+		case ']':
+			// OSC: up to BEL or ST.
+			i += 2
+			for i < len(s) {
+				if s[i] == '\a' {
+					break
+				}
+				if s[i] == '\x1b' && i+1 < len(s) && s[i+1] == '\\' {
+					i++
+					break
+				}
+				i++
+			}
+		default:
+			i++
+		}
+	}
+	return b.String()
+}
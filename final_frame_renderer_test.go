@@ -0,0 +1,46 @@
+package tea
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestFinalFrameRenderer(t *testing.T) {
+	var buf bytes.Buffer
+	m := initCmdModel{testModel: &testModel{}, init: Sequence(
+		Println("\x1b[1mhello\x1b[0m"),
+		SetWindowTitle("title"),
+		EnterAltScreen,
+		Quit,
+	)}
+	p := NewProgram(m, WithInput(nil), WithOutput(&buf))
+	p.defaultOutput = true // as if stdout was piped
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "hello\nsuccess\n"; buf.String() != expected {
+		t.Errorf("expected output %q, got %q", expected, buf.String())
+	}
+}
+
+func TestStripANSI(t *testing.T) {
+	tests := []struct {
+		name, in, expected string
+	}{
+		{"plain", "hello", "hello"},
+		{"sgr", "\x1b[1;31mred\x1b[0m", "red"},
+		{"cursor", "a\x1b[2Kb\x1b[1A", "ab"},
+		{"osc bel", "\x1b]0;title\atext", "text"},
+		{"osc st", "\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x1b\\", "link"},
+		{"escape", "\x1b7saved\x1b8", "saved"},
+		{"truncated", "text\x1b", "text"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if s := stripANSI(test.in); s != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, s)
+			}
+		})
+	}
+}
@@ -0,0 +1,308 @@
+package tea
+
+import (
+	"strings"
+
+	"github.com/muesli/termenv"
+)
+
+// FrameModel is an optional interface models can implement to draw their
+// view cell by cell into a Frame, rather than assembling a string in View.
+// When a model implements it, ViewFrame is called in place of View, with a
+// blank frame the size of the window, or of 80 by 24 cells until the size of
+// the window is known. View is still needed to satisfy Model, and can return
+// an empty string.
+//
+//	func (m model) ViewFrame(f *tea.Frame) {
+//	    f.Box(0, 0, f.Width(), f.Height(), tea.Style{Foreground: termenv.ANSIBlue})
+//	    f.Text(2, 1, m.title, tea.Style{Bold: true})
+//	}
+//
+// Blank lines at the bottom of the frame aren't drawn, so that programs
+// outside of the alt screen take up only the lines they draw on.
+type FrameModel interface {
+	Model
+
+	// ViewFrame draws the view into a frame.
+	ViewFrame(f *Frame)
+}
+
+// Style is the style of a cell of a Frame.
+type Style struct {
+	// Foreground and Background are the colors of the cell. Nil stands for
+	// the terminal's default colors.
+	Foreground, Background termenv.Color
+
+	Bold, Faint, Italic, Underline, Blink, Reverse, Strikethrough bool
+}
+
+// Cell is a cell of a Frame.
+type Cell struct {
+	// Content is the grapheme cluster drawn in the cell: a character,
+	// along with the combining marks, variation selectors or joined
+	// characters following it. The second cell of a wide character has no
+	// content.
+	Content string
+
+	Style Style
+}
+
+// Frame is a grid of styled cells, which models implementing FrameModel
+// draw their view into. Drawing outside of the frame does nothing, so there's
+// no need to check bounds. Since cells are kept apart, a frame also tells
+// what's drawn where, which helps with hit-testing mouse events.
+type Frame struct {
+	width, height int
+	cells         []Cell
+
+	// the color profile colors are converted to when the frame is turned
+	// into a string
+	profile termenv.Profile
+
+	// where the terminal's cursor should be placed, if anywhere
+	cursor *Cursor
+}
+
+// blankFrameCell is what the cells of a new frame hold.
+var blankFrameCell = Cell{Content: " "}
+
+// NewFrame returns a blank frame of the given size, in cells.
+func NewFrame(width, height int) *Frame {
+	if width < 0 {
+		width = 0
+	}
+	if height < 0 {
+		height = 0
+	}
+	f := &Frame{
+		width:   width,
+		height:  height,
+		cells:   make([]Cell, width*height),
+		profile: termenv.TrueColor,
+	}
+	f.Clear()
+	return f
+}
+
+// Width returns the width of the frame, in cells.
+func (f *Frame) Width() int { return f.width }
+
+// Height returns the height of the frame, in cells.
+func (f *Frame) Height() int { return f.height }
+
+// SetCursor places the terminal's cursor at a cell of the frame, such as
+// where text is being entered. Frames start without a cursor, which keeps it
+// hidden.
+func (f *Frame) SetCursor(x, y int) {
+	f.cursor = &Cursor{X: x, Y: y}
+}
+
+// Cursor returns the position of the cursor set with SetCursor, or nil if
+// there's none.
+func (f *Frame) Cursor() *Cursor {
+	if f.cursor == nil {
+		return nil
+	}
+	c := *f.cursor
+	return &c
+}
+
+// Clear blanks the whole frame.
+func (f *Frame) Clear() {
+	for i := range f.cells {
+		f.cells[i] = blankFrameCell
+	}
+}
+
+// Cell returns the cell at a position. Positions outside of the frame hold
+// blank cells.
+func (f *Frame) Cell(x, y int) Cell {
+	if !f.inside(x, y) {
+		return blankFrameCell
+	}
+	return f.cells[y*f.width+x]
+}
+
+// Set sets the cell at a position. A wide character takes up the cell after
+// it too, and isn't drawn if that cell is outside of the frame.
+func (f *Frame) Set(x, y int, c Cell) {
+	w := StringWidth(c.Content)
+	if w == 0 {
+		c.Content, w = " ", 1
+	}
+	if !f.inside(x, y) || !f.inside(x+w-1, y) {
+		return
+	}
+
+	f.clearWide(x, y)
+	f.cells[y*f.width+x] = c
+	for k := 1; k < w; k++ {
+		f.clearWide(x+k, y)
+		f.cells[y*f.width+x+k] = Cell{Style: c.Style}
+	}
+}
+
+// clearWide blanks the rest of a wide character a cell is part of, before the
+// cell is overwritten.
+func (f *Frame) clearWide(x, y int) {
+	row := f.cells[y*f.width : (y+1)*f.width]
+	if row[x].Content == "" {
+		// The second half of a wide character: blank the first.
+		for k := x - 1; k >= 0; k-- {
+			if row[k].Content != "" {
+				row[k] = Cell{Content: " ", Style: row[k].Style}
+				break
+			}
+		}
+	}
+	for k := x + 1; k < f.width && row[k].Content == ""; k++ {
+		row[k] = Cell{Content: " ", Style: row[k].Style}
+	}
+}
+
+// Text draws a line of text from a position, cut off at the right edge of
+// the frame, and returns the number of cells it took up. Each grapheme
+// cluster, such as a character along with the combining marks following it,
+// takes up a cell, or two if it's wide.
+func (f *Frame) Text(x, y int, s string, style Style) int {
+	col := x
+	for i := 0; i < len(s); {
+		cluster, w := firstGrapheme(s[i:])
+		i += len(cluster)
+		if w == 0 {
+			continue
+		}
+		if col+w > f.width {
+			break
+		}
+		f.Set(col, y, Cell{Content: cluster, Style: style})
+		col += w
+	}
+	return col - x
+}
+
+// Fill sets all the cells of a rectangle.
+func (f *Frame) Fill(x, y, width, height int, c Cell) {
+	w := StringWidth(c.Content)
+	if w < 1 {
+		w = 1
+	}
+	for row := y; row < y+height; row++ {
+		for col := x; col+w <= x+width; col += w {
+			f.Set(col, row, c)
+		}
+	}
+}
+
+// Box draws the border of a rectangle, with box-drawing characters.
+func (f *Frame) Box(x, y, width, height int, style Style) {
+	if width < 2 || height < 2 {
+		return
+	}
+	right, bottom := x+width-1, y+height-1
+	for col := x + 1; col < right; col++ {
+		f.Set(col, y, Cell{Content: "─", Style: style})
+		f.Set(col, bottom, Cell{Content: "─", Style: style})
+	}
+	for row := y + 1; row < bottom; row++ {
+		f.Set(x, row, Cell{Content: "│", Style: style})
+		f.Set(right, row, Cell{Content: "│", Style: style})
+	}
+	f.Set(x, y, Cell{Content: "┌", Style: style})
+	f.Set(right, y, Cell{Content: "┐", Style: style})
+	f.Set(x, bottom, Cell{Content: "└", Style: style})
+	f.Set(right, bottom, Cell{Content: "┘", Style: style})
+}
+
+// Draw draws another frame over this one, with its top-left corner at a
+// position.
+func (f *Frame) Draw(x, y int, src *Frame) {
+	for row := 0; row < src.height; row++ {
+		for col := 0; col < src.width; col++ {
+			if c := src.cells[row*src.width+col]; c.Content != "" {
+				f.Set(x+col, y+row, c)
+			}
+		}
+	}
+}
+
+// String returns the frame as a view, with the escape sequences styling its
+// cells. Trailing blank cells and lines are left out, except for the line the
+// cursor is on.
+func (f *Frame) String() string {
+	var b strings.Builder
+
+	height := f.height
+	for height > 0 && f.blankRow(height-1) && (f.cursor == nil || f.cursor.Y < height-1) {
+		height--
+	}
+
+	for y := 0; y < height; y++ {
+		if y > 0 {
+			b.WriteByte('\n')
+		}
+		row := f.cells[y*f.width : (y+1)*f.width]
+		end := len(row)
+		for end > 0 && row[end-1] == blankFrameCell {
+			end--
+		}
+
+		var pen cellStyle
+		for _, c := range row[:end] {
+			if c.Content == "" {
+				continue
+			}
+			style := f.cellStyle(c.Style)
+			b.WriteString(style.sgr(pen))
+			pen = style
+			b.WriteString(c.Content)
+		}
+		if pen != (cellStyle{}) {
+			b.WriteString(termenv.CSI + "m")
+		}
+	}
+	return b.String()
+}
+
+// blankRow reports whether a row of the frame is blank.
+func (f *Frame) blankRow(y int) bool {
+	for _, c := range f.cells[y*f.width : (y+1)*f.width] {
+		if c != blankFrameCell {
+			return false
+		}
+	}
+	return true
+}
+
+// cellStyle converts a style to the one the renderer works with, converting
+// its colors to the frame's color profile.
+func (f *Frame) cellStyle(s Style) cellStyle {
+	var cs cellStyle
+	for _, a := range []struct {
+		on   bool
+		attr cellAttrs
+	}{
+		{s.Bold, attrBold},
+		{s.Faint, attrFaint},
+		{s.Italic, attrItalic},
+		{s.Underline, attrUnderline},
+		{s.Blink, attrBlink},
+		{s.Reverse, attrReverse},
+		{s.Strikethrough, attrStrikethrough},
+	} {
+		if a.on {
+			cs.attrs |= a.attr
+		}
+	}
+	if s.Foreground != nil {
+		cs.fg = f.profile.Convert(s.Foreground).Sequence(false)
+	}
+	if s.Background != nil {
+		cs.bg = f.profile.Convert(s.Background).Sequence(true)
+	}
+	return cs
+}
+
+func (f *Frame) inside(x, y int) bool {
+	return x >= 0 && y >= 0 && x < f.width && y < f.height
+}
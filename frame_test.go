@@ -0,0 +1,113 @@
+package tea
+
+import (
+	"testing"
+
+	"github.com/muesli/termenv"
+)
+
+func TestFrameText(t *testing.T) {
+	f := NewFrame(6, 2)
+	if n := f.Text(1, 0, "héllo world", Style{}); n != 5 {
+		t.Errorf("expected 5 cells to be drawn, got %d", n)
+	}
+	if n := f.Text(0, 1, "a世éb", Style{}); n != 5 {
+		t.Errorf("expected 5 cells to be drawn, got %d", n)
+	}
+	if c := f.Cell(2, 1); c.Content != "" {
+		t.Errorf("expected the second half of a wide character, got %q", c.Content)
+	}
+	if c := f.Cell(3, 1); c.Content != "é" {
+		t.Errorf("expected a combining character to join the one before it, got %q", c.Content)
+	}
+	if got, want := f.String(), " héllo\na世éb"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestFrameWideCharacters(t *testing.T) {
+	f := NewFrame(4, 1)
+	f.Text(0, 0, "世界", Style{})
+
+	// Overwriting half of a wide character blanks the other half.
+	f.Set(1, 0, Cell{Content: "a"})
+	if got, want := f.String(), " a界"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	// Wide characters which don't fit aren't drawn.
+	f.Set(3, 0, Cell{Content: "世"})
+	if got, want := f.String(), " a界"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestFrameStyles(t *testing.T) {
+	f := NewFrame(10, 3)
+	red := Style{Foreground: termenv.ANSIRed, Bold: true}
+	f.Text(0, 0, "ab", red)
+	f.Text(2, 0, "c", Style{})
+	f.Fill(0, 1, 2, 1, Cell{Content: "x", Style: Style{Background: termenv.ANSI256Color(208)}})
+
+	want := "\x1b[1;31mab\x1b[mc\n\x1b[48;5;208mxx\x1b[m"
+	if got := f.String(); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	// Colors are converted to the frame's profile.
+	f.profile = termenv.Ascii
+	if got, want := f.String(), "\x1b[1mab\x1b[mc\nxx"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestFrameBox(t *testing.T) {
+	f := NewFrame(5, 4)
+	f.Box(0, 0, 4, 3, Style{})
+	inner := NewFrame(2, 1)
+	inner.Text(0, 0, "hi", Style{})
+	f.Draw(1, 1, inner)
+
+	want := "┌──┐\n│hi│\n└──┘"
+	if got := f.String(); got != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+type frameModel struct {
+	*testModel
+}
+
+func (m frameModel) ViewFrame(f *Frame) {
+	f.Text(0, 0, "size", Style{})
+	f.Text(f.Width()-1, f.Height()-1, "!", Style{})
+	f.SetCursor(4, 0)
+}
+
+func TestFrameModel(t *testing.T) {
+	p := NewProgram(nil)
+	p.width, p.regions.height = 8, 3
+	p.regions.header = "header"
+
+	v, cursor := p.composedView(frameModel{&testModel{}})
+	if want := "size\n       !"; v != want {
+		t.Errorf("expected %q, got %q", want, v)
+	}
+	if cursor == nil || *cursor != (Cursor{X: 4, Y: 0}) {
+		t.Errorf("expected the cursor at 4,0, got %v", cursor)
+	}
+}
+
+func TestFrameCursor(t *testing.T) {
+	f := NewFrame(4, 4)
+	f.Text(0, 0, "ab", Style{})
+	f.SetCursor(1, 2)
+
+	// The line the cursor is on is kept.
+	if got, want := f.String(), "ab\n\n"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	if c := f.Cursor(); c == nil || *c != (Cursor{X: 1, Y: 2}) {
+		t.Errorf("expected the cursor at 1,2, got %v", c)
+	}
+}
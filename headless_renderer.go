@@ -0,0 +1,145 @@
+package tea
+
+import "sync"
+
+// HeadlessRenderer is a renderer that keeps the latest frame in memory
+// instead of drawing it, and never touches the terminal. Use it to run
+// programs in CI, in tests or as background daemons, while still being able
+// to see what they would display.
+//
+//	r := tea.NewHeadlessRenderer()
+//	p := tea.NewProgram(model, tea.WithHeadlessRenderer(r), tea.WithInput(nil))
+//	go p.Run()
+//
+//	// Later...
+//	fmt.Println(r.LastFrame())
+//
+// A HeadlessRenderer is safe for concurrent use.
+type HeadlessRenderer struct {
+	mtx sync.Mutex
+
+	frame       string
+	frames      int
+	altScreenOn bool
+	cursor      *Cursor
+	bracketed   bool
+}
+
+// NewHeadlessRenderer returns a new HeadlessRenderer.
+func NewHeadlessRenderer() *HeadlessRenderer {
+	return &HeadlessRenderer{}
+}
+
+// LastFrame returns the latest frame rendered by the program, that is, the
+// result of the latest call to its model's View function.
+func (r *HeadlessRenderer) LastFrame() string {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.frame
+}
+
+// Frames returns the number of frames rendered so far, counting only frames
+// that differ from the one before. It can be used to wait for the program to
+// render something new.
+func (r *HeadlessRenderer) Frames() int {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.frames
+}
+
+// AltScreen reports whether the program is using the alternate screen
+// buffer.
+func (r *HeadlessRenderer) AltScreen() bool {
+	return r.altScreen()
+}
+
+// Cursor returns the position of the cursor set by a CursorModel, or nil if
+// the model doesn't place it.
+func (r *HeadlessRenderer) Cursor() *Cursor {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if r.cursor == nil {
+		return nil
+	}
+	c := *r.cursor
+	return &c
+}
+
+func (r *HeadlessRenderer) start()   {}
+func (r *HeadlessRenderer) stop()    {}
+func (r *HeadlessRenderer) kill()    {}
+func (r *HeadlessRenderer) repaint() {}
+
+func (r *HeadlessRenderer) write(s string) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if s != r.frame {
+		r.frame = s
+		r.frames++
+	}
+}
+
+func (r *HeadlessRenderer) clearScreen() {}
+
+func (r *HeadlessRenderer) altScreen() bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.altScreenOn
+}
+
+func (r *HeadlessRenderer) enterAltScreen() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.altScreenOn = true
+}
+
+func (r *HeadlessRenderer) exitAltScreen() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.altScreenOn = false
+}
+
+func (r *HeadlessRenderer) showCursor() {}
+func (r *HeadlessRenderer) hideCursor() {}
+
+func (r *HeadlessRenderer) setCursor(c *Cursor) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.cursor = c
+}
+
+func (r *HeadlessRenderer) enableMouseCellMotion()  {}
+func (r *HeadlessRenderer) disableMouseCellMotion() {}
+func (r *HeadlessRenderer) enableMouseAllMotion()   {}
+func (r *HeadlessRenderer) disableMouseAllMotion()  {}
+func (r *HeadlessRenderer) enableMouseSGRMode()     {}
+func (r *HeadlessRenderer) disableMouseSGRMode()    {}
+func (r *HeadlessRenderer) enableMousePixelsMode()  {}
+func (r *HeadlessRenderer) disableMousePixelsMode() {}
+func (r *HeadlessRenderer) queryCellSize()          {}
+
+func (r *HeadlessRenderer) enableBracketedPaste() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.bracketed = true
+}
+
+func (r *HeadlessRenderer) disableBracketedPaste() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.bracketed = false
+}
+
+func (r *HeadlessRenderer) bracketedPasteActive() bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.bracketed
+}
+
+func (r *HeadlessRenderer) enableKittyKeyboard(_ KittyKeyboardFlags) {}
+func (r *HeadlessRenderer) disableKittyKeyboard()                    {}
+func (r *HeadlessRenderer) enableModifyOtherKeys()                   {}
+func (r *HeadlessRenderer) disableModifyOtherKeys()                  {}
+func (r *HeadlessRenderer) setClipboard(_ string)                    {}
+func (r *HeadlessRenderer) readClipboard()                           {}
+func (r *HeadlessRenderer) setSynchronizedOutput(_ bool)             {}
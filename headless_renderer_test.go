@@ -0,0 +1,49 @@
+package tea
+
+import (
+	"testing"
+)
+
+func TestHeadlessRenderer(t *testing.T) {
+	r := NewHeadlessRenderer()
+
+	var altScreen bool
+	m := initCmdModel{testModel: &testModel{}, init: func() Msg {
+		altScreen = r.AltScreen()
+		return QuitMsg{}
+	}}
+	p := NewProgram(m, WithHeadlessRenderer(r), WithInput(nil), WithAltScreen())
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	if f := r.LastFrame(); f != "success\n" {
+		t.Errorf("expected last frame %q, got %q", "success\n", f)
+	}
+	if n := r.Frames(); n != 1 {
+		t.Errorf("expected 1 frame, got %d", n)
+	}
+	if !altScreen {
+		t.Error("expected alt screen to be entered on startup")
+	}
+	if r.AltScreen() {
+		t.Error("expected alt screen to be exited on shutdown")
+	}
+}
+
+func TestHeadlessRendererCursor(t *testing.T) {
+	r := NewHeadlessRenderer()
+	if r.Cursor() != nil {
+		t.Fatal("expected no cursor")
+	}
+
+	r.setCursor(&Cursor{X: 1, Y: 2})
+	if c := r.Cursor(); c == nil || *c != (Cursor{X: 1, Y: 2}) {
+		t.Fatalf("expected cursor at 1,2, got %v", c)
+	}
+
+	r.setCursor(nil)
+	if r.Cursor() != nil {
+		t.Fatal("expected no cursor")
+	}
+}
@@ -0,0 +1,84 @@
+package tea
+
+import (
+	"strings"
+
+	"github.com/muesli/termenv"
+)
+
+// hyperlinkEnd is the OSC 8 sequence ending a hyperlink.
+const hyperlinkEnd = "\x1b]8;;\x1b\\"
+
+// Hyperlink returns text as a hyperlink to a URL, for views, so that file
+// paths and URLs can be clicked in terminals supporting OSC 8 hyperlinks.
+// Other terminals show the text alone.
+//
+//	fmt.Sprintf("Saved to %s", tea.Hyperlink("file://"+path, path))
+//
+// Each line of the text is a link of its own, since the renderer ends links
+// left open at the end of a line, so that they don't spill over the lines
+// painted after it. Cutting lines wider than the window doesn't split the
+// sequences either.
+func Hyperlink(url, text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = "\x1b]8;;" + url + "\x1b\\" + line + hyperlinkEnd
+	}
+	return strings.Join(lines, "\n")
+}
+
+// truncateLine cuts a line to a width in cells, keeping the escape sequences
+// and the grapheme clusters before the cut whole. Styles left set are reset
+// after the cut, and a hyperlink left open is ended.
+func truncateLine(s string, width int) string {
+	return cellWidths(0).truncateLine(s, width)
+}
+
+func (m cellWidths) truncateLine(s string, width int) string {
+	w, styled := 0, false
+	for i := 0; i < len(s); {
+		if s[i] == '\x1b' {
+			end, _ := sequenceEnd(s, i)
+			if seq := s[i:end]; strings.HasPrefix(seq, termenv.CSI) && strings.HasSuffix(seq, "m") {
+				styled = seq != termenv.CSI+"m" && seq != termenv.CSI+termenv.ResetSeq+"m"
+			}
+			i = end
+			continue
+		}
+		cluster, cw := m.firstGrapheme(s[i:])
+		if w += cw; w > width {
+			cut := s[:i]
+			if styled {
+				cut += termenv.CSI + termenv.ResetSeq + "m"
+			}
+			if hyperlinkOpen(cut) {
+				cut += hyperlinkEnd
+			}
+			return cut
+		}
+		i += len(cluster)
+	}
+	return s
+}
+
+// hyperlinkOpen reports whether a line leaves a hyperlink open: whether the
+// last OSC 8 sequence in it starts a link rather than ending one.
+func hyperlinkOpen(s string) bool {
+	open := false
+	for {
+		i := strings.Index(s, "\x1b]8;")
+		if i < 0 {
+			return open
+		}
+		end, _ := sequenceEnd(s, i)
+		seq := strings.TrimSuffix(strings.TrimSuffix(s[i+4:end], "\a"), "\x1b\\")
+
+		// The parameters come before the URL, which is empty at the end of
+		// a link.
+		open = false
+		if j := strings.IndexByte(seq, ';'); j >= 0 {
+			open = seq[j+1:] != ""
+		}
+		s = s[end:]
+	}
+}
@@ -0,0 +1,89 @@
+package tea
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/muesli/termenv"
+)
+
+func TestHyperlink(t *testing.T) {
+	got := Hyperlink("https://example.com", "a\nb")
+	want := "\x1b]8;;https://example.com\x1b\\a\x1b]8;;\x1b\\\n\x1b]8;;https://example.com\x1b\\b\x1b]8;;\x1b\\"
+	if got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestTruncateLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		width    int
+		expected string
+	}{
+		{"fits", "abc", 3, "abc"},
+		{"plain", "abcdef", 3, "abc"},
+		{"styled", "\x1b[31mabcdef", 3, "\x1b[31mabc\x1b[0m"},
+		{"reset", "\x1b[31ma\x1b[0mbcdef", 3, "\x1b[31ma\x1b[0mbc"},
+		{"wide", "a日本", 2, "a"},
+		{"cluster", "ab👨\u200d👩\u200d👧c", 4, "ab👨\u200d👩\u200d👧"},
+		{
+			"hyperlink",
+			Hyperlink("https://example.com", "link") + " after",
+			2,
+			"\x1b]8;;https://example.com\x1b\\li" + hyperlinkEnd,
+		},
+		{
+			"ended hyperlink",
+			Hyperlink("https://example.com", "link") + " after",
+			6,
+			Hyperlink("https://example.com", "link") + " a",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := truncateLine(test.line, test.width); got != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestHyperlinkOpen(t *testing.T) {
+	tests := []struct {
+		line string
+		open bool
+	}{
+		{"plain", false},
+		{"\x1b]8;;https://example.com\x1b\\link", true},
+		{"\x1b]8;id=1;https://example.com\alink", true},
+		{Hyperlink("https://example.com", "link"), false},
+		{Hyperlink("https://example.com", "a") + "\x1b]8;;https://example.org\x1b\\b", true},
+	}
+	for _, test := range tests {
+		if got := hyperlinkOpen(test.line); got != test.open {
+			t.Errorf("expected hyperlinkOpen(%q) to be %v", test.line, test.open)
+		}
+	}
+}
+
+func TestStandardRendererHyperlinks(t *testing.T) {
+	var buf bytes.Buffer
+	r := newRenderer(termenv.NewOutput(&buf), false, 0).(*standardRenderer)
+	r.width = 8
+
+	link := "\x1b]8;;https://example.com\x1b\\"
+	r.write(link + "a link\nnext")
+	r.flush()
+	if r.lastLines[0] != link+"a link"+hyperlinkEnd {
+		t.Errorf("expected the link to be ended, got %q", r.lastLines[0])
+	}
+
+	// URLs don't count towards the width of lines.
+	r.write(Hyperlink("https://example.com", "12345678") + "9")
+	r.flush()
+	if want := Hyperlink("https://example.com", "12345678"); r.lastLines[0] != want {
+		t.Errorf("expected %q, got %q", want, r.lastLines[0])
+	}
+}
@@ -0,0 +1,460 @@
+package tea
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/png"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/muesli/termenv"
+)
+
+// ImageProtocol is a protocol terminals draw images with.
+type ImageProtocol int
+
+// Image protocols.
+const (
+	// NoImageProtocol is for terminals which can't draw images. Image
+	// commands do nothing with it.
+	NoImageProtocol ImageProtocol = iota
+
+	// KittyImageProtocol is the kitty terminal graphics protocol, supported
+	// by kitty, Ghostty and WezTerm. Images are transmitted once and placed
+	// as often as needed.
+	KittyImageProtocol
+
+	// ITerm2ImageProtocol is iTerm2's inline images protocol, supported by
+	// iTerm2, WezTerm and mintty. Images are transmitted each time they're
+	// drawn.
+	ITerm2ImageProtocol
+
+	// SixelImageProtocol is DEC's Sixel graphics, supported by foot, mlterm,
+	// xterm and others. Images are transmitted each time they're drawn, at
+	// their size in pixels.
+	SixelImageProtocol
+)
+
+// String returns the name of the protocol.
+func (p ImageProtocol) String() string {
+	switch p {
+	case KittyImageProtocol:
+		return "kitty"
+	case ITerm2ImageProtocol:
+		return "iTerm2"
+	case SixelImageProtocol:
+		return "Sixel"
+	default:
+		return "none"
+	}
+}
+
+// DetectImageProtocol reports which protocol the terminal the program is
+// running in draws images with, based on the environment. Terminals which
+// don't announce themselves in the environment are taken not to draw images;
+// use WithImageProtocol for those.
+func DetectImageProtocol() ImageProtocol {
+	return detectImageProtocol(os.Getenv)
+}
+
+func detectImageProtocol(getenv func(string) string) ImageProtocol {
+	term, program := getenv("TERM"), getenv("TERM_PROGRAM")
+	switch {
+	case getenv("KITTY_WINDOW_ID") != "", term == "xterm-kitty", term == "xterm-ghostty",
+		program == "ghostty", program == "WezTerm":
+		return KittyImageProtocol
+	case program == "iTerm.app", getenv("LC_TERMINAL") == "iTerm2", program == "mintty":
+		return ITerm2ImageProtocol
+	case strings.Contains(term, "sixel"), strings.HasPrefix(term, "foot"), term == "mlterm",
+		getenv("MLTERM") != "":
+		return SixelImageProtocol
+	}
+	return NoImageProtocol
+}
+
+// ImagePlacement is where an image is drawn in the view, in cells.
+type ImagePlacement struct {
+	// X and Y are the column and the line of the top-left corner of the
+	// image, relative to the top-left corner of the view.
+	X, Y int
+
+	// Width and Height are the number of cells the image covers. With the
+	// kitty and iTerm2 protocols, the image is scaled to fit them.
+	Width, Height int
+}
+
+type transmitImageMsg struct {
+	id  int
+	img image.Image
+	png string
+}
+
+type placeImageMsg struct {
+	id        int
+	placement ImagePlacement
+}
+
+type deleteImageMsg struct {
+	id int
+}
+
+// TransmitImage is a command which sends an image to the terminal under an
+// id, so that it can be drawn with PlaceImage. Transmitting another image
+// under the same id replaces it. Image commands do nothing unless the
+// terminal draws images; see DetectImageProtocol and WithImageProtocol.
+//
+//	func (m model) Init() tea.Cmd {
+//	    return tea.Sequence(
+//	        tea.TransmitImage(1, m.logo),
+//	        tea.PlaceImage(1, tea.ImagePlacement{X: 2, Y: 1, Width: 20, Height: 10}),
+//	    )
+//	}
+//
+// The view should leave the cells covered by the image blank: they're drawn
+// first, and the image over them.
+func TransmitImage(id int, img image.Image) Cmd {
+	return func() Msg {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return nil
+		}
+		return transmitImageMsg{
+			id:  id,
+			img: img,
+			png: base64.StdEncoding.EncodeToString(buf.Bytes()),
+		}
+	}
+}
+
+// PlaceImage is a command which draws an image transmitted with
+// TransmitImage in the view. An image is drawn at one place at a time:
+// placing it again moves it. The renderer draws the image again whenever the
+// lines it covers are repainted, so that it isn't painted over.
+//
+// Images are only drawn when they fit within the lines of the view. With
+// Sixel, they need a line of the view below them too, since terminals move
+// the cursor past the image, which would scroll the screen otherwise.
+func PlaceImage(id int, placement ImagePlacement) Cmd {
+	return func() Msg {
+		return placeImageMsg{id: id, placement: placement}
+	}
+}
+
+// DeleteImage is a command which removes an image from the view and frees it
+// in the terminal.
+func DeleteImage(id int) Cmd {
+	return func() Msg {
+		return deleteImageMsg{id: id}
+	}
+}
+
+// terminalImage is an image transmitted to the terminal, as the renderer
+// keeps track of it.
+type terminalImage struct {
+	// the image in the form the protocol draws it: base64-encoded PNG for
+	// iTerm2 and a Sixel string for Sixel; kitty keeps images itself
+	data string
+
+	placement ImagePlacement
+	placed    bool
+
+	// whether the image is on the screen, and whether it must be drawn with
+	// the next frame, having been placed since the last one
+	shown bool
+	dirty bool
+}
+
+// kittyChunkSize is the size of the chunks the kitty protocol transmits
+// images in.
+const kittyChunkSize = 4096
+
+// kittyTransmit returns the sequences transmitting a base64-encoded PNG
+// image with the kitty protocol. Responses from the terminal are suppressed,
+// so that they don't end up as input.
+func kittyTransmit(id int, data string) []string {
+	var seqs []string
+	for first := true; first || data != ""; first = false {
+		chunk := data
+		if len(chunk) > kittyChunkSize {
+			chunk = chunk[:kittyChunkSize]
+		}
+		data = data[len(chunk):]
+
+		more := 0
+		if data != "" {
+			more = 1
+		}
+		control := "m=" + strconv.Itoa(more)
+		if first {
+			control = fmt.Sprintf("a=t,f=100,i=%d,q=2,", id) + control
+		}
+		seqs = append(seqs, "\x1b_G"+control+";"+chunk+"\x1b\\")
+	}
+	return seqs
+}
+
+// kittyPlace returns the sequence drawing an image transmitted with the
+// kitty protocol at the cursor, leaving the cursor where it is.
+func kittyPlace(id int, p ImagePlacement) string {
+	return fmt.Sprintf("\x1b_Ga=p,i=%d,p=1,c=%d,r=%d,C=1,q=2\x1b\\", id, p.Width, p.Height)
+}
+
+// kittyDelete returns the sequence removing an image from the screen with the
+// kitty protocol, and freeing it too if free is set.
+func kittyDelete(id int, free bool) string {
+	d := "i"
+	if free {
+		d = "I"
+	}
+	return fmt.Sprintf("\x1b_Ga=d,d=%s,i=%d,q=2\x1b\\", d, id)
+}
+
+// iTerm2Image returns the sequence drawing a base64-encoded PNG image at the
+// cursor with iTerm2's protocol.
+func iTerm2Image(data string, p ImagePlacement) string {
+	return fmt.Sprintf("\x1b]1337;File=inline=1;width=%d;height=%d;preserveAspectRatio=0:%s\a",
+		p.Width, p.Height, data)
+}
+
+// sixelImage encodes an image with Sixel graphics. Colors are brought down to
+// a palette of 256 and transparent pixels are left out.
+func sixelImage(img image.Image) string {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	pal := image.NewPaletted(image.Rect(0, 0, width, height), palette.Plan9)
+	draw.FloydSteinberg.Draw(pal, pal.Bounds(), img, bounds.Min)
+
+	opaque := func(x, y int) bool {
+		_, _, _, a := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+		return a >= 0x8000
+	}
+
+	var b strings.Builder
+	// Pixels left out keep the background, and the image is drawn at one
+	// pixel per pixel.
+	fmt.Fprintf(&b, "\x1bP0;1;0q\"1;1;%d;%d", width, height)
+
+	used := make([]bool, len(palette.Plan9))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if opaque(x, y) {
+				used[pal.ColorIndexAt(x, y)] = true
+			}
+		}
+	}
+	for i, c := range palette.Plan9 {
+		if !used[i] {
+			continue
+		}
+		r, g, bl, _ := c.RGBA()
+		fmt.Fprintf(&b, "#%d;2;%d;%d;%d", i, r*100/0xffff, g*100/0xffff, bl*100/0xffff)
+	}
+
+	// Sixels are columns of six pixels: each band of six lines is drawn
+	// once per color in it.
+	sixels := make([]byte, width)
+	for top := 0; top < height; top += 6 {
+		var colors []int
+		seen := make(map[int]bool)
+		for y := top; y < top+6 && y < height; y++ {
+			for x := 0; x < width; x++ {
+				if i := int(pal.ColorIndexAt(x, y)); opaque(x, y) && !seen[i] {
+					seen[i] = true
+					colors = append(colors, i)
+				}
+			}
+		}
+		sort.Ints(colors)
+
+		for n, i := range colors {
+			if n > 0 {
+				// Back to the start of the band.
+				b.WriteByte('$')
+			}
+			for x := 0; x < width; x++ {
+				var bits byte
+				for k := 0; k < 6 && top+k < height; k++ {
+					if int(pal.ColorIndexAt(x, top+k)) == i && opaque(x, top+k) {
+						bits |= 1 << k
+					}
+				}
+				sixels[x] = '?' + bits
+			}
+			b.WriteString("#" + strconv.Itoa(i))
+			writeSixelRuns(&b, sixels)
+		}
+		// On to the next band.
+		b.WriteByte('-')
+	}
+	b.WriteString("\x1b\\")
+	return b.String()
+}
+
+// writeSixelRuns writes a row of sixels, with runs of the same sixel
+// compressed.
+func writeSixelRuns(b *strings.Builder, sixels []byte) {
+	for i := 0; i < len(sixels); {
+		j := i + 1
+		for j < len(sixels) && sixels[j] == sixels[i] {
+			j++
+		}
+		if n := j - i; n > 3 {
+			b.WriteString("!" + strconv.Itoa(n))
+			b.WriteByte(sixels[i])
+		} else {
+			b.WriteString(strings.Repeat(string(sixels[i]), n))
+		}
+		i = j
+	}
+}
+
+// handleImageMessage keeps track of the images transmitted to the terminal
+// and of where they're placed. It must be called with the mutex held.
+func (r *standardRenderer) handleImageMessage(msg Msg) {
+	if r.imageProtocol == NoImageProtocol {
+		return
+	}
+	if r.images == nil {
+		r.images = make(map[int]*terminalImage)
+	}
+
+	switch msg := msg.(type) {
+	case transmitImageMsg:
+		img := r.images[msg.id]
+		if img == nil {
+			img = &terminalImage{}
+			r.images[msg.id] = img
+		}
+		switch r.imageProtocol {
+		case KittyImageProtocol:
+			// Transmitting doesn't draw anything or move the cursor, so it
+			// can be done between frames.
+			for _, seq := range kittyTransmit(msg.id, msg.png) {
+				_, _ = r.out.WriteString(r.multiplexer.Passthrough(seq))
+			}
+		case ITerm2ImageProtocol:
+			img.data = msg.png
+		case SixelImageProtocol:
+			img.data = sixelImage(msg.img)
+		}
+		img.dirty = img.placed
+
+	case placeImageMsg:
+		img := r.images[msg.id]
+		if img == nil {
+			return
+		}
+		if img.placed && img.shown && img.placement != msg.placement && r.imageProtocol != KittyImageProtocol {
+			// There's no telling what's left of the image where it was
+			// drawn: paint it over.
+			r.repaint()
+		}
+		img.placement, img.placed, img.dirty = msg.placement, true, true
+
+	case deleteImageMsg:
+		img := r.images[msg.id]
+		if img == nil {
+			return
+		}
+		delete(r.images, msg.id)
+		if r.imageProtocol == KittyImageProtocol {
+			_, _ = r.out.WriteString(r.multiplexer.Passthrough(kittyDelete(msg.id, true)))
+		} else if img.shown {
+			r.repaint()
+		}
+	}
+}
+
+// setImageOffset sets the number of lines above the model's view, such as a
+// header's, which image placements are moved down by.
+func (r *standardRenderer) setImageOffset(lines int) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if lines == r.imageOffset {
+		return
+	}
+	r.imageOffset = lines
+	for _, img := range r.images {
+		img.dirty = img.placed
+	}
+}
+
+// drawImages draws the images which were placed since the last frame, or
+// whose lines were repainted, comparing the lines of the last frame with the
+// new ones, unless the frame was painted in full. The cursor must be at the
+// start of the last rendered line, and is left there.
+func (r *standardRenderer) drawImages(out *termenv.Output, lastLines, newLines []string, fullPaint bool) {
+	ids := make([]int, 0, len(r.images))
+	for id := range r.images {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	for _, id := range ids {
+		img := r.images[id]
+		if !img.placed {
+			continue
+		}
+		p := img.placement
+		y := p.Y + r.imageOffset - r.linesDropped
+		bottom := y + p.Height
+		if r.imageProtocol == SixelImageProtocol {
+			bottom++
+		}
+		if y < 0 || p.X < 0 || p.Width < 1 || p.Height < 1 || bottom > r.linesRendered ||
+			(r.width > 0 && p.X+p.Width > r.width) {
+			if img.shown && r.imageProtocol == KittyImageProtocol {
+				_, _ = out.WriteString(r.multiplexer.Passthrough(kittyDelete(id, false)))
+			}
+			img.shown, img.dirty = false, false
+			continue
+		}
+		if !fullPaint && !img.dirty && img.shown && len(lastLines) >= y+p.Height &&
+			equalLines(lastLines[y:y+p.Height], newLines[y:y+p.Height]) {
+			continue
+		}
+
+		var seq string
+		switch r.imageProtocol {
+		case KittyImageProtocol:
+			seq = kittyPlace(id, p)
+		case ITerm2ImageProtocol:
+			seq = iTerm2Image(img.data, p)
+		case SixelImageProtocol:
+			seq = img.data
+		}
+
+		// Save the cursor, move it to the top-left corner of the image and
+		// restore it once drawn, as drawing may move it.
+		_, _ = out.WriteString("\x1b7")
+		if r.altScreenActive {
+			_, _ = out.WriteString(r.caps.moveCursor(y+1, p.X+1))
+		} else {
+			if up := r.linesRendered - 1 - y; up > 0 {
+				_, _ = out.WriteString(r.caps.cursorUp(up))
+			}
+			if p.X > 0 {
+				_, _ = out.WriteString(r.caps.cursorForward(p.X))
+			}
+		}
+		_, _ = out.WriteString(r.multiplexer.Passthrough(seq))
+		_, _ = out.WriteString("\x1b8")
+		img.shown, img.dirty = true, false
+	}
+}
+
+// imagesDirty reports whether images were placed since the last frame.
+func (r *standardRenderer) imagesDirty() bool {
+	for _, img := range r.images {
+		if img.dirty {
+			return true
+		}
+	}
+	return false
+}
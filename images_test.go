@@ -0,0 +1,165 @@
+package tea
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/muesli/termenv"
+)
+
+func TestDetectImageProtocol(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expected ImageProtocol
+	}{
+		{"none", map[string]string{"TERM": "xterm-256color"}, NoImageProtocol},
+		{"kitty", map[string]string{"TERM": "xterm-kitty"}, KittyImageProtocol},
+		{"kitty window", map[string]string{"TERM": "screen", "KITTY_WINDOW_ID": "1"}, KittyImageProtocol},
+		{"wezterm", map[string]string{"TERM_PROGRAM": "WezTerm"}, KittyImageProtocol},
+		{"iterm2", map[string]string{"TERM_PROGRAM": "iTerm.app"}, ITerm2ImageProtocol},
+		{"iterm2 over ssh", map[string]string{"LC_TERMINAL": "iTerm2"}, ITerm2ImageProtocol},
+		{"foot", map[string]string{"TERM": "foot-extra"}, SixelImageProtocol},
+		{"sixel", map[string]string{"TERM": "xterm-sixel"}, SixelImageProtocol},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := detectImageProtocol(func(k string) string { return test.env[k] })
+			if got != test.expected {
+				t.Errorf("expected %v, got %v", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestKittyTransmit(t *testing.T) {
+	if got, want := kittyTransmit(3, "abc"), []string{"\x1b_Ga=t,f=100,i=3,q=2,m=0;abc\x1b\\"}; !equalLines(got, want) {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	data := strings.Repeat("a", kittyChunkSize) + "b"
+	seqs := kittyTransmit(3, data)
+	if len(seqs) != 2 {
+		t.Fatalf("expected 2 chunks, got %d", len(seqs))
+	}
+	if !strings.HasPrefix(seqs[0], "\x1b_Ga=t,f=100,i=3,q=2,m=1;aaa") {
+		t.Errorf("unexpected first chunk %q", seqs[0][:32])
+	}
+	if seqs[1] != "\x1b_Gm=0;b\x1b\\" {
+		t.Errorf("unexpected last chunk %q", seqs[1])
+	}
+}
+
+func TestSixelImage(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 5, 7))
+	for y := 0; y < 7; y++ {
+		for x := 0; x < 5; x++ {
+			img.Set(x, y, color.NRGBA{R: 0xff, A: 0xff})
+		}
+	}
+	// A transparent pixel is left out.
+	img.Set(0, 0, color.NRGBA{})
+
+	got := sixelImage(img)
+	if !strings.HasPrefix(got, "\x1bP0;1;0q\"1;1;5;7#") || !strings.HasSuffix(got, "\x1b\\") {
+		t.Fatalf("unexpected sixel image %q", got)
+	}
+	// Two bands: the first with the top-left pixel left out, then a run of
+	// four full columns, and the second with only its top line.
+	body := got[strings.Index(got, ";100;0;0")+len(";100;0;0") : len(got)-2]
+	bands := strings.Split(strings.TrimSuffix(body, "-"), "-")
+	if len(bands) != 2 {
+		t.Fatalf("expected 2 bands, got %q", body)
+	}
+	if !strings.HasSuffix(bands[0], "}!4~") {
+		t.Errorf("unexpected first band %q", bands[0])
+	}
+	if !strings.HasSuffix(bands[1], "!5@") {
+		t.Errorf("unexpected second band %q", bands[1])
+	}
+}
+
+func TestStandardRendererImages(t *testing.T) {
+	var buf bytes.Buffer
+	r := newRenderer(termenv.NewOutput(&buf), false, 0).(*standardRenderer)
+	r.imageProtocol = KittyImageProtocol
+	r.width, r.height = 10, 10
+
+	place := kittyPlace(1, ImagePlacement{X: 1, Y: 1, Width: 2, Height: 1})
+
+	// Images aren't drawn before they're transmitted.
+	r.handleMessages(placeImageMsg{id: 1, placement: ImagePlacement{X: 1, Y: 1, Width: 2, Height: 1}})
+	r.write("a\nb\nc")
+	r.flush()
+	if strings.Contains(buf.String(), "\x1b_G") {
+		t.Fatalf("expected no image, got %q", buf.String())
+	}
+
+	r.handleMessages(transmitImageMsg{id: 1, png: "abc"})
+	if !strings.Contains(buf.String(), "a=t,f=100,i=1") {
+		t.Errorf("expected the image to be transmitted, got %q", buf.String())
+	}
+
+	// Placing an image draws it, even without a new frame.
+	buf.Reset()
+	r.handleMessages(placeImageMsg{id: 1, placement: ImagePlacement{X: 1, Y: 1, Width: 2, Height: 1}})
+	r.flush()
+	if !strings.Contains(buf.String(), "\x1b7"+r.caps.cursorUp(1)+r.caps.cursorForward(1)+place+"\x1b8") {
+		t.Errorf("expected the image to be placed, got %q", buf.String())
+	}
+
+	// It's drawn again when its line is repainted only.
+	buf.Reset()
+	r.write("a\nb\nd")
+	r.flush()
+	if strings.Contains(buf.String(), place) {
+		t.Errorf("expected the image not to be placed again, got %q", buf.String())
+	}
+	buf.Reset()
+	r.write("a\nx\nd")
+	r.flush()
+	if !strings.Contains(buf.String(), place) {
+		t.Errorf("expected the image to be placed again, got %q", buf.String())
+	}
+
+	// Images which don't fit in the view are taken off the screen.
+	buf.Reset()
+	r.write("a")
+	r.flush()
+	if !strings.Contains(buf.String(), kittyDelete(1, false)) {
+		t.Errorf("expected the image to be taken off the screen, got %q", buf.String())
+	}
+
+	buf.Reset()
+	r.handleMessages(deleteImageMsg{id: 1})
+	if buf.String() != kittyDelete(1, true) || len(r.images) != 0 {
+		t.Errorf("expected the image to be deleted, got %q", buf.String())
+	}
+}
+
+func TestStandardRendererImagesOffset(t *testing.T) {
+	var buf bytes.Buffer
+	r := newRenderer(termenv.NewOutput(&buf), false, 0).(*standardRenderer)
+	r.imageProtocol = ITerm2ImageProtocol
+	r.altScreenActive = true
+
+	r.handleMessages(transmitImageMsg{id: 1, png: "abc"})
+	r.handleMessages(placeImageMsg{id: 1, placement: ImagePlacement{X: 0, Y: 0, Width: 1, Height: 1}})
+	r.setImageOffset(1)
+	r.write("header\nview")
+	r.flush()
+	want := r.caps.moveCursor(2, 1) + iTerm2Image("abc", ImagePlacement{Width: 1, Height: 1})
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("expected the image below the header, got %q", buf.String())
+	}
+
+	// Without an image protocol, image messages are ignored.
+	r.imageProtocol = NoImageProtocol
+	r.handleMessages(deleteImageMsg{id: 1})
+	if len(r.images) != 1 {
+		t.Errorf("expected the image to be kept")
+	}
+}
@@ -0,0 +1,57 @@
+package input
+
+// AltKeys is how keys typed after an escape character are reported, since
+// terminals report alt with an escape character in front of keys, which
+// can't be told apart from esc typed before them.
+type AltKeys int
+
+// Ways of reporting keys typed after an escape character.
+const (
+	// AltKeysPrefix reports keys typed after an escape character as the
+	// keys with alt, "\x1ba" being alt+a. Most terminals report alt this
+	// way.
+	AltKeysPrefix AltKeys = iota
+
+	// AltKeysSeparate reports escape characters and the keys following
+	// them as separate keys, "\x1ba" being esc followed by a. Keys are only
+	// reported with alt where the terminal says so, as with the kitty
+	// keyboard protocol, modifyOtherKeys and Windows console input.
+	AltKeysSeparate
+
+	// AltKeysAuto reports keys as AltKeysSeparate does where the terminal
+	// confirms having enabled the kitty keyboard protocol with
+	// KittyDisambiguateEscapeCodes, as it then reports alt, and esc, with
+	// escape sequences of their own, and as AltKeysPrefix does otherwise.
+	AltKeysAuto
+)
+
+// separate reports whether keys typed after an escape character are to be
+// reported on their own, given the kitty keyboard protocol flags the
+// terminal reported enabling.
+func (a AltKeys) separate(kittyFlags KittyKeyboardFlags) bool {
+	switch a {
+	case AltKeysSeparate:
+		return true
+	case AltKeysAuto:
+		return kittyFlags&KittyDisambiguateEscapeCodes != 0
+	default:
+		return false
+	}
+}
+
+// splitAlt splits a key detected at the start of the input with alt, from
+// an escape character in front of it, into esc and the key. It returns nil
+// if the key wasn't detected that way, such as alt keys reported with the
+// kitty keyboard protocol.
+func splitAlt(b []byte, w int, msg Event) []Event {
+	k, ok := msg.(KeyMsg)
+	if !ok || !k.Alt || w < 2 || b[0] != '\x1b' {
+		return nil
+	}
+	w2, msg2 := detectOneMsg(b[1:w], false)
+	k2, ok := msg2.(KeyMsg)
+	if !ok || w2 != w-1 || k2.Alt || k2.Type != k.Type || string(k2.Runes) != string(k.Runes) {
+		return nil
+	}
+	return []Event{KeyMsg{Type: KeyEscape}, k2}
+}
@@ -0,0 +1,51 @@
+package input
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAltKeys(t *testing.T) {
+	const in = "\x1ba\x1b\x1b[A\x1b[97;3u"
+	altA := KeyMsg{Type: KeyRunes, Runes: []rune("a"), Alt: true}
+	tests := []struct {
+		name string
+		in   string
+		mode AltKeys
+		want []Event
+	}{
+		{"prefix", in, AltKeysPrefix, []Event{altA, KeyMsg{Type: KeyUp, Alt: true}, altA}},
+		{"separate", in, AltKeysSeparate, []Event{
+			KeyMsg{Type: KeyEscape}, KeyMsg{Type: KeyRunes, Runes: []rune("a")},
+			KeyMsg{Type: KeyEscape}, KeyMsg{Type: KeyUp},
+			altA,
+		}},
+		{"auto without kitty", in, AltKeysAuto, []Event{altA, KeyMsg{Type: KeyUp, Alt: true}, altA}},
+		{"auto with kitty", "\x1b[?1u" + in, AltKeysAuto, []Event{
+			KeyMsg{Type: KeyEscape}, KeyMsg{Type: KeyRunes, Runes: []rune("a")},
+			KeyMsg{Type: KeyEscape}, KeyMsg{Type: KeyUp},
+			altA,
+		}},
+		{"auto with other kitty flags", "\x1b[?2u" + in, AltKeysAuto, []Event{altA, KeyMsg{Type: KeyUp, Alt: true}, altA}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			msgs := make(chan Event, 16)
+			err := Parser{AltKeys: test.mode}.Read(context.Background(), strings.NewReader(test.in), msgs)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			close(msgs)
+
+			var got []Event
+			for msg := range msgs {
+				got = append(got, msg)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("expected %v, got %v", test.want, got)
+			}
+		})
+	}
+}
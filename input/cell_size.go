@@ -0,0 +1,30 @@
+package input
+
+import (
+	"regexp"
+	"strconv"
+)
+
+// CellSizeMsg is reported when the terminal reports the size of its cells in
+// pixels, in answer to an XTWINOPS query. Bubble Tea programs query it
+// themselves to report mouse events in pixels.
+type CellSizeMsg struct {
+	Width, Height int
+}
+
+// cellSizeRe matches the report of the size of the cells of the terminal:
+//
+//	CSI 6 ; height ; width t
+var cellSizeRe = regexp.MustCompile(`^\x1b\[6;(\d+);(\d+)t`)
+
+// detectCellSizeReport detects the report of the size of the cells of the
+// terminal.
+func detectCellSizeReport(input []byte) (hasReport bool, width int, msg Event) {
+	m := cellSizeRe.FindSubmatch(input)
+	if m == nil {
+		return false, 0, nil
+	}
+	h, _ := strconv.Atoi(string(m[1]))
+	w, _ := strconv.Atoi(string(m[2]))
+	return true, len(m[0]), CellSizeMsg{Width: w, Height: h}
+}
@@ -0,0 +1,14 @@
+package input
+
+import "testing"
+
+func TestDetectCellSizeReport(t *testing.T) {
+	seq := []byte("\x1b[6;18;9t")
+	w, msg := detectOneMsg(seq, false)
+	if w != len(seq) {
+		t.Errorf("parser did not consume the entire input: got %d, expected %d", w, len(seq))
+	}
+	if msg != (CellSizeMsg{Width: 9, Height: 18}) {
+		t.Errorf("expected a cell size of 9x18, got %#v", msg)
+	}
+}
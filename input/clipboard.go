@@ -0,0 +1,63 @@
+package input
+
+import (
+	"bytes"
+	"encoding/base64"
+)
+
+// ClipboardMsg is reported when the terminal reports the contents of the
+// system clipboard, in response to an OSC 52 query, such as the one sent by
+// tea.ReadClipboard.
+type ClipboardMsg string
+
+// String returns the clipboard contents.
+func (c ClipboardMsg) String() string {
+	return string(c)
+}
+
+// detectOSC52 detects a clipboard report sent by the terminal in response to
+// an OSC 52 query. Reports look like:
+//
+//	OSC 52 ; Pc ; Pd ST
+//
+// where Pc is the clipboard selection, Pd is the base64-encoded clipboard
+// contents and ST is either BEL or ESC \.
+func detectOSC52(input []byte) (hasOSC bool, width int, msg Event) {
+	const oscStart = "\x1b]52;"
+	if !bytes.HasPrefix(input, []byte(oscStart)) {
+		return false, 0, nil
+	}
+
+	data := input[len(oscStart):]
+	end, termLen := -1, 0
+	for i, c := range data {
+		if c == '\a' {
+			end, termLen = i, 1
+			break
+		}
+		if c == '\x1b' && i+1 < len(data) && data[i+1] == '\\' {
+			end, termLen = i, 2 //nolint:gomnd
+			break
+		}
+	}
+	if end == -1 {
+		// We have encountered the end of the input buffer without seeing
+		// the string terminator. Tell the outer loop we want more.
+		return true, 0, nil
+	}
+	width = len(oscStart) + end + termLen
+
+	// Skip over the clipboard selection.
+	payload := data[:end]
+	if i := bytes.IndexByte(payload, ';'); i >= 0 {
+		payload = payload[i+1:]
+	}
+
+	b, err := base64.StdEncoding.DecodeString(string(payload))
+	if err != nil {
+		// Not a valid clipboard report; report the sequence as-is.
+		return true, width, unknownSequence(input[:width])
+	}
+
+	return true, width, ClipboardMsg(b)
+}
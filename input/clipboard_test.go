@@ -0,0 +1,40 @@
+package input
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestDetectOSC52(t *testing.T) {
+	tests := []struct {
+		in    string
+		width int
+		msg   Event
+	}{
+		{"\x1b]52;c;aGVsbG8=\a", 16, ClipboardMsg("hello")},
+		{"\x1b]52;c;aGVsbG8=\x1b\\", 17, ClipboardMsg("hello")},
+		{"\x1b]52;;aGVsbG8=\aabc", 15, ClipboardMsg("hello")},
+		{"\x1b]52;c;\a", 8, ClipboardMsg("")},
+		{"\x1b]52;c;aGVsbG8=", 0, nil},
+		{"\x1b]52;c;!!!\a", 11, UnknownSequenceMsg{[]byte("\x1b]52;c;!!!\a")}},
+	}
+	for _, tc := range tests {
+		t.Run(fmt.Sprintf("%q", tc.in), func(t *testing.T) {
+			found, width, msg := detectOSC52([]byte(tc.in))
+			if !found {
+				t.Fatalf("no clipboard report found")
+			}
+			if width != tc.width {
+				t.Errorf("expected width %d, got %d", tc.width, width)
+			}
+			if !reflect.DeepEqual(tc.msg, msg) {
+				t.Errorf("expected %#v (%T), got %#v (%T)", tc.msg, tc.msg, msg, msg)
+			}
+		})
+	}
+
+	if found, _, _ := detectOSC52([]byte("\x1b]0;title\a")); found {
+		t.Errorf("expected other OSC sequences to be ignored")
+	}
+}
@@ -0,0 +1,25 @@
+// Package input parses the input of terminals, such as keys, mouse events and
+// pasted text, into events, with the parser Bubble Tea programs read their
+// input with. It's meant for tools reading terminal input without running a
+// program:
+//
+//	for ev := range input.Parse(os.Stdin) {
+//	    switch ev := ev.(type) {
+//	    case input.KeyMsg:
+//	        fmt.Println("key", ev)
+//	    case input.MouseMsg:
+//	        fmt.Println("mouse", ev.X, ev.Y)
+//	    case input.ErrorEvent:
+//	        log.Fatal(ev.Err)
+//	    }
+//	}
+//
+// The terminal should be put in raw mode beforehand, and the modes reporting
+// mouse events or pasted text enabled, as programs do on startup.
+//
+// Events are the messages programs receive, tea.KeyMsg being input.KeyMsg and
+// so on, so they're handled the same way.
+// Sequences of their own, such as the ones terminals are configured to send
+// for keys they don't report otherwise, can be reported as events of their
+// own with Parser.Sequences.
+package input
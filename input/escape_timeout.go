@@ -0,0 +1,89 @@
+package input
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"time"
+)
+
+// chunkReader reads an input in a goroutine of its own, so that reads can
+// time out.
+type chunkReader struct {
+	chunks chan chunk
+}
+
+// chunk is the result of a read.
+type chunk struct {
+	b   []byte
+	err error
+}
+
+func newChunkReader(ctx context.Context, r io.Reader) *chunkReader {
+	c := &chunkReader{chunks: make(chan chunk)}
+	go func() {
+		for {
+			b := make([]byte, 256) //nolint:gomnd
+			n, err := r.Read(b)
+			select {
+			case c.chunks <- chunk{b[:n], err}:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return c
+}
+
+// read reads the next chunk of the input into buf, which must be at least
+// as large as the chunks read. With a timeout, it gives up after the timeout
+// and reports that it timed out.
+func (c *chunkReader) read(buf []byte, withTimeout bool, timeout time.Duration) (n int, timedOut bool, err error) {
+	var expired <-chan time.Time
+	if withTimeout {
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		expired = t.C
+	}
+
+	select {
+	case ch := <-c.chunks:
+		return copy(buf, ch.b), false, ch.err
+	case <-expired:
+		return 0, true, nil
+	}
+}
+
+// incompleteEscape reports whether the input is the start of an escape
+// sequence cut short, which is all there is of an ESC key press as well: a
+// lone ESC, the introducer of a CSI or SS3 sequence, a CSI sequence missing
+// its final byte, or a control string missing its terminator.
+func incompleteEscape(b []byte) bool {
+	if len(b) == 0 || b[0] != '\x1b' {
+		return false
+	}
+	if len(b) == 1 {
+		return true
+	}
+	switch b[1] {
+	case 'O':
+		return len(b) == 2
+	case '[':
+		for _, c := range b[2:] {
+			// Only parameter and intermediate bytes.
+			if c < 0x20 || c > 0x3f {
+				return false
+			}
+		}
+		return true
+	case 'P', ']', '_', '^', 'X':
+		// Strings are cut short before their terminator, or between the
+		// two bytes of ST.
+		esc := bytes.IndexByte(b[2:], '\x1b')
+		return bytes.IndexByte(b, '\a') < 0 && (esc < 0 || esc == len(b)-3)
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package input
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEscapeTimeout(t *testing.T) {
+	r, w := io.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	msgs := make(chan Event)
+	go func() {
+		_ = readAnsiInputs(ctx, msgs, r, Parser{EscapeTimeout: 200 * time.Millisecond})
+	}()
+	expect := func(want Event) {
+		t.Helper()
+		select {
+		case got := <-msgs:
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("expected %#v, got %#v", want, got)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("expected %#v, got nothing", want)
+		}
+	}
+
+	// A sequence cut short is put back together.
+	_, _ = w.Write([]byte("\x1b"))
+	time.Sleep(20 * time.Millisecond)
+	_, _ = w.Write([]byte("[A"))
+	expect(KeyMsg{Type: KeyUp})
+
+	_, _ = w.Write([]byte("a\x1b[1;"))
+	expect(KeyMsg{Type: KeyRunes, Runes: []rune("a")})
+	time.Sleep(20 * time.Millisecond)
+	_, _ = w.Write([]byte("5A"))
+	expect(KeyMsg{Type: KeyCtrlUp})
+
+	// A lone ESC is the esc key once the timeout passes.
+	start := time.Now()
+	_, _ = w.Write([]byte("\x1b"))
+	expect(KeyMsg{Type: KeyEscape})
+	if d := time.Since(start); d < 200*time.Millisecond {
+		t.Errorf("expected the esc key to be held back for the timeout, got it after %v", d)
+	}
+	_ = w.Close()
+}
+
+func TestIncompleteEscape(t *testing.T) {
+	tests := []struct {
+		input      string
+		incomplete bool
+	}{
+		{"\x1b", true},
+		{"\x1b[", true},
+		{"\x1bO", true},
+		{"\x1b[1;5", true},
+		{"\x1b[A", false},
+		{"\x1bOA", false},
+		{"\x1ba", false},
+		{"\x1bP>|xterm", true},
+		{"\x1bP>|xterm\x1b", true},
+		{"\x1bP>|xterm\x1b\\", false},
+		{"\x1b]11;rgb:0/0/0\a", false},
+		{"a", false},
+	}
+	for _, test := range tests {
+		if got := incompleteEscape([]byte(test.input)); got != test.incomplete {
+			t.Errorf("expected incompleteEscape(%q) to be %v", test.input, test.incomplete)
+		}
+	}
+}
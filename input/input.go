@@ -0,0 +1,117 @@
+package input
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"time"
+)
+
+// Event is an event parsed from the input, such as a KeyMsg, a MouseMsg or
+// one of Parser.Sequences. Events are the messages Bubble Tea programs
+// receive: tea.Msg is the same type.
+type Event = interface{}
+
+// ErrorEvent is the last event of a stream when reading the input failed.
+type ErrorEvent struct {
+	Err error
+}
+
+// Error implements error.
+func (e ErrorEvent) Error() string {
+	return e.Err.Error()
+}
+
+// Parser parses the input of terminals into events.
+type Parser struct {
+	// Sequences are escape sequences of their own to report as the given
+	// events, matched ahead of the sequences known to the parser, and so
+	// overriding them.
+	Sequences map[string]Event
+
+	// AltKeys is how keys typed after an escape character are reported: as
+	// the keys with alt by default.
+	AltKeys AltKeys
+
+	// EscapeTimeout is how long to wait for the rest of an escape sequence
+	// when the input ends in the middle of one, as it does when a sequence
+	// is split across reads. With none, what was read of the sequence is
+	// reported as it is.
+	EscapeTimeout time.Duration
+}
+
+// Read parses the input read from r into events, which it sends to events,
+// until r ends or fails or ctx is done. It returns nil once r ends.
+//
+// It's what Parse reads the input with, for callers sending the events to a
+// channel of their own, as Bubble Tea programs do.
+func (p Parser) Read(ctx context.Context, r io.Reader, events chan<- Event) error {
+	err := readAnsiInputs(ctx, events, r, p)
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	return err
+}
+
+// Parse parses the input read from r into events, which it sends on the
+// channel returned. The channel is closed once r ends or ctx is done, after
+// an ErrorEvent if reading r failed.
+//
+// Reads from r aren't interrupted when ctx is done, so the goroutine reading
+// it keeps running until the read returns.
+func (p Parser) Parse(ctx context.Context, r io.Reader) <-chan Event {
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		err := p.Read(ctx, r, events)
+		if err != nil && ctx.Err() == nil {
+			select {
+			case events <- ErrorEvent{err}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return events
+}
+
+// Parse parses the input read from r into events with the default parser,
+// until r ends. See Parser.Parse.
+func Parse(r io.Reader) <-chan Event {
+	return Parser{}.Parse(context.Background(), r)
+}
+
+// customSequences are the escape sequences of Parser.Sequences, the longest
+// first, so that sequences starting others don't hide them.
+type customSequences struct {
+	seqs   []string
+	events map[string]Event
+}
+
+func newCustomSequences(m map[string]Event) customSequences {
+	c := customSequences{events: m}
+	for seq := range m {
+		if seq != "" {
+			c.seqs = append(c.seqs, seq)
+		}
+	}
+	sort.Slice(c.seqs, func(i, j int) bool {
+		if len(c.seqs[i]) != len(c.seqs[j]) {
+			return len(c.seqs[i]) > len(c.seqs[j])
+		}
+		return c.seqs[i] < c.seqs[j]
+	})
+	return c
+}
+
+// detect detects a custom sequence at the start of the input, returning a
+// width of zero if there's none.
+func (c customSequences) detect(b []byte) (int, Event) {
+	for _, seq := range c.seqs {
+		if bytes.HasPrefix(b, []byte(seq)) {
+			return len(seq), c.events[seq]
+		}
+	}
+	return 0, nil
+}
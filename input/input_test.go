@@ -0,0 +1,174 @@
+package input
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type saveEvent struct{}
+
+func collect(events <-chan Event) []Event {
+	var got []Event
+	for ev := range events {
+		got = append(got, ev)
+	}
+	return got
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		in    string
+		want  []Event
+		parse func(io.Reader) <-chan Event
+	}{
+		{
+			"runes",
+			"ae\u0301",
+			[]Event{
+				KeyMsg{Type: KeyRunes, Runes: []rune("a")},
+				KeyMsg{Type: KeyRunes, Runes: []rune("e\u0301")},
+			},
+			nil,
+		},
+		{
+			"keys",
+			"\x1b[A\r\x1b",
+			[]Event{
+				KeyMsg{Type: KeyUp},
+				KeyMsg{Type: KeyEnter},
+				KeyMsg{Type: KeyEscape},
+			},
+			nil,
+		},
+		{
+			"mouse",
+			"\x1b[<0;3;4M",
+			[]Event{MouseMsg{
+				X: 2, Y: 3,
+				Type:   MouseLeft,
+				Action: MouseActionPress,
+				Button: MouseButtonLeft,
+			}},
+			nil,
+		},
+		{
+			"paste",
+			"\x1b[200~hi\x1b[201~",
+			[]Event{KeyMsg{Type: KeyRunes, Runes: []rune("hi"), Paste: true}},
+			nil,
+		},
+		{
+			"custom sequence",
+			"\x1b[27;5;13~a",
+			[]Event{saveEvent{}, KeyMsg{Type: KeyRunes, Runes: []rune("a")}},
+			func(r io.Reader) <-chan Event {
+				return Parser{Sequences: map[string]Event{"\x1b[27;5;13~": saveEvent{}}}.Parse(context.Background(), r)
+			},
+		},
+		{
+			"custom sequence overriding a known one",
+			"\x1b[A",
+			[]Event{saveEvent{}},
+			func(r io.Reader) <-chan Event {
+				return Parser{Sequences: map[string]Event{"\x1b[A": saveEvent{}}}.Parse(context.Background(), r)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			parse := test.parse
+			if parse == nil {
+				parse = Parse
+			}
+			got := collect(parse(strings.NewReader(test.in)))
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("expected %#v, got %#v", test.want, got)
+			}
+		})
+	}
+}
+
+type sequenceEvent string
+
+func TestReadSequences(t *testing.T) {
+	events := make(chan Event, 8)
+	p := Parser{Sequences: map[string]Event{
+		"\x1b[1":  sequenceEvent("short"),
+		"\x1b[12": sequenceEvent("long"),
+	}}
+	if err := p.Read(context.Background(), strings.NewReader("\x1b[12\x1b[1a"), events); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	close(events)
+
+	want := []Event{sequenceEvent("long"), sequenceEvent("short"), KeyMsg{Type: KeyRunes, Runes: []rune("a")}}
+	if got := collect(events); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %#v, got %#v", want, got)
+	}
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("broken")
+}
+
+func TestParseError(t *testing.T) {
+	got := collect(Parse(failingReader{}))
+	if len(got) != 1 {
+		t.Fatalf("expected a single event, got %#v", got)
+	}
+	ev, ok := got[0].(ErrorEvent)
+	if !ok || !strings.Contains(ev.Error(), "broken") {
+		t.Errorf("expected an error event, got %#v", got[0])
+	}
+}
+
+func TestParseCanceled(t *testing.T) {
+	r, w := io.Pipe()
+	defer w.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := Parser{}.Parse(ctx, r)
+	if _, err := w.Write([]byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	if ev := <-events; !reflect.DeepEqual(ev, KeyMsg{Type: KeyRunes, Runes: []rune("a")}) {
+		t.Errorf("unexpected event %#v", ev)
+	}
+
+	// Reading fails once canceled, which isn't reported.
+	cancel()
+	w.CloseWithError(errors.New("broken"))
+	for ev := range events {
+		if _, ok := ev.(ErrorEvent); ok {
+			t.Errorf("unexpected error event after canceling: %v", ev)
+		}
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	for _, seed := range []string{
+		"a", "\x1b", "\x1b[A", "\x1b[<0;3;4M", "\x1b[M !!", "\x1b[200~x\x1b[201~",
+		"\x1b[97;5u", "\x1b[27;5;13~", "\x1b]11;rgb:0000/0000/0000\x07", "\x1b[?1016;1$y",
+		"日本\x1b[1;5C", "\x1b\x1b[B",
+	} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, in []byte) {
+		for ev := range Parse(bytes.NewReader(in)) {
+			if ev == nil {
+				t.Fatalf("nil event parsing %q", in)
+			}
+			if _, ok := ev.(ErrorEvent); ok {
+				t.Fatalf("error parsing %q: %v", in, ev)
+			}
+		}
+	})
+}
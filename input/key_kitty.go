@@ -0,0 +1,327 @@
+package input
+
+import (
+	"regexp"
+	"strconv"
+	"unicode"
+)
+
+// KittyKeyboardFlags are the progressive enhancement flags of the kitty
+// keyboard protocol. They can be combined and passed to tea.WithKittyKeyboard.
+//
+// See: https://sw.kovidgoyal.net/kitty/keyboard-protocol/#progressive-enhancement
+type KittyKeyboardFlags int
+
+// Kitty keyboard protocol enhancement flags.
+const (
+	// KittyDisambiguateEscapeCodes makes the terminal report keys that would
+	// otherwise be ambiguous, such as esc, alt+key and ctrl+enter, as CSI u
+	// sequences.
+	KittyDisambiguateEscapeCodes KittyKeyboardFlags = 1 << iota
+
+	// KittyReportEventTypes makes the terminal report key repeat and key
+	// release events, as keys with IsRepeat and IsRelease set.
+	KittyReportEventTypes
+
+	// KittyReportAlternateKeys makes the terminal report the shifted and base
+	// layout keys alongside the key code.
+	KittyReportAlternateKeys
+
+	// KittyReportAllKeysAsEscapeCodes makes the terminal report every key,
+	// including plain text keys, as escape codes.
+	KittyReportAllKeysAsEscapeCodes
+
+	// KittyReportAssociatedText makes the terminal report the text generated
+	// by a key press. It only has an effect in combination with
+	// KittyReportAllKeysAsEscapeCodes.
+	KittyReportAssociatedText
+)
+
+// Kitty keyboard protocol modifier bits. The modifier parameter of a CSI u
+// sequence is these bits plus one.
+const (
+	kittyShift = 1 << iota
+	kittyAlt
+	kittyCtrl
+	kittySuper
+	kittyHyper
+	kittyMeta
+	kittyCapsLock
+	kittyNumLock
+)
+
+// kittyFunctionalKeys maps the key codes the kitty keyboard protocol reports
+// keys without a character of their own with, from its private use area, to
+// key types.
+var kittyFunctionalKeys = map[rune]KeyType{
+	57361: KeyPrintScreen,
+	57362: KeyPause,
+	57363: KeyMenu,
+	57376: KeyF13,
+	57377: KeyF14,
+	57378: KeyF15,
+	57379: KeyF16,
+	57380: KeyF17,
+	57381: KeyF18,
+	57382: KeyF19,
+	57383: KeyF20,
+	57384: KeyF21,
+	57385: KeyF22,
+	57386: KeyF23,
+	57387: KeyF24,
+	57399: KeyKp0,
+	57400: KeyKp1,
+	57401: KeyKp2,
+	57402: KeyKp3,
+	57403: KeyKp4,
+	57404: KeyKp5,
+	57405: KeyKp6,
+	57406: KeyKp7,
+	57407: KeyKp8,
+	57408: KeyKp9,
+	57409: KeyKpDecimal,
+	57410: KeyKpDivide,
+	57411: KeyKpMultiply,
+	57412: KeyKpMinus,
+	57413: KeyKpPlus,
+	57414: KeyKpEnter,
+	57415: KeyKpEqual,
+	57416: KeyKpComma,
+}
+
+// kittyKeyRe matches a CSI u key event as sent by terminals implementing the
+// kitty keyboard protocol:
+//
+//	CSI unicode-key-code:shifted-key:base-layout-key ; modifiers:event-type ; text u
+var kittyKeyRe = regexp.MustCompile(`^\x1b\[(\d+)(?::(\d*))?(?::\d*)?(?:;(\d*)(?::(\d+))?)?(?:;[\d:]*)?u`)
+
+// detectKittyKey detects a key event encoded with the kitty keyboard
+// protocol (CSI u).
+func detectKittyKey(input []byte) (hasKey bool, width int, msg Event) {
+	m := kittyKeyRe.FindSubmatch(input)
+	if m == nil {
+		return false, 0, nil
+	}
+
+	code, err := strconv.Atoi(string(m[1]))
+	if err != nil {
+		return false, 0, nil
+	}
+
+	// The shifted key is only reported with KittyReportAlternateKeys.
+	var shifted int
+	if len(m[2]) > 0 {
+		shifted, _ = strconv.Atoi(string(m[2]))
+	}
+
+	mods := 0
+	if len(m[3]) > 0 {
+		if mod, err := strconv.Atoi(string(m[3])); err == nil && mod > 0 {
+			mods = mod - 1
+		}
+	}
+
+	k := kittyKey(rune(code), rune(shifted), mods)
+	kittyEventType(&k, m[4])
+	return true, len(m[0]), KeyMsg(k)
+}
+
+// kittyFlagsMsg reports the kitty keyboard protocol flags the terminal has
+// enabled, in reply to the query sent along with the flags requested.
+type kittyFlagsMsg KittyKeyboardFlags
+
+var kittyFlagsRe = regexp.MustCompile(`^\x1b\[\?(\d+)u`)
+
+// detectKittyFlags detects a report of the kitty keyboard protocol flags
+// enabled.
+func detectKittyFlags(input []byte) (hasReport bool, width int, msg Event) {
+	m := kittyFlagsRe.FindSubmatch(input)
+	if m == nil {
+		return false, 0, nil
+	}
+	flags, err := strconv.Atoi(string(m[1]))
+	if err != nil {
+		return false, 0, nil
+	}
+	return true, len(m[0]), kittyFlagsMsg(flags)
+}
+
+// Kitty keyboard protocol event types, reported after the modifiers with
+// KittyReportEventTypes.
+const (
+	kittyPress   = 1
+	kittyRepeat  = 2
+	kittyRelease = 3
+)
+
+// kittyEventType sets whether a key repeats or was released from the event
+// type of a key event, which is left out for presses.
+func kittyEventType(k *Key, event []byte) {
+	switch n, _ := strconv.Atoi(string(event)); n {
+	case kittyRepeat:
+		k.IsRepeat = true
+	case kittyRelease:
+		k.IsRelease = true
+	}
+}
+
+// kittyLegacyKeyRe matches a key event for a key the kitty keyboard protocol
+// keeps the legacy encoding of, like the arrow and function keys, along with
+// an event type:
+//
+//	CSI number ; modifiers : event-type final
+var kittyLegacyKeyRe = regexp.MustCompile(`^\x1b\[(\d+);(\d+):(\d+)([~A-DFHPQRS])`)
+
+// detectKittyLegacyKey detects a key event in the legacy encoding with an
+// event type, by looking up the sequence the key is encoded with otherwise.
+func detectKittyLegacyKey(input []byte) (hasKey bool, width int, msg Event) {
+	m := kittyLegacyKeyRe.FindSubmatch(input)
+	if m == nil {
+		return false, 0, nil
+	}
+
+	// Keys without modifiers are encoded without them.
+	num, mods, final := string(m[1]), string(m[2]), string(m[4])
+	seq := "\x1b[" + num + ";" + mods + final
+	if mods == "1" {
+		seq = "\x1b[" + num + final
+		if final != "~" {
+			seq = "\x1b[" + final
+			if final >= "P" {
+				seq = "\x1bO" + final
+			}
+		}
+	}
+	k, ok := sequences[seq]
+	if !ok {
+		return true, len(m[0]), unknownSequence(input[:len(m[0])])
+	}
+	kittyEventType(&k, m[3])
+	return true, len(m[0]), KeyMsg(k)
+}
+
+// modifyOtherKeysRe matches a key event as sent by terminals with xterm's
+// modifyOtherKeys encoding enabled:
+//
+//	CSI 27 ; modifiers ; key-code ~
+var modifyOtherKeysRe = regexp.MustCompile(`^\x1b\[27;(\d+);(\d+)~`)
+
+// detectModifyOtherKeys detects a key event encoded with xterm's
+// modifyOtherKeys encoding. The modifiers are encoded the same way as with
+// the kitty keyboard protocol, but the key code is that of the character the
+// key produces, shifted or not.
+func detectModifyOtherKeys(input []byte) (hasKey bool, width int, msg Event) {
+	m := modifyOtherKeysRe.FindSubmatch(input)
+	if m == nil {
+		return false, 0, nil
+	}
+
+	mod, err := strconv.Atoi(string(m[1]))
+	if err != nil || mod < 1 {
+		return false, 0, nil
+	}
+	code, err := strconv.Atoi(string(m[2]))
+	if err != nil {
+		return false, 0, nil
+	}
+	mods, r := mod-1, rune(code)
+
+	// Shifted letters are reported as the letter with shift, like ctrl+shift+p,
+	// and other shifted characters as the character alone, as the layout
+	// decides which they're shifted from.
+	var shifted rune
+	if mods&kittyShift != 0 {
+		switch {
+		case unicode.IsUpper(r):
+			shifted, r = r, unicode.ToLower(r)
+		case r > ' ' && r != rune(keyDEL):
+			mods &^= kittyShift
+		}
+	}
+
+	return true, len(m[0]), KeyMsg(kittyKey(r, shifted, mods))
+}
+
+// kittyKey translates a kitty key code and modifiers into a Key. Where
+// possible, the key is reported the same way the legacy encoding would report
+// it, so that programs matching on "ctrl+c" or "alt+a" keep working when the
+// protocol is enabled.
+func kittyKey(code, shifted rune, mods int) Key {
+	k := Key{
+		Alt:   mods&kittyAlt != 0,
+		Ctrl:  mods&kittyCtrl != 0,
+		Shift: mods&kittyShift != 0,
+	}
+
+	switch code {
+	case rune(keyCR):
+		k.Type = KeyEnter
+	case rune(keyHT):
+		k.Type = KeyTab
+		if k.Shift {
+			k.Type = KeyShiftTab
+			k.Shift = false
+		}
+	case rune(keyESC):
+		k.Type = KeyEscape
+	case rune(keyDEL), rune(keyBS):
+		k.Type = KeyBackspace
+	case ' ':
+		k.Type = KeySpace
+		k.Runes = spaceRunes
+	default:
+		if t, ok := kittyFunctionalKeys[code]; ok {
+			k.Type = t
+			return k
+		}
+		k.Type = KeyRunes
+		k.Runes = []rune{code}
+	}
+
+	// Control combinations that have a legacy encoding are reported as the
+	// corresponding control key. Combinations including shift can't be
+	// expressed by the legacy encoding and are reported with modifiers.
+	if k.Ctrl && !k.Shift && (k.Type == KeyRunes || k.Type == KeySpace) {
+		if t, ok := ctrlKeyType(code); ok {
+			return Key{Type: t, Alt: k.Alt}
+		}
+	}
+
+	// Shifted text is reported as the text it produces.
+	if k.Type == KeyRunes && k.Shift && !k.Ctrl {
+		r := shifted
+		if r == 0 {
+			r = unicode.ToUpper(code)
+		}
+		if r != code {
+			k.Runes = []rune{r}
+			k.Shift = false
+		}
+	}
+
+	return k
+}
+
+// ctrlKeyType returns the control key type produced by pressing ctrl together
+// with the given key in the legacy encoding.
+func ctrlKeyType(r rune) (KeyType, bool) {
+	switch {
+	case r >= 'a' && r <= 'z':
+		return keySOH + KeyType(r-'a'), true
+	case r == ' ' || r == '@' || r == '`':
+		return keyNUL, true
+	case r == '[':
+		return keyESC, true
+	case r == '\\':
+		return keyFS, true
+	case r == ']':
+		return keyGS, true
+	case r == '^':
+		return keyRS, true
+	case r == '_':
+		return keyUS, true
+	case r == '?':
+		return keyDEL, true
+	}
+	return 0, false
+}
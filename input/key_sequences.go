@@ -1,4 +1,4 @@
-package tea
+package input
 
 import (
 	"bytes"
@@ -54,7 +54,7 @@ var seqLengths = func() []int {
 
 // detectSequence uses a longest prefix match over the input
 // sequence and a hash map.
-func detectSequence(input []byte) (hasSeq bool, width int, msg Msg) {
+func detectSequence(input []byte) (hasSeq bool, width int, msg Event) {
 	seqs := extSequences
 	for _, sz := range seqLengths {
 		if sz > len(input) {
@@ -68,19 +68,54 @@ func detectSequence(input []byte) (hasSeq bool, width int, msg Msg) {
 	}
 	// Is this an unknown CSI sequence?
 	if loc := unknownCSIRe.FindIndex(input); loc != nil {
-		return true, loc[1], unknownCSISequenceMsg(input[:loc[1]])
+		return true, loc[1], unknownSequence(input[:loc[1]])
 	}
 
 	return false, 0, nil
 }
 
+// detectControlString detects a DCS, OSC, APC, PM or SOS string, ended with
+// ST or BEL, which is reported as is. Without its terminator, it's not taken
+// for a string unless more data may follow, so that keys typed with alt, such
+// as alt+P, aren't held back.
+func detectControlString(input []byte, canHaveMoreData bool) (hasString bool, width int, msg Event) {
+	if len(input) < 2 || input[0] != '\x1b' {
+		return false, 0, nil
+	}
+	switch input[1] {
+	case 'P', ']', '_', '^', 'X':
+	default:
+		return false, 0, nil
+	}
+
+	for i := 2; i < len(input); i++ {
+		switch input[i] {
+		case '\a':
+			return true, i + 1, unknownSequence(input[:i+1])
+		case '\x1b':
+			if i+1 < len(input) && input[i+1] == '\\' {
+				return true, i + 2, unknownSequence(input[:i+2])
+			}
+			if i+1 < len(input) {
+				// Not a string, as strings don't contain escape
+				// characters other than their terminator.
+				return false, 0, nil
+			}
+		}
+	}
+	if canHaveMoreData {
+		return true, 0, nil
+	}
+	return false, 0, nil
+}
+
 // detectBracketedPaste detects an input pasted while bracketed
 // paste mode was enabled.
 //
 // Note: this function is a no-op if bracketed paste was not enabled
 // on the terminal, since in that case we'd never see this
 // particular escape sequence.
-func detectBracketedPaste(input []byte) (hasBp bool, width int, msg Msg) {
+func detectBracketedPaste(input []byte) (hasBp bool, width int, msg Event) {
 	// Detect the start sequence.
 	const bpStart = "\x1b[200~"
 	if len(input) < len(bpStart) || string(input[:len(bpStart)]) != bpStart {
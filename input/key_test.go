@@ -1,4 +1,4 @@
-package tea
+package input
 
 import (
 	"bytes"
@@ -36,6 +36,16 @@ func TestKeyString(t *testing.T) {
 		}
 	})
 
+	t.Run("ctrl+shift+enter", func(t *testing.T) {
+		if got := KeyMsg(Key{
+			Type:  KeyEnter,
+			Ctrl:  true,
+			Shift: true,
+		}).String(); got != "ctrl+shift+enter" {
+			t.Fatalf(`expected a "ctrl+shift+enter", got %q`, got)
+		}
+	})
+
 	t.Run("invalid", func(t *testing.T) {
 		if got := KeyMsg(Key{
 			Type: KeyType(99999),
@@ -61,7 +71,7 @@ func TestKeyTypeString(t *testing.T) {
 
 type seqTest struct {
 	seq []byte
-	msg Msg
+	msg Event
 }
 
 // buildBaseSeqTests returns sequence tests that are valid for the
@@ -95,7 +105,7 @@ func buildBaseSeqTests() []seqTest {
 		// Unrecognized CSI sequence.
 		seqTest{
 			[]byte{'\x1b', '[', '-', '-', '-', '-', 'X'},
-			unknownCSISequenceMsg([]byte{'\x1b', '[', '-', '-', '-', '-', 'X'}),
+			UnknownSequenceMsg{[]byte{'\x1b', '[', '-', '-', '-', '-', 'X'}},
 		},
 		// A lone space character.
 		seqTest{
@@ -153,9 +163,22 @@ func TestDetectOneMsg(t *testing.T) {
 			[]byte{'\x1b', 'a'},
 			KeyMsg{Type: KeyRunes, Runes: []rune("a"), Alt: true},
 		},
+		// Grapheme clusters.
+		seqTest{
+			[]byte("e\u0301"),
+			KeyMsg{Type: KeyRunes, Runes: []rune("e\u0301")},
+		},
 		seqTest{
-			[]byte{'a', 'a', 'a'},
-			KeyMsg{Type: KeyRunes, Runes: []rune("aaa")},
+			[]byte("👨\u200d👩\u200d👧"),
+			KeyMsg{Type: KeyRunes, Runes: []rune("👨\u200d👩\u200d👧")},
+		},
+		seqTest{
+			[]byte("🇫🇷"),
+			KeyMsg{Type: KeyRunes, Runes: []rune("🇫🇷")},
+		},
+		seqTest{
+			[]byte("\x1be\u0301"),
+			KeyMsg{Type: KeyRunes, Runes: []rune("e\u0301"), Alt: true},
 		},
 		// Multi-byte rune.
 		seqTest{
@@ -216,22 +239,140 @@ func TestDetectOneMsg(t *testing.T) {
 	}
 }
 
-func TestReadLongInput(t *testing.T) {
-	input := strings.Repeat("a", 1000)
-	msgs := testReadInputs(t, bytes.NewReader([]byte(input)))
-	if len(msgs) != 1 {
-		t.Errorf("expected 1 messages, got %d", len(msgs))
+func TestDetectKittyKey(t *testing.T) {
+	td := []seqTest{
+		{[]byte("\x1b[27u"), KeyMsg{Type: KeyEscape}},
+		{[]byte("\x1b[13u"), KeyMsg{Type: KeyEnter}},
+		{[]byte("\x1b[13;5u"), KeyMsg{Type: KeyEnter, Ctrl: true}},
+		{[]byte("\x1b[13;6u"), KeyMsg{Type: KeyEnter, Ctrl: true, Shift: true}},
+		{[]byte("\x1b[13;3u"), KeyMsg{Type: KeyEnter, Alt: true}},
+		{[]byte("\x1b[9;2u"), KeyMsg{Type: KeyShiftTab}},
+		{[]byte("\x1b[127;5u"), KeyMsg{Type: KeyBackspace, Ctrl: true}},
+		{[]byte("\x1b[97;5u"), KeyMsg{Type: KeyCtrlA}},
+		{[]byte("\x1b[99;7u"), KeyMsg{Type: KeyCtrlC, Alt: true}},
+		{[]byte("\x1b[32;5u"), KeyMsg{Type: KeyCtrlAt}},
+		{[]byte("\x1b[97;6u"), KeyMsg{Type: KeyRunes, Runes: []rune("a"), Ctrl: true, Shift: true}},
+		{[]byte("\x1b[49;5u"), KeyMsg{Type: KeyRunes, Runes: []rune("1"), Ctrl: true}},
+		{[]byte("\x1b[97;3u"), KeyMsg{Type: KeyRunes, Runes: []rune("a"), Alt: true}},
+		{[]byte("\x1b[97;2u"), KeyMsg{Type: KeyRunes, Runes: []rune("A")}},
+		{[]byte("\x1b[49:33;2u"), KeyMsg{Type: KeyRunes, Runes: []rune("!")}},
+		{[]byte("\x1b[97;65u"), KeyMsg{Type: KeyRunes, Runes: []rune("a")}}, // caps lock
+		{[]byte("\x1b[32u"), KeyMsg{Type: KeySpace, Runes: []rune(" ")}},
+	}
+	for _, tc := range td {
+		t.Run(fmt.Sprintf("%q", string(tc.seq)), func(t *testing.T) {
+			width, msg := detectOneMsg(tc.seq, false /* canHaveMoreData */)
+			if width != len(tc.seq) {
+				t.Errorf("parser did not consume the entire input: got %d, expected %d", width, len(tc.seq))
+			}
+			if !reflect.DeepEqual(tc.msg, msg) {
+				t.Errorf("expected event %#v (%T), got %#v (%T)", tc.msg, tc.msg, msg, msg)
+			}
+		})
+	}
+}
+
+func TestDetectExtendedKeys(t *testing.T) {
+	td := []seqTest{
+		{[]byte("\x1b[24;2~"), KeyMsg{Type: KeyF24}},
+		{[]byte("\x1b[12^"), KeyMsg{Type: KeyF24}},
+		{[]byte("\x1bOM"), KeyMsg{Type: KeyKpEnter}},
+		{[]byte("\x1bOp"), KeyMsg{Type: KeyKp0}},
+		{[]byte("\x1bOy"), KeyMsg{Type: KeyKp9}},
+		{[]byte("\x1bOk"), KeyMsg{Type: KeyKpPlus}},
+		{[]byte("\x1b\x1bOj"), KeyMsg{Type: KeyKpMultiply, Alt: true}},
+		{[]byte("\x1b[57376u"), KeyMsg{Type: KeyF13}},
+		{[]byte("\x1b[57387;5u"), KeyMsg{Type: KeyF24, Ctrl: true}},
+		{[]byte("\x1b[57399u"), KeyMsg{Type: KeyKp0}},
+		{[]byte("\x1b[57414;2u"), KeyMsg{Type: KeyKpEnter, Shift: true}},
+		{[]byte("\x1b[57361u"), KeyMsg{Type: KeyPrintScreen}},
+		{[]byte("\x1b[57362u"), KeyMsg{Type: KeyPause}},
+		{[]byte("\x1b[57363u"), KeyMsg{Type: KeyMenu}},
+	}
+	for _, tc := range td {
+		t.Run(fmt.Sprintf("%q", string(tc.seq)), func(t *testing.T) {
+			width, msg := detectOneMsg(tc.seq, false /* canHaveMoreData */)
+			if width != len(tc.seq) {
+				t.Errorf("parser did not consume the entire input: got %d, expected %d", width, len(tc.seq))
+			}
+			if !reflect.DeepEqual(tc.msg, msg) {
+				t.Errorf("expected event %#v (%T), got %#v (%T)", tc.msg, tc.msg, msg, msg)
+			}
+		})
+	}
+
+	if s := (Key{Type: KeyKpEnter, Ctrl: true}).String(); s != "ctrl+kpenter" {
+		t.Errorf("expected ctrl+kpenter, got %q", s)
+	}
+}
+
+func TestDetectKittyEventTypes(t *testing.T) {
+	td := []seqTest{
+		{[]byte("\x1b[97;1:1u"), KeyMsg{Type: KeyRunes, Runes: []rune("a")}},
+		{[]byte("\x1b[97;1:2u"), KeyMsg{Type: KeyRunes, Runes: []rune("a"), IsRepeat: true}},
+		{[]byte("\x1b[97;5:3u"), KeyMsg{Type: KeyCtrlA, IsRelease: true}},
+		{[]byte("\x1b[13;1:3u"), KeyMsg{Type: KeyEnter, IsRelease: true}},
+		{[]byte("\x1b[1;1:2A"), KeyMsg{Type: KeyUp, IsRepeat: true}},
+		{[]byte("\x1b[1;5:3B"), KeyMsg{Type: KeyCtrlDown, IsRelease: true}},
+		{[]byte("\x1b[15;1:3~"), KeyMsg{Type: KeyF5, IsRelease: true}},
+		{[]byte("\x1b[1;1:3P"), KeyMsg{Type: KeyF1, IsRelease: true}},
 	}
-	km := msgs[0]
-	k := Key(km.(KeyMsg))
-	if k.Type != KeyRunes {
-		t.Errorf("expected key runes, got %d", k.Type)
+	for _, tc := range td {
+		t.Run(fmt.Sprintf("%q", string(tc.seq)), func(t *testing.T) {
+			width, msg := detectOneMsg(tc.seq, false /* canHaveMoreData */)
+			if width != len(tc.seq) {
+				t.Errorf("parser did not consume the entire input: got %d, expected %d", width, len(tc.seq))
+			}
+			if !reflect.DeepEqual(tc.msg, msg) {
+				t.Errorf("expected event %#v (%T), got %#v (%T)", tc.msg, tc.msg, msg, msg)
+			}
+		})
 	}
-	if len(k.Runes) != 1000 || !reflect.DeepEqual(k.Runes, []rune(input)) {
-		t.Errorf("unexpected runes: %+v", k)
+}
+
+func TestDetectModifyOtherKeys(t *testing.T) {
+	td := []seqTest{
+		{[]byte("\x1b[27;6;80~"), KeyMsg{Type: KeyRunes, Runes: []rune("p"), Ctrl: true, Shift: true}},
+		{[]byte("\x1b[27;5;13~"), KeyMsg{Type: KeyEnter, Ctrl: true}},
+		{[]byte("\x1b[27;2;13~"), KeyMsg{Type: KeyEnter, Shift: true}},
+		{[]byte("\x1b[27;5;49~"), KeyMsg{Type: KeyRunes, Runes: []rune("1"), Ctrl: true}},
+		{[]byte("\x1b[27;6;33~"), KeyMsg{Type: KeyRunes, Runes: []rune("!"), Ctrl: true}},
+		{[]byte("\x1b[27;2;65~"), KeyMsg{Type: KeyRunes, Runes: []rune("A")}},
+		{[]byte("\x1b[27;5;97~"), KeyMsg{Type: KeyCtrlA}},
+		{[]byte("\x1b[27;3;97~"), KeyMsg{Type: KeyRunes, Runes: []rune("a"), Alt: true}},
+		{[]byte("\x1b[27;2;9~"), KeyMsg{Type: KeyShiftTab}},
 	}
-	if k.Alt {
-		t.Errorf("unexpected alt")
+	for _, tc := range td {
+		t.Run(fmt.Sprintf("%q", string(tc.seq)), func(t *testing.T) {
+			width, msg := detectOneMsg(tc.seq, false /* canHaveMoreData */)
+			if width != len(tc.seq) {
+				t.Errorf("parser did not consume the entire input: got %d, expected %d", width, len(tc.seq))
+			}
+			if !reflect.DeepEqual(tc.msg, msg) {
+				t.Errorf("expected event %#v (%T), got %#v (%T)", tc.msg, tc.msg, msg, msg)
+			}
+		})
+	}
+}
+
+func TestReadLongInput(t *testing.T) {
+	// Clusters of three bytes, some of which are split across reads.
+	input := strings.Repeat("e\u0301", 500)
+	msgs := testReadInputs(t, bytes.NewReader([]byte(input)))
+	if len(msgs) != 500 {
+		t.Fatalf("expected 500 messages, got %d", len(msgs))
+	}
+	for _, km := range msgs {
+		k := Key(km.(KeyMsg))
+		if k.Type != KeyRunes {
+			t.Fatalf("expected key runes, got %d", k.Type)
+		}
+		if !reflect.DeepEqual(k.Runes, []rune("e\u0301")) {
+			t.Fatalf("unexpected runes: %+v", k)
+		}
+		if k.Alt {
+			t.Fatalf("unexpected alt")
+		}
 	}
 }
 
@@ -239,12 +380,12 @@ func TestReadInput(t *testing.T) {
 	type test struct {
 		keyname string
 		in      []byte
-		out     []Msg
+		out     []Event
 	}
 	testData := []test{
 		{"a",
 			[]byte{'a'},
-			[]Msg{
+			[]Event{
 				KeyMsg{
 					Type:  KeyRunes,
 					Runes: []rune{'a'},
@@ -253,7 +394,7 @@ func TestReadInput(t *testing.T) {
 		},
 		{" ",
 			[]byte{' '},
-			[]Msg{
+			[]Event{
 				KeyMsg{
 					Type:  KeySpace,
 					Runes: []rune{' '},
@@ -262,14 +403,14 @@ func TestReadInput(t *testing.T) {
 		},
 		{"a alt+a",
 			[]byte{'a', '\x1b', 'a'},
-			[]Msg{
+			[]Event{
 				KeyMsg{Type: KeyRunes, Runes: []rune{'a'}},
 				KeyMsg{Type: KeyRunes, Runes: []rune{'a'}, Alt: true},
 			},
 		},
 		{"a alt+a a",
 			[]byte{'a', '\x1b', 'a', 'a'},
-			[]Msg{
+			[]Event{
 				KeyMsg{Type: KeyRunes, Runes: []rune{'a'}},
 				KeyMsg{Type: KeyRunes, Runes: []rune{'a'}, Alt: true},
 				KeyMsg{Type: KeyRunes, Runes: []rune{'a'}},
@@ -277,7 +418,7 @@ func TestReadInput(t *testing.T) {
 		},
 		{"ctrl+a",
 			[]byte{byte(keySOH)},
-			[]Msg{
+			[]Event{
 				KeyMsg{
 					Type: KeyCtrlA,
 				},
@@ -285,14 +426,14 @@ func TestReadInput(t *testing.T) {
 		},
 		{"ctrl+a ctrl+b",
 			[]byte{byte(keySOH), byte(keySTX)},
-			[]Msg{
+			[]Event{
 				KeyMsg{Type: KeyCtrlA},
 				KeyMsg{Type: KeyCtrlB},
 			},
 		},
 		{"alt+a",
 			[]byte{byte(0x1b), 'a'},
-			[]Msg{
+			[]Event{
 				KeyMsg{
 					Type:  KeyRunes,
 					Alt:   true,
@@ -300,18 +441,25 @@ func TestReadInput(t *testing.T) {
 				},
 			},
 		},
-		{"abcd",
+		{"a b c d",
 			[]byte{'a', 'b', 'c', 'd'},
-			[]Msg{
-				KeyMsg{
-					Type:  KeyRunes,
-					Runes: []rune{'a', 'b', 'c', 'd'},
-				},
+			[]Event{
+				KeyMsg{Type: KeyRunes, Runes: []rune{'a'}},
+				KeyMsg{Type: KeyRunes, Runes: []rune{'b'}},
+				KeyMsg{Type: KeyRunes, Runes: []rune{'c'}},
+				KeyMsg{Type: KeyRunes, Runes: []rune{'d'}},
+			},
+		},
+		{"e\u0301 🇫🇷",
+			[]byte("e\u0301🇫🇷"),
+			[]Event{
+				KeyMsg{Type: KeyRunes, Runes: []rune("e\u0301")},
+				KeyMsg{Type: KeyRunes, Runes: []rune("🇫🇷")},
 			},
 		},
 		{"up",
 			[]byte("\x1b[A"),
-			[]Msg{
+			[]Event{
 				KeyMsg{
 					Type: KeyUp,
 				},
@@ -319,7 +467,7 @@ func TestReadInput(t *testing.T) {
 		},
 		{"wheel up",
 			[]byte{'\x1b', '[', 'M', byte(32) + 0b0100_0000, byte(65), byte(49)},
-			[]Msg{
+			[]Event{
 				MouseMsg{
 					X:      32,
 					Y:      16,
@@ -334,7 +482,7 @@ func TestReadInput(t *testing.T) {
 				'\x1b', '[', 'M', byte(32) + 0b0010_0000, byte(32 + 33), byte(16 + 33),
 				'\x1b', '[', 'M', byte(32) + 0b0000_0011, byte(64 + 33), byte(32 + 33),
 			},
-			[]Msg{
+			[]Event{
 				MouseMsg(MouseEvent{
 					X:      32,
 					Y:      16,
@@ -353,7 +501,7 @@ func TestReadInput(t *testing.T) {
 		},
 		{"shift+tab",
 			[]byte{'\x1b', '[', 'Z'},
-			[]Msg{
+			[]Event{
 				KeyMsg{
 					Type: KeyShiftTab,
 				},
@@ -361,11 +509,11 @@ func TestReadInput(t *testing.T) {
 		},
 		{"enter",
 			[]byte{'\r'},
-			[]Msg{KeyMsg{Type: KeyEnter}},
+			[]Event{KeyMsg{Type: KeyEnter}},
 		},
 		{"alt+enter",
 			[]byte{'\x1b', '\r'},
-			[]Msg{
+			[]Event{
 				KeyMsg{
 					Type: KeyEnter,
 					Alt:  true,
@@ -374,7 +522,7 @@ func TestReadInput(t *testing.T) {
 		},
 		{"insert",
 			[]byte{'\x1b', '[', '2', '~'},
-			[]Msg{
+			[]Event{
 				KeyMsg{
 					Type: KeyInsert,
 				},
@@ -382,57 +530,68 @@ func TestReadInput(t *testing.T) {
 		},
 		{"alt+ctrl+a",
 			[]byte{'\x1b', byte(keySOH)},
-			[]Msg{
+			[]Event{
 				KeyMsg{
 					Type: KeyCtrlA,
 					Alt:  true,
 				},
 			},
 		},
-		{"?CSI[45 45 45 45 88]?",
+		{`?"\x1b[----X"?`,
 			[]byte{'\x1b', '[', '-', '-', '-', '-', 'X'},
-			[]Msg{unknownCSISequenceMsg([]byte{'\x1b', '[', '-', '-', '-', '-', 'X'})},
+			[]Event{UnknownSequenceMsg{[]byte{'\x1b', '[', '-', '-', '-', '-', 'X'}}},
+		},
+		{`?"\x1bP>|xterm(380)\x1b\\"? a`,
+			[]byte("\x1bP>|xterm(380)\x1b\\a"),
+			[]Event{
+				UnknownSequenceMsg{[]byte("\x1bP>|xterm(380)\x1b\\")},
+				KeyMsg{Type: KeyRunes, Runes: []rune("a")},
+			},
+		},
+		{"alt+P",
+			[]byte("\x1bP"),
+			[]Event{KeyMsg{Type: KeyRunes, Runes: []rune("P"), Alt: true}},
 		},
 		// Powershell sequences.
 		{"up",
 			[]byte{'\x1b', 'O', 'A'},
-			[]Msg{KeyMsg{Type: KeyUp}},
+			[]Event{KeyMsg{Type: KeyUp}},
 		},
 		{"down",
 			[]byte{'\x1b', 'O', 'B'},
-			[]Msg{KeyMsg{Type: KeyDown}},
+			[]Event{KeyMsg{Type: KeyDown}},
 		},
 		{"right",
 			[]byte{'\x1b', 'O', 'C'},
-			[]Msg{KeyMsg{Type: KeyRight}},
+			[]Event{KeyMsg{Type: KeyRight}},
 		},
 		{"left",
 			[]byte{'\x1b', 'O', 'D'},
-			[]Msg{KeyMsg{Type: KeyLeft}},
+			[]Event{KeyMsg{Type: KeyLeft}},
 		},
 		{"alt+enter",
 			[]byte{'\x1b', '\x0d'},
-			[]Msg{KeyMsg{Type: KeyEnter, Alt: true}},
+			[]Event{KeyMsg{Type: KeyEnter, Alt: true}},
 		},
 		{"alt+backspace",
 			[]byte{'\x1b', '\x7f'},
-			[]Msg{KeyMsg{Type: KeyBackspace, Alt: true}},
+			[]Event{KeyMsg{Type: KeyBackspace, Alt: true}},
 		},
 		{"ctrl+@",
 			[]byte{'\x00'},
-			[]Msg{KeyMsg{Type: KeyCtrlAt}},
+			[]Event{KeyMsg{Type: KeyCtrlAt}},
 		},
 		{"alt+ctrl+@",
 			[]byte{'\x1b', '\x00'},
-			[]Msg{KeyMsg{Type: KeyCtrlAt, Alt: true}},
+			[]Event{KeyMsg{Type: KeyCtrlAt, Alt: true}},
 		},
 		{"esc",
 			[]byte{'\x1b'},
-			[]Msg{KeyMsg{Type: KeyEsc}},
+			[]Event{KeyMsg{Type: KeyEsc}},
 		},
 		{"alt+esc",
 			[]byte{'\x1b', '\x1b'},
-			[]Msg{KeyMsg{Type: KeyEsc, Alt: true}},
+			[]Event{KeyMsg{Type: KeyEsc, Alt: true}},
 		},
 		{"[a b] o",
 			[]byte{
@@ -441,7 +600,7 @@ func TestReadInput(t *testing.T) {
 				'\x1b', '[', '2', '0', '1', '~',
 				'o',
 			},
-			[]Msg{
+			[]Event{
 				KeyMsg{Type: KeyRunes, Runes: []rune("a b"), Paste: true},
 				KeyMsg{Type: KeyRunes, Runes: []rune("o")},
 			},
@@ -451,7 +610,7 @@ func TestReadInput(t *testing.T) {
 				'\x1b', '[', '2', '0', '0', '~',
 				'a', '\x03', '\n', 'b',
 				'\x1b', '[', '2', '0', '1', '~'},
-			[]Msg{
+			[]Event{
 				KeyMsg{Type: KeyRunes, Runes: []rune("a\x03\nb"), Paste: true},
 			},
 		},
@@ -462,11 +621,11 @@ func TestReadInput(t *testing.T) {
 		testData = append(testData,
 			test{"?0xfe?",
 				[]byte{'\xfe'},
-				[]Msg{unknownInputByteMsg(0xfe)},
+				[]Event{unknownInputByteMsg(0xfe)},
 			},
 			test{"a ?0xfe?   b",
 				[]byte{'a', '\xfe', ' ', 'b'},
-				[]Msg{
+				[]Event{
 					KeyMsg{Type: KeyRunes, Runes: []rune{'a'}},
 					unknownInputByteMsg(0xfe),
 					KeyMsg{Type: KeySpace, Runes: []rune{' '}},
@@ -507,7 +666,7 @@ func TestReadInput(t *testing.T) {
 	}
 }
 
-func testReadInputs(t *testing.T, input io.Reader) []Msg {
+func testReadInputs(t *testing.T, input io.Reader) []Event {
 	// We'll check that the input reader finishes at the end
 	// without error.
 	var wg sync.WaitGroup
@@ -522,17 +681,17 @@ func testReadInputs(t *testing.T, input io.Reader) []Msg {
 	}()
 
 	// The messages we're consuming.
-	msgsC := make(chan Msg)
+	msgsC := make(chan Event)
 
 	// Start the reader in the background.
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		inputErr = readAnsiInputs(ctx, msgsC, input)
+		inputErr = readAnsiInputs(ctx, msgsC, input, Parser{})
 		msgsC <- nil
 	}()
 
-	var msgs []Msg
+	var msgs []Event
 loop:
 	for {
 		select {
@@ -642,7 +801,7 @@ func TestDetectRandomSequencesLex(t *testing.T) {
 }
 
 func runTestDetectSequence(
-	t *testing.T, detectSequence func(input []byte) (hasSeq bool, width int, msg Msg),
+	t *testing.T, detectSequence func(input []byte) (hasSeq bool, width int, msg Event),
 ) {
 	for i := 0; i < 10; i++ {
 		t.Run("", func(t *testing.T) {
@@ -0,0 +1,44 @@
+package input
+
+import (
+	"regexp"
+	"strconv"
+)
+
+// Values a terminal can report for a mode in a DECRPM response.
+const (
+	ModeNotRecognized    = 0
+	ModeSet              = 1
+	ModeReset            = 2
+	ModePermanentlySet   = 3
+	ModePermanentlyReset = 4
+)
+
+// ModeReportMsg is reported when the terminal answers a DECRQM query about a
+// private mode, with the state of the mode. Bubble Tea programs query and
+// handle the modes they use themselves.
+type ModeReportMsg struct {
+	Mode  int
+	Value int
+}
+
+// Supported reports whether the terminal supports changing the mode.
+func (m ModeReportMsg) Supported() bool {
+	return m.Value == ModeSet || m.Value == ModeReset
+}
+
+// modeReportRe matches a DECRPM response for a private mode:
+//
+//	CSI ? Pd ; Ps $ y
+var modeReportRe = regexp.MustCompile(`^\x1b\[\?(\d+);(\d+)\$y`)
+
+// detectModeReport detects a DECRPM response.
+func detectModeReport(input []byte) (hasReport bool, width int, msg Event) {
+	m := modeReportRe.FindSubmatch(input)
+	if m == nil {
+		return false, 0, nil
+	}
+	mode, _ := strconv.Atoi(string(m[1]))
+	value, _ := strconv.Atoi(string(m[2]))
+	return true, len(m[0]), ModeReportMsg{Mode: mode, Value: value}
+}
@@ -0,0 +1,27 @@
+package input
+
+import "testing"
+
+func TestDetectModeReport(t *testing.T) {
+	found, width, msg := detectModeReport([]byte("\x1b[?2026;2$yabc"))
+	if !found {
+		t.Fatalf("no mode report found")
+	}
+	if width != 11 {
+		t.Errorf("expected width 11, got %d", width)
+	}
+	report, ok := msg.(ModeReportMsg)
+	if !ok {
+		t.Fatalf("expected a ModeReportMsg, got %T", msg)
+	}
+	if report.Mode != 2026 || !report.Supported() {
+		t.Errorf("expected supported synchronized output mode, got %+v", report)
+	}
+
+	if _, _, msg := detectModeReport([]byte("\x1b[?2026;0$y")); msg.(ModeReportMsg).Supported() {
+		t.Errorf("expected mode to be unsupported")
+	}
+	if found, _, _ := detectModeReport([]byte("\x1b[?2026h")); found {
+		t.Errorf("expected no mode report")
+	}
+}
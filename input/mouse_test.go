@@ -1,4 +1,4 @@
-package tea
+package input
 
 import (
 	"fmt"
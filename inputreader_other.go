@@ -12,3 +12,9 @@ import (
 func newInputReader(r io.Reader) (cancelreader.CancelReader, error) {
 	return cancelreader.NewReader(r)
 }
+
+// isConsoleInput reports whether r reads console input events rather than a
+// stream of bytes, which is only the case on Windows.
+func isConsoleInput(io.Reader) bool {
+	return false
+}
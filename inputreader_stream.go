@@ -0,0 +1,114 @@
+package tea
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/muesli/cancelreader"
+)
+
+// streamCancelGracePeriod is how long a cancelled streamReader waits for a
+// pending read to complete.
+const streamCancelGracePeriod = 20 * time.Millisecond
+
+// inputChunk is the result of a single read from a streamInput.
+type inputChunk struct {
+	b   []byte
+	err error
+}
+
+// streamInput reads from an input that can't be cancelled natively, such as
+// a network connection or an SSH channel. Each read from the input happens in
+// a goroutine of its own, so that cancelling the reader handed to the read
+// loop doesn't have to interrupt a blocking read. If a reader is cancelled
+// while a read is pending, the result is kept for the next reader, so nothing
+// is lost when the terminal is released and restored.
+//
+// There's a single streamInput per program, which outlives the readers
+// created for it. It only reads from the input when asked to, so the input
+// can be handed to another process, as Exec does, in the meantime.
+type streamInput struct {
+	r io.Reader
+
+	mtx     sync.Mutex
+	pending inputChunk      // left over from a previous read
+	result  chan inputChunk // the result of the pending read, if any
+}
+
+// newStreamInput returns a streamInput reading from r.
+func newStreamInput(r io.Reader) *streamInput {
+	return &streamInput{r: r}
+}
+
+// newReader returns a new cancelable reader reading from the input.
+func (s *streamInput) newReader() cancelreader.CancelReader {
+	return &streamReader{input: s, canceled: make(chan struct{})}
+}
+
+// streamReader is a cancelreader.CancelReader reading from a streamInput.
+type streamReader struct {
+	input *streamInput
+
+	canceled   chan struct{}
+	cancelOnce sync.Once
+}
+
+// Read implements io.Reader. It returns cancelreader.ErrCanceled once the
+// reader has been cancelled.
+func (r *streamReader) Read(b []byte) (int, error) {
+	s := r.input
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if len(s.pending.b) == 0 && s.pending.err == nil {
+		if s.result == nil {
+			result := make(chan inputChunk, 1)
+			s.result = result
+			go func() {
+				b := make([]byte, 256) //nolint:gomnd
+				n, err := s.r.Read(b)
+				result <- inputChunk{b: b[:n], err: err}
+			}()
+		}
+
+		select {
+		case <-r.canceled:
+			// Give the pending read a moment to complete, so that the input
+			// is left alone once we return, if at all possible. Its result
+			// is kept for the next reader.
+			select {
+			case s.pending = <-s.result:
+				s.result = nil
+			case <-time.After(streamCancelGracePeriod):
+			}
+			return 0, cancelreader.ErrCanceled
+		case s.pending = <-s.result:
+			s.result = nil
+		}
+	}
+
+	n := copy(b, s.pending.b)
+	s.pending.b = s.pending.b[n:]
+	if len(s.pending.b) > 0 {
+		return n, nil
+	}
+
+	// Errors are final, so keep returning them.
+	return n, s.pending.err
+}
+
+// Cancel implements cancelreader.CancelReader. Cancelling always succeeds.
+func (r *streamReader) Cancel() bool {
+	r.cancelOnce.Do(func() {
+		close(r.canceled)
+	})
+	return true
+}
+
+// Close implements io.Closer. It cancels the reader, but leaves the
+// underlying input open, as it's owned by the caller.
+func (r *streamReader) Close() error {
+	r.Cancel()
+	return nil
+}
@@ -0,0 +1,55 @@
+package tea
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/muesli/cancelreader"
+)
+
+func TestStreamInput(t *testing.T) {
+	pr, pw := io.Pipe()
+	s := newStreamInput(pr)
+
+	// Cancelling a reader interrupts a blocking read.
+	r := s.newReader()
+	errs := make(chan error, 1)
+	go func() {
+		_, err := r.Read(make([]byte, 8))
+		errs <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	if !r.Cancel() {
+		t.Fatal("expected cancel to succeed")
+	}
+	select {
+	case err := <-errs:
+		if !errors.Is(err, cancelreader.ErrCanceled) {
+			t.Fatalf("expected ErrCanceled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("read wasn't cancelled")
+	}
+
+	// Input arriving afterwards goes to the next reader, even if it doesn't
+	// fit in a single read.
+	go func() {
+		_, _ = pw.Write([]byte("hello"))
+		_ = pw.Close()
+	}()
+	r = s.newReader()
+	b := make([]byte, 3)
+	n, err := r.Read(b)
+	if err != nil || string(b[:n]) != "hel" {
+		t.Fatalf("expected %q, got %q (%v)", "hel", b[:n], err)
+	}
+	n, err = r.Read(b)
+	if err != nil || string(b[:n]) != "lo" {
+		t.Fatalf("expected %q, got %q (%v)", "lo", b[:n], err)
+	}
+	if _, err := r.Read(b); !errors.Is(err, io.EOF) {
+		t.Fatalf("expected EOF, got %v", err)
+	}
+}
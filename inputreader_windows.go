@@ -31,6 +31,13 @@ type conInputReader struct {
 
 var _ cancelreader.CancelReader = &conInputReader{}
 
+// isConsoleInput reports whether r reads console input events rather than a
+// stream of bytes.
+func isConsoleInput(r io.Reader) bool {
+	_, ok := r.(*conInputReader)
+	return ok
+}
+
 func newInputReader(r io.Reader) (cancelreader.CancelReader, error) {
 	fallback := func(io.Reader) (cancelreader.CancelReader, error) {
 		return cancelreader.NewReader(r)
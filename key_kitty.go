@@ -0,0 +1,18 @@
+package tea
+
+import "github.com/charmbracelet/bubbletea/input"
+
+// KittyKeyboardFlags are the progressive enhancement flags of the kitty
+// keyboard protocol. They can be combined and passed to WithKittyKeyboard.
+//
+// See: https://sw.kovidgoyal.net/kitty/keyboard-protocol/#progressive-enhancement
+type KittyKeyboardFlags = input.KittyKeyboardFlags
+
+// Kitty keyboard protocol enhancement flags.
+const (
+	KittyDisambiguateEscapeCodes    = input.KittyDisambiguateEscapeCodes
+	KittyReportEventTypes           = input.KittyReportEventTypes
+	KittyReportAlternateKeys        = input.KittyReportAlternateKeys
+	KittyReportAllKeysAsEscapeCodes = input.KittyReportAllKeysAsEscapeCodes
+	KittyReportAssociatedText       = input.KittyReportAssociatedText
+)
@@ -6,8 +6,10 @@ package tea
 import (
 	"context"
 	"io"
+
+	"github.com/charmbracelet/bubbletea/input"
 )
 
-func readInputs(ctx context.Context, msgs chan<- Msg, input io.Reader) error {
-	return readAnsiInputs(ctx, msgs, input)
+func readInputs(ctx context.Context, msgs chan<- Msg, r io.Reader, parser input.Parser) error {
+	return parser.Read(ctx, r, msgs)
 }
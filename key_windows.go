@@ -7,18 +7,21 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"unicode/utf16"
 
+	"github.com/charmbracelet/bubbletea/input"
 	"github.com/erikgeiser/coninput"
 	localereader "github.com/mattn/go-localereader"
+	"github.com/rivo/uniseg"
 	"golang.org/x/sys/windows"
 )
 
-func readInputs(ctx context.Context, msgs chan<- Msg, input io.Reader) error {
-	if coninReader, ok := input.(*conInputReader); ok {
+func readInputs(ctx context.Context, msgs chan<- Msg, r io.Reader, parser input.Parser) error {
+	if coninReader, ok := r.(*conInputReader); ok {
 		return readConInputs(ctx, msgs, coninReader.conin)
 	}
 
-	return readAnsiInputs(ctx, msgs, localereader.NewReader(input))
+	return parser.Read(ctx, localereader.NewReader(r), msgs)
 }
 
 func readConInputs(ctx context.Context, msgsch chan<- Msg, con windows.Handle) error {
@@ -29,26 +32,36 @@ func readConInputs(ctx context.Context, msgsch chan<- Msg, con windows.Handle) e
 			return fmt.Errorf("read coninput events: %w", err)
 		}
 
-		for _, event := range events {
+		// Characters are received one UTF-16 code unit at a time, and
+		// are reported by grapheme cluster once the events that follow them
+		// aren't characters.
+		var text []uint16
+		for n, event := range events {
 			var msgs []Msg
 			switch e := event.Unwrap().(type) {
 			case coninput.KeyEventRecord:
 				if !e.KeyDown || e.VirtualKeyCode == coninput.VK_SHIFT {
-					continue
+					break
+				}
+
+				alt := e.ControlKeyState.Contains(coninput.LEFT_ALT_PRESSED | coninput.RIGHT_ALT_PRESSED)
+				if keyType(e) == KeyRunes && !alt && e.RepeatCount == 1 && e.Char != 0 {
+					text = append(text, uint16(e.Char))
+					break
 				}
 
 				for i := 0; i < int(e.RepeatCount); i++ {
 					msgs = append(msgs, KeyMsg{
 						Type:  keyType(e),
 						Runes: []rune{e.Char},
-						Alt:   e.ControlKeyState.Contains(coninput.LEFT_ALT_PRESSED | coninput.RIGHT_ALT_PRESSED),
+						Alt:   alt,
 					})
 				}
 			case coninput.WindowBufferSizeEventRecord:
-				msgs = append(msgs, WindowSizeMsg{
-					Width:  int(e.Size.X),
-					Height: int(e.Size.Y),
-				})
+				// The event carries the size of the screen buffer, which
+				// can be much taller than the window, so have the program
+				// query the size of the window itself.
+				msgs = append(msgs, windowSizeMsg{})
 			case coninput.MouseEventRecord:
 				event := mouseEvent(ps, e)
 				if event.Type != MouseUnknown {
@@ -58,7 +71,11 @@ func readConInputs(ctx context.Context, msgsch chan<- Msg, con windows.Handle) e
 			case coninput.FocusEventRecord, coninput.MenuEventRecord:
 				// ignore
 			default: // unknown event
-				continue
+			}
+
+			if len(text) > 0 && (len(msgs) > 0 || n == len(events)-1) {
+				msgs = append(textKeys(text), msgs...)
+				text = text[:0]
 			}
 
 			// Send all messages to the channel
@@ -77,6 +94,19 @@ func readConInputs(ctx context.Context, msgsch chan<- Msg, con windows.Handle) e
 	}
 }
 
+// textKeys returns the key messages for the characters of text, one per
+// grapheme cluster.
+func textKeys(text []uint16) []Msg {
+	var msgs []Msg
+	rest, state := string(utf16.Decode(text)), -1
+	for rest != "" {
+		var cluster string
+		cluster, rest, _, state = uniseg.FirstGraphemeClusterInString(rest, state)
+		msgs = append(msgs, KeyMsg{Type: KeyRunes, Runes: []rune(cluster)})
+	}
+	return msgs
+}
+
 func mouseEventButton(p, s coninput.ButtonState) (button MouseButton, action MouseAction) {
 	btn := p ^ s
 	action = MouseActionPress
@@ -259,7 +289,18 @@ func keyType(e coninput.KeyEventRecord) KeyType {
 		return KeyPgDown
 	case coninput.VK_DELETE:
 		return KeyDelete
+	case coninput.VK_SNAPSHOT:
+		return KeyPrintScreen
+	case coninput.VK_PAUSE:
+		return KeyPause
+	case coninput.VK_APPS:
+		return KeyMenu
 	default:
+		if code >= coninput.VK_F1 && code <= coninput.VK_F24 {
+			// Function key types are declared in order, counting down.
+			return KeyF1 - KeyType(code-coninput.VK_F1)
+		}
+
 		if e.ControlKeyState&(coninput.LEFT_CTRL_PRESSED|coninput.RIGHT_CTRL_PRESSED) == 0 {
 			return KeyRunes
 		}
@@ -0,0 +1,194 @@
+package tea
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/muesli/termenv"
+)
+
+// Layer is a view drawn over another one, such as a modal dialog, a tooltip
+// or a notification.
+type Layer struct {
+	// Content is the view of the layer. Its lines are padded to the width of
+	// the widest one, so that the layer hides what's beneath it entirely.
+	Content string
+
+	// X and Y are the column and the line of the top-left corner of the
+	// layer, relative to the top-left corner of the view beneath it. Layers
+	// may stick out of the view: what's left of its first column or above
+	// its first line is cut off.
+	X, Y int
+
+	// Z orders layers: layers with a higher Z are drawn over those with a
+	// lower one. Layers with the same Z are drawn in order.
+	Z int
+
+	// Cursor is where the terminal's cursor should be placed within the
+	// layer, relative to its top-left corner, if anywhere, such as in a
+	// dialog's text input. The cursor of the topmost layer which has one
+	// takes precedence over the cursor of the view.
+	Cursor *Cursor
+}
+
+// LayerModel is an optional interface models can implement to draw layers
+// over their view, rather than splicing strings together themselves. Layers
+// is called after every View, and the layers are composited over the view
+// with Composite.
+//
+//	func (m model) Layers() []tea.Layer {
+//	    if !m.confirming {
+//	        return nil
+//	    }
+//	    return []tea.Layer{{Content: m.dialog.View(), X: 10, Y: 5}}
+//	}
+type LayerModel interface {
+	Model
+
+	// Layers returns the layers to draw over the view.
+	Layers() []Layer
+}
+
+// Composite draws layers over a view, in the order of their Z, and returns
+// the result. Widths are measured in cells, ignoring escape sequences, and
+// the styles of the view are kept on either side of each layer.
+func Composite(view string, layers ...Layer) string {
+	if len(layers) == 0 {
+		return view
+	}
+
+	sorted := make([]Layer, len(layers))
+	copy(sorted, layers)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Z < sorted[j].Z
+	})
+
+	lines := strings.Split(view, "\n")
+	for _, l := range sorted {
+		lines = compositeLayer(lines, l)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// layersCursor returns the position of the cursor of the topmost layer which
+// has one, relative to the view beneath the layers, or nil if none has.
+func layersCursor(layers []Layer) *Cursor {
+	var (
+		cursor *Cursor
+		z      int
+	)
+	for _, l := range layers {
+		if l.Cursor == nil || (cursor != nil && l.Z < z) {
+			continue
+		}
+		x, y := l.X+l.Cursor.X, l.Y+l.Cursor.Y
+		if x < 0 || y < 0 {
+			continue
+		}
+		cursor, z = &Cursor{X: x, Y: y}, l.Z
+	}
+	return cursor
+}
+
+// compositeLayer draws a layer over the lines of a view.
+func compositeLayer(lines []string, l Layer) []string {
+	layerLines := strings.Split(l.Content, "\n")
+	width := 0
+	for _, line := range layerLines {
+		if w := StringWidth(line); w > width {
+			width = w
+		}
+	}
+
+	for i, line := range layerLines {
+		y := l.Y + i
+		if y < 0 {
+			continue
+		}
+		for len(lines) <= y {
+			lines = append(lines, "")
+		}
+
+		// Pad the line to the width of the layer, then cut off what sticks
+		// out to the left of the view.
+		x, w := l.X, width
+		line += strings.Repeat(" ", width-StringWidth(line))
+		if x < 0 {
+			line = skipColumns(line, -x)
+			w += x
+			x = 0
+		}
+		if w <= 0 {
+			continue
+		}
+
+		// Keep the view on both sides of the layer.
+		left := truncateLine(lines[y], x)
+		if lw := StringWidth(left); lw < x {
+			left += strings.Repeat(" ", x-lw)
+		}
+		reset := termenv.CSI + termenv.ResetSeq + "m"
+		lines[y] = left + reset + line + reset + skipColumns(lines[y], x+w)
+	}
+	return lines
+}
+
+// skipColumns drops the first n columns of the printable characters of a
+// line. Escape sequences are all kept, so that the rest of the line keeps its
+// style. A wide character cut in half is replaced with a space.
+func skipColumns(s string, n int) string {
+	var b strings.Builder
+	col := 0
+	for i := 0; i < len(s); {
+		if s[i] == '\x1b' {
+			end := escapeEnd(s, i)
+			b.WriteString(s[i:end])
+			i = end
+			continue
+		}
+
+		cluster, w := firstGrapheme(s[i:])
+		if col >= n {
+			b.WriteString(cluster)
+		} else {
+			if col+w > n {
+				b.WriteString(strings.Repeat(" ", col+w-n))
+			}
+			col += w
+		}
+		i += len(cluster)
+	}
+	return b.String()
+}
+
+// escapeEnd returns the index right after the escape sequence starting at
+// s[i]: a CSI sequence, up to its final byte, an OSC sequence, up to BEL or
+// ST, or a two-character escape.
+func escapeEnd(s string, i int) int {
+	if i+1 >= len(s) {
+		return len(s)
+	}
+	switch s[i+1] {
+	case '[':
+		j := i + 2
+		for j < len(s) && (s[j] < 0x40 || s[j] > 0x7e) {
+			j++
+		}
+		if j < len(s) {
+			j++
+		}
+		return j
+	case ']':
+		for j := i + 2; j < len(s); j++ {
+			if s[j] == '\a' {
+				return j + 1
+			}
+			if s[j] == '\x1b' && j+1 < len(s) && s[j+1] == '\\' {
+				return j + 2
+			}
+		}
+		return len(s)
+	default:
+		return i + 2
+	}
+}
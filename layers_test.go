@@ -0,0 +1,98 @@
+package tea
+
+import (
+	"testing"
+)
+
+func TestComposite(t *testing.T) {
+	tests := []struct {
+		name     string
+		view     string
+		layers   []Layer
+		expected string
+	}{
+		{
+			name:     "no layers",
+			view:     "abc",
+			expected: "abc",
+		},
+		{
+			name:     "layer over the view",
+			view:     "abcdef\nghijkl\nmnopqr",
+			layers:   []Layer{{Content: "12\n3", X: 2, Y: 1}},
+			expected: "abcdef\ngh\x1b[0m12\x1b[0mkl\nmn\x1b[0m3 \x1b[0mqr",
+		},
+		{
+			name:     "layer past the view",
+			view:     "ab",
+			layers:   []Layer{{Content: "1\n2", X: 4, Y: 0}},
+			expected: "ab  \x1b[0m1\x1b[0m\n    \x1b[0m2\x1b[0m",
+		},
+		{
+			name:     "layer cut off",
+			view:     "abc\ndef",
+			layers:   []Layer{{Content: "12\n34", X: -1, Y: -1}},
+			expected: "\x1b[0m4\x1b[0mbc\ndef",
+		},
+		{
+			name: "z order",
+			view: "abc",
+			layers: []Layer{
+				{Content: "2", X: 1, Z: 2},
+				{Content: "11", X: 0, Z: 1},
+			},
+			expected: "\x1b[0m1\x1b[0m2\x1b[0m\x1b[0m\x1b[0mc",
+		},
+		{
+			name:     "styles are kept",
+			view:     "\x1b[31mabcd\x1b[0m",
+			layers:   []Layer{{Content: "1", X: 1}},
+			expected: "\x1b[31ma\x1b[0m\x1b[0m1\x1b[0m\x1b[31mcd\x1b[0m",
+		},
+		{
+			name:     "wide characters",
+			view:     "世界",
+			layers:   []Layer{{Content: "1", X: 1}},
+			expected: " \x1b[0m1\x1b[0m界",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Composite(test.view, test.layers...); got != test.expected {
+				t.Errorf("expected:\n%q\ngot:\n%q", test.expected, got)
+			}
+		})
+	}
+}
+
+type layerModel struct {
+	*testModel
+}
+
+func (m layerModel) Layers() []Layer {
+	return []Layer{{Content: "!", X: 1}}
+}
+
+func TestLayerModel(t *testing.T) {
+	got, _ := NewProgram(nil).composedView(layerModel{&testModel{}})
+	if want := "s\x1b[0m!\x1b[0mccess\n"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestLayersCursor(t *testing.T) {
+	layers := []Layer{
+		{X: 5, Y: 2, Z: 1, Cursor: &Cursor{X: 1, Y: 1}},
+		{X: 0, Y: 0, Z: 2},
+		{X: 10, Y: 3, Cursor: &Cursor{X: 0, Y: 0}},
+		{X: -2, Y: 0, Z: 3, Cursor: &Cursor{X: 1, Y: 0}},
+	}
+	// The topmost layer with a cursor which is on the view wins.
+	if c := layersCursor(layers); c == nil || *c != (Cursor{X: 6, Y: 3}) {
+		t.Errorf("expected the cursor at 6,3, got %v", c)
+	}
+	if c := layersCursor(layers[1:2]); c != nil {
+		t.Errorf("expected no cursor, got %v", c)
+	}
+}
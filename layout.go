@@ -0,0 +1,288 @@
+package tea
+
+import (
+	"strings"
+
+	"github.com/muesli/termenv"
+)
+
+// SplitDirection is the direction in which a Split lays out its panes.
+type SplitDirection int
+
+// Split directions.
+const (
+	// SplitHorizontal lays out panes side by side, from left to right.
+	SplitHorizontal SplitDirection = iota
+
+	// SplitVertical stacks panes, from top to bottom.
+	SplitVertical
+)
+
+// Pane is a model laid out by a Split.
+type Pane struct {
+	// Model is the model drawn in the pane. It can be a Split itself, to
+	// nest layouts.
+	Model Model
+
+	// Ratio is the share of the space the pane gets, relative to the ratios
+	// of the other panes: panes with ratios 2 and 1 get two thirds and one
+	// third of the space. A ratio of 0 counts as 1.
+	Ratio float64
+
+	// Min is the minimum width or height of the pane, depending on the
+	// direction of the split.
+	Min int
+}
+
+// Split is a model laying out other models in panes, side by side or
+// stacked, so that multi-pane programs don't need to compute the size of each
+// pane on every resize.
+//
+// When a Split gets a WindowSizeMsg, it divides the space between its panes
+// and passes each of them a WindowSizeMsg with its own size. Other messages
+// are passed to all panes, and the views of the panes are joined, each cut or
+// padded to the size of its pane.
+//
+//	func initialModel() tea.Model {
+//	    return tea.NewSplit(tea.SplitHorizontal,
+//	        tea.Pane{Model: sidebar.New(), Min: 20},
+//	        tea.Pane{Model: tea.NewSplit(tea.SplitVertical,
+//	            tea.Pane{Model: editor.New(), Ratio: 3},
+//	            tea.Pane{Model: terminal.New()},
+//	        ), Ratio: 3},
+//	    )
+//	}
+//
+// A Split is usually embedded in a model of its own, which handles the
+// messages meant for the program as a whole, such as quitting, and passes
+// the others on to the Split.
+type Split struct {
+	Direction SplitDirection
+	Panes     []Pane
+
+	width, height int
+	sizes         []int
+}
+
+// NewSplit returns a Split laying out the given panes.
+func NewSplit(direction SplitDirection, panes ...Pane) Split {
+	return Split{Direction: direction, Panes: panes}
+}
+
+// Init calls the Init functions of the split's panes.
+func (s Split) Init() Cmd {
+	cmds := make([]Cmd, 0, len(s.Panes))
+	for _, p := range s.Panes {
+		cmds = append(cmds, p.Model.Init())
+	}
+	return Batch(cmds...)
+}
+
+// Update passes a message to the split's panes. A WindowSizeMsg is divided
+// between them.
+func (s Split) Update(msg Msg) (Model, Cmd) {
+	panes := make([]Pane, len(s.Panes))
+	copy(panes, s.Panes)
+
+	var cmds []Cmd
+	if size, ok := msg.(WindowSizeMsg); ok {
+		s.width, s.height = size.Width, size.Height
+		s.sizes = s.divide()
+		for i := range panes {
+			var cmd Cmd
+			panes[i].Model, cmd = panes[i].Model.Update(s.paneSize(i))
+			cmds = append(cmds, cmd)
+		}
+	} else {
+		for i := range panes {
+			var cmd Cmd
+			panes[i].Model, cmd = panes[i].Model.Update(msg)
+			cmds = append(cmds, cmd)
+		}
+	}
+	s.Panes = panes
+	return s, Batch(cmds...)
+}
+
+// View joins the views of the split's panes.
+func (s Split) View() string {
+	if len(s.sizes) != len(s.Panes) {
+		// The size isn't known yet: just join the views.
+		views := make([]string, len(s.Panes))
+		for i, p := range s.Panes {
+			views[i] = p.Model.View()
+		}
+		if s.Direction == SplitVertical {
+			return strings.Join(views, "\n")
+		}
+		return strings.Join(views, " ")
+	}
+
+	if s.Direction == SplitVertical {
+		var lines []string
+		for i, p := range s.Panes {
+			lines = append(lines, fitLines(p.Model.View(), s.width, s.sizes[i])...)
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	lines := make([]string, s.height)
+	for i, p := range s.Panes {
+		for y, line := range fitLines(p.Model.View(), s.sizes[i], s.height) {
+			lines[y] += line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Cursor returns the position of the cursor of the first pane which places
+// one, as a CursorModel, relative to the split. The cursor is hidden until the
+// split gets a WindowSizeMsg, and when it's outside of its pane.
+func (s Split) Cursor() *Cursor {
+	if len(s.sizes) != len(s.Panes) {
+		return nil
+	}
+
+	offset := 0
+	for i, p := range s.Panes {
+		m, ok := p.Model.(CursorModel)
+		if !ok {
+			offset += s.sizes[i]
+			continue
+		}
+		c := m.Cursor()
+		if c == nil {
+			offset += s.sizes[i]
+			continue
+		}
+
+		size := s.paneSize(i)
+		if c.X < 0 || c.Y < 0 || c.X >= size.Width || c.Y >= size.Height {
+			return nil
+		}
+		if s.Direction == SplitVertical {
+			return &Cursor{X: c.X, Y: c.Y + offset}
+		}
+		return &Cursor{X: c.X + offset, Y: c.Y}
+	}
+	return nil
+}
+
+// Sizes returns the widths of the panes for a horizontal split, or their
+// heights for a vertical one, as of the last WindowSizeMsg. It's nil until
+// the split gets one.
+func (s Split) Sizes() []int {
+	if s.sizes == nil {
+		return nil
+	}
+	sizes := make([]int, len(s.sizes))
+	copy(sizes, s.sizes)
+	return sizes
+}
+
+// paneSize returns the size of a pane.
+func (s Split) paneSize(i int) WindowSizeMsg {
+	if s.Direction == SplitVertical {
+		return WindowSizeMsg{Width: s.width, Height: s.sizes[i]}
+	}
+	return WindowSizeMsg{Width: s.sizes[i], Height: s.height}
+}
+
+// divide divides the space between the panes, according to their ratios,
+// giving panes at least their minimum size. If there isn't room for all the
+// minimum sizes, the last panes get less, down to nothing.
+func (s Split) divide() []int {
+	total := s.width
+	if s.Direction == SplitVertical {
+		total = s.height
+	}
+
+	sizes := make([]int, len(s.Panes))
+	fixed := make([]bool, len(s.Panes))
+	ratio := func(p Pane) float64 {
+		if p.Ratio <= 0 {
+			return 1
+		}
+		return p.Ratio
+	}
+
+	// Give panes which would get less than their minimum size their
+	// minimum size, until the others all get at least theirs.
+	for {
+		room, sum := total, 0.0
+		for i, p := range s.Panes {
+			if fixed[i] {
+				room -= sizes[i]
+			} else {
+				sum += ratio(p)
+			}
+		}
+
+		changed := false
+		for i, p := range s.Panes {
+			if fixed[i] {
+				continue
+			}
+			if share := float64(room) * ratio(p) / sum; share < float64(p.Min) {
+				sizes[i], fixed[i] = p.Min, true
+				changed = true
+			}
+		}
+		if changed {
+			continue
+		}
+
+		// Share the room left between the other panes, giving what's left
+		// after rounding down to the last of them.
+		last, used := -1, 0
+		for i, p := range s.Panes {
+			if fixed[i] {
+				continue
+			}
+			sizes[i] = int(float64(room) * ratio(p) / sum)
+			used += sizes[i]
+			last = i
+		}
+		if last >= 0 {
+			sizes[last] += room - used
+		}
+		break
+	}
+
+	// Without room for all the minimum sizes, cut the last panes.
+	room := total
+	for i := range sizes {
+		if sizes[i] > room {
+			sizes[i] = room
+		}
+		if sizes[i] < 0 {
+			sizes[i] = 0
+		}
+		room -= sizes[i]
+	}
+	return sizes
+}
+
+// fitLines returns the lines of a view, cut or padded to the given width and
+// height. Styles are reset at the end of each line.
+func fitLines(view string, width, height int) []string {
+	lines := strings.Split(view, "\n")
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+	for i, line := range lines {
+		line = truncateLine(line, width)
+		if strings.ContainsRune(line, '\x1b') {
+			// Keep styles left open from spilling into the next pane.
+			line += termenv.CSI + termenv.ResetSeq + "m"
+		}
+		if w := StringWidth(line); w < width {
+			line += strings.Repeat(" ", width-w)
+		}
+		lines[i] = line
+	}
+	return lines
+}
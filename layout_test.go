@@ -0,0 +1,120 @@
+package tea
+
+import (
+	"reflect"
+	"testing"
+)
+
+// sizeModel records the size it was given, and shows a letter.
+type sizeModel struct {
+	letter string
+	size   WindowSizeMsg
+}
+
+func (m sizeModel) Init() Cmd { return nil }
+
+func (m sizeModel) Update(msg Msg) (Model, Cmd) {
+	if msg, ok := msg.(WindowSizeMsg); ok {
+		m.size = msg
+	}
+	return m, nil
+}
+
+func (m sizeModel) View() string { return m.letter + "\n" + m.letter }
+
+func TestSplitDivide(t *testing.T) {
+	tests := []struct {
+		name     string
+		total    int
+		panes    []Pane
+		expected []int
+	}{
+		{"equal", 10, []Pane{{}, {}, {}}, []int{3, 3, 4}},
+		{"ratios", 12, []Pane{{Ratio: 2}, {Ratio: 1}}, []int{8, 4}},
+		{"minimum sizes", 10, []Pane{{Min: 6}, {}, {}}, []int{6, 2, 2}},
+		{"not enough room", 10, []Pane{{Min: 6}, {Min: 6}}, []int{6, 4}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := NewSplit(SplitHorizontal, test.panes...)
+			s.width = test.total
+			if sizes := s.divide(); !reflect.DeepEqual(sizes, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, sizes)
+			}
+		})
+	}
+}
+
+func TestSplit(t *testing.T) {
+	var m Model = NewSplit(SplitHorizontal,
+		Pane{Model: sizeModel{letter: "a"}},
+		Pane{Model: NewSplit(SplitVertical,
+			Pane{Model: sizeModel{letter: "b"}},
+			Pane{Model: sizeModel{letter: "c"}},
+		), Ratio: 2},
+	)
+	m, _ = m.Update(WindowSizeMsg{Width: 9, Height: 4})
+
+	s := m.(Split)
+	if sizes := s.Sizes(); !reflect.DeepEqual(sizes, []int{3, 6}) {
+		t.Errorf("expected sizes [3 6], got %v", sizes)
+	}
+	if size := s.Panes[0].Model.(sizeModel).size; size != (WindowSizeMsg{Width: 3, Height: 4}) {
+		t.Errorf("expected the first pane to be 3x4, got %v", size)
+	}
+	nested := s.Panes[1].Model.(Split)
+	if size := nested.Panes[1].Model.(sizeModel).size; size != (WindowSizeMsg{Width: 6, Height: 2}) {
+		t.Errorf("expected the nested pane to be 6x2, got %v", size)
+	}
+
+	expected := "a  b     \na  b     \n   c     \n   c     "
+	if v := s.View(); v != expected {
+		t.Errorf("expected:\n%q\ngot:\n%q", expected, v)
+	}
+}
+
+// cursorSizeModel is a sizeModel placing the cursor.
+type cursorSizeModel struct {
+	sizeModel
+	cursor *Cursor
+}
+
+func (m cursorSizeModel) Update(msg Msg) (Model, Cmd) {
+	sm, cmd := m.sizeModel.Update(msg)
+	m.sizeModel = sm.(sizeModel)
+	return m, cmd
+}
+
+func (m cursorSizeModel) Cursor() *Cursor { return m.cursor }
+
+func TestSplitCursor(t *testing.T) {
+	var m Model = NewSplit(SplitHorizontal,
+		Pane{Model: sizeModel{letter: "a"}},
+		Pane{Model: NewSplit(SplitVertical,
+			Pane{Model: cursorSizeModel{sizeModel{letter: "b"}, nil}},
+			Pane{Model: cursorSizeModel{sizeModel{letter: "c"}, &Cursor{X: 1, Y: 1}}},
+		)},
+	)
+	if c := m.(Split).Cursor(); c != nil {
+		t.Errorf("expected no cursor before the size is known, got %v", c)
+	}
+
+	m, _ = m.Update(WindowSizeMsg{Width: 8, Height: 4})
+	if c := m.(Split).Cursor(); c == nil || *c != (Cursor{X: 5, Y: 3}) {
+		t.Errorf("expected the cursor at 5,3, got %v", c)
+	}
+
+	// Cursors outside of their pane are hidden.
+	m, _ = m.Update(WindowSizeMsg{Width: 8, Height: 2})
+	if c := m.(Split).Cursor(); c != nil {
+		t.Errorf("expected no cursor, got %v", c)
+	}
+}
+
+func TestFitLines(t *testing.T) {
+	lines := fitLines("abcd\n\x1b[31mef", 3, 3)
+	expected := []string{"abc", "\x1b[31mef\x1b[0m ", "   "}
+	if !reflect.DeepEqual(lines, expected) {
+		t.Errorf("expected %q, got %q", expected, lines)
+	}
+}
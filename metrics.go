@@ -0,0 +1,72 @@
+package tea
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Metrics receives measurements of a running program, which can be used to
+// monitor long-running programs, such as TUIs served over SSH. Implement it
+// to feed the measurements to OpenTelemetry, expvar or any other metrics
+// system, and set it with WithMetrics:
+//
+//	type metrics struct {
+//	    updates  *expvar.Int
+//	    frames   *expvar.Int
+//	    // ...
+//	}
+//
+//	func (m metrics) UpdateDuration(msg tea.Msg, d time.Duration) {
+//	    m.updates.Add(1)
+//	}
+//
+//	func (m metrics) FrameRendered() {
+//	    m.frames.Add(1)
+//	}
+//
+// The methods are called synchronously, some of them from the event loop, so
+// they should be quick. They may be called concurrently.
+type Metrics interface {
+	// QueueDepth is called each time the program takes a message off its
+	// queue, with the number of messages still waiting to be processed.
+	QueueDepth(n int)
+
+	// UpdateDuration is called after each call to the model's Update
+	// function, with the message it was given and how long it took.
+	UpdateDuration(msg Msg, d time.Duration)
+
+	// FrameRendered is called each time a frame is drawn to the terminal.
+	// Frames are only drawn when the view changes, so the rate of frames
+	// tells how busy the renderer is.
+	FrameRendered()
+
+	// CommandDuration is called each time a command finishes, with how long
+	// it ran.
+	CommandDuration(d time.Duration)
+}
+
+// queueDepth returns the number of messages waiting to be sent to the event
+// loop.
+func (p *Program) queueDepth() int {
+	return int(atomic.LoadInt32(&p.queuedMsgs))
+}
+
+// callCmd calls a command and returns its message, reporting how long it ran
+// to the metrics, if any.
+func (p *Program) callCmd(cmd Cmd) Msg {
+	if p.metrics == nil {
+		return cmd()
+	}
+	start := time.Now()
+	msg := cmd()
+	p.metrics.CommandDuration(time.Since(start))
+	return msg
+}
+
+// frameRendered is called by the renderer each time it draws a frame.
+func (p *Program) frameRendered() {
+	p.debug.frameRendered()
+	if p.metrics != nil {
+		p.metrics.FrameRendered()
+	}
+}
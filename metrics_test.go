@@ -0,0 +1,82 @@
+package tea
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testMetrics struct {
+	mtx      sync.Mutex
+	depths   int
+	updates  []Msg
+	frames   int
+	commands []time.Duration
+}
+
+func (m *testMetrics) QueueDepth(int) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.depths++
+}
+
+func (m *testMetrics) UpdateDuration(msg Msg, _ time.Duration) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.updates = append(m.updates, msg)
+}
+
+func (m *testMetrics) FrameRendered() {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.frames++
+}
+
+func (m *testMetrics) CommandDuration(d time.Duration) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.commands = append(m.commands, d)
+}
+
+func TestMetrics(t *testing.T) {
+	var buf bytes.Buffer
+	metrics := &testMetrics{}
+	m := initCmdModel{&testModel{}, Sequence(func() Msg {
+		time.Sleep(5 * time.Millisecond)
+		return incrementMsg{}
+	}, Quit)}
+
+	p := NewProgram(m, WithInput(nil), WithOutput(&buf), WithMetrics(metrics))
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	metrics.mtx.Lock()
+	defer metrics.mtx.Unlock()
+
+	if metrics.depths == 0 {
+		t.Error("expected the queue depth to be reported")
+	}
+	var incremented bool
+	for _, msg := range metrics.updates {
+		if _, ok := msg.(incrementMsg); ok {
+			incremented = true
+		}
+	}
+	if !incremented {
+		t.Errorf("expected the update of incrementMsg to be reported, got %v", metrics.updates)
+	}
+	if metrics.frames == 0 {
+		t.Error("expected frames to be reported")
+	}
+	var slow bool
+	for _, d := range metrics.commands {
+		if d >= 5*time.Millisecond {
+			slow = true
+		}
+	}
+	if !slow {
+		t.Errorf("expected the duration of the slow command to be reported, got %v", metrics.commands)
+	}
+}
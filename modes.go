@@ -0,0 +1,22 @@
+package tea
+
+import "strconv"
+
+// Terminal modes Bubble Tea queries with DECRQM.
+const (
+	// synchronizedOutputMode is the terminal mode used to tell the terminal
+	// to hold off on drawing until a frame is complete.
+	//
+	// See: https://gist.github.com/christianparpart/d8a62cc1ab659194337d73e399004036
+	synchronizedOutputMode = 2026
+
+	// mousePixelsMode is the mouse mode reporting mouse events in pixels
+	// rather than cells, known as SGR-Pixels.
+	mousePixelsMode = 1016
+)
+
+// queryModeSequence returns the DECRQM sequence asking the terminal to report
+// the state of the given private mode.
+func queryModeSequence(mode int) string {
+	return "\x1b[?" + strconv.Itoa(mode) + "$p"
+}
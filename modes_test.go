@@ -0,0 +1,22 @@
+package tea
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/muesli/termenv"
+)
+
+func TestSynchronizedOutput(t *testing.T) {
+	var buf bytes.Buffer
+	r := newRenderer(termenv.NewOutput(&buf), false, 0).(*standardRenderer)
+	r.setSynchronizedOutput(true)
+	r.write("hello")
+	r.flush()
+
+	out := buf.String()
+	if !strings.HasPrefix(out, "\x1b[?2026h") || !strings.HasSuffix(out, "\x1b[?2026l") {
+		t.Errorf("expected frame to be wrapped in synchronized output sequences, got %q", out)
+	}
+}
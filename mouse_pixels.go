@@ -0,0 +1,17 @@
+package tea
+
+// queryCellSizeSequence asks the terminal to report the size of its cells in
+// pixels, with XTWINOPS.
+const queryCellSizeSequence = "\x1b[16t"
+
+// pixelMouse translates a mouse event reported in pixels, as parsed like one
+// reported in cells, into one reporting both.
+func (p *Program) pixelMouse(m MouseMsg) MouseMsg {
+	m.PixelX, m.PixelY = m.X, m.Y
+	m.X, m.Y = 0, 0
+	m.CellWidth, m.CellHeight = p.cellWidth, p.cellHeight
+	if p.cellWidth > 0 && p.cellHeight > 0 {
+		m.X, m.Y = m.PixelX/p.cellWidth, m.PixelY/p.cellHeight
+	}
+	return m
+}
@@ -0,0 +1,67 @@
+package tea
+
+import (
+	"bytes"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+type mousePixelsTestModel struct {
+	testModel
+	mouse atomic.Value
+}
+
+func (m *mousePixelsTestModel) Update(msg Msg) (Model, Cmd) {
+	switch msg := msg.(type) {
+	case MouseMsg:
+		m.mouse.Store(msg)
+	case KeyMsg:
+		return m, Quit
+	}
+	return m, nil
+}
+
+func TestMousePixels(t *testing.T) {
+	var buf bytes.Buffer
+	in := bytes.NewBufferString("\x1b[?1016;1$y\x1b[6;18;9t\x1b[<0;20;37Mq")
+
+	m := &mousePixelsTestModel{}
+	p := NewProgram(m, WithInput(in), WithOutput(&buf), WithMouseCellMotion(), WithMousePixels())
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(buf.String(), "\x1b[?1016h") {
+		t.Errorf("expected mouse pixel mode to be enabled, got %q", buf.String())
+	}
+	if !strings.Contains(buf.String(), queryModeSequence(mousePixelsMode)+queryCellSizeSequence) {
+		t.Errorf("expected the terminal to be queried, got %q", buf.String())
+	}
+
+	got, _ := m.mouse.Load().(MouseMsg)
+	want := MouseMsg{
+		X: 2, Y: 2, PixelX: 19, PixelY: 36, CellWidth: 9, CellHeight: 18,
+		Action: MouseActionPress, Button: MouseButtonLeft, Type: MouseLeft, Clicks: 1,
+	}
+	if got != want {
+		t.Errorf("expected %#v, got %#v", want, got)
+	}
+}
+
+func TestMousePixelsUnsupported(t *testing.T) {
+	var buf bytes.Buffer
+	in := bytes.NewBufferString("\x1b[?1016;0$y\x1b[<0;20;37Mq")
+
+	m := &mousePixelsTestModel{}
+	p := NewProgram(m, WithInput(in), WithOutput(&buf), WithMouseCellMotion(), WithMousePixels())
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Mouse events are left in cells.
+	got, _ := m.mouse.Load().(MouseMsg)
+	if got.X != 19 || got.Y != 36 || got.PixelX != 0 {
+		t.Errorf("expected the event to be reported in cells, got %#v", got)
+	}
+}
@@ -0,0 +1,109 @@
+package tea
+
+import (
+	"os"
+	"strings"
+
+	"github.com/aymanbagabas/go-osc52/v2"
+	"github.com/muesli/termenv"
+)
+
+// Multiplexer is a terminal multiplexer, such as tmux or GNU Screen, which a
+// program may be running in.
+//
+// Multiplexers sit between programs and the terminal and swallow the
+// sequences they don't know about, such as the ones setting the clipboard or
+// the kitty keyboard protocol's. Wrapped in a DCS passthrough sequence, they
+// reach the outer terminal instead; see Passthrough and
+// WithMultiplexerPassthrough.
+type Multiplexer int
+
+// Terminal multiplexers.
+const (
+	NoMultiplexer Multiplexer = iota
+	Tmux
+	Screen
+)
+
+// String returns the name of the multiplexer.
+func (m Multiplexer) String() string {
+	switch m {
+	case Tmux:
+		return "tmux"
+	case Screen:
+		return "screen"
+	default:
+		return "none"
+	}
+}
+
+// DetectMultiplexer reports which terminal multiplexer the program is
+// running in, based on the environment.
+func DetectMultiplexer() Multiplexer {
+	return detectMultiplexer(os.Getenv)
+}
+
+func detectMultiplexer(getenv func(string) string) Multiplexer {
+	switch {
+	case getenv("TMUX") != "" || getenv("TERM_PROGRAM") == "tmux":
+		return Tmux
+	case getenv("STY") != "" || strings.HasPrefix(getenv("TERM"), "screen"):
+		return Screen
+	default:
+		return NoMultiplexer
+	}
+}
+
+// Screen drops DCS strings longer than 768 bytes, so long sequences are sent
+// in chunks.
+const screenChunkSize = 76
+
+// Passthrough wraps a sequence in a DCS passthrough sequence, so that the
+// multiplexer hands it over to the outer terminal instead of interpreting it.
+// Sequences are returned unchanged with NoMultiplexer. Note that tmux only
+// lets sequences through with its allow-passthrough option on.
+func (m Multiplexer) Passthrough(seq string) string {
+	switch m {
+	case Tmux:
+		return "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	case Screen:
+		var b strings.Builder
+		for i := 0; i < len(seq); i += screenChunkSize {
+			end := i + screenChunkSize
+			if end > len(seq) {
+				end = len(seq)
+			}
+			b.WriteString("\x1bP" + seq[i:end] + "\x1b\\")
+		}
+		return b.String()
+	default:
+		return seq
+	}
+}
+
+// osc52 sets the mode of an OSC 52 sequence for the multiplexer.
+func (m Multiplexer) osc52(seq osc52.Sequence) osc52.Sequence {
+	switch m {
+	case Tmux:
+		return seq.Tmux()
+	case Screen:
+		return seq.Screen()
+	default:
+		return seq
+	}
+}
+
+// colorProfile returns the color profile to use in the multiplexer. Colors
+// are drawn by the multiplexer itself, so they can't be passed through, but
+// tmux supports true color even when TERM says screen, which makes termenv
+// settle for 256 colors.
+func (m Multiplexer) colorProfile(getenv func(string) string, profile termenv.Profile) termenv.Profile {
+	if m != Tmux || profile != termenv.ANSI256 {
+		return profile
+	}
+	switch strings.ToLower(getenv("COLORTERM")) {
+	case "truecolor", "24bit":
+		return termenv.TrueColor
+	}
+	return profile
+}
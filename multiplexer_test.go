@@ -0,0 +1,92 @@
+package tea
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/muesli/termenv"
+)
+
+func TestDetectMultiplexer(t *testing.T) {
+	tests := []struct {
+		env      map[string]string
+		expected Multiplexer
+	}{
+		{map[string]string{"TERM": "xterm-256color"}, NoMultiplexer},
+		{map[string]string{"TMUX": "/tmp/tmux-1000/default,1234,0", "TERM": "screen-256color"}, Tmux},
+		{map[string]string{"TERM_PROGRAM": "tmux", "TERM": "tmux-256color"}, Tmux},
+		{map[string]string{"STY": "1234.pts-0.host"}, Screen},
+		{map[string]string{"TERM": "screen.xterm-256color"}, Screen},
+	}
+	for _, test := range tests {
+		m := detectMultiplexer(func(k string) string { return test.env[k] })
+		if m != test.expected {
+			t.Errorf("%v: expected %s, got %s", test.env, test.expected, m)
+		}
+	}
+}
+
+func TestPassthrough(t *testing.T) {
+	seq := "\x1b[>1u"
+	if s := NoMultiplexer.Passthrough(seq); s != seq {
+		t.Errorf("expected the sequence unchanged, got %q", s)
+	}
+	if s, exp := Tmux.Passthrough(seq), "\x1bPtmux;\x1b\x1b[>1u\x1b\\"; s != exp {
+		t.Errorf("expected %q, got %q", exp, s)
+	}
+	if s, exp := Screen.Passthrough(seq), "\x1bP\x1b[>1u\x1b\\"; s != exp {
+		t.Errorf("expected %q, got %q", exp, s)
+	}
+
+	// Screen gets long sequences in chunks.
+	long := strings.Repeat("a", screenChunkSize+1)
+	exp := "\x1bP" + long[:screenChunkSize] + "\x1b\\\x1bPa\x1b\\"
+	if s := Screen.Passthrough(long); s != exp {
+		t.Errorf("expected %q, got %q", exp, s)
+	}
+}
+
+func TestMultiplexerColorProfile(t *testing.T) {
+	getenv := func(k string) string {
+		if k == "COLORTERM" {
+			return "truecolor"
+		}
+		return ""
+	}
+	if p := Tmux.colorProfile(getenv, termenv.ANSI256); p != termenv.TrueColor {
+		t.Errorf("expected true color in tmux, got %v", p)
+	}
+	if p := Screen.colorProfile(getenv, termenv.ANSI256); p != termenv.ANSI256 {
+		t.Errorf("expected 256 colors in screen, got %v", p)
+	}
+	if p := Tmux.colorProfile(getenv, termenv.Ascii); p != termenv.Ascii {
+		t.Errorf("expected no colors without a terminal, got %v", p)
+	}
+}
+
+func TestMultiplexerPassthrough(t *testing.T) {
+	var buf bytes.Buffer
+	var in bytes.Buffer
+
+	p := NewProgram(&testModel{}, WithInput(&in), WithOutput(&buf), WithKittyKeyboard(KittyDisambiguateEscapeCodes))
+	p.multiplexer = Tmux
+
+	go p.Send(sequenceMsg{SetWindowTitle("a"), SetClipboard("b"), Quit})
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, seq := range []string{
+		"\x1bPtmux;\x1b\x1b[>1u\x1b\\",
+		"\x1bPtmux;\x1b\x1b[<u\x1b\\",
+		"\x1bPtmux;\x1b\x1b[22;2t\x1b\\",
+		"\x1bPtmux;\x1b\x1b]2;a\a\x1b\\",
+		"\x1bPtmux;\x1b\x1b[23;2t\x1b\\",
+		"\x1bPtmux;\x1b\x1b]52;c;Yg==\a\x1b\\",
+	} {
+		if !strings.Contains(buf.String(), seq) {
+			t.Errorf("expected %q in the output:\n%q", seq, buf.String())
+		}
+	}
+}
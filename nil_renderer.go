@@ -21,4 +21,16 @@ func (n nilRenderer) enableBracketedPaste()      {}
 func (n nilRenderer) disableBracketedPaste()     {}
 func (n nilRenderer) enableMouseSGRMode()        {}
 func (n nilRenderer) disableMouseSGRMode()       {}
+func (n nilRenderer) enableMousePixelsMode()     {}
+func (n nilRenderer) disableMousePixelsMode()    {}
+func (n nilRenderer) queryCellSize()             {}
 func (n nilRenderer) bracketedPasteActive() bool { return false }
+
+func (n nilRenderer) enableKittyKeyboard(_ KittyKeyboardFlags) {}
+func (n nilRenderer) disableKittyKeyboard()                    {}
+func (n nilRenderer) enableModifyOtherKeys()                   {}
+func (n nilRenderer) disableModifyOtherKeys()                  {}
+func (n nilRenderer) setClipboard(_ string)                    {}
+func (n nilRenderer) readClipboard()                           {}
+func (n nilRenderer) setSynchronizedOutput(_ bool)             {}
+func (n nilRenderer) setCursor(_ *Cursor)                      {}
@@ -3,7 +3,9 @@ package tea
 import (
 	"context"
 	"io"
+	"os"
 	"sync/atomic"
+	"time"
 
 	"github.com/muesli/termenv"
 )
@@ -27,6 +29,12 @@ func WithContext(ctx context.Context) ProgramOption {
 
 // WithOutput sets the output which, by default, is stdout. In most cases you
 // won't need to use this.
+//
+// When the output isn't set and stdout isn't a terminal, for instance because
+// it's piped to another program, the program doesn't draw its frames: it
+// prints the final one as plain text when it exits. Input is still read from
+// the terminal, if there's one. Set the output to os.Stdout explicitly to draw
+// with escape sequences regardless.
 func WithOutput(output io.Writer) ProgramOption {
 	return func(p *Program) {
 		if o, ok := output.(*termenv.Output); ok {
@@ -37,10 +45,80 @@ func WithOutput(output io.Writer) ProgramOption {
 	}
 }
 
+// WithOutputMirror copies everything written to the output to the given
+// writer as well, such as a file or a pipe, without changing what the
+// terminal sees. This can be useful for debugging rendering issues or
+// auditing sessions.
+//
+//	f, err := os.Create("session.log")
+//	if err != nil {
+//	    return err
+//	}
+//	defer f.Close()
+//
+//	p := tea.NewProgram(model, tea.WithOutputMirror(f))
+//
+// Errors writing to the mirror are ignored.
+func WithOutputMirror(w io.Writer) ProgramOption {
+	return func(p *Program) {
+		p.outputMirror = w
+	}
+}
+
+// WithRecording records the session to a file at the given path, in the
+// asciicast v2 format used by asciinema. Everything the program writes to
+// the terminal is recorded, along with changes of the terminal size, so the
+// session can be replayed later:
+//
+//	asciinema play session.cast
+//
+// Input is recorded too, so the session can also be played back into the
+// program with WithPlayback. Beware that this includes anything typed, such
+// as passwords.
+//
+// If the file can't be created, Run returns an error.
+func WithRecording(path string) ProgramOption {
+	return func(p *Program) {
+		p.recorder = &recorder{path: path}
+	}
+}
+
+// WithPlayback plays a session recorded with WithRecording back into the
+// program: the recorded input is fed to the program as if it was being typed,
+// and recorded resizes are delivered as WindowSizeMsgs, with the original
+// timing. This is useful for automating demos and for reproducing bugs from
+// a user's recording.
+//
+// The speed scales the timing: 1 plays the session back in real time, 2
+// twice as fast, and so on. If speed isn't positive, 1 is used.
+//
+// Playback replaces the program's input; once it's done, the program gets no
+// more input. If the recording can't be read, Run returns an error.
+func WithPlayback(path string, speed float64) ProgramOption {
+	return func(p *Program) {
+		if speed <= 0 {
+			speed = 1
+		}
+		r, w := io.Pipe()
+		p.player = &player{path: path, speed: speed, r: r, w: w}
+		p.input = r
+		p.inputType = customInput
+	}
+}
+
 // WithInput sets the input which, by default, is stdin. In most cases you
 // won't need to use this. To disable input entirely pass nil.
 //
 //	p := NewProgram(model, WithInput(nil))
+//
+// The input doesn't need to be a file: network connections, SSH channels and
+// other readers work too. As such inputs don't get SIGWINCH, report their
+// size changes, such as SSH window change requests, by sending a
+// WindowSizeMsg with Program.Send:
+//
+//	for win := range windowChanges {
+//	    p.Send(tea.WindowSizeMsg{Width: win.Width, Height: win.Height})
+//	}
 func WithInput(input io.Reader) ProgramOption {
 	return func(p *Program) {
 		p.input = input
@@ -55,6 +133,23 @@ func WithInputTTY() ProgramOption {
 	}
 }
 
+// WithStdinMsgs delivers the data piped to the program's standard input, as
+// in "some-cmd | my-tui", as StdinMsgs, while keys are read from the terminal,
+// which is opened for input when standard input isn't one. Nothing is
+// delivered when standard input is the terminal, or when the program's input
+// was set with WithInput or WithInputTTY.
+//
+//	case tea.StdinMsg:
+//	    m.data = append(m.data, msg.Data...)
+//	    if msg.EOF {
+//	        m.loading = false
+//	    }
+func WithStdinMsgs() ProgramOption {
+	return func(p *Program) {
+		p.startupOptions |= withStdinMsgs
+	}
+}
+
 // WithoutSignalHandler disables the signal handler that Bubble Tea sets up for
 // Programs. This is useful if you want to handle signals yourself.
 func WithoutSignalHandler() ProgramOption {
@@ -63,10 +158,63 @@ func WithoutSignalHandler() ProgramOption {
 	}
 }
 
+// WithSignals subscribes the program to the given OS signals. Whenever one of
+// them is received, fn is called with the signal and the message it returns,
+// if any, is sent to the program's update function. The subscription lasts
+// as long as the program runs.
+//
+// For example, to reload the configuration on SIGHUP:
+//
+//	type reloadMsg struct{}
+//
+//	p := tea.NewProgram(model{}, tea.WithSignals(func(os.Signal) tea.Msg {
+//	    return reloadMsg{}
+//	}, syscall.SIGHUP))
+//
+// WithSignals can be used multiple times to handle different signals with
+// different functions. Functions subscribed to the same signal are each
+// called, in the order they were given. Subscribing to SIGINT or SIGTERM
+// doesn't disable the default signal handler; see WithInterruptMsg and
+// WithoutSignalHandler.
+func WithSignals(fn func(os.Signal) Msg, signals ...os.Signal) ProgramOption {
+	return func(p *Program) {
+		p.signalHandlers = append(p.signalHandlers, signalHandler{
+			signals: signals,
+			fn:      fn,
+		})
+	}
+}
+
+// WithInterruptMsg delivers SIGINT and SIGTERM to the program's update
+// function as an InterruptMsg instead of quitting immediately. This gives
+// models a chance to flush state or ask the user to save before exiting.
+// Models are responsible for returning Quit when they're ready to exit.
+//
+//	func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+//	    switch msg.(type) {
+//	    case tea.InterruptMsg:
+//	        if m.hasChanges {
+//	            m.confirmQuit = true
+//	            return m, nil
+//	        }
+//	        return m, tea.Quit
+//	    }
+//	    return m, nil
+//	}
+//
+// Note that in most cases ctrl+c is delivered as a KeyMsg, as the terminal is
+// in raw mode. This option has no effect if the signal handler is disabled
+// with WithoutSignalHandler.
+func WithInterruptMsg() ProgramOption {
+	return func(p *Program) {
+		p.startupOptions |= withInterruptMsg
+	}
+}
+
 // WithoutCatchPanics disables the panic catching that Bubble Tea does by
-// default. If panic catching is disabled the terminal will be in a fairly
-// unusable state after a panic because Bubble Tea will not perform its usual
-// cleanup on exit.
+// default, where panics are reported by Program.Run as a PanicError. If panic
+// catching is disabled the terminal will be in a fairly unusable state after
+// a panic because Bubble Tea will not perform its usual cleanup on exit.
 func WithoutCatchPanics() ProgramOption {
 	return func(p *Program) {
 		p.startupOptions |= withoutCatchPanics
@@ -108,6 +256,88 @@ func WithoutBracketedPaste() ProgramOption {
 	}
 }
 
+// WithKittyKeyboard starts the program with the given kitty keyboard protocol
+// enhancements enabled. Terminals supporting the protocol will then report
+// keys unambiguously, which makes it possible to tell esc apart from escape
+// sequences without a timeout and to receive combinations such as
+// ctrl+shift+enter. Terminals that don't support the protocol ignore it.
+//
+//	p := tea.NewProgram(Model{}, tea.WithKittyKeyboard(tea.KittyDisambiguateEscapeCodes))
+//
+// The flags will be automatically restored when the program exits.
+func WithKittyKeyboard(flags KittyKeyboardFlags) ProgramOption {
+	return func(p *Program) {
+		p.kittyKeyboardFlags = flags
+	}
+}
+
+// WithAltKeys sets how keys typed after an escape character are reported:
+// with alt, as most terminals report alt with an escape character in front of
+// keys, which is the default, as esc followed by the keys, or by what the
+// terminal reports of its encoding of alt. It applies to the escape
+// characters read from the terminal, and not to Windows console input, which
+// reports alt itself.
+//
+//	p := tea.NewProgram(Model{}, tea.WithKittyKeyboard(tea.KittyDisambiguateEscapeCodes), tea.WithAltKeys(tea.AltKeysAuto))
+func WithAltKeys(mode AltKeys) ProgramOption {
+	return func(p *Program) {
+		p.inputParser.AltKeys = mode
+	}
+}
+
+// WithModifyOtherKeys starts the program with xterm's modifyOtherKeys
+// encoding enabled, so that terminals supporting it, xterm and tmux with
+// extended-keys among them, report combinations such as ctrl+shift+p,
+// ctrl+enter and ctrl+1 as keys of their own. They're reported the same way
+// as with the kitty keyboard protocol, which is preferable where supported.
+//
+// The encoding will be automatically reset when the program exits.
+func WithModifyOtherKeys() ProgramOption {
+	return func(p *Program) {
+		p.startupOptions |= withModifyOtherKeys
+	}
+}
+
+// WithMultiplexerPassthrough makes the sequences which multiplexers swallow
+// reach the outer terminal when the program runs in tmux or GNU Screen: the
+// ones setting the clipboard, the window title and the kitty keyboard
+// protocol are wrapped in DCS passthrough sequences. It also lets programs
+// use true color in tmux when the outer terminal supports it. It has no
+// effect outside of multiplexers.
+//
+// tmux only lets the sequences through with its allow-passthrough option on:
+//
+//	set -g allow-passthrough on
+func WithMultiplexerPassthrough() ProgramOption {
+	return func(p *Program) {
+		p.multiplexer = DetectMultiplexer()
+	}
+}
+
+// WithSynchronizedOutput forces synchronized output (mode 2026) on. When it's
+// enabled, each frame is wrapped in sequences that tell the terminal to hold
+// off drawing until the frame is complete, which eliminates tearing and
+// flicker on large repaints.
+//
+// By default Bubble Tea asks the terminal whether it supports synchronized
+// output and enables it automatically if it does. Use this option for
+// terminals that support it, but don't answer the query.
+func WithSynchronizedOutput() ProgramOption {
+	return func(p *Program) {
+		p.startupOptions |= withSynchronizedOutput     // set
+		p.startupOptions &^= withoutSynchronizedOutput // clear
+	}
+}
+
+// WithoutSynchronizedOutput disables synchronized output (mode 2026), even if
+// the terminal reports that it supports it.
+func WithoutSynchronizedOutput() ProgramOption {
+	return func(p *Program) {
+		p.startupOptions |= withoutSynchronizedOutput // set
+		p.startupOptions &^= withSynchronizedOutput   // clear
+	}
+}
+
 // WithMouseCellMotion starts the program with the mouse enabled in "cell
 // motion" mode.
 //
@@ -130,6 +360,102 @@ func WithMouseCellMotion() ProgramOption {
 	}
 }
 
+// WithMousePixels makes terminals supporting it report mouse events in
+// pixels, for precise interactions such as with images, when the mouse is
+// enabled, with WithMouseCellMotion, WithMouseAllMotion or the commands of the
+// same names. Mouse events then carry the position of the mouse in pixels
+// along with the cell under it, and the size of the cells. Other terminals
+// keep reporting mouse events in cells.
+func WithMousePixels() ProgramOption {
+	return func(p *Program) {
+		p.startupOptions |= withMousePixels
+	}
+}
+
+// WithMultiClickInterval sets the longest time between the clicks of a
+// double or triple click, reported with MouseEvent.Clicks. It's 500ms by
+// default. A value of 0 or less makes every click a single click.
+func WithMultiClickInterval(d time.Duration) ProgramOption {
+	return func(p *Program) {
+		p.multiClickInterval = d
+		p.startupOptions |= withMultiClickInterval
+	}
+}
+
+// WithMotionCoalescing keeps at most one mouse motion event per frame: of
+// the motions in a burst, such as reported when moving the mouse with
+// WithMouseAllMotion, only the last one of each frame reaches Update. Presses,
+// releases and other events are still all delivered, in order, so only the
+// positions the mouse moved through in between are lost. This keeps models
+// that take a while to update from lagging behind the mouse.
+func WithMotionCoalescing() ProgramOption {
+	return func(p *Program) {
+		p.startupOptions |= withMotionCoalescing
+	}
+}
+
+// WithKeySequences registers sequences of keys, such as "g g" or
+// "ctrl+x ctrl+s", to be delivered as a KeySequenceMsg when they're pressed
+// one after the other. Keys are named as by Key.String and separated by
+// spaces.
+//
+// Keys starting a sequence are held back until the next key tells whether
+// the sequence goes on; if it doesn't, or if no key follows within the
+// timeout, one second by default, they're delivered as they are. Keys that
+// don't start any sequence are delivered right away.
+func WithKeySequences(sequences ...string) ProgramOption {
+	return func(p *Program) {
+		p.keySequenceList = append(p.keySequenceList, sequences...)
+	}
+}
+
+// WithKeySequenceTimeout sets how long the program waits for the next key of
+// a key sequence registered with WithKeySequences, one second by default.
+func WithKeySequenceTimeout(d time.Duration) ProgramOption {
+	return func(p *Program) {
+		p.keySequenceTimeout = d
+	}
+}
+
+// WithEscapeTimeout sets how long to wait for the rest of an escape sequence
+// when the input ends in the middle of one, before taking what was read as
+// it is. A lone ESC byte is either the esc key or the start of a sequence,
+// such as the one the up arrow sends, and the rest of the sequence may come
+// late over slow connections such as SSH, in which case the keys would be
+// read as esc followed by text. Waiting a little longer tells them apart at
+// the cost of delaying the esc key by as much.
+//
+// By default there's no timeout, and what was read is taken as it is right
+// away, which suits programs in which esc reacts at once. Terminals
+// supporting the kitty keyboard protocol, enabled with WithKittyKeyboard,
+// report the esc key unambiguously, which makes the timeout unnecessary.
+func WithEscapeTimeout(d time.Duration) ProgramOption {
+	return func(p *Program) {
+		p.inputParser.EscapeTimeout = d
+	}
+}
+
+// WithInputSequences makes the program report escape sequences of its own as
+// the given messages, such as the sequences terminals are configured to send
+// for keys they don't report otherwise:
+//
+//	p := tea.NewProgram(model{}, tea.WithInputSequences(map[string]tea.Msg{
+//	    "\x1b[27;5;13~": saveMsg{},
+//	}))
+//
+// They're matched ahead of the sequences known to Bubble Tea, and so can
+// override them.
+func WithInputSequences(sequences map[string]Msg) ProgramOption {
+	return func(p *Program) {
+		if p.inputParser.Sequences == nil {
+			p.inputParser.Sequences = map[string]Msg{}
+		}
+		for seq, msg := range sequences {
+			p.inputParser.Sequences[seq] = msg
+		}
+	}
+}
+
 // WithMouseAllMotion starts the program with the mouse enabled in "all motion"
 // mode.
 //
@@ -169,8 +495,38 @@ func WithoutRenderer() ProgramOption {
 	}
 }
 
+// WithHeadlessRenderer renders the program with the given HeadlessRenderer,
+// which keeps the latest frame in memory instead of drawing it. Anything else
+// the program would write to the terminal, such as window titles, is
+// discarded. To keep the program away from the terminal entirely, disable
+// input too:
+//
+//	r := tea.NewHeadlessRenderer()
+//	p := tea.NewProgram(model, tea.WithHeadlessRenderer(r), tea.WithInput(nil))
+func WithHeadlessRenderer(r *HeadlessRenderer) ProgramOption {
+	return func(p *Program) {
+		p.renderer = r
+		p.output = termenv.NewOutput(io.Discard)
+	}
+}
+
+// WithRenderer renders the program with a custom Renderer instead of drawing
+// it on the terminal. As with WithHeadlessRenderer, anything else the program
+// would write to the terminal, such as window titles, is discarded.
+func WithRenderer(r Renderer) ProgramOption {
+	return func(p *Program) {
+		p.renderer = newCustomRenderer(r)
+		p.output = termenv.NewOutput(io.Discard)
+	}
+}
+
 // WithANSICompressor removes redundant ANSI sequences to produce potentially
-// smaller output, at the cost of some processing overhead.
+// smaller output, at the cost of some processing overhead. The style
+// sequences between two characters are coalesced into the one changing the
+// style of the terminal to what it should be, which is nothing at all for
+// resets followed by the same style, and adjacent cursor movements are
+// merged. Heavily styled views, such as made with Lip Gloss, get much smaller,
+// which pays off over slow links.
 //
 // This feature is provisional, and may be changed or removed in a future version
 // of this package.
@@ -180,6 +536,58 @@ func WithANSICompressor() ProgramOption {
 	}
 }
 
+// WithCellRenderer renders the program with the cell renderer, which parses
+// each frame into a grid of styled cells and only paints the cells which
+// changed since the last frame, with as few cursor movements and style changes
+// as possible. This pays off for dashboards and other views of which only a few
+// cells change at a time, at the cost of some processing overhead.
+//
+// Frames holding escape sequences other than colors and text attributes, such
+// as hyperlinks, are painted line by line, as usual.
+func WithCellRenderer() ProgramOption {
+	return func(p *Program) {
+		p.startupOptions |= withCellRenderer
+	}
+}
+
+// WithAccessibleRenderer renders the program for screen readers. Rather than
+// drawing each frame with cursor movements and styles, which screen readers
+// can't make sense of, the lines of the view which changed are printed as
+// plain text, one after the other, a few times a second at most. Models can
+// narrow down what's printed to the parts of the view which matter by
+// implementing LiveRegionModel, and announce messages with Announce.
+//
+// The accessible renderer is also used when the ACCESSIBLE environment
+// variable is set to 1, t, T, true, True or TRUE, so that users can turn it on
+// for any program. Other values, such as 0 or false, leave it off.
+func WithAccessibleRenderer() ProgramOption {
+	return func(p *Program) {
+		p.startupOptions |= withAccessibleRenderer
+	}
+}
+
+// WithImageProtocol sets the protocol images are drawn with, rather than
+// detecting it from the environment, such as for terminals which don't
+// announce themselves in it, or for programs served over SSH, whose
+// environment isn't the terminal's. NoImageProtocol turns images off.
+func WithImageProtocol(protocol ImageProtocol) ProgramOption {
+	return func(p *Program) {
+		p.startupOptions |= withImageProtocol
+		p.imageProtocol = protocol
+	}
+}
+
+// WithTerm sets the terminal type, as in TERM, whose capabilities the
+// renderer looks up in the terminfo database, rather than taking it from the
+// environment, for programs drawing to terminals other than the one they run
+// in, such as programs served over SSH. The output needn't be a terminal.
+func WithTerm(term string) ProgramOption {
+	return func(p *Program) {
+		p.startupOptions |= withTerm
+		p.term = term
+	}
+}
+
 // WithFilter supplies an event filter that will be invoked before Bubble Tea
 // processes a tea.Msg. The event filter can return any tea.Msg which will then
 // get handled by Bubble Tea instead of the original event. If the event filter
@@ -215,11 +623,212 @@ func WithFilter(filter func(Model, Msg) Msg) ProgramOption {
 	}
 }
 
+// UpdateHook is a middleware function wrapped around the model's Update
+// function. It receives every message and a next function which passes the
+// message on to the next hook, or to the model's Update function for the last
+// hook, and returns the resulting command.
+//
+// Hooks can observe messages and commands, replace messages before they reach
+// the model, or short-circuit the update entirely by not calling next.
+type UpdateHook func(msg Msg, next func(Msg) Cmd) Cmd
+
+// WithUpdateHook adds a middleware function around the model's Update
+// function, for concerns that cut across the whole program, such as logging,
+// metrics, undo recording or access control. It can be used multiple times;
+// hooks are run in the order they were added, with the first one outermost.
+//
+// Unlike a filter, hooks run after Bubble Tea has processed the message, so
+// they can't prevent special messages such as QuitMsg from taking effect.
+//
+// Example:
+//
+//	func logUpdates(msg tea.Msg, next func(tea.Msg) tea.Cmd) tea.Cmd {
+//		start := time.Now()
+//		cmd := next(msg)
+//		log.Printf("%T took %s", msg, time.Since(start))
+//		return cmd
+//	}
+//
+//	p := tea.NewProgram(Model{}, tea.WithUpdateHook(logUpdates))
+func WithUpdateHook(hook UpdateHook) ProgramOption {
+	return func(p *Program) {
+		p.updateHooks = append(p.updateHooks, hook)
+	}
+}
+
 // WithFPS sets a custom maximum FPS at which the renderer should run. If
 // less than 1, the default value of 60 will be used. If over 120, the FPS
 // will be capped at 120.
-func WithFPS(fps int) ProgramOption {
+//
+// Given two values, a minimum and a maximum, the frame rate is adaptive: the
+// renderer drops to the minimum FPS after a second without messages and
+// ramps back up to the maximum as soon as one arrives, such as a key press.
+// This saves power in long-lived programs which are idle most of the time,
+// such as on battery-powered machines.
+//
+//	p := tea.NewProgram(model, tea.WithFPS(1, 60))
+func WithFPS(fps ...int) ProgramOption {
+	return func(p *Program) {
+		switch len(fps) {
+		case 0:
+		case 1:
+			p.fps = fps[0]
+		default:
+			p.minFPS, p.fps = fps[0], fps[1]
+		}
+	}
+}
+
+// WithOverflow sets what the renderer does with the lines of a view wider
+// than the window: cut them, which is the default, wrap them, or cut them
+// short and end them with an ellipsis. Either way, lines never wrap on their
+// own, which would throw rendering off, so views don't need to cut every line
+// themselves.
+func WithOverflow(overflow Overflow) ProgramOption {
+	return func(p *Program) {
+		p.overflow = overflow
+	}
+}
+
+// WithTabWidth sets the width of the tab stops the tabs in views are expanded
+// to, which is 8 cells by default. Tabs are expanded to spaces before frames
+// are painted, since terminals move the cursor over them rather than painting
+// over what's in between, which leaves bits of the last frame behind. A width
+// of 0 or less leaves tabs to the terminal.
+func WithTabWidth(n int) ProgramOption {
+	return func(p *Program) {
+		p.startupOptions |= withTabWidth
+		p.tabWidth = n
+	}
+}
+
+// WithEastAsianAmbiguousWidth sets the width, 1 or 2 cells, of the
+// characters whose width depends on the terminal and its font, such as Greek
+// and Cyrillic letters and some symbols, which CJK terminals usually draw two
+// cells wide. They're taken to be one cell wide by default.
+//
+// The width applies to the frames of the program only: the renderer measures
+// them with it to cut and paint their lines. StringWidth always takes the
+// characters to be one cell wide.
+func WithEastAsianAmbiguousWidth(width int) ProgramOption {
+	return func(p *Program) {
+		if width == 1 || width == 2 {
+			p.ambiguousWidth = width
+		}
+	}
+}
+
+// WithReservedHeight reserves a number of lines at the bottom of the terminal
+// for the program when it's not in the alt screen. Every frame is rendered
+// into exactly these lines: shorter views are padded with blank lines, and
+// the top lines of taller ones are cut off. This keeps inline programs from
+// jumping around as the height of their view changes from frame to frame.
+//
+// The lines are capped at the height of the terminal. A value of 0 or less
+// does nothing.
+func WithReservedHeight(lines int) ProgramOption {
+	return func(p *Program) {
+		p.reservedHeight = lines
+	}
+}
+
+// WithMaxBytesPerSecond limits the bandwidth the renderer uses to about n
+// bytes per second. After each frame, the next one is held off until the
+// frame would have gone through at that rate, and frames rendered in the
+// meantime are skipped in favor of the latest one. On slow connections, such
+// as SSH sessions over laggy links or serial lines, the frame rate drops
+// rather than output piling up, which would leave the program lagging behind
+// its input.
+//
+// A value of 0 or less means no limit, which is the default.
+func WithMaxBytesPerSecond(n int) ProgramOption {
+	return func(p *Program) {
+		p.maxBytesPerSecond = n
+	}
+}
+
+// WithClock sets the clock used for the program's timers, such as the ones
+// started by Tick, Every and Cron, in place of the system clock. This makes
+// it possible to drive animations, debouncing and timeouts deterministically
+// in tests, by advancing a virtual clock rather than waiting; see
+// teatest.Clock.
+func WithClock(c Clock) ProgramOption {
+	return func(p *Program) {
+		p.clock = c
+	}
+}
+
+// WithMetrics sets a Metrics implementation to receive measurements of the
+// program, such as how long Update calls and commands take, how many messages
+// are waiting to be processed and how many frames are drawn.
+func WithMetrics(m Metrics) ProgramOption {
+	return func(p *Program) {
+		p.metrics = m
+	}
+}
+
+// WithMessageTrace records every message given to the model's Update
+// function to w, along with the time it was received and its type, as one
+// JSON-encoded TraceEntry per line. Replay the trace with ReplayTrace to debug
+// how the model got into a given state after the fact.
+//
+// Messages that can't be encoded as JSON, such as ones holding functions, are
+// recorded with their type only. Write errors are ignored.
+func WithMessageTrace(w io.Writer) ProgramOption {
+	return func(p *Program) {
+		p.tracer = newTracer(w)
+	}
+}
+
+// WithTimeTravel records the model after every update, keeping the latest
+// limit snapshots, or 1000 if limit isn't positive, so that the program can go
+// back through them with ToggleTimeTravel, TimeTravelBack and
+// TimeTravelForward, the views of past models being shown in place of the
+// current one. It's meant to help debug how the model got into a given state.
+//
+// Models are recorded as they are unless they implement Snapshotter, which
+// models holding state their Update function changes in place, such as
+// slices, maps or pointers, should do so that past snapshots aren't changed
+// along with the model.
+func WithTimeTravel(limit int) ProgramOption {
+	return func(p *Program) {
+		p.timeTravel = newTimeTravel(limit)
+	}
+}
+
+// WithStatePersistence saves the state of the model to the given store when
+// the program quits, and restores it from there when it starts again, if the
+// model is a PersistentModel:
+//
+//	p := tea.NewProgram(model, tea.WithStatePersistence(tea.StateFile(path)))
+//
+// The state is saved when the program quits by itself, but not when it's
+// killed or panics. If the state can't be restored, Run returns an error
+// right away; if it can't be saved, Run returns the error along with the
+// final model.
+func WithStatePersistence(store StateStore) ProgramOption {
+	return func(p *Program) {
+		p.stateStore = store
+	}
+}
+
+// WithCommandWorkers runs commands on a fixed pool of n workers rather than
+// on a goroutine each. Commands are queued until a worker is free, so
+// programs batching hundreds of commands don't spike the number of
+// goroutines. Set a Metrics implementing WorkerMetrics with WithMetrics to
+// keep an eye on the queue.
+//
+// Commands hold a worker for as long as they run, so make sure there's enough
+// of them for long-running commands, e.g. ones waiting for events, not to
+// starve the others. The commands the program runs itself, such as the steps
+// of Sequence and the commands batched within them, and the ones of Retry,
+// Race and WithTimeout, run on the pool too. Sequences, timers, such as the
+// ones started by Tick, and Stream jobs don't hold a worker while they wait.
+//
+// If n is less than 1, commands each run on their own goroutine, which is the
+// default.
+func WithCommandWorkers(n int) ProgramOption {
 	return func(p *Program) {
-		p.fps = fps
+		p.cmdWorkers = n
 	}
 }
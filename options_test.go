@@ -2,8 +2,10 @@ package tea
 
 import (
 	"bytes"
+	"os"
 	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestOptions(t *testing.T) {
@@ -15,6 +17,24 @@ func TestOptions(t *testing.T) {
 		}
 	})
 
+	t.Run("output mirror", func(t *testing.T) {
+		f, err := os.CreateTemp(t.TempDir(), "output")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close() //nolint:errcheck
+
+		var mirror bytes.Buffer
+		p := NewProgram(nil, WithOutputMirror(&mirror), WithOutput(f))
+		if p.output.TTY() == nil || p.output.TTY().Fd() != f.Fd() {
+			t.Errorf("expected output to still expose the file")
+		}
+		_, _ = p.output.WriteString("hi")
+		if mirror.String() != "hi" {
+			t.Errorf("expected output to be mirrored, got %q", mirror.String())
+		}
+	})
+
 	t.Run("custom input", func(t *testing.T) {
 		var b bytes.Buffer
 		p := NewProgram(nil, WithInput(&b))
@@ -50,6 +70,43 @@ func TestOptions(t *testing.T) {
 		}
 	})
 
+	t.Run("kitty keyboard", func(t *testing.T) {
+		p := NewProgram(nil, WithKittyKeyboard(KittyDisambiguateEscapeCodes|KittyReportEventTypes))
+		if p.kittyKeyboardFlags != KittyDisambiguateEscapeCodes|KittyReportEventTypes {
+			t.Errorf("expected kitty keyboard flags to be set, got %v", p.kittyKeyboardFlags)
+		}
+	})
+
+	t.Run("alt keys", func(t *testing.T) {
+		p := NewProgram(nil, WithAltKeys(AltKeysAuto))
+		if p.inputParser.AltKeys != AltKeysAuto {
+			t.Errorf("expected alt keys to be %v, got %v", AltKeysAuto, p.inputParser.AltKeys)
+		}
+	})
+
+	t.Run("stdin messages", func(t *testing.T) {
+		p := NewProgram(nil, WithStdinMsgs())
+		if !p.startupOptions.has(withStdinMsgs) {
+			t.Errorf("expected startup options to have %v, got %v", withStdinMsgs, p.startupOptions)
+		}
+	})
+
+	t.Run("modify other keys", func(t *testing.T) {
+		p := NewProgram(nil, WithModifyOtherKeys())
+		if !p.startupOptions.has(withModifyOtherKeys) {
+			t.Errorf("expected startup options to have %v, got %v", withModifyOtherKeys, p.startupOptions)
+		}
+	})
+
+	t.Run("multi-click interval", func(t *testing.T) {
+		if p := NewProgram(nil); p.multiClickInterval != defaultMultiClickInterval {
+			t.Errorf("expected a multi-click interval of %v, got %v", defaultMultiClickInterval, p.multiClickInterval)
+		}
+		if p := NewProgram(nil, WithMultiClickInterval(0)); p.multiClickInterval != 0 {
+			t.Errorf("expected no multi-click interval, got %v", p.multiClickInterval)
+		}
+	})
+
 	t.Run("input options", func(t *testing.T) {
 		exercise := func(t *testing.T, opt ProgramOption, expect inputType) {
 			p := NewProgram(nil, opt)
@@ -69,6 +126,73 @@ func TestOptions(t *testing.T) {
 
 	})
 
+	t.Run("adaptive fps", func(t *testing.T) {
+		p := NewProgram(nil, WithFPS(1, 30))
+		if p.minFPS != 1 || p.fps != 30 {
+			t.Errorf("expected fps between 1 and 30, got %d and %d", p.minFPS, p.fps)
+		}
+	})
+
+	t.Run("max bytes per second", func(t *testing.T) {
+		p := NewProgram(nil, WithMaxBytesPerSecond(9600))
+		if p.maxBytesPerSecond != 9600 {
+			t.Errorf("expected max bytes per second to be 9600, got %d", p.maxBytesPerSecond)
+		}
+	})
+
+	t.Run("reserved height", func(t *testing.T) {
+		p := NewProgram(nil, WithReservedHeight(5))
+		if p.reservedHeight != 5 {
+			t.Errorf("expected reserved height to be 5, got %d", p.reservedHeight)
+		}
+	})
+
+	t.Run("overflow", func(t *testing.T) {
+		p := NewProgram(nil, WithOverflow(OverflowWrap))
+		if p.overflow != OverflowWrap {
+			t.Errorf("expected lines to be wrapped, got %v", p.overflow)
+		}
+	})
+
+	t.Run("tab width", func(t *testing.T) {
+		p := NewProgram(nil, WithTabWidth(4))
+		if !p.startupOptions.has(withTabWidth) || p.tabWidth != 4 {
+			t.Errorf("expected tab width to be 4, got %d", p.tabWidth)
+		}
+	})
+
+	t.Run("east asian ambiguous width", func(t *testing.T) {
+		p := NewProgram(nil, WithEastAsianAmbiguousWidth(2))
+		if p.ambiguousWidth != 2 {
+			t.Errorf("expected ambiguous width to be 2, got %d", p.ambiguousWidth)
+		}
+		if p := NewProgram(nil, WithEastAsianAmbiguousWidth(3)); p.ambiguousWidth != 0 {
+			t.Errorf("expected ambiguous width to be unset, got %d", p.ambiguousWidth)
+		}
+	})
+
+	t.Run("image protocol", func(t *testing.T) {
+		p := NewProgram(nil, WithImageProtocol(SixelImageProtocol))
+		if !p.startupOptions.has(withImageProtocol) || p.imageProtocol != SixelImageProtocol {
+			t.Errorf("expected the image protocol to be Sixel, got %v", p.imageProtocol)
+		}
+	})
+
+	t.Run("time travel", func(t *testing.T) {
+		p := NewProgram(nil, WithTimeTravel(0))
+		if p.timeTravel == nil || p.timeTravel.limit != defaultTimeTravelLimit {
+			t.Errorf("expected time travel with the default limit, got %+v", p.timeTravel)
+		}
+	})
+
+	t.Run("state persistence", func(t *testing.T) {
+		store := StateFile("state")
+		p := NewProgram(nil, WithStatePersistence(store))
+		if p.stateStore != store {
+			t.Errorf("expected the state store to be set, got %v", p.stateStore)
+		}
+	})
+
 	t.Run("startup options", func(t *testing.T) {
 		exercise := func(t *testing.T, opt ProgramOption, expect startupOptions) {
 			p := NewProgram(nil, opt)
@@ -89,10 +213,36 @@ func TestOptions(t *testing.T) {
 			exercise(t, WithANSICompressor(), withANSICompressor)
 		})
 
+		t.Run("cell renderer", func(t *testing.T) {
+			exercise(t, WithCellRenderer(), withCellRenderer)
+		})
+
+		t.Run("accessible renderer", func(t *testing.T) {
+			exercise(t, WithAccessibleRenderer(), withAccessibleRenderer)
+		})
+
 		t.Run("without catch panics", func(t *testing.T) {
 			exercise(t, WithoutCatchPanics(), withoutCatchPanics)
 		})
 
+		t.Run("synchronized output", func(t *testing.T) {
+			p := NewProgram(nil, WithoutSynchronizedOutput(), WithSynchronizedOutput())
+			if !p.startupOptions.has(withSynchronizedOutput) {
+				t.Errorf("expected startup options have %v, got %v", withSynchronizedOutput, p.startupOptions)
+			}
+			if p.startupOptions.has(withoutSynchronizedOutput) {
+				t.Errorf("expected startup options not have %v, got %v", withoutSynchronizedOutput, p.startupOptions)
+			}
+		})
+
+		t.Run("without synchronized output", func(t *testing.T) {
+			exercise(t, WithoutSynchronizedOutput(), withoutSynchronizedOutput)
+		})
+
+		t.Run("interrupt msg", func(t *testing.T) {
+			exercise(t, WithInterruptMsg(), withInterruptMsg)
+		})
+
 		t.Run("without signal handler", func(t *testing.T) {
 			exercise(t, WithoutSignalHandler(), withoutSignalHandler)
 		})
@@ -130,3 +280,19 @@ func TestOptions(t *testing.T) {
 		}
 	})
 }
+
+type inputTestMsg string
+
+func TestWithInputSequences(t *testing.T) {
+	p := NewProgram(nil,
+		WithInputSequences(map[string]Msg{"a": inputTestMsg("a")}),
+		WithInputSequences(map[string]Msg{"b": inputTestMsg("b")}),
+		WithEscapeTimeout(time.Millisecond),
+	)
+	if len(p.inputParser.Sequences) != 2 {
+		t.Errorf("expected both sets of sequences, got %v", p.inputParser.Sequences)
+	}
+	if p.inputParser.EscapeTimeout != time.Millisecond {
+		t.Errorf("expected the escape timeout to be kept, got %v", p.inputParser.EscapeTimeout)
+	}
+}
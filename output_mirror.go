@@ -0,0 +1,49 @@
+package tea
+
+import (
+	"io"
+
+	"github.com/muesli/termenv"
+)
+
+// mirrorWriter copies everything written to it to a mirror, without letting
+// the mirror affect writes to the output.
+type mirrorWriter struct {
+	out    io.Writer
+	mirror io.Writer
+}
+
+// Write implements io.Writer. Errors writing to the mirror are ignored.
+func (w *mirrorWriter) Write(b []byte) (int, error) {
+	n, err := w.out.Write(b)
+	if n > 0 {
+		_, _ = w.mirror.Write(b[:n])
+	}
+	return n, err
+}
+
+// mirrorFile is a mirrorWriter for a terminal. It still exposes the
+// terminal's file descriptor, so that the terminal can be detected and
+// queried through it.
+type mirrorFile struct {
+	termenv.File
+	mirrorWriter
+}
+
+// Write implements io.Writer.
+func (f *mirrorFile) Write(b []byte) (int, error) {
+	return f.mirrorWriter.Write(b)
+}
+
+// mirrorOutput returns an output writing to both out and the mirror, with the
+// same color profile as out.
+func mirrorOutput(out *termenv.Output, mirror io.Writer) *termenv.Output {
+	var w io.Writer = &mirrorWriter{out: out, mirror: mirror}
+	if f := out.TTY(); f != nil {
+		w = &mirrorFile{File: f, mirrorWriter: mirrorWriter{out: out, mirror: mirror}}
+	}
+	return termenv.NewOutput(w,
+		termenv.WithProfile(out.Profile),
+		termenv.WithColorCache(true),
+	)
+}
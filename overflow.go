@@ -0,0 +1,156 @@
+package tea
+
+import (
+	"strings"
+
+	"github.com/muesli/termenv"
+)
+
+// Overflow is what the renderer does with the lines of a view wider than the
+// window.
+type Overflow int
+
+// Overflow policies.
+const (
+	// OverflowTruncate cuts lines at the width of the window.
+	OverflowTruncate Overflow = iota
+
+	// OverflowWrap wraps lines onto the lines below them, between words
+	// where possible. Styles and hyperlinks carry on to the wrapped lines.
+	// Since wrapping makes the view taller, lines placed by position, such
+	// as the cursor, scroll areas and images, should be kept from wrapping.
+	OverflowWrap
+
+	// OverflowEllipsis cuts lines short of the width of the window and ends
+	// them with an ellipsis, which tells that there's more to the line.
+	OverflowEllipsis
+)
+
+// overflowMarker is what lines cut with OverflowEllipsis end with.
+const overflowMarker = "…"
+
+// fitWidth fits the lines of a frame to the width of the window, by the
+// overflow policy. Tabs are expanded beforehand, as terminals move the cursor
+// over tabs rather than painting over what was there, and hyperlinks left
+// open at the end of a line are ended, as they would spill over whatever is
+// painted next otherwise.
+func (r *standardRenderer) fitWidth(lines []string) []string {
+	var wrapped []string
+	for i, line := range lines {
+		if r.tabWidth > 0 && strings.IndexByte(line, '\t') >= 0 {
+			line = r.widths.expandTabs(line, r.tabWidth)
+			lines[i] = line
+		}
+
+		// Most lines fit, and measuring them is much cheaper than fitting
+		// them.
+		fits := r.width <= 0 || len(line) <= r.width || r.widths.stringWidth(line) <= r.width
+		switch {
+		case !fits && r.overflow == OverflowWrap:
+			if wrapped == nil {
+				wrapped = make([]string, i, len(lines)+1)
+				copy(wrapped, lines[:i])
+			}
+			wrapped = append(wrapped, r.widths.wrapLine(line, r.width)...)
+			continue
+		case !fits && r.overflow == OverflowEllipsis:
+			lines[i] = r.widths.truncateLine(line, r.width-r.widths.stringWidth(overflowMarker)) + overflowMarker
+		case !fits:
+			lines[i] = r.widths.truncateLine(line, r.width)
+		case strings.Contains(line, "\x1b]8;") && hyperlinkOpen(line):
+			lines[i] = line + hyperlinkEnd
+		}
+		if wrapped != nil {
+			wrapped = append(wrapped, lines[i])
+		}
+	}
+	if wrapped != nil {
+		return wrapped
+	}
+	return lines
+}
+
+// wrapPoint is a point in a line where it's wrapped, along with the style and
+// the hyperlink in effect there.
+type wrapPoint struct {
+	at        int
+	sgr, link string
+}
+
+// wrapLine wraps a line to a width in cells, after the last space before the
+// width if there's one, or right at it otherwise. Wrapped lines end with their
+// style reset and their hyperlink ended, and the lines they're wrapped onto
+// start with them set again.
+func (m cellWidths) wrapLine(s string, width int) []string {
+	var (
+		lines     []string
+		start     wrapPoint
+		space     wrapPoint
+		hasSpace  bool
+		col       int
+		spaceCol  int
+		sgr, link string
+	)
+	wrap := func(end wrapPoint) {
+		line := start.sgr + start.link + s[start.at:end.at]
+		if end.sgr != "" {
+			line += termenv.CSI + termenv.ResetSeq + "m"
+		}
+		if end.link != "" {
+			line += hyperlinkEnd
+		}
+		lines = append(lines, line)
+		start = end
+	}
+
+	for i := 0; i < len(s); {
+		if s[i] == '\x1b' {
+			end, _ := sequenceEnd(s, i)
+			sgr, link = wrapState(sgr, link, s[i:end])
+			i = end
+			continue
+		}
+
+		cluster, w := m.firstGrapheme(s[i:])
+		for col+w > width && col > 0 {
+			if hasSpace {
+				wrap(space)
+				col -= spaceCol
+				hasSpace = false
+			} else {
+				wrap(wrapPoint{i, sgr, link})
+				col = 0
+			}
+		}
+		col += w
+		i += len(cluster)
+		if cluster == " " {
+			space, spaceCol, hasSpace = wrapPoint{i, sgr, link}, col, true
+		}
+	}
+	wrap(wrapPoint{len(s), sgr, link})
+	return lines
+}
+
+// wrapState returns the style and the hyperlink in effect after a sequence,
+// given the ones in effect before it. Styles are kept as the SGR sequences
+// setting them since the last reset.
+func wrapState(sgr, link, seq string) (string, string) {
+	switch {
+	case strings.HasPrefix(seq, termenv.CSI) && strings.HasSuffix(seq, "m"):
+		params := seq[len(termenv.CSI) : len(seq)-1]
+		if first := params[:strings.IndexByte(params+";", ';')]; first == "" || first == termenv.ResetSeq {
+			sgr = ""
+			if params == first {
+				return sgr, link
+			}
+		}
+		sgr += seq
+	case strings.HasPrefix(seq, "\x1b]8;"):
+		link = ""
+		if hyperlinkOpen(seq) {
+			link = seq
+		}
+	}
+	return sgr, link
+}
@@ -0,0 +1,70 @@
+package tea
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/muesli/termenv"
+)
+
+func TestWrapLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		width    int
+		expected []string
+	}{
+		{"words", "ab cd ef", 5, []string{"ab ", "cd ef"}},
+		{"long word", "abcdefgh", 3, []string{"abc", "def", "gh"}},
+		{"word after space", "ab cdefgh", 5, []string{"ab ", "cdefg", "h"}},
+		{"wide", "a世界", 3, []string{"a世", "界"}},
+		{
+			"styled",
+			"\x1b[1mab cd\x1b[0m e",
+			3,
+			[]string{"\x1b[1mab \x1b[0m", "\x1b[1mcd\x1b[0m ", "e"},
+		},
+		{
+			"hyperlink",
+			Hyperlink("https://example.com", "abcd"),
+			2,
+			[]string{
+				"\x1b]8;;https://example.com\x1b\\ab" + hyperlinkEnd,
+				"\x1b]8;;https://example.com\x1b\\cd" + hyperlinkEnd,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := cellWidths(0).wrapLine(test.line, test.width); !equalLines(got, test.expected) {
+				t.Errorf("expected %q, got %q", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestStandardRendererOverflow(t *testing.T) {
+	tests := []struct {
+		name     string
+		overflow Overflow
+		expected []string
+	}{
+		{"truncate", OverflowTruncate, []string{"ab c", "x"}},
+		{"wrap", OverflowWrap, []string{"ab ", "cdef", "gh", "x"}},
+		{"ellipsis", OverflowEllipsis, []string{"ab …", "x"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			r := newRenderer(termenv.NewOutput(&buf), false, 0).(*standardRenderer)
+			r.width = 4
+			r.overflow = test.overflow
+
+			r.write("ab cdefgh\nx")
+			r.flush()
+			if !equalLines(r.lastLines, test.expected) {
+				t.Errorf("expected %q, got %q", test.expected, r.lastLines)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package tea
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError is returned by Program.Run when the program panics, be it in
+// Init, Update or View, or in a command. By the time Run returns, the
+// terminal has been restored, so the error can be printed as usual:
+//
+//	if _, err := p.Run(); err != nil {
+//	    var perr *tea.PanicError
+//	    if errors.As(err, &perr) {
+//	        log.Printf("crashed: %v\n%s", perr.Value, perr.Stack)
+//	    }
+//	    os.Exit(1)
+//	}
+//
+// Panics aren't caught if the program was started with WithoutCatchPanics.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value interface{}
+
+	// Stack is the stack trace of the goroutine that panicked.
+	Stack []byte
+}
+
+// newPanicError returns a PanicError for a recovered value. It must be called
+// from the deferred function recovering it, for the stack to be the one of
+// the panic.
+func newPanicError(r interface{}) *PanicError {
+	return &PanicError{Value: r, Stack: debug.Stack()}
+}
+
+// Error implements error. The message includes the stack trace.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("program panicked: %v\n\n%s", e.Value, e.Stack)
+}
+
+// Unwrap returns the value passed to panic, if it's an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// recoverFromGoPanic recovers from a panic in one of the goroutines started
+// by the program, such as the ones running commands, and stops the program,
+// which returns a PanicError. It must be deferred.
+func (p *Program) recoverFromGoPanic() {
+	if p.startupOptions.has(withoutCatchPanics) {
+		return
+	}
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	p.panicMtx.Lock()
+	if p.panicErr == nil {
+		p.panicErr = newPanicError(r)
+	}
+	p.panicMtx.Unlock()
+
+	// Stop the program right away: it's likely in a broken state.
+	p.cancel()
+}
@@ -0,0 +1,90 @@
+package tea
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+type panicMsg struct{}
+
+// panicModel sends itself a message on startup, and panics when it receives
+// it.
+type panicModel struct {
+	*testModel
+}
+
+func (m panicModel) Init() Cmd {
+	return func() Msg { return panicMsg{} }
+}
+
+func (m panicModel) Update(msg Msg) (Model, Cmd) {
+	if _, ok := msg.(panicMsg); ok {
+		panic("boom")
+	}
+	return m, nil
+}
+
+func TestPanicError(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	tests := []struct {
+		name  string
+		model Model
+	}{
+		{
+			name:  "update",
+			model: panicModel{&testModel{}},
+		},
+		{
+			name: "command",
+			model: initCmdModel{&testModel{}, func() Msg {
+				panic(errBoom)
+			}},
+		},
+		{
+			name: "sequence",
+			model: initCmdModel{&testModel{}, Sequence(func() Msg {
+				panic(errBoom)
+			})},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			p := NewProgram(test.model, WithInput(nil), WithOutput(&buf))
+
+			model, err := p.Run()
+			var perr *PanicError
+			if !errors.As(err, &perr) {
+				t.Fatalf("expected a PanicError, got %v", err)
+			}
+			if model != nil {
+				t.Errorf("expected no model, got %#v", model)
+			}
+			if len(perr.Stack) == 0 || !strings.Contains(err.Error(), "panic_test.go") {
+				t.Errorf("expected the stack trace in the error, got %q", err)
+			}
+			if test.name != "update" && !errors.Is(err, errBoom) {
+				t.Errorf("expected the error to wrap the panic value, got %v", err)
+			}
+		})
+	}
+}
+
+func TestPanicErrorInView(t *testing.T) {
+	p := NewProgram(panicViewModel{}, WithInput(nil), WithOutput(io.Discard))
+	_, err := p.Run()
+	var perr *PanicError
+	if !errors.As(err, &perr) || perr.Value != "view" {
+		t.Fatalf("expected a PanicError, got %v", err)
+	}
+}
+
+type panicViewModel struct{}
+
+func (panicViewModel) Init() Cmd                 { return nil }
+func (m panicViewModel) Update(Msg) (Model, Cmd) { return m, nil }
+func (panicViewModel) View() string              { panic("view") }
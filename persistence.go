@@ -0,0 +1,115 @@
+package tea
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// PersistentModel is implemented by models whose state is saved when the
+// program quits and restored when it starts again, with WithStatePersistence,
+// so that users can pick up where they left off: scroll positions, selections,
+// drafts and so on.
+type PersistentModel interface {
+	Model
+
+	// MarshalState encodes the state of the model to be saved.
+	MarshalState() ([]byte, error)
+
+	// UnmarshalState returns the model with the state decoded from data, as
+	// saved by MarshalState, possibly by an older version of the program.
+	// It's called on the initial model, before its Init function. Data it
+	// can't make sense of should rather be ignored than make it fail, since
+	// failing keeps the program from starting.
+	UnmarshalState(data []byte) (Model, error)
+}
+
+// StateStore stores the state of a model between runs of a program.
+type StateStore interface {
+	// Load returns the state saved last, or nil if there's none.
+	Load() ([]byte, error)
+
+	// Save saves the state, replacing the one saved last.
+	Save(data []byte) error
+}
+
+// StateFile returns a StateStore keeping the state in a file at the given
+// path. The file is replaced as a whole when the state is saved, so that it's
+// never left half-written, and the directory it's in is created if needed.
+func StateFile(path string) StateStore {
+	return stateFile(path)
+}
+
+// stateFile is a StateStore keeping the state in a file.
+type stateFile string
+
+// Load implements StateStore.
+func (f stateFile) Load() ([]byte, error) {
+	data, err := os.ReadFile(string(f))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// Save implements StateStore.
+func (f stateFile) Save(data []byte) error {
+	dir := filepath.Dir(string(f))
+	if err := os.MkdirAll(dir, 0o700); err != nil { //nolint:gomnd
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(string(f))+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close() //nolint:errcheck
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), string(f))
+}
+
+// restoreState restores the state of the initial model from the state store,
+// if it's a PersistentModel and there's a state to restore.
+func (p *Program) restoreState() error {
+	m, ok := p.initialModel.(PersistentModel)
+	if p.stateStore == nil || !ok {
+		return nil
+	}
+	data, err := p.stateStore.Load()
+	if err != nil {
+		return fmt.Errorf("error loading state: %w", err)
+	}
+	if data == nil {
+		return nil
+	}
+	model, err := m.UnmarshalState(data)
+	if err != nil {
+		return fmt.Errorf("error restoring state: %w", err)
+	}
+	p.initialModel = model
+	return nil
+}
+
+// saveState saves the state of the final model to the state store, if it's a
+// PersistentModel.
+func (p *Program) saveState(model Model) error {
+	m, ok := model.(PersistentModel)
+	if p.stateStore == nil || !ok {
+		return nil
+	}
+	data, err := m.MarshalState()
+	if err != nil {
+		return fmt.Errorf("error saving state: %w", err)
+	}
+	if err := p.stateStore.Save(data); err != nil {
+		return fmt.Errorf("error saving state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package tea
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+type persistentModel struct {
+	count int
+	init  Cmd
+}
+
+func (m persistentModel) Init() Cmd {
+	return m.init
+}
+
+func (m persistentModel) Update(msg Msg) (Model, Cmd) {
+	if _, ok := msg.(counterMsg); ok {
+		m.count++
+	}
+	return m, nil
+}
+
+func (m persistentModel) View() string {
+	return strconv.Itoa(m.count)
+}
+
+func (m persistentModel) MarshalState() ([]byte, error) {
+	return []byte(strconv.Itoa(m.count)), nil
+}
+
+func (m persistentModel) UnmarshalState(data []byte) (Model, error) {
+	n, err := strconv.Atoi(string(data))
+	if err != nil {
+		return nil, err
+	}
+	m.count = n
+	return m, nil
+}
+
+func TestStateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dir", "state")
+	f := StateFile(path)
+
+	if data, err := f.Load(); err != nil || data != nil {
+		t.Fatalf("expected no state, got %q, %v", data, err)
+	}
+	for _, want := range []string{"first", "second"} {
+		if err := f.Save([]byte(want)); err != nil {
+			t.Fatal(err)
+		}
+		if data, err := f.Load(); err != nil || string(data) != want {
+			t.Errorf("expected %q, got %q, %v", want, data, err)
+		}
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected no temporary files to be left, got %d entries", len(entries))
+	}
+}
+
+func TestStatePersistence(t *testing.T) {
+	store := StateFile(filepath.Join(t.TempDir(), "state"))
+	m := persistentModel{init: Sequence(
+		func() Msg { return counterMsg{} },
+		func() Msg { return counterMsg{} },
+		Quit,
+	)}
+
+	for _, want := range []int{2, 4} {
+		p := NewProgram(m, WithHeadlessRenderer(NewHeadlessRenderer()), WithInput(nil), WithStatePersistence(store))
+		final, err := p.Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := final.(persistentModel).count; got != want {
+			t.Errorf("expected the count to carry on to %d, got %d", want, got)
+		}
+	}
+	if data, _ := store.Load(); string(data) != "4" {
+		t.Errorf("expected the state to be saved, got %q", data)
+	}
+}
+
+type brokenStore struct {
+	data []byte
+	err  error
+}
+
+func (s brokenStore) Load() ([]byte, error) { return s.data, s.err }
+func (s brokenStore) Save([]byte) error     { return s.err }
+
+func TestStatePersistenceErrors(t *testing.T) {
+	errBroken := errors.New("broken")
+	m := persistentModel{init: Quit}
+
+	p := NewProgram(m, WithHeadlessRenderer(NewHeadlessRenderer()), WithInput(nil), WithStatePersistence(brokenStore{err: errBroken}))
+	if _, err := p.Run(); !errors.Is(err, errBroken) {
+		t.Errorf("expected the load error, got %v", err)
+	}
+
+	p = NewProgram(m, WithHeadlessRenderer(NewHeadlessRenderer()), WithInput(nil), WithStatePersistence(brokenStore{data: []byte("x")}))
+	if _, err := p.Run(); err == nil {
+		t.Error("expected an error restoring the state")
+	}
+
+	// Models that aren't persistent are left alone.
+	p = NewProgram(initCmdModel{testModel: &testModel{}, init: Quit},
+		WithHeadlessRenderer(NewHeadlessRenderer()), WithInput(nil), WithStatePersistence(brokenStore{err: errBroken}))
+	if _, err := p.Run(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
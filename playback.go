@@ -0,0 +1,131 @@
+package tea
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// player plays a session recorded with WithRecording back into a program:
+// recorded input is fed to the program as if it was typed, and recorded
+// resizes are delivered as WindowSizeMsgs, with the original timing.
+type player struct {
+	path  string
+	speed float64
+
+	// The program reads its input from r, which the player writes to.
+	r *io.PipeReader
+	w *io.PipeWriter
+
+	header asciicastHeader
+	events []asciicastEvent
+}
+
+// asciicastEvent is an event of an asciicast v2 recording.
+type asciicastEvent struct {
+	time time.Duration
+	code string
+	data string
+}
+
+// load reads the recording.
+func (pl *player) load() error {
+	f, err := os.Open(pl.path)
+	if err != nil {
+		return fmt.Errorf("error opening recording: %w", err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20) //nolint:gomnd
+	if !scanner.Scan() {
+		return fmt.Errorf("error reading recording %s: missing header", pl.path)
+	}
+	if err := json.Unmarshal(scanner.Bytes(), &pl.header); err != nil {
+		return fmt.Errorf("error reading recording header: %w", err)
+	}
+	if pl.header.Version != 2 { //nolint:gomnd
+		return fmt.Errorf("error reading recording: unsupported version %d", pl.header.Version)
+	}
+
+	for scanner.Scan() {
+		var e [3]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return fmt.Errorf("error reading recording event: %w", err)
+		}
+		t, ok1 := e[0].(float64)
+		code, ok2 := e[1].(string)
+		data, ok3 := e[2].(string)
+		if !ok1 || !ok2 || !ok3 {
+			return fmt.Errorf("error reading recording: invalid event %s", scanner.Text())
+		}
+		if code != "i" && code != "r" {
+			// Output isn't played back: the program renders it anew.
+			continue
+		}
+		pl.events = append(pl.events, asciicastEvent{
+			time: time.Duration(t / pl.speed * float64(time.Second)),
+			code: code,
+			data: data,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading recording: %w", err)
+	}
+	return nil
+}
+
+// play plays the recording back into the program. Once it's done, the input
+// is closed.
+func (p *Program) play() chan struct{} {
+	ch := make(chan struct{})
+	pl := p.player
+
+	// Unblock any pending write when the program exits.
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-p.ctx.Done():
+			_ = pl.r.Close()
+		case <-done:
+		}
+	}()
+
+	go func() {
+		defer close(ch)
+		defer close(done)
+		defer pl.w.Close() //nolint:errcheck
+
+		if pl.header.Width > 0 && pl.header.Height > 0 {
+			p.Send(WindowSizeMsg{Width: pl.header.Width, Height: pl.header.Height})
+		}
+
+		start := time.Now()
+		for _, e := range pl.events {
+			t := time.NewTimer(time.Until(start.Add(e.time)))
+			select {
+			case <-p.ctx.Done():
+				t.Stop()
+				return
+			case <-t.C:
+			}
+
+			switch e.code {
+			case "i":
+				if _, err := io.WriteString(pl.w, e.data); err != nil {
+					return
+				}
+			case "r":
+				var w, h int
+				if _, err := fmt.Sscanf(e.data, "%dx%d", &w, &h); err == nil {
+					p.Send(WindowSizeMsg{Width: w, Height: h})
+				}
+			}
+		}
+	}()
+
+	return ch
+}
@@ -0,0 +1,101 @@
+package tea
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+type playbackModel struct {
+	msgs []Msg
+}
+
+func (m *playbackModel) Init() Cmd {
+	return nil
+}
+
+func (m *playbackModel) Update(msg Msg) (Model, Cmd) {
+	switch msg := msg.(type) {
+	case WindowSizeMsg:
+		m.msgs = append(m.msgs, msg)
+	case KeyMsg:
+		m.msgs = append(m.msgs, msg.String())
+		if msg.String() == "q" {
+			return m, Quit
+		}
+	}
+	return m, nil
+}
+
+func (m *playbackModel) View() string {
+	return "playback\n"
+}
+
+func TestPlayback(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.cast")
+	cast := `{"version": 2, "width": 90, "height": 20}
+[0.1, "o", "ignored"]
+[0.2, "r", "100x30"]
+[0.3, "i", "a"]
+[0.4, "i", "q"]
+`
+	if err := os.WriteFile(path, []byte(cast), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	m := &playbackModel{}
+	p := NewProgram(m, WithOutput(&buf), WithPlayback(path, 10))
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Msg{
+		WindowSizeMsg{Width: 90, Height: 20},
+		WindowSizeMsg{Width: 100, Height: 30},
+		"a",
+		"q",
+	}
+	if !reflect.DeepEqual(m.msgs, expected) {
+		t.Fatalf("expected %v, got %v", expected, m.msgs)
+	}
+}
+
+func TestPlaybackRecording(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.cast")
+
+	// Record a session...
+	var buf bytes.Buffer
+	var in bytes.Buffer
+	in.WriteString("q")
+	p := NewProgram(&playbackModel{}, WithInput(&in), WithOutput(&buf), WithRecording(path))
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	// ...and play it back.
+	m := &playbackModel{}
+	p = NewProgram(m, WithOutput(&buf), WithPlayback(path, 1))
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Msg{
+		WindowSizeMsg{Width: defaultRecordingWidth, Height: defaultRecordingHeight},
+		"q",
+	}
+	if !reflect.DeepEqual(m.msgs, expected) {
+		t.Fatalf("expected %v, got %v", expected, m.msgs)
+	}
+}
+
+func TestPlaybackError(t *testing.T) {
+	var buf bytes.Buffer
+	path := filepath.Join(t.TempDir(), "missing.cast")
+	p := NewProgram(&playbackModel{}, WithOutput(&buf), WithPlayback(path, 1))
+	if _, err := p.Run(); err == nil {
+		t.Fatal("expected an error")
+	}
+}
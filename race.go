@@ -0,0 +1,79 @@
+package tea
+
+import "context"
+
+// Race produces a command that runs the given commands concurrently and
+// delivers the first message one of them returns, such as the reply of the
+// quickest of several mirrors. Nil messages don't count, so nothing is
+// delivered if all the commands return nil. The context of the commands made
+// with Cancelable is done once one of them wins, which cancels their work; the
+// messages of other commands are dropped once they complete.
+//
+//	cmd := tea.Race(
+//	    tea.Cancelable(fetchFrom(primary)),
+//	    tea.Cancelable(fetchFrom(mirror)),
+//	)
+//
+// Scheduled commands take part like any other, so racing a command against a
+// Tick gives it a deadline, delivering the message of the tick if the command
+// doesn't complete first. Commands made with Race must be run by a program,
+// like Tick.
+func Race(cmds ...Cmd) Cmd {
+	var valid []Cmd
+	for _, cmd := range cmds {
+		if cmd != nil {
+			valid = append(valid, cmd)
+		}
+	}
+	if len(valid) == 0 {
+		return nil
+	}
+	return func() Msg {
+		return raceMsg{cmds: valid}
+	}
+}
+
+// raceMsg is an internal message used to have the program race commands. The
+// message of the winner goes through out, if set.
+type raceMsg struct {
+	cmds []Cmd
+	out  func(Msg) Msg
+}
+
+func (r raceMsg) run(p *Program, _ context.Context) Msg {
+	return p.runRace(r)
+}
+
+func (r raceMsg) then(final, _ func(Msg) Msg) Msg {
+	r.out = chain(r.out, final)
+	return r
+}
+
+// runRace runs commands concurrently and returns the first message one of
+// them returns. It returns nil if none of them returns a message, or if the
+// program exits first.
+func (p *Program) runRace(r raceMsg) Msg {
+	ctx, cancel := context.WithCancel(p.ctx)
+	defer cancel()
+
+	results := make(chan Msg, len(r.cmds))
+	for _, cmd := range r.cmds {
+		cmd := cmd
+		go func() {
+			defer p.recoverFromGoPanic()
+			results <- p.settle(ctx, p.callOnWorker(cmd))
+		}()
+	}
+
+	for range r.cmds {
+		select {
+		case msg := <-results:
+			if msg != nil {
+				return through(r.out, msg)
+			}
+		case <-p.ctx.Done():
+			return nil
+		}
+	}
+	return nil
+}
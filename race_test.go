@@ -0,0 +1,59 @@
+package tea
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRace(t *testing.T) {
+	if Race(nil, nil) != nil {
+		t.Fatal("expected nil")
+	}
+
+	t.Run("first wins", func(t *testing.T) {
+		p := NewProgram(nil)
+		canceled := make(chan struct{})
+		msg := p.runCmd(Race(
+			Cancelable(func(ctx context.Context) Msg {
+				<-ctx.Done()
+				close(canceled)
+				return "slow"
+			}),
+			func() Msg { return "fast" },
+		))
+		if msg != "fast" {
+			t.Errorf("expected %q, got %#v", "fast", msg)
+		}
+		<-canceled
+	})
+
+	t.Run("nil messages", func(t *testing.T) {
+		p := NewProgram(nil)
+		if msg := p.runCmd(Race(func() Msg { return nil }, func() Msg { return "a" })); msg != "a" {
+			t.Errorf("expected %q, got %#v", "a", msg)
+		}
+		if msg := p.runCmd(Race(func() Msg { return nil }, func() Msg { return nil })); msg != nil {
+			t.Errorf("expected no message, got %#v", msg)
+		}
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		clock := manualClock{timers: make(chan instantTimer, 1)}
+		p := NewProgram(nil, WithClock(clock))
+		block := make(chan struct{})
+		defer close(block)
+
+		done := make(chan Msg)
+		go func() {
+			done <- p.runCmd(Race(
+				func() Msg { <-block; return "late" },
+				Tick(time.Second, func(time.Time) Msg { return "deadline" }),
+			))
+		}()
+		(<-clock.timers) <- time.Time{}
+		if msg := <-done; msg != "deadline" {
+			t.Errorf("expected %q, got %#v", "deadline", msg)
+		}
+	})
+}
@@ -0,0 +1,165 @@
+package tea
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// Terminal size assumed for recordings when the output isn't a terminal.
+const (
+	defaultRecordingWidth  = 80
+	defaultRecordingHeight = 24
+)
+
+// recorder records a session in the asciicast v2 format, as used by
+// asciinema: a header line followed by one line per output, input or resize
+// event, each timestamped relative to the start of the recording.
+//
+// See: https://docs.asciinema.org/manual/asciicast/v2/
+type recorder struct {
+	path string
+
+	mtx   sync.Mutex
+	w     io.WriteCloser
+	start time.Time
+}
+
+// asciicastHeader is the first line of an asciicast v2 recording.
+type asciicastHeader struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// open creates the recording file and writes its header.
+func (r *recorder) open(width, height int) error {
+	f, err := os.Create(r.path)
+	if err != nil {
+		return fmt.Errorf("error creating recording: %w", err)
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.w = f
+	r.start = time.Now()
+
+	h := asciicastHeader{
+		Version:   2, //nolint:gomnd
+		Width:     width,
+		Height:    height,
+		Timestamp: r.start.Unix(),
+	}
+	if t := os.Getenv("TERM"); t != "" {
+		h.Env = map[string]string{"TERM": t}
+	}
+	return r.writeLine(h)
+}
+
+// Write records output. It implements io.Writer, so the recorder can be used
+// as an output mirror. Output written before the recording is opened or
+// after it's closed is ignored.
+func (r *recorder) Write(b []byte) (int, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.w == nil {
+		return len(b), nil
+	}
+	if err := r.writeEvent("o", string(b)); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// input records input read from the terminal.
+func (r *recorder) input(b []byte) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.w == nil {
+		return
+	}
+	_ = r.writeEvent("i", string(b))
+}
+
+// recordingReader records everything read from an input.
+type recordingReader struct {
+	r   io.Reader
+	rec *recorder
+}
+
+// Read implements io.Reader.
+func (rr recordingReader) Read(b []byte) (int, error) {
+	n, err := rr.r.Read(b)
+	if n > 0 {
+		rr.rec.input(b[:n])
+	}
+	return n, err
+}
+
+// resize records a change of the terminal size.
+func (r *recorder) resize(width, height int) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.w == nil {
+		return
+	}
+	_ = r.writeEvent("r", fmt.Sprintf("%dx%d", width, height))
+}
+
+// close closes the recording file.
+func (r *recorder) close() error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.w == nil {
+		return nil
+	}
+	err := r.w.Close()
+	r.w = nil
+	return err
+}
+
+// writeEvent writes an event line. r.mtx must be held.
+func (r *recorder) writeEvent(code, data string) error {
+	elapsed := time.Since(r.start).Seconds()
+	return r.writeLine([]interface{}{elapsed, code, data})
+}
+
+// writeLine writes v as a line of JSON. r.mtx must be held.
+func (r *recorder) writeLine(v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("error encoding recording: %w", err)
+	}
+	if _, err := r.w.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("error writing recording: %w", err)
+	}
+	return nil
+}
+
+// startRecording opens the recording requested with WithRecording, if any,
+// using the current size of the terminal.
+func (p *Program) startRecording() error {
+	if p.recorder == nil {
+		return nil
+	}
+
+	w, h := defaultRecordingWidth, defaultRecordingHeight
+	if f, ok := p.output.TTY().(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		if tw, th, err := term.GetSize(int(f.Fd())); err == nil {
+			w, h = tw, th
+		}
+	}
+	return p.recorder.open(w, h)
+}
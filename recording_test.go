@@ -0,0 +1,83 @@
+package tea
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecording(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.cast")
+
+	var buf bytes.Buffer
+	var in bytes.Buffer
+
+	p := NewProgram(&testModel{}, WithInput(&in), WithOutput(&buf), WithRecording(path))
+	go func() {
+		p.Send(WindowSizeMsg{Width: 100, Height: 30})
+		p.Quit()
+	}()
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	scanner := bufio.NewScanner(f)
+	if !scanner.Scan() {
+		t.Fatal("expected a header")
+	}
+	var header asciicastHeader
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil {
+		t.Fatal(err)
+	}
+	if header.Version != 2 || header.Width != defaultRecordingWidth || header.Height != defaultRecordingHeight {
+		t.Errorf("unexpected header: %+v", header)
+	}
+
+	var output strings.Builder
+	var resizes []string
+	for scanner.Scan() {
+		var event []interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatal(err)
+		}
+		if len(event) != 3 {
+			t.Fatalf("unexpected event: %v", event)
+		}
+		switch event[1] {
+		case "o":
+			output.WriteString(event[2].(string))
+		case "r":
+			resizes = append(resizes, event[2].(string))
+		default:
+			t.Fatalf("unexpected event: %v", event)
+		}
+	}
+
+	if output.String() != buf.String() {
+		t.Errorf("expected recorded output %q, got %q", buf.String(), output.String())
+	}
+	if len(resizes) != 1 || resizes[0] != "100x30" {
+		t.Errorf("expected a single resize to 100x30, got %v", resizes)
+	}
+}
+
+func TestRecordingError(t *testing.T) {
+	var buf bytes.Buffer
+	var in bytes.Buffer
+
+	path := filepath.Join(t.TempDir(), "missing", "session.cast")
+	p := NewProgram(&testModel{}, WithInput(&in), WithOutput(&buf), WithRecording(path))
+	if _, err := p.Run(); err == nil {
+		t.Fatal("expected an error")
+	}
+}
@@ -0,0 +1,95 @@
+package tea
+
+import "strings"
+
+// regions are the header and footer pinned to the top and the bottom of the
+// view, and the height of the window they're pinned in.
+type regions struct {
+	header, footer string
+	height         int
+}
+
+type setHeaderMsg string
+
+// SetHeader pins a header, such as a tab bar, above the model's view. The
+// header stays in place while the view underneath changes, so that it doesn't
+// need to be part of each view. It's kept until it's set again; set it to an
+// empty string to remove it.
+//
+// When the view is too tall for the window, its top lines are dropped rather
+// than the header's. Positions within the view, such as the cursor's or those
+// of layers, are relative to the view, below the header.
+func SetHeader(header string) Cmd {
+	return func() Msg {
+		return setHeaderMsg(header)
+	}
+}
+
+type setFooterMsg string
+
+// SetFooter pins a footer, such as a status bar, below the model's view. As
+// with SetHeader, the footer stays in place until it's set again. On the
+// alternate screen, the footer is kept on the bottom lines of the window,
+// however tall the view is.
+func SetFooter(footer string) Cmd {
+	return func() Msg {
+		return setFooterMsg(footer)
+	}
+}
+
+// viewHeight returns the number of lines left for the view between the header
+// and the footer, or 0 if the height of the window isn't known.
+func (r *regions) viewHeight() int {
+	if r.height <= 0 {
+		return 0
+	}
+	room := r.height
+	for _, region := range []string{r.header, r.footer} {
+		if region != "" {
+			room -= strings.Count(strings.TrimSuffix(region, "\n"), "\n") + 1
+		}
+	}
+	if room < 0 {
+		room = 0
+	}
+	return room
+}
+
+// pin adds the header and the footer to a view, if any, and returns the
+// number of lines of the header.
+func (r *regions) pin(view string, altScreen bool) (string, int) {
+	if r.header == "" && r.footer == "" {
+		return view, 0
+	}
+
+	var header, footer []string
+	if r.header != "" {
+		header = strings.Split(strings.TrimSuffix(r.header, "\n"), "\n")
+	}
+	if r.footer != "" {
+		footer = strings.Split(strings.TrimSuffix(r.footer, "\n"), "\n")
+		// A view ending with a newline would leave a blank line above the
+		// footer.
+		view = strings.TrimSuffix(view, "\n")
+	}
+
+	lines := strings.Split(view, "\n")
+	if r.height > 0 {
+		room := r.height - len(header) - len(footer)
+		if room < 0 {
+			room = 0
+		}
+		if len(lines) > room {
+			lines = lines[len(lines)-room:]
+		}
+		for altScreen && len(footer) > 0 && len(lines) < room {
+			lines = append(lines, "")
+		}
+	}
+
+	all := make([]string, 0, len(header)+len(lines)+len(footer))
+	all = append(all, header...)
+	all = append(all, lines...)
+	all = append(all, footer...)
+	return strings.Join(all, "\n"), len(header)
+}
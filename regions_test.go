@@ -0,0 +1,94 @@
+package tea
+
+import (
+	"testing"
+)
+
+func TestRegionsPin(t *testing.T) {
+	tests := []struct {
+		name        string
+		regions     regions
+		altScreen   bool
+		view        string
+		expected    string
+		headerLines int
+	}{
+		{
+			name:     "no regions",
+			view:     "view\n",
+			expected: "view\n",
+		},
+		{
+			name:        "header and footer",
+			regions:     regions{header: "tabs\n", footer: "status"},
+			view:        "view\n",
+			expected:    "tabs\nview\nstatus",
+			headerLines: 1,
+		},
+		{
+			name:        "tall view",
+			regions:     regions{header: "tabs", footer: "status", height: 4},
+			view:        "1\n2\n3\n4",
+			expected:    "tabs\n3\n4\nstatus",
+			headerLines: 1,
+		},
+		{
+			name:      "footer at the bottom of the alt screen",
+			regions:   regions{footer: "status", height: 4},
+			altScreen: true,
+			view:      "1",
+			expected:  "1\n\n\nstatus",
+		},
+		{
+			name:     "short inline view",
+			regions:  regions{footer: "status", height: 4},
+			view:     "1",
+			expected: "1\nstatus",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, headerLines := test.regions.pin(test.view, test.altScreen)
+			if got != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, got)
+			}
+			if headerLines != test.headerLines {
+				t.Errorf("expected %d header lines, got %d", test.headerLines, headerLines)
+			}
+		})
+	}
+}
+
+type regionsModel struct{}
+
+func (m regionsModel) Init() Cmd {
+	return Sequence(SetHeader("header"), SetFooter("footer"), Quit)
+}
+
+func (m regionsModel) Update(Msg) (Model, Cmd) {
+	return m, nil
+}
+
+func (m regionsModel) View() string {
+	return "view\n"
+}
+
+func (m regionsModel) Cursor() *Cursor {
+	return &Cursor{X: 1, Y: 0}
+}
+
+func TestSetHeaderFooter(t *testing.T) {
+	r := NewHeadlessRenderer()
+	p := NewProgram(regionsModel{}, WithHeadlessRenderer(r), WithInput(nil))
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	if f := r.LastFrame(); f != "header\nview\nfooter" {
+		t.Errorf("expected the header and footer around the view, got %q", f)
+	}
+	if c := r.Cursor(); c == nil || *c != (Cursor{X: 1, Y: 1}) {
+		t.Errorf("expected the cursor below the header, got %v", c)
+	}
+}
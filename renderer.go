@@ -57,6 +57,16 @@ type renderer interface {
 	// disableMouseSGRMode disables mouse extended mode (SGR).
 	disableMouseSGRMode()
 
+	// enableMousePixelsMode enables mouse pixel mode (SGR-Pixels), and asks
+	// the terminal whether it supports it and for the size of its cells.
+	enableMousePixelsMode()
+
+	// disableMousePixelsMode disables mouse pixel mode (SGR-Pixels).
+	disableMousePixelsMode()
+
+	// queryCellSize asks the terminal for the size of its cells in pixels.
+	queryCellSize()
+
 	// enableBracketedPaste enables bracketed paste, where characters
 	// inside the input are not interpreted when pasted as a whole.
 	enableBracketedPaste()
@@ -67,6 +77,39 @@ type renderer interface {
 	// bracketedPasteActive reports whether bracketed paste mode is
 	// currently enabled.
 	bracketedPasteActive() bool
+
+	// enableKittyKeyboard pushes the given kitty keyboard protocol
+	// enhancement flags onto the terminal's flag stack.
+	enableKittyKeyboard(flags KittyKeyboardFlags)
+
+	// disableKittyKeyboard pops the kitty keyboard protocol enhancement flags
+	// pushed by enableKittyKeyboard, if any.
+	disableKittyKeyboard()
+
+	// enableModifyOtherKeys makes the terminal report modified keys with
+	// xterm's modifyOtherKeys encoding.
+	enableModifyOtherKeys()
+
+	// disableModifyOtherKeys resets the modifyOtherKeys encoding enabled by
+	// enableModifyOtherKeys, if any.
+	disableModifyOtherKeys()
+
+	// setClipboard sets the system clipboard using OSC 52.
+	setClipboard(string)
+
+	// readClipboard asks the terminal to report the contents of the system
+	// clipboard using OSC 52.
+	readClipboard()
+
+	// setSynchronizedOutput sets whether frames should be wrapped in
+	// synchronized output sequences (mode 2026) so the terminal draws them
+	// atomically.
+	setSynchronizedOutput(bool)
+
+	// setCursor sets where the hardware cursor should be placed after each
+	// frame, relative to the top-left corner of the view. A nil cursor
+	// leaves it at the renderer's discretion.
+	setCursor(*Cursor)
 }
 
 // repaintMsg forces a full repaint.
@@ -0,0 +1,147 @@
+package tea
+
+import (
+	"context"
+	"time"
+)
+
+// RetryPolicy is how Retry retries a command. Fields left at zero take their
+// defaults.
+type RetryPolicy struct {
+	// MaxAttempts is how many times the command is run at most, the first
+	// time included. It defaults to 3.
+	MaxAttempts int
+
+	// Delay is how long to wait before running the command again the first
+	// time. It defaults to 100ms.
+	Delay time.Duration
+
+	// Multiplier is what the delay is multiplied by after each attempt, for
+	// the delays between attempts to grow exponentially. It defaults to 2.
+	Multiplier float64
+
+	// MaxDelay caps the delay between attempts. The delay is uncapped by
+	// default.
+	MaxDelay time.Duration
+}
+
+// Default retry policy values.
+const (
+	defaultRetryAttempts   = 3
+	defaultRetryDelay      = 100 * time.Millisecond
+	defaultRetryMultiplier = 2
+)
+
+// delay returns how long to wait after the given failed attempt, counting
+// from 1.
+func (r RetryPolicy) delay(attempt int) time.Duration {
+	d, mult := r.Delay, r.Multiplier
+	if d <= 0 {
+		d = defaultRetryDelay
+	}
+	if mult <= 0 {
+		mult = defaultRetryMultiplier
+	}
+	for i := 1; i < attempt; i++ {
+		d = time.Duration(float64(d) * mult)
+		if r.MaxDelay > 0 && d >= r.MaxDelay {
+			break
+		}
+	}
+	if r.MaxDelay > 0 && d > r.MaxDelay {
+		d = r.MaxDelay
+	}
+	return d
+}
+
+func (r RetryPolicy) maxAttempts() int {
+	if r.MaxAttempts <= 0 {
+		return defaultRetryAttempts
+	}
+	return r.MaxAttempts
+}
+
+// RetryMsg reports that an attempt at running a command retried with Retry
+// failed, and that it will be run again after Delay.
+type RetryMsg struct {
+	// Attempt is the attempt that failed, counting from 1.
+	Attempt int
+
+	// Err is the error message the attempt returned.
+	Err error
+
+	// Delay is how long until the next attempt.
+	Delay time.Duration
+}
+
+// Retry produces a command that runs cmd, and runs it again if it returns an
+// error message, such as the ErrMsg of a command made with Try, waiting
+// longer before each attempt, as told by the policy. A RetryMsg is delivered
+// ahead of each new attempt, for programs to show progress. The message of
+// the last attempt is delivered, be it an error or not.
+//
+//	func fetch(url string) tea.Cmd {
+//	    return tea.Retry(tea.Try(func() (tea.Msg, error) {
+//	        return get(url)
+//	    }), tea.RetryPolicy{MaxAttempts: 5, Delay: time.Second})
+//	}
+//
+// The delays are waited for with the program's clock, so commands made with
+// Retry must be run by a program, like Tick, and they're dropped once the
+// program exits.
+func Retry(cmd Cmd, policy RetryPolicy) Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() Msg {
+		return retryMsg{cmd: cmd, policy: policy}
+	}
+}
+
+// retryMsg is an internal message used to have the program run a command with
+// retries. The messages delivered go through out, if set, for them to be
+// wrapped or checked once they're produced.
+type retryMsg struct {
+	cmd    Cmd
+	policy RetryPolicy
+	out    func(Msg) Msg
+}
+
+func (r retryMsg) run(p *Program, _ context.Context) Msg {
+	return p.runRetry(r)
+}
+
+func (r retryMsg) then(final, progress func(Msg) Msg) Msg {
+	r.out = chain(r.out, func(msg Msg) Msg {
+		if _, ok := msg.(RetryMsg); ok {
+			return progress(msg)
+		}
+		return final(msg)
+	})
+	return r
+}
+
+// runRetry runs a command with retries and returns the message of the last
+// attempt. It returns nil if the program exits first.
+func (p *Program) runRetry(r retryMsg) Msg {
+	for attempt := 1; ; attempt++ {
+		msg := p.runCmd(r.cmd)
+		err, failed := msg.(error)
+		if !failed || attempt >= r.policy.maxAttempts() {
+			return through(r.out, msg)
+		}
+
+		d := r.policy.delay(attempt)
+		if progress := through(r.out, RetryMsg{Attempt: attempt, Err: err, Delay: d}); progress != nil {
+			p.Send(progress)
+		}
+
+		t := p.clock.NewTimer(d)
+		select {
+		case <-p.ctx.Done():
+			t.Stop()
+			return nil
+		case <-t.C():
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package tea
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy RetryPolicy
+		want   []time.Duration
+	}{
+		{"defaults", RetryPolicy{}, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond}},
+		{"multiplier", RetryPolicy{Delay: time.Second, Multiplier: 3}, []time.Duration{time.Second, 3 * time.Second, 9 * time.Second}},
+		{"capped", RetryPolicy{Delay: time.Second, MaxDelay: 3 * time.Second}, []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for i, want := range test.want {
+				if got := test.policy.delay(i + 1); got != want {
+					t.Errorf("expected a delay of %v after attempt %d, got %v", want, i+1, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRetry(t *testing.T) {
+	broken := errors.New("broken")
+	failing := func(failures int) Cmd {
+		attempts := 0
+		return func() Msg {
+			attempts++
+			if attempts <= failures {
+				return ErrMsg{Err: broken}
+			}
+			return "done"
+		}
+	}
+
+	tests := []struct {
+		name     string
+		cmd      Cmd
+		policy   RetryPolicy
+		progress []RetryMsg
+		want     Msg
+	}{
+		{"success", failing(0), RetryPolicy{}, nil, "done"},
+		{
+			"eventual success",
+			failing(2),
+			RetryPolicy{Delay: time.Second},
+			[]RetryMsg{
+				{Attempt: 1, Err: ErrMsg{Err: broken}, Delay: time.Second},
+				{Attempt: 2, Err: ErrMsg{Err: broken}, Delay: 2 * time.Second},
+			},
+			"done",
+		},
+		{
+			"failure",
+			failing(5),
+			RetryPolicy{MaxAttempts: 2},
+			[]RetryMsg{{Attempt: 1, Err: ErrMsg{Err: broken}, Delay: 100 * time.Millisecond}},
+			ErrMsg{Err: broken},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := NewProgram(nil, WithClock(instantClock{}))
+			done := make(chan Msg)
+			go func() { done <- p.runCmd(Retry(test.cmd, test.policy)) }()
+
+			var progress []RetryMsg
+			for {
+				select {
+				case msg := <-p.msgs:
+					progress = append(progress, msg.(RetryMsg))
+					continue
+				case msg := <-done:
+					if !reflect.DeepEqual(progress, test.progress) {
+						t.Errorf("expected progress %v, got %v", test.progress, progress)
+					}
+					if !reflect.DeepEqual(msg, test.want) {
+						t.Errorf("expected %#v, got %#v", test.want, msg)
+					}
+				}
+				break
+			}
+		})
+	}
+}
+
+func TestRetryWrapped(t *testing.T) {
+	p := NewProgram(nil, WithClock(instantClock{}))
+	attempts := 0
+	cmd := wrap("child", Retry(func() Msg {
+		if attempts++; attempts == 1 {
+			return ErrMsg{Err: errors.New("broken")}
+		}
+		return "done"
+	}, RetryPolicy{}))
+
+	done := make(chan Msg)
+	go func() { done <- p.runCmd(cmd) }()
+	if msg, ok := (<-p.msgs).(WrappedMsg); !ok || msg.ID != "child" {
+		t.Errorf("expected the progress message to be wrapped, got %#v", msg)
+	}
+	if msg := <-done; !reflect.DeepEqual(msg, WrappedMsg{ID: "child", Msg: "done"}) {
+		t.Errorf("expected the message to be wrapped, got %#v", msg)
+	}
+}
+
+func TestRetryCanceled(t *testing.T) {
+	p := NewProgram(nil, WithClock(manualClock{timers: make(chan instantTimer, 1)}))
+	p.cancel()
+
+	msg := p.runCmd(Retry(func() Msg { return ErrMsg{Err: errors.New("broken")} }, RetryPolicy{}))
+	if msg != nil {
+		t.Errorf("expected no message once the program exited, got %#v", msg)
+	}
+}
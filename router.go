@@ -0,0 +1,130 @@
+package tea
+
+// Router routes messages to a set of child models by the IDs their commands
+// are wrapped with, so that a parent doesn't need to switch on the IDs of
+// wrapped messages itself. Messages wrapped with the ID of a child go to that
+// child only, unwrapped; messages wrapped with other IDs are ignored, and
+// messages that aren't wrapped, such as key presses and window size changes,
+// go to every child in the order they were registered.
+//
+//	type model struct {
+//	    panes tea.Router[string]
+//	}
+//
+//	func newModel() model {
+//	    var panes tea.Router[string]
+//	    panes = panes.Register("files", files.New())
+//	    panes = panes.Register("preview", preview.New())
+//	    return model{panes: panes}
+//	}
+//
+//	func (m model) Init() tea.Cmd {
+//	    return m.panes.Init()
+//	}
+//
+//	func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+//	    var cmd tea.Cmd
+//	    m.panes, cmd = m.panes.Dispatch(msg)
+//	    return m, cmd
+//	}
+//
+// Routers are values, like models: Register and Dispatch return an updated
+// router and leave the one they're called on as it was. The zero Router has
+// no children.
+type Router[ID comparable] struct {
+	ids      []ID
+	children map[ID]Model
+}
+
+// Register returns a router with the given child registered under an ID,
+// replacing the child registered under it, if any.
+func (r Router[ID]) Register(id ID, child Model) Router[ID] {
+	next := r.clone()
+	if _, ok := next.children[id]; !ok {
+		next.ids = append(next.ids, id)
+	}
+	next.children[id] = child
+	return next
+}
+
+// Unregister returns a router with the child registered under an ID removed.
+func (r Router[ID]) Unregister(id ID) Router[ID] {
+	if _, ok := r.children[id]; !ok {
+		return r
+	}
+	next := r.clone()
+	delete(next.children, id)
+	ids := next.ids[:0]
+	for _, other := range next.ids {
+		if other != id {
+			ids = append(ids, other)
+		}
+	}
+	next.ids = ids
+	return next
+}
+
+// Child returns the child registered under an ID, and whether there's one.
+func (r Router[ID]) Child(id ID) (Model, bool) {
+	child, ok := r.children[id]
+	return child, ok
+}
+
+// IDs returns the IDs of the children, in the order they were registered.
+func (r Router[ID]) IDs() []ID {
+	return append([]ID(nil), r.ids...)
+}
+
+// Init collects the initial commands of the children, wrapped with their IDs,
+// into a single batch.
+func (r Router[ID]) Init() Cmd {
+	cmds := make([]Cmd, 0, len(r.ids))
+	for _, id := range r.ids {
+		cmds = append(cmds, wrap(id, r.children[id].Init()))
+	}
+	return Batch(cmds...)
+}
+
+// Dispatch routes a message to the children it's addressed to, and returns
+// the router with the updated children along with their commands, wrapped
+// with their IDs, in a single batch.
+func (r Router[ID]) Dispatch(msg Msg) (Router[ID], Cmd) {
+	if w, ok := msg.(WrappedMsg); ok {
+		id, ok := w.ID.(ID)
+		if !ok {
+			return r, nil
+		}
+		child, ok := r.children[id]
+		if !ok {
+			return r, nil
+		}
+		next := r.clone()
+		var cmd Cmd
+		next.children[id], cmd = forward(id, child, msg)
+		return next, cmd
+	}
+
+	if len(r.ids) == 0 {
+		return r, nil
+	}
+	next := r.clone()
+	cmds := make([]Cmd, 0, len(next.ids))
+	for _, id := range next.ids {
+		var cmd Cmd
+		next.children[id], cmd = forward(id, next.children[id], msg)
+		cmds = append(cmds, cmd)
+	}
+	return next, Batch(cmds...)
+}
+
+// clone returns a copy of the router that can be changed without changing it.
+func (r Router[ID]) clone() Router[ID] {
+	next := Router[ID]{
+		ids:      append([]ID(nil), r.ids...),
+		children: make(map[ID]Model, len(r.children)+1),
+	}
+	for id, child := range r.children {
+		next.children[id] = child
+	}
+	return next
+}
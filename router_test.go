@@ -0,0 +1,77 @@
+package tea
+
+import "testing"
+
+func TestRouter(t *testing.T) {
+	var r Router[string]
+	if r.Init() != nil {
+		t.Error("expected an empty router to have no initial command")
+	}
+	if next, cmd := r.Dispatch(KeyMsg{}); cmd != nil || len(next.IDs()) != 0 {
+		t.Error("expected an empty router to do nothing")
+	}
+
+	r = r.Register("a", counterModel{}).Register("b", counterModel{})
+	if ids := r.IDs(); len(ids) != 2 || ids[0] != "a" || ids[1] != "b" {
+		t.Fatalf("expected IDs [a b], got %v", ids)
+	}
+
+	batch, ok := r.Init()().(BatchMsg)
+	if !ok || len(batch) != 2 {
+		t.Fatalf("expected a batch of 2 commands, got %#v", batch)
+	}
+	for i, id := range []string{"a", "b"} {
+		if w, ok := run(batch[i]).(WrappedMsg); !ok || w.ID != id {
+			t.Errorf("expected a message wrapped with %q, got %#v", id, w)
+		}
+	}
+
+	count := func(r Router[string], id string) int {
+		child, _ := r.Child(id)
+		return child.(counterModel).count
+	}
+
+	// Addressed to a child.
+	next, cmd := r.Dispatch(WrappedMsg{ID: "a", Msg: counterMsg{}})
+	if count(next, "a") != 1 || count(next, "b") != 0 {
+		t.Errorf("expected only a to be updated, got %d and %d", count(next, "a"), count(next, "b"))
+	}
+	if count(r, "a") != 0 {
+		t.Error("expected the original router to be left as it was")
+	}
+	batch, ok = cmd().(BatchMsg)
+	if !ok || len(batch) != 2 {
+		t.Fatalf("expected the child's batch, got %#v", batch)
+	}
+	if w, ok := run(batch[0]).(WrappedMsg); !ok || w.ID != "a" || w.Msg != "done" {
+		t.Errorf("expected the child's message to be wrapped, got %#v", w)
+	}
+	r = next
+
+	// Addressed to someone else.
+	for _, msg := range []Msg{WrappedMsg{ID: "c", Msg: counterMsg{}}, WrappedMsg{ID: 1, Msg: counterMsg{}}} {
+		if next, cmd := r.Dispatch(msg); cmd != nil || count(next, "a") != 1 || count(next, "b") != 0 {
+			t.Errorf("expected %#v to be ignored", msg)
+		}
+	}
+
+	// Addressed to no one in particular.
+	r, _ = r.Dispatch(KeyMsg{Type: KeyEnter})
+	if count(r, "a") != 11 || count(r, "b") != 10 {
+		t.Errorf("expected both children to be updated, got %d and %d", count(r, "a"), count(r, "b"))
+	}
+
+	// Registering a child again replaces it in place.
+	r = r.Register("a", counterModel{count: 5})
+	if ids := r.IDs(); len(ids) != 2 || ids[0] != "a" || count(r, "a") != 5 {
+		t.Errorf("expected a to be replaced, got %v", ids)
+	}
+
+	r = r.Unregister("a").Unregister("c")
+	if _, ok := r.Child("a"); ok {
+		t.Error("expected a to be unregistered")
+	}
+	if ids := r.IDs(); len(ids) != 1 || ids[0] != "b" {
+		t.Errorf("expected IDs [b], got %v", ids)
+	}
+}
@@ -1,14 +1,37 @@
 package tea
 
+import (
+	"fmt"
+
+	"github.com/muesli/termenv"
+)
+
 // WindowSizeMsg is used to report the terminal size. It's sent to Update once
 // initially and then on every terminal resize. Note that Windows does not
 // have support for reporting when resizes occur as it does not support the
-// SIGWINCH signal.
+// SIGWINCH signal; use WindowSize to query the size on demand there.
 type WindowSizeMsg struct {
 	Width  int
 	Height int
 }
 
+// WindowSize is a special command that queries the current size of the
+// terminal and delivers it to the program's update function as a
+// WindowSizeMsg, as if the terminal had just been resized.
+//
+// This is useful on platforms that don't report resizes, such as Windows,
+// after running another program with Exec, or for components that are
+// mounted late and missed the initial WindowSizeMsg.
+//
+// If the output isn't a terminal, no message is sent.
+func WindowSize() Msg {
+	return windowSizeMsg{}
+}
+
+// windowSizeMsg is an internal message used to query the terminal size. You
+// can send a windowSizeMsg with WindowSize.
+type windowSizeMsg struct{}
+
 // ClearScreen is a special command that tells the program to clear the screen
 // before the next update. This can be used to move the cursor to the top left
 // of the screen and clear visual clutter when the alt screen is not in use.
@@ -196,7 +219,72 @@ func (p *Program) DisableMouseAllMotion() {
 	p.renderer.disableMouseAllMotion()
 }
 
-// SetWindowTitle sets the terminal window title.
+// XTWINOPS sequences used to save and restore the window title. The 2
+// parameter selects the window title, as opposed to the icon title.
+const (
+	pushWindowTitleSeq = termenv.CSI + "22;2t"
+	popWindowTitleSeq  = termenv.CSI + "23;2t"
+)
+
+// SetWindowTitle sets the terminal window title. The original title will be
+// restored when the program exits.
+//
+// The title is set by the event loop, so that it's safe to call from any
+// goroutine. Like Send, it blocks until the program has started, and it's a
+// no-op once it has exited. From within a Bubble Tea program, use the
+// SetWindowTitle command instead.
 func (p *Program) SetWindowTitle(title string) {
-	p.output.SetWindowTitle(title)
+	p.Send(setWindowTitleMsg(title))
+}
+
+// setWindowTitle sets the terminal window title, saving the original one
+// first. It's only called from the event loop.
+func (p *Program) setWindowTitle(title string) {
+	if p.plainOutput {
+		return
+	}
+	if !p.titleSaved && p.titlesPushed == 0 {
+		// Save the original title so we can restore it on exit.
+		p.writeTitleSeq(pushWindowTitleSeq)
+		p.titleSaved = true
+	}
+	p.writeTitleSeq(termenv.OSC + fmt.Sprintf(termenv.SetWindowTitleSeq, title))
+}
+
+// pushWindowTitle saves the current window title on the terminal's title
+// stack and sets a new one.
+func (p *Program) pushWindowTitle(title string) {
+	if p.plainOutput {
+		return
+	}
+	p.writeTitleSeq(pushWindowTitleSeq)
+	p.titlesPushed++
+	p.writeTitleSeq(termenv.OSC + fmt.Sprintf(termenv.SetWindowTitleSeq, title))
+}
+
+// popWindowTitle restores the last window title saved with pushWindowTitle.
+func (p *Program) popWindowTitle() {
+	if p.titlesPushed == 0 {
+		return
+	}
+	p.writeTitleSeq(popWindowTitleSeq)
+	p.titlesPushed--
+}
+
+// restoreWindowTitle restores the window title the terminal had before the
+// program changed it, if it changed it at all.
+func (p *Program) restoreWindowTitle() {
+	for ; p.titlesPushed > 0; p.titlesPushed-- {
+		p.writeTitleSeq(popWindowTitleSeq)
+	}
+	if p.titleSaved {
+		p.writeTitleSeq(popWindowTitleSeq)
+		p.titleSaved = false
+	}
+}
+
+// writeTitleSeq writes a sequence changing the window title, passing it
+// through to the outer terminal in multiplexers.
+func (p *Program) writeTitleSeq(seq string) {
+	_, _ = p.output.WriteString(p.multiplexer.Passthrough(seq))
 }
@@ -2,6 +2,7 @@ package tea
 
 import (
 	"bytes"
+	"strings"
 	"testing"
 )
 
@@ -56,6 +57,36 @@ func TestClearMsg(t *testing.T) {
 			cmds:     []Cmd{DisableBracketedPaste, EnableBracketedPaste},
 			expected: "\x1b[?25l\x1b[?2004h\x1b[?2004l\x1b[?2004h\rsuccess\r\n\x1b[0D\x1b[2K\x1b[?2004l\x1b[?25h\x1b[?1002l\x1b[?1003l\x1b[?1006l",
 		},
+		{
+			name:     "window_size",
+			cmds:     []Cmd{WindowSize},
+			expected: "\x1b[?25l\x1b[?2004h\rsuccess\r\n\x1b[0D\x1b[2K\x1b[?2004l\x1b[?25h\x1b[?1002l\x1b[?1003l\x1b[?1006l",
+		},
+		{
+			name:     "set_clipboard",
+			cmds:     []Cmd{SetClipboard("hi")},
+			expected: "\x1b[?25l\x1b[?2004h\x1b]52;c;aGk=\a\rsuccess\r\n\x1b[0D\x1b[2K\x1b[?2004l\x1b[?25h\x1b[?1002l\x1b[?1003l\x1b[?1006l",
+		},
+		{
+			name:     "read_clipboard",
+			cmds:     []Cmd{ReadClipboard},
+			expected: "\x1b[?25l\x1b[?2004h\x1b]52;c;?\a\rsuccess\r\n\x1b[0D\x1b[2K\x1b[?2004l\x1b[?25h\x1b[?1002l\x1b[?1003l\x1b[?1006l",
+		},
+		{
+			name:     "set_window_title",
+			cmds:     []Cmd{SetWindowTitle("a"), SetWindowTitle("b")},
+			expected: "\x1b[?25l\x1b[?2004h\x1b[22;2t\x1b]2;a\a\x1b]2;b\a\rsuccess\r\n\x1b[0D\x1b[2K\x1b[?2004l\x1b[?25h\x1b[?1002l\x1b[?1003l\x1b[?1006l\x1b[23;2t",
+		},
+		{
+			name:     "push_pop_window_title",
+			cmds:     []Cmd{PushWindowTitle("a"), PushWindowTitle("b"), PopWindowTitle, PopWindowTitle, PopWindowTitle},
+			expected: "\x1b[?25l\x1b[?2004h\x1b[22;2t\x1b]2;a\a\x1b[22;2t\x1b]2;b\a\x1b[23;2t\x1b[23;2t\rsuccess\r\n\x1b[0D\x1b[2K\x1b[?2004l\x1b[?25h\x1b[?1002l\x1b[?1003l\x1b[?1006l",
+		},
+		{
+			name:     "push_window_title_autorestore",
+			cmds:     []Cmd{PushWindowTitle("a")},
+			expected: "\x1b[?25l\x1b[?2004h\x1b[22;2t\x1b]2;a\a\rsuccess\r\n\x1b[0D\x1b[2K\x1b[?2004l\x1b[?25h\x1b[?1002l\x1b[?1003l\x1b[?1006l\x1b[23;2t",
+		},
 	}
 
 	for _, test := range tests {
@@ -79,3 +110,21 @@ func TestClearMsg(t *testing.T) {
 		})
 	}
 }
+
+func TestProgramSetWindowTitle(t *testing.T) {
+	var buf bytes.Buffer
+	var in bytes.Buffer
+
+	p := NewProgram(&testModel{}, WithInput(&in), WithOutput(&buf))
+	go func() {
+		p.SetWindowTitle("a")
+		p.Quit()
+	}()
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	if out := buf.String(); !strings.Contains(out, "\x1b[22;2t\x1b]2;a\a") || !strings.HasSuffix(out, "\x1b[23;2t") {
+		t.Errorf("expected the title to be set and restored, got:\n%q", out)
+	}
+}
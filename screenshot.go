@@ -0,0 +1,36 @@
+package tea
+
+import "strings"
+
+// Screenshot returns the latest frame rendered by the program, as it was
+// sent to the terminal, with its escape sequences, or an empty string if no
+// frame was rendered yet. Lines are cut at the width of the window, as the
+// renderer does. It's safe to call from any goroutine, such as to copy the
+// screen, log the final state of a program or check it in tests.
+func (p *Program) Screenshot() string {
+	frame, _ := p.latestFrame()
+	return frame
+}
+
+// PlainScreenshot returns the latest frame rendered by the program like
+// Screenshot, stripped of its escape sequences.
+func (p *Program) PlainScreenshot() string {
+	return stripANSI(p.Screenshot())
+}
+
+// latestFrame returns the latest frame sent to the renderer, with its lines
+// cut at the width of the window, and whether there's one.
+func (p *Program) latestFrame() (string, bool) {
+	p.lastFrameMtx.Lock()
+	frame, width, ok := p.lastFrame, p.lastFrameWidth, p.hasLastFrame
+	p.lastFrameMtx.Unlock()
+
+	if width > 0 {
+		lines := strings.Split(frame, "\n")
+		for i, line := range lines {
+			lines[i] = truncateLine(line, width)
+		}
+		frame = strings.Join(lines, "\n")
+	}
+	return frame, ok
+}
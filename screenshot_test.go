@@ -0,0 +1,48 @@
+package tea
+
+import (
+	"bytes"
+	"testing"
+)
+
+type screenshotModel struct{}
+
+func (m screenshotModel) Init() Cmd { return nil }
+
+func (m screenshotModel) Update(msg Msg) (Model, Cmd) {
+	if _, ok := msg.(KeyMsg); ok {
+		return m, Quit
+	}
+	return m, nil
+}
+
+func (m screenshotModel) View() string {
+	return "\x1b[1mbold\x1b[0m text\nline"
+}
+
+func TestProgramScreenshot(t *testing.T) {
+	var buf, in bytes.Buffer
+	in.Write([]byte("q"))
+	p := NewProgram(screenshotModel{}, WithInput(&in), WithOutput(&buf))
+	if s := p.Screenshot(); s != "" {
+		t.Errorf("expected no screenshot before the first frame, got %q", s)
+	}
+
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := p.Screenshot(), "\x1b[1mbold\x1b[0m text\nline"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	if got, want := p.PlainScreenshot(), "bold text\nline"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestProgramScreenshotWidth(t *testing.T) {
+	p := NewProgram(nil)
+	p.lastFrame, p.lastFrameWidth, p.hasLastFrame = "abcdef\nab", 4, true
+	if got, want := p.Screenshot(), "abcd\nab"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
@@ -7,8 +7,14 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 )
 
+// How long to wait for further resizes after the terminal resizes before
+// sending its size. Dragging the corner of a window resizes it many times a
+// second, and there's no use rendering a frame for each size in between.
+const resizeDebounce = 20 * time.Millisecond
+
 // listenForResize sends messages (or errors) when the terminal resizes.
 // Argument output should be the file descriptor for the terminal; usually
 // os.Stdout.
@@ -28,6 +34,18 @@ func (p *Program) listenForResize(done chan struct{}) {
 		case <-sig:
 		}
 
+		// Wait for the resizing to settle, dropping the signals received in
+		// the meantime.
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-time.After(resizeDebounce):
+		}
+		select {
+		case <-sig:
+		default:
+		}
+
 		p.checkResize()
 	}
 }
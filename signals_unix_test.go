@@ -0,0 +1,96 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris || aix || zos
+// +build darwin dragonfly freebsd linux netbsd openbsd solaris aix zos
+
+package tea
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+type signalTestModel struct {
+	testModel
+	received atomic.Value
+}
+
+func (m *signalTestModel) Update(msg Msg) (Model, Cmd) {
+	if s, ok := msg.(os.Signal); ok {
+		m.received.Store(s)
+		return m, Quit
+	}
+	return m, nil
+}
+
+func TestWithSignals(t *testing.T) {
+	var buf bytes.Buffer
+	var in bytes.Buffer
+
+	m := &signalTestModel{}
+	p := NewProgram(m, WithInput(&in), WithOutput(&buf), WithSignals(func(s os.Signal) Msg {
+		return s
+	}, syscall.SIGUSR1))
+
+	go func() {
+		for {
+			time.Sleep(time.Millisecond)
+			if m.executed.Load() != nil {
+				_ = syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+				return
+			}
+		}
+	}()
+
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if s := m.received.Load(); s != syscall.SIGUSR1 {
+		t.Fatalf("expected SIGUSR1, got %v", s)
+	}
+}
+
+type signalsTestModel struct {
+	testModel
+	received []Msg
+}
+
+func (m *signalsTestModel) Update(msg Msg) (Model, Cmd) {
+	if s, ok := msg.(string); ok {
+		m.received = append(m.received, s)
+		if len(m.received) == 2 {
+			return m, Quit
+		}
+	}
+	return m, nil
+}
+
+func TestWithSignalsSameSignal(t *testing.T) {
+	var buf bytes.Buffer
+	var in bytes.Buffer
+
+	m := &signalsTestModel{}
+	p := NewProgram(m, WithInput(&in), WithOutput(&buf),
+		WithSignals(func(os.Signal) Msg { return "first" }, syscall.SIGUSR2),
+		WithSignals(func(os.Signal) Msg { return "second" }, syscall.SIGUSR2))
+
+	go func() {
+		for {
+			time.Sleep(time.Millisecond)
+			if m.executed.Load() != nil {
+				_ = syscall.Kill(os.Getpid(), syscall.SIGUSR2)
+				return
+			}
+		}
+	}()
+
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(m.received, []Msg{"first", "second"}) {
+		t.Fatalf("expected both handlers to be called in order, got %v", m.received)
+	}
+}
@@ -3,8 +3,51 @@
 
 package tea
 
-// listenForResize is not available on windows because windows does not
-// implement syscall.SIGWINCH.
+import (
+	"os"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// How often to check the size of the console when resizes can't be detected
+// through console input events.
+const resizePollInterval = 250 * time.Millisecond
+
+// listenForResize sends messages (or errors) when the terminal resizes.
+//
+// Windows doesn't implement SIGWINCH. Instead, the console reports resizes
+// as WINDOW_BUFFER_SIZE_EVENT input records, which readConInputs turns into
+// WindowSizeMsgs, so there's nothing to do when reading console input. When
+// the input isn't the console, e.g. because it was redirected, the size of the
+// console is polled instead.
 func (p *Program) listenForResize(done chan struct{}) {
-	close(done)
+	defer close(done)
+
+	if isConsoleInput(p.cancelReader) {
+		return
+	}
+	f, ok := p.output.TTY().(*os.File)
+	if !ok {
+		return
+	}
+
+	w, h, _ := term.GetSize(int(f.Fd()))
+	t := time.NewTicker(resizePollInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-t.C:
+		}
+
+		nw, nh, err := term.GetSize(int(f.Fd()))
+		if err != nil || (nw == w && nh == h) {
+			continue
+		}
+		w, h = nw, nh
+		p.Send(WindowSizeMsg{Width: w, Height: h})
+	}
 }
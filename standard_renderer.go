@@ -1,15 +1,15 @@
 package tea
 
 import (
-	"bytes"
+	"bufio"
 	"fmt"
+	"hash/maphash"
 	"io"
 	"strings"
 	"sync"
 	"time"
 
-	"github.com/muesli/ansi/compressor"
-	"github.com/muesli/reflow/truncate"
+	"github.com/aymanbagabas/go-osc52/v2"
 	"github.com/muesli/termenv"
 )
 
@@ -18,6 +18,10 @@ const (
 	// update the view.
 	defaultFPS = 60
 	maxFPS     = 120
+
+	// idleDelay is how long the renderer waits without messages before
+	// dropping to its minimum framerate, if it's adaptive.
+	idleDelay = time.Second
 )
 
 // standardRenderer is a framerate-based terminal renderer, updating the view
@@ -29,29 +33,118 @@ type standardRenderer struct {
 	mtx *sync.Mutex
 	out *termenv.Output
 
-	buf                bytes.Buffer
+	view               string
 	queuedMessageLines []string
 	framerate          time.Duration
 	ticker             *time.Ticker
 	done               chan struct{}
-	lastRender         string
 	linesRendered      int
+	lastLines          []string
+	spareLines         []string
 	useANSICompressor  bool
 	once               sync.Once
 
+	// hashes of the frame waiting to be flushed and of the last frame
+	// painted, which tell whether there's anything new to paint without
+	// comparing frames
+	hashSeed maphash.Seed
+	viewHash uint64
+	lastHash uint64
+
+	// whether the last frame is on the screen; not so before the first frame
+	// or once a repaint was requested
+	painted bool
+
 	// cursor visibility state
 	cursorHidden bool
 
+	// the cursor position requested by the model, if any
+	cursor *Cursor
+
+	// whether the cursor has been moved to the requested position, and the
+	// line it was moved to
+	cursorParked  bool
+	cursorParkedY int
+
+	// whether the requested cursor position changed since the last flush
+	cursorDirty bool
+
+	// the number of lines dropped from the top of the last frame because it
+	// was taller than the terminal
+	linesDropped int
+
+	// called each time a frame is drawn, if set
+	onFrame func()
+
+	// the multiplexer to pass sequences through to the outer terminal, if
+	// any
+	multiplexer Multiplexer
+
+	// the sequences controlling the terminal
+	caps *termCaps
+
+	// whether to paint only the changed part of changed lines, rather than
+	// whole lines; it's only done on terminals, as whole lines keep other
+	// output, such as in tests, easy to search
+	paintSuffixes bool
+
+	// whether to diff frames cell by cell rather than line by line, and the
+	// cells of the last frame; nil if it couldn't be parsed into cells
+	cellDiffing bool
+	lastCells   [][]cell
+
 	// essentially whether or not we're using the full size of the terminal
 	altScreenActive bool
 
 	// whether or not we're currently using bracketed paste
 	bpActive bool
 
+	// whether or not we've pushed kitty keyboard protocol flags
+	kittyKeyboardActive bool
+
+	// whether or not we've enabled xterm's modifyOtherKeys encoding
+	modifyOtherKeysActive bool
+
+	// whether or not we've enabled mouse pixel mode
+	mousePixelsActive bool
+
+	// whether or not to wrap frames in synchronized output sequences
+	syncOutput bool
+
 	// renderer dimensions; usually the size of the window
 	width  int
 	height int
 
+	// the number of lines frames are cut or padded to outside of the alt
+	// screen, if set
+	reservedHeight int
+
+	// the framerate to drop to while no messages arrive, if the framerate is
+	// adaptive, and the channel waking the renderer up when they do
+	idleFramerate time.Duration
+	wake          chan struct{}
+
+	// the maximum number of bytes written to the output per second, if set,
+	// and the time until which frames are held off to stay within it
+	maxBytesPerSecond int
+	pacedUntil        time.Time
+
+	// the protocol images are drawn with, the images transmitted to the
+	// terminal, by id, and the number of lines placements are moved down by
+	imageProtocol ImageProtocol
+	images        map[int]*terminalImage
+	imageOffset   int
+
+	// the width of the tab stops tabs are expanded to; tabs are left to the
+	// terminal if it's 0 or less
+	tabWidth int
+
+	// how wide characters are measured to be
+	widths cellWidths
+
+	// what's done with lines wider than the window
+	overflow Overflow
+
 	// lines explicitly set not to render
 	ignoreLines map[int]struct{}
 }
@@ -68,12 +161,16 @@ func newRenderer(out *termenv.Output, useANSICompressor bool, fps int) renderer
 		out:                out,
 		mtx:                &sync.Mutex{},
 		done:               make(chan struct{}),
+		wake:               make(chan struct{}, 1),
 		framerate:          time.Second / time.Duration(fps),
 		useANSICompressor:  useANSICompressor,
 		queuedMessageLines: []string{},
+		caps:               xtermCaps,
+		hashSeed:           maphash.MakeSeed(),
+		tabWidth:           defaultTabWidth,
 	}
 	if r.useANSICompressor {
-		r.out = termenv.NewOutput(&compressor.Writer{Forward: out})
+		r.out = termenv.NewOutput(newANSICompressor(out), termenv.WithProfile(out.Profile))
 	}
 	return r
 }
@@ -108,13 +205,8 @@ func (r *standardRenderer) stop() {
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
 
-	r.out.ClearLine()
-
-	if r.useANSICompressor {
-		if w, ok := r.out.TTY().(io.WriteCloser); ok {
-			_ = w.Close()
-		}
-	}
+	r.unparkCursor(r.out)
+	_, _ = r.out.WriteString(r.caps.clearLine)
 }
 
 // kill halts the renderer. The final frame will not be rendered.
@@ -127,11 +219,63 @@ func (r *standardRenderer) kill() {
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
 
-	r.out.ClearLine()
+	r.unparkCursor(r.out)
+	_, _ = r.out.WriteString(r.caps.clearLine)
+}
+
+// pace holds off the next frames until the bytes just written would have
+// gone through at the output's maximum bandwidth, if it has one, so that
+// slow connections get fewer frames rather than a backlog of them.
+func (r *standardRenderer) pace(n int) {
+	if r.maxBytesPerSecond <= 0 {
+		return
+	}
+	from := time.Now()
+	if r.pacedUntil.After(from) {
+		from = r.pacedUntil
+	}
+	r.pacedUntil = from.Add(time.Duration(n) * time.Second / time.Duration(r.maxBytesPerSecond))
+}
+
+// paced reports whether frames are being held off to stay within the
+// output's maximum bandwidth.
+func (r *standardRenderer) paced() bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return time.Now().Before(r.pacedUntil)
+}
+
+// setMinFPS makes the framerate adaptive: the renderer drops to the given
+// framerate when no messages have arrived for a while, and goes back to its
+// full framerate as soon as one does. It must be called before the renderer
+// is started.
+func (r *standardRenderer) setMinFPS(fps int) {
+	if fps < 1 {
+		r.idleFramerate = 0
+		return
+	}
+	r.idleFramerate = time.Second / time.Duration(fps)
+	if r.idleFramerate < r.framerate {
+		r.idleFramerate = r.framerate
+	}
+}
+
+// active wakes the renderer up if its framerate is adaptive.
+func (r *standardRenderer) active() {
+	if r.idleFramerate == 0 {
+		return
+	}
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
 }
 
 // listen waits for ticks on the ticker, or a signal to stop the renderer.
 func (r *standardRenderer) listen() {
+	idle := false
+	lastActive := time.Now()
+
 	for {
 		select {
 		case <-r.done:
@@ -139,8 +283,97 @@ func (r *standardRenderer) listen() {
 			return
 
 		case <-r.ticker.C:
-			r.flush()
+			if !r.paced() {
+				r.flush()
+			}
+			if r.idleFramerate > 0 && !idle && time.Since(lastActive) >= idleDelay {
+				idle = true
+				r.ticker.Reset(r.idleFramerate)
+			}
+
+		case <-r.wake:
+			lastActive = time.Now()
+			if idle {
+				// Paint what woke us up right away, rather than on the
+				// next tick.
+				idle = false
+				r.ticker.Reset(r.framerate)
+				if !r.paced() {
+					r.flush()
+				}
+			}
+		}
+	}
+}
+
+// frameBufferSize is the size of the buffers frames are written to before
+// they're written to the terminal. Most frames fit, and are written at once.
+const frameBufferSize = 32 * 1024
+
+// frameWriter buffers the sequences written to the terminal, so that frames
+// are written at once rather than sequence by sequence.
+type frameWriter struct {
+	*bufio.Writer
+	out *termenv.Output
+
+	// the renderer's output, and the number of bytes written to it
+	dst io.Writer
+	n   int
+}
+
+// frameWriters are shared between renderers, as they're only needed while a
+// frame is being written, and programs serving many sessions, such as over
+// SSH, would otherwise each hold on to one.
+var frameWriters = sync.Pool{
+	New: func() interface{} {
+		w := &frameWriter{}
+		w.Writer = bufio.NewWriterSize(frameWriterDst{w}, frameBufferSize)
+		// The profile doesn't matter, but detecting it is expensive.
+		w.out = termenv.NewOutput(w.Writer, termenv.WithProfile(termenv.Ascii))
+		return w
+	},
+}
+
+// frameWriter returns a writer buffering what's written to the renderer's
+// output. It must be released with done.
+func (r *standardRenderer) frameWriter() *frameWriter {
+	w := frameWriters.Get().(*frameWriter)
+	w.dst, w.n = r.out, 0
+	return w
+}
+
+// done writes what's buffered to the output and releases the writer. It
+// returns the number of bytes written to the output.
+func (w *frameWriter) done() int {
+	_ = w.Flush()
+	n := w.n
+	w.Reset(frameWriterDst{w})
+	w.dst = nil
+	frameWriters.Put(w)
+	return n
+}
+
+// frameWriterDst is what a frameWriter's buffer is flushed to: its
+// renderer's output, counting the bytes written.
+type frameWriterDst struct {
+	w *frameWriter
+}
+
+func (d frameWriterDst) Write(b []byte) (int, error) {
+	n, err := d.w.dst.Write(b)
+	d.w.n += n
+	return n, err
+}
+
+// splitLines splits a frame into lines, appending them to dst.
+func splitLines(dst []string, s string) []string {
+	for {
+		i := strings.IndexByte(s, '\n')
+		if i < 0 {
+			return append(dst, s)
 		}
+		dst = append(dst, s[:i])
+		s = s[i+1:]
 	}
 }
 
@@ -149,28 +382,129 @@ func (r *standardRenderer) flush() {
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
 
-	if r.buf.Len() == 0 || r.buf.String() == r.lastRender {
-		// Nothing to do
+	if r.view == "" {
+		// Nothing to render, but the cursor may have moved, and images may
+		// have been placed.
+		if (r.cursorDirty || r.imagesDirty()) && r.linesRendered > 0 {
+			w := r.frameWriter()
+			r.unparkCursor(w.out)
+			r.drawImages(w.out, r.lastLines, r.lastLines, false)
+			r.parkCursor(w.out)
+			w.done()
+		}
 		return
 	}
 
-	// Output buffer
-	buf := &bytes.Buffer{}
-	out := termenv.NewOutput(buf)
+	w := r.frameWriter()
+	out := w.out
+	if r.syncOutput {
+		// Ask the terminal to hold off drawing until the frame is complete
+		// to avoid tearing.
+		_, _ = out.WriteString(termenv.CSI + "?2026h")
+	}
+
+	// Return the cursor to the start of the last line, where the rest of the
+	// routine expects it to be.
+	r.unparkCursor(out)
 
-	newLines := strings.Split(r.buf.String(), "\n")
+	// Split the frame into the lines of the frame before last, which aren't
+	// needed anymore, to save allocating them.
+	newLines := splitLines(r.spareLines[:0], r.view)
+
+	// Fit lines wider than the window to its width, which keeps them from
+	// wrapping and messing up rendering: they're cut unless wrapping them or
+	// marking them was asked for. If we don't have the width of the window
+	// this will be ignored.
+	//
+	// Note that on Windows we only get the width of the window on program
+	// initialization, so after a resize this won't perform correctly (signal
+	// SIGWINCH is not supported on Windows).
+	newLines = r.fitWidth(newLines)
 
 	// If we know the output's height, we can use it to determine how many
 	// lines we can render. We drop lines from the top of the render buffer if
 	// necessary, as we can't navigate the cursor into the terminal's scrollback
 	// buffer.
+	r.linesDropped = 0
 	if r.height > 0 && len(newLines) > r.height {
+		r.linesDropped = len(newLines) - r.height
 		newLines = newLines[len(newLines)-r.height:]
 	}
 
-	numLinesThisFlush := len(newLines)
-	oldLines := strings.Split(r.lastRender, "\n")
-	skipLines := make(map[int]struct{})
+	// With a reserved height, inline frames always take up the same number
+	// of lines, so that the view doesn't jump around as its height changes.
+	if h := r.reservedHeight; h > 0 && !r.altScreenActive {
+		if r.height > 0 && h > r.height {
+			h = r.height
+		}
+		if len(newLines) > h {
+			r.linesDropped += len(newLines) - h
+			newLines = newLines[len(newLines)-h:]
+		}
+		for len(newLines) < h {
+			newLines = append(newLines, "")
+		}
+	}
+
+	var cells [][]cell
+	if r.cellDiffing {
+		cells = r.widths.parseCells(newLines, r.width)
+	}
+
+	fullPaint := !r.painted || (len(r.queuedMessageLines) > 0 && !r.altScreenActive)
+	if !fullPaint {
+		r.scrollChanges(out, newLines)
+	}
+
+	switch {
+	case fullPaint:
+		r.paintFrame(out, newLines)
+	case cells != nil && r.lastCells != nil:
+		r.paintCells(out, cells)
+	default:
+		r.paintChanges(out, newLines)
+	}
+	lastLines := r.lastLines
+	r.linesRendered = len(newLines)
+	r.spareLines, r.lastLines = r.lastLines, newLines
+	r.lastCells = cells
+
+	// Make sure the cursor is at the start of the last line to keep rendering
+	// behavior consistent.
+	if r.altScreenActive {
+		// This case fixes a bug in macOS terminal. In other terminals the
+		// other case seems to do the job regardless of whether or not we're
+		// using the full terminal window.
+		_, _ = out.WriteString(r.caps.moveCursor(r.linesRendered, 0))
+	} else {
+		_, _ = out.WriteString(r.caps.cursorBack(r.width))
+	}
+
+	// Draw images over the lines painted over them.
+	if len(r.images) > 0 {
+		r.drawImages(out, lastLines, newLines, fullPaint)
+	}
+
+	// Place the cursor where the model asked for it.
+	r.parkCursor(out)
+
+	if r.syncOutput {
+		_, _ = out.WriteString(termenv.CSI + "?2026l")
+	}
+	r.pace(w.done())
+	r.painted = true
+	r.lastHash = r.viewHash
+	r.view = ""
+
+	if r.onFrame != nil {
+		r.onFrame()
+	}
+}
+
+// paintFrame clears the lines painted in the last frame and paints a new one
+// in full, flushing the queued message lines above it if there are any. The
+// cursor must be at the start of the last rendered line.
+func (r *standardRenderer) paintFrame(out *termenv.Output, newLines []string) {
 	flushQueuedMessages := len(r.queuedMessageLines) > 0 && !r.altScreenActive
 
 	// Clear any lines we painted in the last render.
@@ -179,18 +513,10 @@ func (r *standardRenderer) flush() {
 			// if we are clearing queued messages, we want to clear all lines, since
 			// printing messages allows for native terminal word-wrap, we
 			// don't have control over the queued lines
-			if flushQueuedMessages {
-				out.ClearLine()
-			} else if (len(newLines) <= len(oldLines)) && (len(newLines) > i && len(oldLines) > i) && (newLines[i] == oldLines[i]) {
-				// If the number of lines we want to render hasn't increased and
-				// new line is the same as the old line we can skip rendering for
-				// this line as a performance optimization.
-				skipLines[i] = struct{}{}
-			} else if _, exists := r.ignoreLines[i]; !exists {
-				out.ClearLine()
+			if _, exists := r.ignoreLines[i]; flushQueuedMessages || !exists {
+				_, _ = out.WriteString(r.caps.clearLine)
 			}
-
-			out.CursorUp(1)
+			_, _ = out.WriteString(r.caps.cursorUp(1))
 		}
 
 		if _, exists := r.ignoreLines[0]; !exists {
@@ -203,17 +529,11 @@ func (r *standardRenderer) flush() {
 			// standard (whereas others are proprietary to, say, VT100/VT52).
 			// If cursor previous line (ESC[ + <n> + F) were better supported
 			// we could use that above to eliminate this step.
-			out.CursorBack(r.width)
-			out.ClearLine()
+			_, _ = out.WriteString(r.caps.cursorBack(r.width))
+			_, _ = out.WriteString(r.caps.clearLine)
 		}
 	}
 
-	// Merge the set of lines we're skipping as a rendering optimization with
-	// the set of lines we've explicitly asked the renderer to ignore.
-	for k, v := range r.ignoreLines {
-		skipLines[k] = v
-	}
-
 	if flushQueuedMessages {
 		// Dump the lines we've queued up for printing
 		for _, line := range r.queuedMessageLines {
@@ -225,63 +545,225 @@ func (r *standardRenderer) flush() {
 	}
 
 	// Paint new lines
-	for i := 0; i < len(newLines); i++ {
-		if _, skip := skipLines[i]; skip {
+	for i, line := range newLines {
+		if _, skip := r.ignoreLines[i]; skip {
 			// Unless this is the last line, move the cursor down.
 			if i < len(newLines)-1 {
-				out.CursorDown(1)
-			}
-		} else {
-			if i == 0 && r.lastRender == "" {
-				// On first render, reset the cursor to the start of the line
-				// before writing anything.
-				buf.WriteByte('\r')
+				_, _ = out.WriteString(r.caps.cursorDown(1))
 			}
+			continue
+		}
+
+		if i == 0 && !r.painted {
+			// On first render, reset the cursor to the start of the line
+			// before writing anything.
+			_, _ = out.WriteString("\r")
+		}
+		_, _ = out.WriteString(line)
+		if i < len(newLines)-1 {
+			_, _ = out.WriteString("\r\n")
+		}
+	}
+}
 
-			line := newLines[i]
+// paintChanges paints the lines of a frame which changed since the last one,
+// leaving the others alone. On terminals, only the part of a changed line
+// after the plain text it shares with the last frame is painted. The cursor
+// must be at the start of the last rendered line, and is left on the last
+// line of the new frame.
+func (r *standardRenderer) paintChanges(out *termenv.Output, newLines []string) {
+	oldLines := r.lastLines
+	row := r.linesRendered - 1
+
+	for i := 0; i < len(oldLines); i++ {
+		if _, skip := r.ignoreLines[i]; skip {
+			continue
+		}
 
-			// Truncate lines wider than the width of the window to avoid
-			// wrapping, which will mess up rendering. If we don't have the
-			// width of the window this will be ignored.
-			//
-			// Note that on Windows we only get the width of the window on
-			// program initialization, so after a resize this won't perform
-			// correctly (signal SIGWINCH is not supported on Windows).
-			if r.width > 0 {
-				line = truncate.String(line, uint(r.width))
-			}
+		if i >= len(newLines) {
+			// The frame got shorter: clear the lines left below it.
+			r.moveTo(out, row, i, 0)
+			row = i
+			_, _ = out.WriteString(r.caps.clearLine)
+			continue
+		}
+
+		oldLine, newLine := oldLines[i], newLines[i]
+		if oldLine == newLine {
+			continue
+		}
+
+		if !r.paintSuffixes {
+			r.moveTo(out, row, i, 0)
+			row = i
+			_, _ = out.WriteString(r.caps.clearLine)
+			_, _ = out.WriteString(newLine)
+			continue
+		}
 
+		n := commonPrefix(oldLine, newLine)
+		r.moveTo(out, row, i, r.widths.stringWidth(newLine[:n]))
+		row = i
+		if n < len(oldLine) {
+			_, _ = out.WriteString(r.caps.clearLineRight)
+		}
+		_, _ = out.WriteString(newLine[n:])
+	}
+
+	if len(newLines) > len(oldLines) {
+		// The frame got taller: add lines below the last one, scrolling the
+		// terminal if need be.
+		r.moveTo(out, row, len(oldLines)-1, 0)
+		for _, line := range newLines[len(oldLines):] {
+			_, _ = out.WriteString("\r\n")
 			_, _ = out.WriteString(line)
+		}
+		row = len(newLines) - 1
+	}
 
-			if i < len(newLines)-1 {
-				_, _ = out.WriteString("\r\n")
+	if !r.altScreenActive {
+		r.moveTo(out, row, len(newLines)-1, 0)
+	}
+}
+
+// scrollChanges scrolls the lines of the last frame which moved up or down in
+// the new one, as a whole, so that only the lines they make room for have to
+// be painted. This is what happens to logs, chats and other views which
+// append lines: rather than painting them again, they're scrolled with a
+// scrolling region (DECSTBM), and the last frame is updated accordingly.
+//
+// Scrolling regions are addressed on the screen, so only frames painted on
+// the alternate screen are scrolled, and only when no lines are ignored.
+func (r *standardRenderer) scrollChanges(out *termenv.Output, newLines []string) {
+	oldLines := r.lastLines
+	if !r.altScreenActive || r.height == 0 || len(r.ignoreLines) > 0 || len(oldLines) != len(newLines) {
+		return
+	}
+
+	// The lines which changed, which are the ones which could have scrolled.
+	top, bottom := 0, len(newLines)-1
+	for top <= bottom && oldLines[top] == newLines[top] {
+		top++
+	}
+	for bottom > top && oldLines[bottom] == newLines[bottom] {
+		bottom--
+	}
+	height := bottom - top + 1
+	if height < 2 { //nolint:gomnd
+		return
+	}
+
+	// Find by how many lines they scrolled, up or down.
+	var up, down int
+	for k := 1; k < height && up == 0 && down == 0; k++ {
+		if newLines[top] == oldLines[top+k] && equalLines(newLines[top:bottom+1-k], oldLines[top+k:bottom+1]) {
+			up = k
+		} else if newLines[top+k] == oldLines[top] && equalLines(newLines[top+k:bottom+1], oldLines[top:bottom+1-k]) {
+			down = k
+		}
+	}
+	if up == 0 && down == 0 {
+		return
+	}
+
+	// Lines scrolled in are blank, rather than in the style left behind by
+	// the last frame.
+	_, _ = out.WriteString(termenv.CSI + "m")
+	_, _ = out.WriteString(r.caps.scrollRegion(top+1, bottom+1))
+	if up > 0 {
+		_, _ = out.WriteString(r.caps.moveCursor(bottom+1, 1))
+		_, _ = out.WriteString(strings.Repeat("\n", up))
+	} else {
+		_, _ = out.WriteString(r.caps.moveCursor(top+1, 1))
+		_, _ = out.WriteString(r.caps.insertLines(down))
+	}
+	_, _ = out.WriteString(r.caps.scrollRegion(1, r.height))
+	_, _ = out.WriteString(r.caps.moveCursor(r.linesRendered, 1))
+
+	// Scroll the last frame the same way.
+	lines := make([]string, len(oldLines))
+	copy(lines, oldLines)
+	cells := r.lastCells
+	if cells != nil {
+		cells = make([][]cell, len(r.lastCells))
+		copy(cells, r.lastCells)
+	}
+	if up > 0 {
+		copy(lines[top:], oldLines[top+up:bottom+1])
+		for i := bottom + 1 - up; i <= bottom; i++ {
+			lines[i] = ""
+		}
+		if cells != nil {
+			copy(cells[top:], r.lastCells[top+up:bottom+1])
+			for i := bottom + 1 - up; i <= bottom; i++ {
+				cells[i] = nil
+			}
+		}
+	} else {
+		copy(lines[top+down:], oldLines[top:bottom+1-down])
+		for i := top; i < top+down; i++ {
+			lines[i] = ""
+		}
+		if cells != nil {
+			copy(cells[top+down:], r.lastCells[top:bottom+1-down])
+			for i := top; i < top+down; i++ {
+				cells[i] = nil
 			}
 		}
 	}
-	r.linesRendered = numLinesThisFlush
+	r.lastLines, r.lastCells = lines, cells
+}
 
-	// Make sure the cursor is at the start of the last line to keep rendering
-	// behavior consistent.
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// moveTo moves the cursor from a line of the frame to a column of another
+// line. Lines are addressed directly in the alternate screen, and relative to
+// the cursor otherwise, since the rows the frame takes up are unknown.
+func (r *standardRenderer) moveTo(out *termenv.Output, from, to, col int) {
 	if r.altScreenActive {
-		// This case fixes a bug in macOS terminal. In other terminals the
-		// other case seems to do the job regardless of whether or not we're
-		// using the full terminal window.
-		out.MoveCursor(r.linesRendered, 0)
-	} else {
-		out.CursorBack(r.width)
+		_, _ = out.WriteString(r.caps.moveCursor(to+1, col+1))
+		return
+	}
+	if to < from {
+		_, _ = out.WriteString(r.caps.cursorUp(from - to))
+	} else if to > from {
+		_, _ = out.WriteString(r.caps.cursorDown(to - from))
+	}
+	_, _ = out.WriteString("\r")
+	if col > 0 {
+		_, _ = out.WriteString(r.caps.cursorForward(col))
 	}
+}
 
-	_, _ = r.out.Write(buf.Bytes())
-	r.lastRender = r.buf.String()
-	r.buf.Reset()
+// commonPrefix returns the length in bytes of the plain text two lines start
+// with. The prefix stops at the first escape sequence, since the styles in
+// effect after it would have to be tracked, and at the start of the grapheme
+// cluster it would end in otherwise, in either line, since the rest of a
+// cluster changes how it's drawn.
+func commonPrefix(a, b string) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] && a[n] != '\x1b' {
+		n++
+	}
+	return graphemeStart(b, graphemeStart(a, n))
 }
 
-// write writes to the internal buffer. The buffer will be outputted via the
-// ticker which calls flush().
+// write sets the frame to render. It will be outputted via the ticker which
+// calls flush().
 func (r *standardRenderer) write(s string) {
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
-	r.buf.Reset()
+	r.view = ""
 
 	// If an empty string was passed we should clear existing output and
 	// rendering nothing. Rather than introduce additional state to manage
@@ -291,19 +773,149 @@ func (r *standardRenderer) write(s string) {
 		s = " "
 	}
 
-	_, _ = r.buf.WriteString(s)
+	// Frames identical to the one on the screen, such as views which don't
+	// change on ticks, aren't even buffered.
+	var h maphash.Hash
+	h.SetSeed(r.hashSeed)
+	_, _ = h.WriteString(s)
+	r.viewHash = h.Sum64()
+	if r.painted && r.viewHash == r.lastHash {
+		return
+	}
+
+	r.view = s
+	r.active()
+}
+
+// setCursor sets the position the cursor should be placed at after each
+// frame.
+func (r *standardRenderer) setCursor(c *Cursor) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if c == nil && r.cursor == nil {
+		return
+	}
+	if c != nil && r.cursor != nil && *c == *r.cursor {
+		return
+	}
+	if c != nil {
+		cur := *c
+		c = &cur
+	}
+	r.cursor = c
+	r.cursorDirty = true
+}
+
+// parkCursor moves the cursor from the start of the last rendered line to the
+// position requested by the model, showing it if necessary. It's a no-op if
+// no position was requested or if the position is outside the rendered
+// lines.
+func (r *standardRenderer) parkCursor(out *termenv.Output) {
+	r.cursorDirty = false
+	if r.cursor == nil {
+		return
+	}
+
+	y := r.cursor.Y - r.linesDropped
+	if y < 0 || y >= r.linesRendered || r.cursor.X < 0 {
+		return
+	}
+	x := r.cursor.X
+	if r.width > 0 && x >= r.width {
+		x = r.width - 1
+	}
+
+	if r.altScreenActive {
+		_, _ = out.WriteString(r.caps.moveCursor(y+1, x+1))
+	} else {
+		if up := r.linesRendered - 1 - y; up > 0 {
+			_, _ = out.WriteString(r.caps.cursorUp(up))
+		}
+		if x > 0 {
+			_, _ = out.WriteString(r.caps.cursorForward(x))
+		}
+	}
+	if r.cursorHidden {
+		_, _ = out.WriteString(r.caps.showCursor)
+	}
+
+	r.cursorParked = true
+	r.cursorParkedY = y
+}
+
+// unparkCursor returns the cursor to the start of the last rendered line if
+// it was moved with parkCursor, hiding it again if necessary.
+func (r *standardRenderer) unparkCursor(out *termenv.Output) {
+	if !r.cursorParked {
+		return
+	}
+	r.cursorParked = false
+
+	if r.cursorHidden {
+		_, _ = out.WriteString(r.caps.hideCursor)
+	}
+	if r.altScreenActive {
+		_, _ = out.WriteString(r.caps.moveCursor(r.linesRendered, 0))
+		return
+	}
+	if down := r.linesRendered - 1 - r.cursorParkedY; down > 0 {
+		_, _ = out.WriteString(r.caps.cursorDown(down))
+	}
+	_, _ = out.WriteString("\r")
+}
+
+// resize sets the size of the window. Rather than painting the next frame in
+// full, which blanks the screen for a moment on each resize as the window is
+// dragged, the last frame is cut to what's left of it on the screen, and the
+// next frame is painted over it like any other. That's only done in the alt
+// screen, and outside of it when the window doesn't get narrower or shorter
+// than the last frame, as terminals may rewrap or scroll lines otherwise.
+func (r *standardRenderer) resize(width, height int) {
+	lastWidth := r.width
+	r.width, r.height = width, height
+	if !r.painted {
+		return
+	}
+	// The next frame is painted even if the view didn't change, as lines cut
+	// at the last width may fit now.
+	r.lastHash = ^r.viewHash
+
+	switch {
+	case r.altScreenActive && len(r.ignoreLines) == 0:
+		lines := r.lastLines
+		if height > 0 && len(lines) > height {
+			lines = lines[:height]
+		}
+		if width > 0 {
+			for i, line := range lines {
+				if len(line) > width && r.widths.stringWidth(line) > width {
+					lines[i] = r.widths.truncateLine(line, width)
+				}
+			}
+		}
+		r.lastLines, r.linesRendered = lines, len(lines)
+		// Only start diffing cells again once a frame was painted at the new
+		// size.
+		r.lastCells = nil
+		r.unparkCursor(r.out)
+	case !r.altScreenActive && lastWidth > 0 && width >= lastWidth && (height == 0 || r.linesRendered <= height):
+	default:
+		r.repaint()
+	}
 }
 
 func (r *standardRenderer) repaint() {
-	r.lastRender = ""
+	r.painted = false
 }
 
 func (r *standardRenderer) clearScreen() {
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
 
-	r.out.ClearScreen()
-	r.out.MoveCursor(1, 1)
+	_, _ = r.out.WriteString(r.caps.clearScreen)
+	_, _ = r.out.WriteString(r.caps.moveCursor(1, 1))
+	r.cursorParked = false
 
 	r.repaint()
 }
@@ -324,7 +936,7 @@ func (r *standardRenderer) enterAltScreen() {
 	}
 
 	r.altScreenActive = true
-	r.out.AltScreen()
+	_, _ = r.out.WriteString(r.caps.enterAltScreen)
 
 	// Ensure that the terminal is cleared, even when it doesn't support
 	// alt screen (or alt screen support is disabled, like GNU screen by
@@ -332,18 +944,19 @@ func (r *standardRenderer) enterAltScreen() {
 	//
 	// Note: we can't use r.clearScreen() here because the mutex is already
 	// locked.
-	r.out.ClearScreen()
-	r.out.MoveCursor(1, 1)
+	_, _ = r.out.WriteString(r.caps.clearScreen)
+	_, _ = r.out.WriteString(r.caps.moveCursor(1, 1))
 
 	// cmd.exe and other terminals keep separate cursor states for the AltScreen
 	// and the main buffer. We have to explicitly reset the cursor visibility
 	// whenever we enter AltScreen.
 	if r.cursorHidden {
-		r.out.HideCursor()
+		_, _ = r.out.WriteString(r.caps.hideCursor)
 	} else {
-		r.out.ShowCursor()
+		_, _ = r.out.WriteString(r.caps.showCursor)
 	}
 
+	r.cursorParked = false
 	r.repaint()
 }
 
@@ -356,17 +969,18 @@ func (r *standardRenderer) exitAltScreen() {
 	}
 
 	r.altScreenActive = false
-	r.out.ExitAltScreen()
+	_, _ = r.out.WriteString(r.caps.exitAltScreen)
 
 	// cmd.exe and other terminals keep separate cursor states for the AltScreen
 	// and the main buffer. We have to explicitly reset the cursor visibility
 	// whenever we exit AltScreen.
 	if r.cursorHidden {
-		r.out.HideCursor()
+		_, _ = r.out.WriteString(r.caps.hideCursor)
 	} else {
-		r.out.ShowCursor()
+		_, _ = r.out.WriteString(r.caps.showCursor)
 	}
 
+	r.cursorParked = false
 	r.repaint()
 }
 
@@ -375,7 +989,7 @@ func (r *standardRenderer) showCursor() {
 	defer r.mtx.Unlock()
 
 	r.cursorHidden = false
-	r.out.ShowCursor()
+	_, _ = r.out.WriteString(r.caps.showCursor)
 }
 
 func (r *standardRenderer) hideCursor() {
@@ -383,7 +997,7 @@ func (r *standardRenderer) hideCursor() {
 	defer r.mtx.Unlock()
 
 	r.cursorHidden = true
-	r.out.HideCursor()
+	_, _ = r.out.WriteString(r.caps.hideCursor)
 }
 
 func (r *standardRenderer) enableMouseCellMotion() {
@@ -428,6 +1042,34 @@ func (r *standardRenderer) disableMouseSGRMode() {
 	r.out.DisableMouseExtendedMode()
 }
 
+func (r *standardRenderer) enableMousePixelsMode() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.out.EnableMousePixelsMode()
+	_, _ = r.out.WriteString(queryModeSequence(mousePixelsMode) + queryCellSizeSequence)
+	r.mousePixelsActive = true
+}
+
+func (r *standardRenderer) disableMousePixelsMode() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if !r.mousePixelsActive {
+		return
+	}
+
+	r.out.DisableMousePixelsMode()
+	r.mousePixelsActive = false
+}
+
+func (r *standardRenderer) queryCellSize() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	_, _ = r.out.WriteString(queryCellSizeSequence)
+}
+
 func (r *standardRenderer) enableBracketedPaste() {
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
@@ -451,6 +1093,80 @@ func (r *standardRenderer) bracketedPasteActive() bool {
 	return r.bpActive
 }
 
+func (r *standardRenderer) enableKittyKeyboard(flags KittyKeyboardFlags) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.kittyKeyboardActive {
+		return
+	}
+
+	// The flags enabled are queried, as they tell how alt is reported.
+	_, _ = r.out.WriteString(r.multiplexer.Passthrough(fmt.Sprintf(termenv.CSI+">%du", flags)))
+	_, _ = r.out.WriteString(r.multiplexer.Passthrough(termenv.CSI + "?u"))
+	r.kittyKeyboardActive = true
+}
+
+func (r *standardRenderer) disableKittyKeyboard() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if !r.kittyKeyboardActive {
+		return
+	}
+
+	_, _ = r.out.WriteString(r.multiplexer.Passthrough(termenv.CSI + "<u"))
+	r.kittyKeyboardActive = false
+}
+
+// enableModifyOtherKeys sets xterm's modifyOtherKeys resource to 2, where
+// every modified key is reported as an escape sequence. It isn't passed
+// through multiplexers, as tmux reports extended keys itself when asked to.
+func (r *standardRenderer) enableModifyOtherKeys() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.modifyOtherKeysActive {
+		return
+	}
+
+	_, _ = r.out.WriteString(termenv.CSI + ">4;2m")
+	r.modifyOtherKeysActive = true
+}
+
+func (r *standardRenderer) disableModifyOtherKeys() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if !r.modifyOtherKeysActive {
+		return
+	}
+
+	_, _ = r.out.WriteString(termenv.CSI + ">4m")
+	r.modifyOtherKeysActive = false
+}
+
+func (r *standardRenderer) setClipboard(s string) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	_, _ = r.multiplexer.osc52(osc52.New(s)).WriteTo(r.out)
+}
+
+func (r *standardRenderer) readClipboard() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	_, _ = r.multiplexer.osc52(osc52.Query()).WriteTo(r.out)
+}
+
+func (r *standardRenderer) setSynchronizedOutput(v bool) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.syncOutput = v
+}
+
 // setIgnoredLines specifies lines not to be touched by the standard Bubble Tea
 // renderer.
 func (r *standardRenderer) setIgnoredLines(from int, to int) {
@@ -470,17 +1186,19 @@ func (r *standardRenderer) setIgnoredLines(from int, to int) {
 
 	// Erase ignored lines
 	if r.linesRendered > 0 {
-		buf := &bytes.Buffer{}
-		out := termenv.NewOutput(buf)
+		w := r.frameWriter()
+		out := w.out
 
 		for i := r.linesRendered - 1; i >= 0; i-- {
 			if _, exists := r.ignoreLines[i]; exists {
-				out.ClearLine()
+				_, _ = out.WriteString(r.caps.clearLine)
 			}
-			out.CursorUp(1)
+			_, _ = out.WriteString(r.caps.cursorUp(1))
 		}
-		out.MoveCursor(r.linesRendered, 0) // put cursor back
-		_, _ = r.out.Write(buf.Bytes())
+		_, _ = out.WriteString(r.caps.moveCursor(r.linesRendered, 0)) // put cursor back
+		r.cursorParked = false
+		r.cursorDirty = r.cursor != nil
+		w.done()
 	}
 }
 
@@ -513,19 +1231,23 @@ func (r *standardRenderer) insertTop(lines []string, topBoundary, bottomBoundary
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
 
-	buf := &bytes.Buffer{}
-	out := termenv.NewOutput(buf)
+	lines = r.scrollAreaLines(lines, bottomBoundary-topBoundary+1, true)
 
-	out.ChangeScrollingRegion(topBoundary, bottomBoundary)
-	out.MoveCursor(topBoundary, 0)
-	out.InsertLines(len(lines))
+	w := r.frameWriter()
+	out := w.out
+
+	_, _ = out.WriteString(r.caps.scrollRegion(topBoundary, bottomBoundary))
+	_, _ = out.WriteString(r.caps.moveCursor(topBoundary, 0))
+	_, _ = out.WriteString(r.caps.insertLines(len(lines)))
 	_, _ = out.WriteString(strings.Join(lines, "\r\n"))
-	out.ChangeScrollingRegion(0, r.height)
+	_, _ = out.WriteString(r.caps.scrollRegion(0, r.height))
 
 	// Move cursor back to where the main rendering routine expects it to be
-	out.MoveCursor(r.linesRendered, 0)
+	_, _ = out.WriteString(r.caps.moveCursor(r.linesRendered, 0))
+	r.cursorParked = false
+	r.cursorDirty = r.cursor != nil
 
-	_, _ = r.out.Write(buf.Bytes())
+	w.done()
 }
 
 // insertBottom effectively scrolls down. It inserts lines at the bottom of
@@ -541,22 +1263,52 @@ func (r *standardRenderer) insertBottom(lines []string, topBoundary, bottomBound
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
 
-	buf := &bytes.Buffer{}
-	out := termenv.NewOutput(buf)
+	lines = r.scrollAreaLines(lines, bottomBoundary-topBoundary+1, false)
+
+	w := r.frameWriter()
+	out := w.out
 
-	out.ChangeScrollingRegion(topBoundary, bottomBoundary)
-	out.MoveCursor(bottomBoundary, 0)
+	_, _ = out.WriteString(r.caps.scrollRegion(topBoundary, bottomBoundary))
+	_, _ = out.WriteString(r.caps.moveCursor(bottomBoundary, 0))
 	_, _ = out.WriteString("\r\n" + strings.Join(lines, "\r\n"))
-	out.ChangeScrollingRegion(0, r.height)
+	_, _ = out.WriteString(r.caps.scrollRegion(0, r.height))
 
 	// Move cursor back to where the main rendering routine expects it to be
-	out.MoveCursor(r.linesRendered, 0)
+	_, _ = out.WriteString(r.caps.moveCursor(r.linesRendered, 0))
+	r.cursorParked = false
+	r.cursorDirty = r.cursor != nil
 
-	_, _ = r.out.Write(buf.Bytes())
+	w.done()
+}
+
+// scrollAreaLines returns the lines to insert into a scrollable area of the
+// given height: the ones which fit, taken from the top or the bottom, cut at
+// the width of the window so they don't wrap.
+func (r *standardRenderer) scrollAreaLines(lines []string, height int, top bool) []string {
+	if height > 0 && len(lines) > height {
+		if top {
+			lines = lines[:height]
+		} else {
+			lines = lines[len(lines)-height:]
+		}
+	}
+	if r.width <= 0 {
+		return lines
+	}
+	cut := make([]string, len(lines))
+	for i, line := range lines {
+		if r.tabWidth > 0 && strings.IndexByte(line, '\t') >= 0 {
+			line = r.widths.expandTabs(line, r.tabWidth)
+		}
+		cut[i] = r.widths.truncateLine(line, r.width)
+	}
+	return cut
 }
 
 // handleMessages handles internal messages for the renderer.
 func (r *standardRenderer) handleMessages(msg Msg) {
+	r.active()
+
 	switch msg := msg.(type) {
 	case repaintMsg:
 		// Force a repaint by clearing the render cache as we slide into a
@@ -567,9 +1319,7 @@ func (r *standardRenderer) handleMessages(msg Msg) {
 
 	case WindowSizeMsg:
 		r.mtx.Lock()
-		r.width = msg.Width
-		r.height = msg.Height
-		r.repaint()
+		r.resize(msg.Width, msg.Height)
 		r.mtx.Unlock()
 
 	case clearScrollAreaMsg:
@@ -598,6 +1348,11 @@ func (r *standardRenderer) handleMessages(msg Msg) {
 	case scrollDownMsg:
 		r.insertBottom(msg.lines, msg.topBoundary, msg.bottomBoundary)
 
+	case transmitImageMsg, placeImageMsg, deleteImageMsg:
+		r.mtx.Lock()
+		r.handleImageMessage(msg)
+		r.mtx.Unlock()
+
 	case printLineMessage:
 		if !r.altScreenActive {
 			lines := strings.Split(msg.messageBody, "\n")
@@ -621,7 +1376,10 @@ type syncScrollAreaMsg struct {
 // scrollable area. This is required to initialize the scrollable region and
 // should also be called on resize (WindowSizeMsg).
 //
-// For high-performance, scroll-based rendering only.
+// For high-performance, scroll-based rendering only. Views on the alternate
+// screen which simply append lines to a log, or prepend them, don't need it:
+// the renderer notices that the lines scrolled and scrolls them on the
+// terminal too, only painting the new lines.
 func SyncScrollArea(lines []string, topBoundary int, bottomBoundary int) Cmd {
 	return func() Msg {
 		return syncScrollAreaMsg{
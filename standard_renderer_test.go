@@ -0,0 +1,396 @@
+package tea
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/muesli/termenv"
+)
+
+func TestStandardRendererChanges(t *testing.T) {
+	tests := []struct {
+		name      string
+		altScreen bool
+		first     string
+		second    string
+		expected  string
+	}{
+		{
+			name:     "unchanged lines are left alone",
+			first:    "a\nb\nc",
+			second:   "a\nB\nc",
+			expected: "\x1b[1A\r\x1b[0KB\x1b[1B\r\x1b[0D",
+		},
+		{
+			name:     "shared text is skipped",
+			first:    "hello world",
+			second:   "hello there",
+			expected: "\r\x1b[6C\x1b[0Kthere\r\x1b[0D",
+		},
+		{
+			name:     "appended text",
+			first:    "ab",
+			second:   "abcd",
+			expected: "\r\x1b[2Ccd\r\x1b[0D",
+		},
+		{
+			name:     "shared text stops at escape sequences",
+			first:    "ab\x1b[1mc",
+			second:   "ab\x1b[1md",
+			expected: "\r\x1b[2C\x1b[0K\x1b[1md\r\x1b[0D",
+		},
+		{
+			name:     "shared text doesn't end before combining runes",
+			first:    "cafe",
+			second:   "cafe\u0301",
+			expected: "\r\x1b[3C\x1b[0Ke\u0301\r\x1b[0D",
+		},
+		{
+			name:     "taller frame",
+			first:    "a",
+			second:   "a\nb\nc",
+			expected: "\r\r\nb\r\nc\r\x1b[0D",
+		},
+		{
+			name:     "shorter frame",
+			first:    "a\nb\nc",
+			second:   "a",
+			expected: "\x1b[1A\r\x1b[2K\x1b[1B\r\x1b[2K\x1b[2A\r\x1b[0D",
+		},
+		{
+			name:      "alt screen",
+			altScreen: true,
+			first:     "a\nb\nc",
+			second:    "a\nbb\nc",
+			expected:  "\x1b[2;2Hb\x1b[3;0H",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			r := newRenderer(termenv.NewOutput(&buf), false, 0).(*standardRenderer)
+			r.altScreenActive = test.altScreen
+			r.paintSuffixes = true
+
+			r.write(test.first)
+			r.flush()
+			buf.Reset()
+
+			r.write(test.second)
+			r.flush()
+			if got := buf.String(); got != test.expected {
+				t.Errorf("expected:\n%q\ngot:\n%q", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestStandardRendererWholeLines(t *testing.T) {
+	var buf bytes.Buffer
+	r := newRenderer(termenv.NewOutput(&buf), false, 0).(*standardRenderer)
+
+	r.write("a\nhello world")
+	r.flush()
+	buf.Reset()
+
+	// Outside of terminals, changed lines are painted whole, so that the
+	// output can be searched for them.
+	r.write("a\nhello there")
+	r.flush()
+	if got, want := buf.String(), "\r\x1b[2Khello there\r\x1b[0D"; got != want {
+		t.Errorf("expected:\n%q\ngot:\n%q", want, got)
+	}
+}
+
+func TestStandardRendererRepaint(t *testing.T) {
+	var buf bytes.Buffer
+	r := newRenderer(termenv.NewOutput(&buf), false, 0).(*standardRenderer)
+
+	r.write("a\nb")
+	r.flush()
+	buf.Reset()
+
+	// After a repaint, the whole frame is painted again.
+	r.repaint()
+	r.write("a\nc")
+	r.flush()
+	if got, want := buf.String(), "\x1b[2K\x1b[1A\x1b[0D\x1b[2K\ra\r\nc\x1b[0D"; got != want {
+		t.Errorf("expected:\n%q\ngot:\n%q", want, got)
+	}
+}
+
+func TestStandardRendererIdenticalFrames(t *testing.T) {
+	var buf bytes.Buffer
+	r := newRenderer(termenv.NewOutput(&buf), false, 0).(*standardRenderer)
+
+	r.write("a\nb")
+	r.flush()
+	buf.Reset()
+
+	// Frames identical to the one on the screen aren't painted.
+	r.write("a\nc")
+	r.write("a\nb")
+	r.flush()
+	if buf.Len() != 0 {
+		t.Errorf("expected nothing to be painted, got %q", buf.String())
+	}
+
+	// Unless a repaint was requested.
+	r.repaint()
+	r.write("a\nb")
+	r.flush()
+	if buf.Len() == 0 {
+		t.Error("expected the frame to be painted again")
+	}
+}
+
+func TestStandardRendererReservedHeight(t *testing.T) {
+	var buf bytes.Buffer
+	r := newRenderer(termenv.NewOutput(&buf), false, 0).(*standardRenderer)
+	r.reservedHeight = 3
+
+	// Short frames are padded to the reserved height.
+	r.write("a")
+	r.flush()
+	if r.linesRendered != 3 {
+		t.Errorf("expected 3 lines to be rendered, got %d", r.linesRendered)
+	}
+
+	// Tall frames lose their top lines.
+	r.write("a\nb\nc\nd")
+	r.flush()
+	if r.linesRendered != 3 || r.lastLines[0] != "b" {
+		t.Errorf("expected lines b to d to be rendered, got %q", r.lastLines)
+	}
+
+	// The reserved height is capped at the height of the window.
+	r.height = 2
+	r.write("a")
+	r.flush()
+	if r.linesRendered != 2 {
+		t.Errorf("expected 2 lines to be rendered, got %d", r.linesRendered)
+	}
+
+	// The alt screen isn't affected.
+	r.altScreenActive = true
+	r.write("b")
+	r.flush()
+	if r.linesRendered != 1 {
+		t.Errorf("expected 1 line to be rendered, got %d", r.linesRendered)
+	}
+}
+
+func TestStandardRendererTabs(t *testing.T) {
+	var buf bytes.Buffer
+	r := newRenderer(termenv.NewOutput(&buf), false, 0).(*standardRenderer)
+
+	r.write("a\tb")
+	r.flush()
+	if r.lastLines[0] != "a       b" {
+		t.Errorf("expected the tab to be expanded, got %q", r.lastLines[0])
+	}
+
+	r.tabWidth = 0
+	r.write("a\tc")
+	r.flush()
+	if r.lastLines[0] != "a\tc" {
+		t.Errorf("expected the tab to be kept, got %q", r.lastLines[0])
+	}
+}
+
+func TestStandardRendererResize(t *testing.T) {
+	var buf bytes.Buffer
+	r := newRenderer(termenv.NewOutput(&buf), false, 0).(*standardRenderer)
+	r.width, r.height = 10, 3
+	r.altScreenActive = true
+
+	r.write("abcdef\nb\nc")
+	r.flush()
+
+	// In the alt screen, the last frame is cut to the new size rather than
+	// the screen being cleared.
+	buf.Reset()
+	r.resize(4, 2)
+	if !r.painted {
+		t.Fatal("expected the frame not to be painted in full")
+	}
+	if len(r.lastLines) != 2 || r.lastLines[0] != "abcd" {
+		t.Errorf("expected the last frame to be cut, got %q", r.lastLines)
+	}
+	r.write("abcdef\nb")
+	r.flush()
+	if strings.Contains(buf.String(), "ab") {
+		t.Errorf("expected the lines left on the screen not to be painted again, got %q", buf.String())
+	}
+
+	// Inline, frames are painted in full when the window gets narrower.
+	r.altScreenActive = false
+	r.resize(10, 3)
+	if !r.painted {
+		t.Error("expected the frame not to be painted in full when the window gets wider")
+	}
+	r.resize(3, 3)
+	if r.painted {
+		t.Error("expected the frame to be painted in full when the window gets narrower")
+	}
+}
+
+func TestStandardRendererMinFPS(t *testing.T) {
+	var buf bytes.Buffer
+	r := newRenderer(termenv.NewOutput(&buf), false, 10).(*standardRenderer)
+
+	r.setMinFPS(2)
+	if r.idleFramerate != 500*time.Millisecond {
+		t.Errorf("expected an idle framerate of 500ms, got %v", r.idleFramerate)
+	}
+
+	// The minimum can't be over the maximum.
+	r.setMinFPS(20)
+	if r.idleFramerate != r.framerate {
+		t.Errorf("expected an idle framerate of %v, got %v", r.framerate, r.idleFramerate)
+	}
+
+	// Waking the renderer up never blocks.
+	r.active()
+	r.active()
+
+	r.setMinFPS(0)
+	if r.idleFramerate != 0 {
+		t.Errorf("expected the framerate not to be adaptive, got %v", r.idleFramerate)
+	}
+}
+
+func TestStandardRendererPacing(t *testing.T) {
+	var buf bytes.Buffer
+	r := newRenderer(termenv.NewOutput(&buf), false, 0).(*standardRenderer)
+
+	// Without a limit, frames are never held off.
+	r.write("hello")
+	r.flush()
+	if r.paced() {
+		t.Error("expected frames not to be held off")
+	}
+
+	// With one, the next frame waits for the last one to go through.
+	r.maxBytesPerSecond = 100
+	written, start := buf.Len(), time.Now()
+	r.write("hello there")
+	r.flush()
+	if !r.paced() {
+		t.Error("expected frames to be held off")
+	}
+	wait := r.pacedUntil.Sub(start)
+	if want := time.Duration(buf.Len()-written) * 10 * time.Millisecond; wait < want || wait > want+time.Second/10 {
+		t.Errorf("expected frames to be held off for about %v, got %v", want, wait)
+	}
+}
+
+func TestStandardRendererScroll(t *testing.T) {
+	tests := []struct {
+		name      string
+		altScreen bool
+		second    string
+		expected  string
+	}{
+		{
+			name:      "lines scrolled up",
+			altScreen: true,
+			second:    "header\nb\nc\nd\nfooter",
+			expected:  "\x1b[m\x1b[2;4r\x1b[4;1H\n\x1b[1;5r\x1b[5;1H\x1b[4;1Hd\x1b[5;0H",
+		},
+		{
+			name:      "lines scrolled down",
+			altScreen: true,
+			second:    "header\nz\na\nb\nfooter",
+			expected:  "\x1b[m\x1b[2;4r\x1b[2;1H\x1b[1L\x1b[1;5r\x1b[5;1H\x1b[2;1Hz\x1b[5;0H",
+		},
+		{
+			name:     "inline frames aren't scrolled",
+			second:   "header\nb\nc\nd\nfooter",
+			expected: "\x1b[3A\r\x1b[0Kb\x1b[1B\r\x1b[0Kc\x1b[1B\r\x1b[0Kd\x1b[1B\r\x1b[80D",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			r := newRenderer(termenv.NewOutput(&buf), false, 0).(*standardRenderer)
+			r.altScreenActive = test.altScreen
+			r.paintSuffixes = true
+			r.width, r.height = 80, 5
+
+			r.write("header\na\nb\nc\nfooter")
+			r.flush()
+			buf.Reset()
+
+			r.write(test.second)
+			r.flush()
+			if got := buf.String(); got != test.expected {
+				t.Errorf("expected:\n%q\ngot:\n%q", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestScrollAreaLines(t *testing.T) {
+	r := newRenderer(termenv.NewOutput(&bytes.Buffer{}), false, 0).(*standardRenderer)
+	r.width = 3
+
+	lines := []string{"abcd", "efgh", "ijkl"}
+	if got := r.scrollAreaLines(lines, 2, true); len(got) != 2 || got[0] != "abc" || got[1] != "efg" {
+		t.Errorf("expected the first two lines, cut, got %q", got)
+	}
+	if got := r.scrollAreaLines(lines, 2, false); len(got) != 2 || got[0] != "efg" || got[1] != "ijk" {
+		t.Errorf("expected the last two lines, cut, got %q", got)
+	}
+}
+
+// benchmarkFrames returns frames of a large, styled view, each with a line
+// changed.
+func benchmarkFrames(n int) []string {
+	var lines []string
+	for i := 0; i < 60; i++ {
+		lines = append(lines, strings.Repeat("\x1b[1;32mcpu\x1b[m 42% \x1b[34m▇▇▇▇\x1b[m ", 8))
+	}
+	frames := make([]string, n)
+	for i := range frames {
+		lines[i%len(lines)] = strings.Repeat(fmt.Sprintf("\x1b[1;31mcpu\x1b[m %2d%% \x1b[34m▇▇▇▇\x1b[m ", i%100), 8)
+		frames[i] = strings.Join(lines, "\n")
+	}
+	return frames
+}
+
+func benchmarkRenderer(b *testing.B, setup func(r *standardRenderer)) {
+	frames := benchmarkFrames(100)
+	r := newRenderer(termenv.NewOutput(io.Discard), false, 0).(*standardRenderer)
+	r.width, r.height = 200, 60
+	setup(r)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.write(frames[i%len(frames)])
+		r.flush()
+	}
+}
+
+func BenchmarkStandardRenderer(b *testing.B) {
+	benchmarkRenderer(b, func(r *standardRenderer) {})
+}
+
+func BenchmarkStandardRendererSuffixes(b *testing.B) {
+	benchmarkRenderer(b, func(r *standardRenderer) { r.paintSuffixes = true })
+}
+
+func BenchmarkStandardRendererAltScreen(b *testing.B) {
+	benchmarkRenderer(b, func(r *standardRenderer) { r.altScreenActive = true })
+}
+
+func BenchmarkCellRenderer(b *testing.B) {
+	benchmarkRenderer(b, func(r *standardRenderer) { r.cellDiffing = true })
+}
@@ -0,0 +1,46 @@
+package tea
+
+import (
+	"errors"
+	"io"
+)
+
+// stdinChunkSize is the most data piped to standard input delivered in a
+// single StdinMsg.
+const stdinChunkSize = 32 * 1024
+
+// StdinMsg carries data piped to the standard input of a program started
+// with WithStdinMsgs, such as the output of the command before it in
+// "some-cmd | my-tui". The data is delivered as it's read, in chunks of any
+// size, the last StdinMsg having EOF set once the data ends, along with the
+// error reading it failed with, if any.
+type StdinMsg struct {
+	Data []byte
+	EOF  bool
+	Err  error
+}
+
+// readStdin reads data piped to standard input, sending it to the program
+// as StdinMsgs until it ends.
+func (p *Program) readStdin(r io.Reader) {
+	for {
+		buf := make([]byte, stdinChunkSize)
+		n, err := r.Read(buf)
+		if n > 0 {
+			p.Send(StdinMsg{Data: buf[:n]})
+		}
+		if err != nil {
+			msg := StdinMsg{EOF: true}
+			if !errors.Is(err, io.EOF) {
+				msg.Err = err
+			}
+			p.Send(msg)
+			return
+		}
+		select {
+		case <-p.ctx.Done():
+			return
+		default:
+		}
+	}
+}
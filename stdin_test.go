@@ -0,0 +1,48 @@
+package tea
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadStdin(t *testing.T) {
+	broken := errors.New("broken")
+	tests := []struct {
+		name string
+		r    io.Reader
+		want []StdinMsg
+	}{
+		{"data", strings.NewReader("some data"), []StdinMsg{{Data: []byte("some data")}, {EOF: true}}},
+		{"empty", strings.NewReader(""), []StdinMsg{{EOF: true}}},
+		{
+			"error",
+			io.MultiReader(strings.NewReader("some"), &errorReader{broken}),
+			[]StdinMsg{{Data: []byte("some")}, {EOF: true, Err: broken}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := NewProgram(nil)
+			go p.readStdin(test.r)
+
+			var got []StdinMsg
+			for {
+				msg := (<-p.msgs).(StdinMsg)
+				got = append(got, msg)
+				if msg.EOF {
+					break
+				}
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("expected %v, got %v", test.want, got)
+			}
+		})
+	}
+}
+
+type errorReader struct{ err error }
+
+func (r *errorReader) Read([]byte) (int, error) { return 0, r.err }
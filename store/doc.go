@@ -0,0 +1,45 @@
+// Package store holds the state of large Bubble Tea programs in a single
+// store, changed only by dispatching actions to a reducer, for programs whose
+// models grew too tangled to pass every change through Update:
+//
+//	type state struct {
+//	    todos []string
+//	}
+//
+//	type addTodo struct{ text string }
+//
+//	func reduce(s state, action interface{}) state {
+//	    switch a := action.(type) {
+//	    case addTodo:
+//	        s.todos = append(s.todos[:len(s.todos):len(s.todos)], a.text)
+//	    }
+//	    return s
+//	}
+//
+//	var todos = store.New(state{}, reduce)
+//
+// Models read the state they need with Select, and re-render when it
+// changes, whoever dispatched the action that changed it:
+//
+//	func (m list) Init() tea.Cmd {
+//	    return store.Select(todos, countTodos, 0)
+//	}
+//
+//	func (m list) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+//	    switch msg := msg.(type) {
+//	    case store.SelectedMsg[int]:
+//	        m.count = msg.Value
+//	        return m, store.Select(todos, countTodos, msg.Value)
+//	    case tea.KeyMsg:
+//	        todos.Dispatch(addTodo{text: "new"})
+//	    }
+//	    return m, nil
+//	}
+//
+// Reducers should return a new state rather than change the one they're
+// given, so that the states handed out by the store aren't changed behind
+// the backs of the models holding them.
+//
+// Actions can be logged as they're dispatched, along with the states before
+// and after them, with Store.Observe and Store.LogActions.
+package store
@@ -0,0 +1,205 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Reducer returns the state following an action.
+type Reducer[S, A any] func(state S, action A) S
+
+// Entry is an action dispatched to a store, along with the states before and
+// after it, as passed to observers.
+type Entry[S, A any] struct {
+	// Version is the version of the state after the action, counting the
+	// actions dispatched to the store.
+	Version uint64
+
+	Action A
+	Prev   S
+	Next   S
+	Time   time.Time
+}
+
+// ChangedMsg is delivered by Subscribe when the state of a store changed.
+type ChangedMsg[S, A any] struct {
+	// State is the state of the store, and Version its version.
+	State   S
+	Version uint64
+
+	// Action is the latest action dispatched. Some actions may have been
+	// dispatched before it since the subscription was made.
+	Action A
+}
+
+// SelectedMsg is delivered by Select when the value it selects from the state
+// of a store changed.
+type SelectedMsg[T any] struct {
+	Value T
+}
+
+// Store holds a state, changed by dispatching actions to its reducer. It's
+// safe to use from any goroutine.
+type Store[S, A any] struct {
+	mu        sync.Mutex
+	state     S
+	action    A
+	version   uint64
+	reducer   Reducer[S, A]
+	changed   chan struct{}
+	observers []func(Entry[S, A])
+}
+
+// New returns a store holding an initial state, changed by actions through a
+// reducer.
+func New[S, A any](initial S, reducer Reducer[S, A]) *Store[S, A] {
+	return &Store[S, A]{
+		state:   initial,
+		reducer: reducer,
+		changed: make(chan struct{}),
+	}
+}
+
+// State returns the state of the store.
+func (s *Store[S, A]) State() S {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state
+}
+
+// Version returns the version of the state of the store, which is the number
+// of actions dispatched to it.
+func (s *Store[S, A]) Version() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.version
+}
+
+// Dispatch changes the state of the store by an action, and lets its
+// subscribers and observers know. Actions are applied one at a time, in the
+// order they're dispatched.
+func (s *Store[S, A]) Dispatch(action A) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev := s.state
+	s.state = s.reducer(prev, action)
+	s.action = action
+	s.version++
+	close(s.changed)
+	s.changed = make(chan struct{})
+
+	if len(s.observers) == 0 {
+		return
+	}
+	e := Entry[S, A]{Version: s.version, Action: action, Prev: prev, Next: s.state, Time: time.Now()}
+	for _, fn := range s.observers {
+		fn(e)
+	}
+}
+
+// DispatchCmd produces a command dispatching an action, for actions to be
+// dispatched along with other commands.
+func (s *Store[S, A]) DispatchCmd(action A) tea.Cmd {
+	return func() tea.Msg {
+		s.Dispatch(action)
+		return nil
+	}
+}
+
+// Observe has fn called with every action dispatched from now on, along with
+// the states before and after it. It's called as the action is dispatched, one
+// action at a time, so it should be fast, and must not dispatch actions
+// itself.
+func (s *Store[S, A]) Observe(fn func(Entry[S, A])) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.observers = append(s.observers, fn)
+}
+
+// LogActions logs every action dispatched from now on to w, along with the
+// state following it, such as to a file logging was set up with tea.LogToFile
+// while debugging.
+func (s *Store[S, A]) LogActions(w io.Writer) {
+	s.Observe(func(e Entry[S, A]) {
+		fmt.Fprintf(w, "%s #%d %T %+v\n\tstate: %+v\n", e.Time.Format("15:04:05.000"), e.Version, e.Action, e.Action, e.Next)
+	})
+}
+
+// Subscribe produces a command delivering a ChangedMsg when the state of the
+// store changes from now on. Subscriptions are for a single change: models
+// subscribe again as they receive the message, with SubscribeAfter so that
+// changes made in the meantime aren't missed.
+//
+// Commands waiting for a change end when the program exits, so they must be
+// run by a program.
+func (s *Store[S, A]) Subscribe() tea.Cmd {
+	return s.SubscribeAfter(s.Version())
+}
+
+// SubscribeAfter produces a command delivering a ChangedMsg as soon as the
+// version of the state of the store is past the given one, such as the
+// version of the last ChangedMsg received.
+func (s *Store[S, A]) SubscribeAfter(version uint64) tea.Cmd {
+	return tea.Cancelable(func(ctx context.Context) tea.Msg {
+		return s.waitChange(ctx, version)
+	})
+}
+
+// waitChange waits until the version of the state is past the given one, and
+// returns a ChangedMsg, or nil if ctx is done first.
+func (s *Store[S, A]) waitChange(ctx context.Context, version uint64) tea.Msg {
+	for {
+		s.mu.Lock()
+		if s.version > version {
+			msg := ChangedMsg[S, A]{State: s.state, Version: s.version, Action: s.action}
+			s.mu.Unlock()
+			return msg
+		}
+		changed := s.changed
+		s.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Select produces a command delivering a SelectedMsg with the value selector
+// picks out of the state of a store, as soon as it's other than the last one,
+// such as the value of the last SelectedMsg received. Models pick the state
+// they need with it, so that they're only updated when that state changes.
+//
+// Like with Subscribe, selections are for a single change, and must be run
+// by a program.
+func Select[S, A any, T comparable](s *Store[S, A], selector func(S) T, last T) tea.Cmd {
+	return tea.Cancelable(func(ctx context.Context) tea.Msg {
+		return waitSelect(ctx, s, selector, last)
+	})
+}
+
+// waitSelect waits until the value selector picks out of the state is other
+// than last, and returns a SelectedMsg, or nil if ctx is done first.
+func waitSelect[S, A any, T comparable](ctx context.Context, s *Store[S, A], selector func(S) T, last T) tea.Msg {
+	for {
+		s.mu.Lock()
+		state, changed := s.state, s.changed
+		s.mu.Unlock()
+
+		if v := selector(state); v != last {
+			return SelectedMsg[T]{Value: v}
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type counter struct {
+	count int
+	name  string
+}
+
+type (
+	increment struct{}
+	rename    struct{ name string }
+)
+
+func reduce(s counter, action interface{}) counter {
+	switch a := action.(type) {
+	case increment:
+		s.count++
+	case rename:
+		s.name = a.name
+	}
+	return s
+}
+
+func TestDispatch(t *testing.T) {
+	s := New(counter{}, reduce)
+
+	var entries []Entry[counter, interface{}]
+	s.Observe(func(e Entry[counter, interface{}]) {
+		entries = append(entries, e)
+	})
+
+	s.Dispatch(increment{})
+	s.Dispatch(increment{})
+	if got := s.State(); got.count != 2 {
+		t.Errorf("expected a count of 2, got %d", got.count)
+	}
+	if v := s.Version(); v != 2 {
+		t.Errorf("expected version 2, got %d", v)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if e := entries[1]; e.Version != 2 || e.Prev.count != 1 || e.Next.count != 2 || e.Action != (increment{}) {
+		t.Errorf("unexpected entry %+v", e)
+	}
+
+	if msg := s.DispatchCmd(rename{"a"})(); msg != nil {
+		t.Errorf("expected no message, got %#v", msg)
+	}
+	if got := s.State(); got.name != "a" {
+		t.Errorf("expected the name to be a, got %q", got.name)
+	}
+}
+
+func TestLogActions(t *testing.T) {
+	var buf bytes.Buffer
+	s := New(counter{}, reduce)
+	s.LogActions(&buf)
+	s.Dispatch(rename{"a"})
+
+	if out := buf.String(); !strings.Contains(out, "#1 store.rename {name:a}") || !strings.Contains(out, "state: {count:0 name:a}") {
+		t.Errorf("unexpected log %q", out)
+	}
+}
+
+func TestWaitChange(t *testing.T) {
+	s := New(counter{}, reduce)
+
+	done := make(chan tea.Msg)
+	go func() { done <- s.waitChange(context.Background(), 0) }()
+	time.Sleep(10 * time.Millisecond)
+	s.Dispatch(increment{})
+
+	select {
+	case msg := <-done:
+		changed, ok := msg.(ChangedMsg[counter, interface{}])
+		if !ok || changed.State.count != 1 || changed.Version != 1 || changed.Action != (increment{}) {
+			t.Errorf("unexpected message %#v", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the change")
+	}
+
+	// Changes made before waiting aren't missed.
+	if msg := s.waitChange(context.Background(), 0); msg == nil {
+		t.Error("expected a change past version 0")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if msg := s.waitChange(ctx, 1); msg != nil {
+		t.Errorf("expected no message once canceled, got %#v", msg)
+	}
+}
+
+func TestWaitSelect(t *testing.T) {
+	s := New(counter{}, reduce)
+	count := func(c counter) int { return c.count }
+
+	done := make(chan tea.Msg)
+	go func() { done <- waitSelect(context.Background(), s, count, 0) }()
+	time.Sleep(10 * time.Millisecond)
+
+	// Changes to other parts of the state aren't selected.
+	s.Dispatch(rename{"a"})
+	select {
+	case msg := <-done:
+		t.Fatalf("expected no message, got %#v", msg)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	s.Dispatch(increment{})
+	select {
+	case msg := <-done:
+		if msg != (SelectedMsg[int]{Value: 1}) {
+			t.Errorf("unexpected message %#v", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the selection")
+	}
+}
+
+type model struct {
+	store *Store[counter, interface{}]
+	seen  []int
+}
+
+func (m model) Init() tea.Cmd {
+	return Select(m.store, func(c counter) int { return c.count }, 0)
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(SelectedMsg[int]); ok {
+		m.seen = append(m.seen, msg.Value)
+		if msg.Value == 3 {
+			return m, tea.Quit
+		}
+		return m, Select(m.store, func(c counter) int { return c.count }, msg.Value)
+	}
+	return m, nil
+}
+
+func (m model) View() string {
+	return ""
+}
+
+func TestProgram(t *testing.T) {
+	s := New(counter{}, reduce)
+	go func() {
+		for i := 0; i < 3; i++ {
+			time.Sleep(5 * time.Millisecond)
+			s.Dispatch(increment{})
+		}
+	}()
+
+	var buf bytes.Buffer
+	p := tea.NewProgram(model{store: s}, tea.WithInput(nil), tea.WithOutput(&buf))
+	final, err := p.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := final.(model).seen
+	if len(seen) == 0 || seen[len(seen)-1] != 3 {
+		t.Errorf("expected the last count seen to be 3, got %v", seen)
+	}
+}
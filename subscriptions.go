@@ -0,0 +1,230 @@
+package tea
+
+import (
+	"context"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// SubscriptionModel is implemented by models that declare the long-lived
+// sources of messages they listen to, such as tickers, file watchers or
+// channels, rather than starting them with commands and starting them again
+// after each message:
+//
+//	func (m model) Subscriptions() []tea.Subscription {
+//	    if m.paused {
+//	        return nil
+//	    }
+//	    return []tea.Subscription{
+//	        tea.TickSubscription("clock", time.Second, func(t time.Time) tea.Msg {
+//	            return tickMsg(t)
+//	        }),
+//	    }
+//	}
+//
+// Subscriptions is called as the program starts and after every update.
+// Subscriptions are told apart by their IDs: the ones with IDs that weren't
+// there before are started, and the ones whose IDs are gone are stopped.
+// Subscriptions with the same ID as a running one are left running as they
+// are, so parameters the subscription depends on, such as its interval,
+// should be part of its ID for it to be restarted when they change.
+//
+// Every subscription ends when the program exits.
+type SubscriptionModel interface {
+	Model
+
+	// Subscriptions returns the subscriptions the model listens to.
+	Subscriptions() []Subscription
+}
+
+// Subscription is a long-lived source of messages declared by a
+// SubscriptionModel.
+type Subscription struct {
+	id  interface{}
+	run func(p *Program, ctx context.Context, send func(Msg))
+}
+
+// NewSubscription returns a subscription running fn until it's stopped, with
+// a function sending messages to the program. fn should return once ctx is
+// done. The ID must be unique among the subscriptions of the model.
+//
+//	tea.NewSubscription("jobs", func(ctx context.Context, send func(tea.Msg)) {
+//	    for {
+//	        job, err := queue.Next(ctx)
+//	        if err != nil {
+//	            return
+//	        }
+//	        send(jobMsg(job))
+//	    }
+//	})
+func NewSubscription[ID comparable](id ID, fn func(ctx context.Context, send func(Msg))) Subscription {
+	return Subscription{id: id, run: func(_ *Program, ctx context.Context, send func(Msg)) {
+		fn(ctx, send)
+	}}
+}
+
+// TickSubscription returns a subscription delivering the message fn returns
+// every given duration, on the program's clock, as Tick does, starting one
+// duration after it's started.
+func TickSubscription[ID comparable](id ID, d time.Duration, fn func(time.Time) Msg) Subscription {
+	return Subscription{id: id, run: func(p *Program, ctx context.Context, send func(Msg)) {
+		for {
+			timer := p.clock.NewTimer(d)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case t := <-timer.C():
+				send(fn(t))
+			}
+		}
+	}}
+}
+
+// ChannelSubscription returns a subscription delivering the message fn
+// returns for each value received from a channel, until it's closed.
+func ChannelSubscription[ID comparable, T any](id ID, ch <-chan T, fn func(T) Msg) Subscription {
+	return Subscription{id: id, run: func(_ *Program, ctx context.Context, send func(Msg)) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok := <-ch:
+				if !ok {
+					return
+				}
+				send(fn(v))
+			}
+		}
+	}}
+}
+
+// FileSubscription returns a subscription delivering the message fn returns
+// for each change to a file, as WatchFile does.
+func FileSubscription[ID comparable](id ID, path string, fn func(FileEvent) Msg) Subscription {
+	return watchSubscription(id, watchMsg{path: filepath.Clean(path), file: true, fn: fn})
+}
+
+// DirSubscription returns a subscription delivering the message fn returns
+// for each change to the files of a directory, as WatchDir does.
+func DirSubscription[ID comparable](id ID, path string, fn func(FileEvent) Msg) Subscription {
+	return watchSubscription(id, watchMsg{path: filepath.Clean(path), fn: fn})
+}
+
+// watchSubscription returns a subscription watching a file or directory.
+func watchSubscription(id interface{}, w watchMsg) Subscription {
+	return Subscription{id: id, run: func(p *Program, ctx context.Context, send func(Msg)) {
+		p.watchUntil(ctx, w, send)
+	}}
+}
+
+// wrappedSubscriptionID is the ID of a subscription wrapped with
+// WrapSubscriptions.
+type wrappedSubscriptionID struct {
+	wrapID, id interface{}
+}
+
+// WrapSubscriptions wraps the subscriptions of a child model with an ID, as
+// Wrap does with its commands: the messages they deliver are wrapped with the
+// ID, and so are their IDs, so that they don't clash with the ones of other
+// children. Parents declare the subscriptions of their children with it:
+//
+//	func (m model) Subscriptions() []tea.Subscription {
+//	    return append(
+//	        tea.WrapSubscriptions("sidebar", m.sidebar.Subscriptions()),
+//	        tea.WrapSubscriptions("editor", m.editor.Subscriptions())...,
+//	    )
+//	}
+func WrapSubscriptions[ID comparable](id ID, subs []Subscription) []Subscription {
+	wrapped := make([]Subscription, len(subs))
+	for i, s := range subs {
+		run := s.run
+		wrapped[i] = Subscription{
+			id: wrappedSubscriptionID{wrapID: id, id: s.id},
+			run: func(p *Program, ctx context.Context, send func(Msg)) {
+				run(p, ctx, func(msg Msg) {
+					send(mapMsg(msg, func(msg Msg) Msg {
+						return WrappedMsg{ID: id, Msg: msg}
+					}))
+				})
+			},
+		}
+	}
+	return wrapped
+}
+
+// syncSubscriptions starts the subscriptions of the model that aren't running
+// yet, and stops the running ones the model no longer has. It's only called
+// from the event loop.
+func (p *Program) syncSubscriptions(model Model) {
+	m, ok := model.(SubscriptionModel)
+	if !ok && len(p.subscriptions) == 0 {
+		return
+	}
+
+	var subs []Subscription
+	if ok {
+		subs = m.Subscriptions()
+	}
+	wanted := make(map[interface{}]struct{}, len(subs))
+	for _, s := range subs {
+		if _, ok := wanted[s.id]; ok {
+			continue
+		}
+		wanted[s.id] = struct{}{}
+		if _, ok := p.subscriptions[s.id]; !ok {
+			p.startSubscription(s)
+		}
+	}
+	for id, stop := range p.subscriptions {
+		if _, ok := wanted[id]; !ok {
+			stop()
+			delete(p.subscriptions, id)
+		}
+	}
+}
+
+// subscriptionMsg is an internal message carrying a message sent by a
+// subscription, so that the event loop drops it if the subscription was
+// stopped since.
+type subscriptionMsg struct {
+	ctx context.Context
+	msg Msg
+}
+
+// startSubscription runs a subscription in the background until it's
+// stopped or the program exits. Messages it sends once it's stopped are
+// dropped.
+func (p *Program) startSubscription(s Subscription) {
+	if p.subscriptions == nil {
+		p.subscriptions = map[interface{}]context.CancelFunc{}
+	}
+	ctx, cancel := context.WithCancel(p.ctx)
+	p.subscriptions[s.id] = cancel
+
+	send := func(msg Msg) {
+		if msg == nil {
+			return
+		}
+		atomic.AddInt32(&p.queuedMsgs, 1)
+		defer atomic.AddInt32(&p.queuedMsgs, -1)
+
+		select {
+		case <-ctx.Done():
+		case p.msgs <- subscriptionMsg{ctx: ctx, msg: msg}:
+		}
+	}
+	go func() {
+		defer p.recoverFromGoPanic()
+		s.run(p, ctx, send)
+	}()
+}
+
+// stopSubscriptions stops every running subscription.
+func (p *Program) stopSubscriptions() {
+	for id, stop := range p.subscriptions {
+		stop()
+		delete(p.subscriptions, id)
+	}
+}
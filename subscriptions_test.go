@@ -0,0 +1,145 @@
+package tea
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+type subscriptionTickMsg struct{}
+
+type subscribingModel struct {
+	ticks int
+}
+
+func (m subscribingModel) Init() Cmd {
+	return nil
+}
+
+func (m subscribingModel) Update(msg Msg) (Model, Cmd) {
+	if _, ok := msg.(subscriptionTickMsg); ok {
+		m.ticks++
+		if m.ticks == 3 {
+			return m, Quit
+		}
+	}
+	return m, nil
+}
+
+func (m subscribingModel) View() string {
+	return ""
+}
+
+func (m subscribingModel) Subscriptions() []Subscription {
+	if m.ticks >= 3 {
+		return nil
+	}
+	return []Subscription{
+		TickSubscription("tick", time.Millisecond, func(time.Time) Msg {
+			return subscriptionTickMsg{}
+		}),
+	}
+}
+
+func TestSubscriptions(t *testing.T) {
+	p := NewProgram(subscribingModel{}, WithHeadlessRenderer(NewHeadlessRenderer()), WithInput(nil), WithClock(instantClock{}))
+	final, err := p.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ticks := final.(subscribingModel).ticks; ticks != 3 {
+		t.Errorf("expected ticks to stop once unsubscribed, got %d", ticks)
+	}
+	if len(p.subscriptions) != 0 {
+		t.Errorf("expected subscriptions to end with the program, got %d", len(p.subscriptions))
+	}
+}
+
+type subscriptionsModel []Subscription
+
+func (m subscriptionsModel) Init() Cmd                     { return nil }
+func (m subscriptionsModel) Update(Msg) (Model, Cmd)       { return m, nil }
+func (m subscriptionsModel) View() string                  { return "" }
+func (m subscriptionsModel) Subscriptions() []Subscription { return m }
+
+func TestSyncSubscriptions(t *testing.T) {
+	p := NewProgram(nil)
+	defer p.cancel()
+
+	var (
+		mtx     sync.Mutex
+		started = map[string]int{}
+		stopped = make(chan string, 10)
+	)
+	sub := func(id string) Subscription {
+		return NewSubscription(id, func(ctx context.Context, _ func(Msg)) {
+			mtx.Lock()
+			started[id]++
+			mtx.Unlock()
+			<-ctx.Done()
+			stopped <- id
+		})
+	}
+
+	p.syncSubscriptions(subscriptionsModel{sub("a"), sub("b"), sub("b")})
+	if len(p.subscriptions) != 2 {
+		t.Fatalf("expected 2 subscriptions, got %d", len(p.subscriptions))
+	}
+
+	// Subscriptions left in place keep running; the ones gone are stopped.
+	p.syncSubscriptions(subscriptionsModel{sub("b"), sub("c")})
+	select {
+	case id := <-stopped:
+		if id != "a" {
+			t.Errorf("expected a to be stopped, got %s", id)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for a to be stopped")
+	}
+
+	// Models that aren't SubscriptionModels have no subscriptions.
+	p.syncSubscriptions(counterModel{})
+	for i := 0; i < 2; i++ {
+		select {
+		case <-stopped:
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for subscriptions to be stopped")
+		}
+	}
+	if len(p.subscriptions) != 0 {
+		t.Errorf("expected no subscriptions, got %d", len(p.subscriptions))
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	for id, n := range map[string]int{"a": 1, "b": 1, "c": 1} {
+		if started[id] != n {
+			t.Errorf("expected %s to be started %d times, got %d", id, n, started[id])
+		}
+	}
+}
+
+func TestWrapSubscriptions(t *testing.T) {
+	ch := make(chan int, 1)
+	ch <- 1
+	close(ch)
+	subs := WrapSubscriptions("child", []Subscription{
+		ChannelSubscription("numbers", ch, func(n int) Msg { return n }),
+	})
+
+	if want := (wrappedSubscriptionID{wrapID: "child", id: "numbers"}); subs[0].id != want {
+		t.Errorf("expected ID %v, got %v", want, subs[0].id)
+	}
+
+	var msgs []Msg
+	subs[0].run(NewProgram(nil), context.Background(), func(msg Msg) {
+		msgs = append(msgs, msg)
+	})
+	if len(msgs) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(msgs))
+	}
+	if w, ok := msgs[0].(WrappedMsg); !ok || w.ID != "child" || w.Msg != 1 {
+		t.Errorf("expected the message to be wrapped, got %#v", msgs[0])
+	}
+}
@@ -16,11 +16,12 @@ import (
 	"io"
 	"os"
 	"os/signal"
-	"runtime/debug"
 	"sync"
 	"sync/atomic"
 	"syscall"
+	"time"
 
+	"github.com/charmbracelet/bubbletea/input"
 	"github.com/muesli/cancelreader"
 	"github.com/muesli/termenv"
 	"golang.org/x/sync/errgroup"
@@ -30,9 +31,21 @@ import (
 // ErrProgramKilled is returned by [Program.Run] when the program got killed.
 var ErrProgramKilled = errors.New("program was killed")
 
+// ExitError is returned by [Program.Run] when the program exited with a
+// non-zero exit code via [Exit].
+type ExitError struct {
+	Code int
+}
+
+// Error implements the error interface.
+func (e ExitError) Error() string {
+	return fmt.Sprintf("program exited with code %d", e.Code)
+}
+
 // Msg contain data from the result of a IO operation. Msgs trigger the update
-// function and, henceforth, the UI.
-type Msg interface{}
+// function and, henceforth, the UI. Msg is the same type as input.Event, the
+// events read from the terminal being messages like any other.
+type Msg = input.Event
 
 // Model contains the program's state as well as its core functions.
 type Model interface {
@@ -80,7 +93,7 @@ func (i inputType) String() string {
 // generally set with ProgramOptions.
 //
 // The options here are treated as bits.
-type startupOptions int16
+type startupOptions int32
 
 func (s startupOptions) has(option startupOptions) bool {
 	return s&option != 0
@@ -98,6 +111,19 @@ const (
 	// feature is on by default.
 	withoutCatchPanics
 	withoutBracketedPaste
+	withSynchronizedOutput
+	withoutSynchronizedOutput
+	withInterruptMsg
+	withCellRenderer
+	withAccessibleRenderer
+	withImageProtocol
+	withTabWidth
+	withModifyOtherKeys
+	withMousePixels
+	withMultiClickInterval
+	withMotionCoalescing
+	withStdinMsgs
+	withTerm
 )
 
 // channelHandlers manages the series of channels returned by various processes.
@@ -141,11 +167,99 @@ type Program struct {
 	errs     chan error
 	finished chan struct{}
 
+	// exited is closed once the program has exited and restored the
+	// terminal.
+	exited chan struct{}
+
+	// stopped is closed once the event loop has stopped, after which new
+	// commands aren't run and messages sent to the program are dropped.
+	stopped chan struct{}
+
+	// cmdsInFlight tracks commands that are still running, so Shutdown can
+	// wait for them.
+	cmdsInFlight sync.WaitGroup
+
+	// shutdownCtx is the context passed to Shutdown, if any. It bounds how
+	// long we wait for in-flight commands when quitting.
+	shutdownMtx sync.Mutex
+	shutdownCtx context.Context
+
 	// where to send output, this will usually be os.Stdout.
 	output        *termenv.Output
 	restoreOutput func() error
 	renderer      renderer
 
+	// outputMirror receives a copy of everything written to the output.
+	outputMirror io.Writer
+
+	// recorder records the session, if requested with WithRecording.
+	recorder *recorder
+
+	// player plays back a recorded session, if requested with WithPlayback.
+	player *player
+
+	// clock is the source of time for timers, the system clock by default.
+	clock Clock
+
+	// metrics receives measurements of the program, if set with
+	// WithMetrics.
+	metrics Metrics
+
+	// queuedMsgs is the number of messages waiting to be sent to the event
+	// loop.
+	queuedMsgs int32
+
+	// defaultOutput reports whether the output is stdout because none was
+	// set with WithOutput.
+	defaultOutput bool
+
+	// plainOutput reports whether escape sequences can't be written to the
+	// output, because it's a stdout that isn't a terminal or a legacy Windows
+	// console.
+	plainOutput bool
+
+	// noVirtualTerminal reports whether escape sequences couldn't be enabled
+	// on the output, which is the case of legacy Windows consoles.
+	noVirtualTerminal bool
+
+	// cmdWorkers is the number of workers running commands, if limited with
+	// WithCommandWorkers.
+	cmdWorkers int
+
+	// cmdQueue is the queue of the worker pool, if limited with
+	// WithCommandWorkers.
+	cmdQueue *cmdQueue
+
+	// tracer records the messages given to the model, if requested with
+	// WithMessageTrace.
+	tracer *tracer
+
+	// debug is the debug overlay, shown with ToggleDebugOverlay.
+	debug debugOverlay
+
+	// timeTravel keeps the history of the model, if requested with
+	// WithTimeTravel.
+	timeTravel *timeTravel
+
+	// stateStore stores the state of the model between runs, if set with
+	// WithStatePersistence.
+	stateStore StateStore
+
+	// subscriptions stops the running subscriptions, by ID, of a
+	// SubscriptionModel. It's only accessed from the event loop.
+	subscriptions map[interface{}]context.CancelFunc
+
+	// regions are the header and footer set with SetHeader and SetFooter.
+	regions regions
+
+	// width is the width of the window as of the last WindowSizeMsg, which
+	// frames drawn by a FrameModel are sized to.
+	width int
+
+	// panicErr is set when a goroutine of the program panics.
+	panicMtx sync.Mutex
+	panicErr *PanicError
+
 	// where to read inputs from, this will usually be os.Stdin.
 	input io.Reader
 	// tty is null if input is not a TTY.
@@ -154,17 +268,122 @@ type Program struct {
 	cancelReader     cancelreader.CancelReader
 	readLoopDone     chan struct{}
 
+	// streamInput reads inputs that aren't files in the background, so that
+	// reading them can be cancelled.
+	streamInput *streamInput
+
+	ignoreSignals uint32
+
+	// terminalMtx guards the state of the terminal saved by ReleaseTerminal
+	// and restored by RestoreTerminal, which can be called from any
+	// goroutine, along with the mouse mode once the program has started.
+	terminalMtx sync.Mutex
+
 	// was the altscreen active before releasing the terminal?
 	altScreenWasActive bool
-	ignoreSignals      uint32
+
+	// whether the terminal is currently released with ReleaseTerminal, and
+	// whether signals were ignored before it was released.
+	terminalReleased bool
+	ignoreSignalsWas uint32
+
+	// the mouse mode currently enabled, so it can be restored after
+	// releasing the terminal. It's only changed by the event loop, which
+	// reads it without locking.
+	mouseMode mouseMode
+
+	// whether the terminal reports mouse events in pixels, and the size of
+	// its cells in pixels, if reported.
+	mousePixels           bool
+	cellWidth, cellHeight int
+
+	// the longest time between clicks making multi-clicks, and the clicks
+	// counted so far.
+	multiClickInterval time.Duration
+	clicks             clickCounter
+
+	// the drag in progress, if any.
+	drag dragTracker
+
+	// the zones marked in the last frame rendered.
+	zoneMap zoneMap
+
+	// the key sequences registered with WithKeySequences and the timeout
+	// for their keys, and the recognizer for them, if any.
+	keySequenceList    []string
+	keySequenceTimeout time.Duration
+	keySequences       *keySequences
+
+	// how input is parsed, as set with WithEscapeTimeout and
+	// WithInputSequences.
+	inputParser input.Parser
+
+	// the terminal type set with WithTerm.
+	term string
 
 	bpWasActive bool // was the bracketed paste mode active before releasing the terminal?
 
+	// kittyKeyboardFlags are the kitty keyboard protocol enhancement flags
+	// requested with WithKittyKeyboard.
+	kittyKeyboardFlags KittyKeyboardFlags
+
+	// multiplexer is the multiplexer to pass sequences through to the outer
+	// terminal, set by WithMultiplexerPassthrough.
+	multiplexer Multiplexer
+
+	// titleSaved reports whether the original window title was saved on the
+	// terminal's title stack, and titlesPushed is the number of titles
+	// pushed on top of it with PushWindowTitle.
+	titleSaved   bool
+	titlesPushed int
+
+	// signalHandlers are the OS signals subscribed to with WithSignals.
+	signalHandlers []signalHandler
+
+	// exitCode is the exit code requested with Exit. It's accessed
+	// atomically, as it can be read from any goroutine.
+	exitCode int32
+
 	filter func(Model, Msg) Msg
 
+	// updateHooks are the middleware functions added with WithUpdateHook.
+	updateHooks []UpdateHook
+
+	// bus holds the subscriptions made with Subscribe. It's only accessed
+	// from the event loop.
+	bus bus
+
 	// fps is the frames per second we should set on the renderer, if
-	// applicable,
-	fps int
+	// applicable, and the minimum frames per second, if the framerate is
+	// adaptive.
+	fps    int
+	minFPS int
+
+	// reservedHeight is the number of lines inline frames take up, if set.
+	reservedHeight int
+
+	// maxBytesPerSecond is the bandwidth the renderer keeps to, if set.
+	maxBytesPerSecond int
+
+	// imageProtocol is the protocol images are drawn with.
+	imageProtocol ImageProtocol
+
+	// tabWidth is the width of the tab stops the renderer expands tabs to,
+	// if set.
+	tabWidth int
+
+	// the latest frame sent to the renderer, for Screenshot and ExportFrame
+	lastFrameMtx   sync.Mutex
+	lastFrame      string
+	lastFrameWidth int
+	hasLastFrame   bool
+
+	// overflow is what the renderer does with lines wider than the window.
+	overflow Overflow
+
+	// ambiguousWidth is the width of East Asian ambiguous characters, if
+	// set.
+	ambiguousWidth int
 }
 
 // Quit is a special command that tells the Bubble Tea program to exit.
@@ -176,11 +395,47 @@ func Quit() Msg {
 // Quit.
 type QuitMsg struct{}
 
+// Exit is a command that tells the Bubble Tea program to exit with the given
+// exit code. The terminal is restored as usual, and [Program.Run] returns an
+// [ExitError] carrying the code if it's not zero, so that the code can be
+// passed to os.Exit once the program has shut down:
+//
+//	if _, err := p.Run(); err != nil {
+//	    var exitErr tea.ExitError
+//	    if errors.As(err, &exitErr) {
+//	        os.Exit(exitErr.Code)
+//	    }
+//	    fmt.Println("Error running program:", err)
+//	    os.Exit(1)
+//	}
+//
+// Exit(0) is equivalent to Quit.
+func Exit(code int) Cmd {
+	return func() Msg {
+		return exitMsg{code: code}
+	}
+}
+
+// exitMsg is an internal message signalling that the program should quit with
+// the given exit code. You can send an exitMsg with Exit.
+type exitMsg struct {
+	code int
+}
+
+// InterruptMsg is sent to the program's update function when it receives
+// SIGINT or SIGTERM and the program was started with WithInterruptMsg. The
+// program keeps running; return Quit from Update to exit.
+type InterruptMsg struct {
+	Signal os.Signal
+}
+
 // NewProgram creates a new Program.
 func NewProgram(model Model, opts ...ProgramOption) *Program {
 	p := &Program{
 		initialModel: model,
 		msgs:         make(chan Msg),
+		exited:       make(chan struct{}),
+		stopped:      make(chan struct{}),
 	}
 
 	// Apply all options to the program.
@@ -196,15 +451,43 @@ func NewProgram(model Model, opts ...ProgramOption) *Program {
 	// Initialize context and teardown channel.
 	p.ctx, p.cancel = context.WithCancel(p.ctx)
 
+	if p.clock == nil {
+		p.clock = systemClock{}
+	}
+	if len(p.keySequenceList) > 0 {
+		timeout := p.keySequenceTimeout
+		if timeout <= 0 {
+			timeout = defaultKeySequenceTimeout
+		}
+		p.keySequences = newKeySequences(p.keySequenceList, timeout)
+	}
+	if !p.startupOptions.has(withMultiClickInterval) {
+		p.multiClickInterval = defaultMultiClickInterval
+	}
+
 	// if no output was set, set it to stdout
 	if p.output == nil {
 		p.output = termenv.DefaultOutput()
+		p.defaultOutput = true
 
 		// cache detected color values
 		termenv.WithColorCache(true)(p.output)
 	}
 
-	p.restoreOutput, _ = termenv.EnableVirtualTerminalProcessing(p.output)
+	var mirrors []io.Writer
+	if p.outputMirror != nil {
+		mirrors = append(mirrors, p.outputMirror)
+	}
+	if p.recorder != nil {
+		mirrors = append(mirrors, p.recorder)
+	}
+	if len(mirrors) > 0 {
+		p.output = mirrorOutput(p.output, io.MultiWriter(mirrors...))
+	}
+
+	var err error
+	p.restoreOutput, err = termenv.EnableVirtualTerminalProcessing(p.output)
+	p.noVirtualTerminal = err != nil
 
 	return p
 }
@@ -233,10 +516,65 @@ func (p *Program) handleSignals() chan struct{} {
 			case <-p.ctx.Done():
 				return
 
-			case <-sig:
-				if atomic.LoadUint32(&p.ignoreSignals) == 0 {
-					p.msgs <- QuitMsg{}
-					return
+			case s := <-sig:
+				if atomic.LoadUint32(&p.ignoreSignals) != 0 {
+					continue
+				}
+				if p.startupOptions.has(withInterruptMsg) {
+					// Let the model decide what to do.
+					p.Send(InterruptMsg{Signal: s})
+					continue
+				}
+				p.msgs <- QuitMsg{}
+				return
+			}
+		}
+	}()
+
+	return ch
+}
+
+// signalHandler converts the given OS signals into messages.
+type signalHandler struct {
+	signals []os.Signal
+	fn      func(os.Signal) Msg
+}
+
+// handleSubscribedSignals listens for the signals subscribed to with
+// WithSignals and sends the corresponding messages to the program.
+func (p *Program) handleSubscribedSignals() chan struct{} {
+	ch := make(chan struct{})
+
+	if len(p.signalHandlers) == 0 {
+		close(ch)
+		return ch
+	}
+
+	sig := make(chan os.Signal, 1)
+	fns := make(map[os.Signal][]func(os.Signal) Msg)
+	for _, h := range p.signalHandlers {
+		for _, s := range h.signals {
+			fns[s] = append(fns[s], h.fn)
+		}
+		signal.Notify(sig, h.signals...)
+	}
+
+	go func() {
+		defer func() {
+			signal.Stop(sig)
+			close(ch)
+		}()
+
+		for {
+			select {
+			case <-p.ctx.Done():
+				return
+
+			case s := <-sig:
+				for _, fn := range fns[s] {
+					if msg := fn(s); msg != nil {
+						p.Send(msg)
+					}
 				}
 			}
 		}
@@ -265,6 +603,10 @@ func (p *Program) handleResize() chan struct{} {
 // handleCommands runs commands in a goroutine and sends the result to the
 // program's message channel.
 func (p *Program) handleCommands(cmds chan Cmd) chan struct{} {
+	if p.cmdWorkers > 0 {
+		return p.handleCommandsWithWorkers(cmds)
+	}
+
 	ch := make(chan struct{})
 
 	go func() {
@@ -275,6 +617,9 @@ func (p *Program) handleCommands(cmds chan Cmd) chan struct{} {
 			case <-p.ctx.Done():
 				return
 
+			case <-p.stopped:
+				return
+
 			case cmd := <-cmds:
 				if cmd == nil {
 					continue
@@ -285,10 +630,8 @@ func (p *Program) handleCommands(cmds chan Cmd) chan struct{} {
 				// (e.g. tick commands that sleep for half a second). It's not
 				// possible to cancel them so we'll have to leak the goroutine
 				// until Cmd returns.
-				go func() {
-					msg := cmd() // this can be long.
-					p.Send(msg)
-				}()
+				p.cmdsInFlight.Add(1)
+				go p.execCmd(cmd)
 			}
 		}
 	}()
@@ -296,15 +639,59 @@ func (p *Program) handleCommands(cmds chan Cmd) chan struct{} {
 	return ch
 }
 
+// execCmd runs a command counted in cmdsInFlight and sends its message to the
+// program.
+func (p *Program) execCmd(cmd Cmd) {
+	defer p.recoverFromGoPanic()
+
+	msg := func() Msg {
+		defer p.cmdsInFlight.Done()
+		return p.callCmd(cmd) // this can be long.
+	}()
+	p.Send(msg)
+}
+
+// mouseMode is the kind of mouse tracking enabled on the terminal.
+type mouseMode int
+
+const (
+	mouseModeNone mouseMode = iota
+	mouseModeCellMotion
+	mouseModeAllMotion
+)
+
+// enableMouse enables the given mouse tracking mode. The extended mouse mode
+// (SGR) is enabled as well, which is a no-op if the terminal doesn't support
+// it.
+func (p *Program) enableMouse(mode mouseMode) {
+	switch mode {
+	case mouseModeCellMotion:
+		p.renderer.enableMouseCellMotion()
+	case mouseModeAllMotion:
+		p.renderer.enableMouseAllMotion()
+	default:
+		return
+	}
+	p.renderer.enableMouseSGRMode()
+	if p.startupOptions.has(withMousePixels) {
+		p.renderer.enableMousePixelsMode()
+	}
+}
+
+// disableMouse disables mouse tracking. Whether events are reported in pixels
+// is left to the event loop to reset.
 func (p *Program) disableMouse() {
 	p.renderer.disableMouseCellMotion()
 	p.renderer.disableMouseAllMotion()
 	p.renderer.disableMouseSGRMode()
+	p.renderer.disableMousePixelsMode()
 }
 
 // eventLoop is the central message loop. It receives and handles the default
 // Bubble Tea messages, update the model and triggers redraws.
 func (p *Program) eventLoop(model Model, cmds chan Cmd) (Model, error) {
+	p.timeTravel.record(model, nil)
+	p.syncSubscriptions(model)
 	for {
 		select {
 		case <-p.ctx.Done():
@@ -314,6 +701,28 @@ func (p *Program) eventLoop(model Model, cmds chan Cmd) (Model, error) {
 			return model, err
 
 		case msg := <-p.msgs:
+			if p.metrics != nil {
+				p.metrics.QueueDepth(p.queueDepth())
+			}
+
+			if s, ok := msg.(subscriptionMsg); ok {
+				if s.ctx.Err() != nil {
+					continue
+				}
+				msg = s.msg
+			}
+
+			if m, ok := msg.(MouseMsg); ok {
+				if p.mousePixels {
+					m = p.pixelMouse(m)
+				}
+				m.Clicks = p.clicks.count(MouseEvent(m), p.clock.Now(), p.multiClickInterval)
+				if hits := p.zoneMap.hits(m.X, m.Y); len(hits) > 0 {
+					m = MouseMsg(MouseEvent(m).WithZones(hits))
+				}
+				msg = MouseMsg(p.drag.track(MouseEvent(m)))
+			}
+
 			// Filter messages.
 			if p.filter != nil {
 				msg = p.filter(model, msg)
@@ -321,33 +730,82 @@ func (p *Program) eventLoop(model Model, cmds chan Cmd) (Model, error) {
 			if msg == nil {
 				continue
 			}
+			if p.debug.enabled {
+				p.debug.lastMsg = fmt.Sprintf("%T", msg)
+			}
+
+			// Keys step through the history of the model while going
+			// through it.
+			if key, ok := msg.(KeyMsg); ok && p.timeTravel.traveling() && p.timeTravel.key(key) {
+				p.render(model)
+				continue
+			}
+
+			// Hold back the keys of key sequences. Keys held back before
+			// the key breaking a sequence are delivered right away.
+			if p.keySequences != nil {
+				msgs := p.keySequences.handle(p, msg)
+				if len(msgs) == 0 {
+					continue
+				}
+				for _, m := range msgs[:len(msgs)-1] {
+					var cmd Cmd
+					model, cmd = p.update(model, m)
+					cmds <- cmd
+				}
+				msg = msgs[len(msgs)-1]
+			}
+
+			// Handle message bus messages.
+			if busMsg, from := unwrapBusMsg(msg); busMsg != nil {
+				if p.bus == nil {
+					p.bus = bus{}
+				}
+				for _, cmd := range p.bus.handle(busMsg, from) {
+					cmds <- cmd
+				}
+				continue
+			}
 
 			// Handle special internal messages.
 			switch msg := msg.(type) {
 			case QuitMsg:
 				return model, nil
 
+			case exitMsg:
+				atomic.StoreInt32(&p.exitCode, int32(msg.code))
+				return model, nil
+
 			case clearScreenMsg:
 				p.renderer.clearScreen()
 
+			case toggleDebugOverlayMsg:
+				p.debug.enabled = !p.debug.enabled
+
+			case timeTravelMsg:
+				p.timeTravel.handle(msg)
+
+			case setHeaderMsg:
+				p.regions.header = string(msg)
+
+			case setFooterMsg:
+				p.regions.footer = string(msg)
+
 			case enterAltScreenMsg:
 				p.renderer.enterAltScreen()
 
 			case exitAltScreenMsg:
 				p.renderer.exitAltScreen()
 
-			case enableMouseCellMotionMsg, enableMouseAllMotionMsg:
-				switch msg.(type) {
-				case enableMouseCellMotionMsg:
-					p.renderer.enableMouseCellMotion()
-				case enableMouseAllMotionMsg:
-					p.renderer.enableMouseAllMotion()
-				}
-				// mouse mode (1006) is a no-op if the terminal doesn't support it.
-				p.renderer.enableMouseSGRMode()
+			case enableMouseCellMotionMsg:
+				p.setMouseMode(mouseModeCellMotion)
+
+			case enableMouseAllMotionMsg:
+				p.setMouseMode(mouseModeAllMotion)
 
 			case disableMouseMsg:
-				p.disableMouse()
+				p.setMouseMode(mouseModeNone)
+				p.mousePixels = false
 
 			case showCursorMsg:
 				p.renderer.showCursor()
@@ -361,6 +819,22 @@ func (p *Program) eventLoop(model Model, cmds chan Cmd) (Model, error) {
 			case disableBracketedPasteMsg:
 				p.renderer.disableBracketedPaste()
 
+			case WindowSizeMsg:
+				// The size of the cells changes along with the font,
+				// which resizes the window.
+				if p.mousePixels {
+					p.renderer.queryCellSize()
+				}
+				p.debug.width = msg.Width
+				p.width = msg.Width
+				p.regions.height = msg.Height
+				if p.recorder != nil {
+					p.recorder.resize(msg.Width, msg.Height)
+				}
+
+			case windowSizeMsg:
+				go p.checkResize()
+
 			case execMsg:
 				// NB: this blocks.
 				p.exec(msg.cmd, msg.fn)
@@ -372,20 +846,25 @@ func (p *Program) eventLoop(model Model, cmds chan Cmd) (Model, error) {
 				continue
 
 			case sequenceMsg:
+				p.cmdsInFlight.Add(1)
 				go func() {
+					defer p.cmdsInFlight.Done()
+					defer p.recoverFromGoPanic()
+
 					// Execute commands one at a time, in order.
 					for _, cmd := range msg {
 						if cmd == nil {
 							continue
 						}
 
-						msg := cmd()
+						msg := p.runCmd(cmd)
 						if batchMsg, ok := msg.(BatchMsg); ok {
 							g, _ := errgroup.WithContext(p.ctx)
 							for _, cmd := range batchMsg {
 								cmd := cmd
 								g.Go(func() error {
-									p.Send(cmd())
+									defer p.recoverFromGoPanic()
+									p.Send(p.runCmd(cmd))
 									return nil
 								})
 							}
@@ -399,27 +878,111 @@ func (p *Program) eventLoop(model Model, cmds chan Cmd) (Model, error) {
 					}
 				}()
 
+			case deferredMsg:
+				p.runDeferred(msg)
+				continue
+
 			case setWindowTitleMsg:
-				p.SetWindowTitle(string(msg))
+				p.setWindowTitle(string(msg))
+
+			case pushWindowTitleMsg:
+				p.pushWindowTitle(string(msg))
+
+			case popWindowTitleMsg:
+				p.popWindowTitle()
+
+			case setClipboardMsg:
+				p.renderer.setClipboard(string(msg))
+
+			case readClipboardMsg:
+				p.renderer.readClipboard()
+
+			case input.ModeReportMsg:
+				if msg.Mode == synchronizedOutputMode && msg.Supported() &&
+					!p.startupOptions.has(withoutSynchronizedOutput) {
+					p.renderer.setSynchronizedOutput(true)
+				}
+				if msg.Mode == mousePixelsMode && p.mouseMode != mouseModeNone {
+					p.mousePixels = msg.Value == input.ModeSet
+				}
+
+			case input.CellSizeMsg:
+				p.cellWidth, p.cellHeight = msg.Width, msg.Height
 			}
 
 			// Process internal messages for the renderer.
-			if r, ok := p.renderer.(*standardRenderer); ok {
+			if r, ok := p.renderer.(interface{ handleMessages(Msg) }); ok {
 				r.handleMessages(msg)
 			}
 
+			if p.tracer != nil {
+				p.tracer.trace(p.clock.Now(), msg)
+			}
+
 			var cmd Cmd
-			model, cmd = model.Update(msg) // run update
-			cmds <- cmd                    // process command (if any)
-			p.renderer.write(model.View()) // send view to renderer
+			model, cmd = p.update(model, msg) // run update
+			cmds <- cmd                       // process command (if any)
+			p.render(model)                   // send view to renderer
 		}
 	}
 }
 
+// update runs the model's Update function with the given message, through
+// the update hooks, if any.
+func (p *Program) update(model Model, msg Msg) (Model, Cmd) {
+	if p.metrics != nil {
+		defer func(start time.Time) {
+			p.metrics.UpdateDuration(msg, time.Since(start))
+		}(time.Now())
+	}
+
+	next := func(msg Msg) Cmd {
+		var cmd Cmd
+		model, cmd = model.Update(msg)
+		return cmd
+	}
+	for i := len(p.updateHooks) - 1; i >= 0; i-- {
+		hook, inner := p.updateHooks[i], next
+		next = func(msg Msg) Cmd {
+			return hook(msg, inner)
+		}
+	}
+
+	cmd := next(msg)
+	p.timeTravel.record(model, msg)
+	p.syncSubscriptions(model)
+	return model, cmd
+}
+
+// isRuntimeMsg reports whether msg is handled by the event loop or the
+// renderer, and so must reach them as-is rather than wrapped with Wrap. Keep
+// this in sync with eventLoop and standardRenderer.handleMessages.
+func isRuntimeMsg(msg Msg) bool {
+	switch msg.(type) {
+	case deferredMsg,
+		QuitMsg, exitMsg, BatchMsg, sequenceMsg, execMsg,
+		clearScreenMsg, toggleDebugOverlayMsg, timeTravelMsg, enterAltScreenMsg, exitAltScreenMsg,
+		enableMouseCellMotionMsg, enableMouseAllMotionMsg, disableMouseMsg,
+		showCursorMsg, hideCursorMsg,
+		enableBracketedPasteMsg, disableBracketedPasteMsg,
+		setWindowTitleMsg, pushWindowTitleMsg, popWindowTitleMsg,
+		setClipboardMsg, readClipboardMsg, input.ModeReportMsg, input.CellSizeMsg,
+		windowSizeMsg, WindowSizeMsg, repaintMsg, clearScrollAreaMsg, syncScrollAreaMsg,
+		scrollUpMsg, scrollDownMsg, printLineMessage, setHeaderMsg, setFooterMsg,
+		announceMsg, transmitImageMsg, placeImageMsg, deleteImageMsg, keySequenceTimeoutMsg:
+		return true
+	}
+	return false
+}
+
 // Run initializes the program and runs its event loops, blocking until it gets
 // terminated by either [Program.Quit], [Program.Kill], or its signal handler.
 // Returns the final model.
-func (p *Program) Run() (Model, error) {
+//
+// If the program panics, the terminal is restored and Run returns a nil model
+// and a [PanicError], unless panic catching was disabled with
+// [WithoutCatchPanics].
+func (p *Program) Run() (returnModel Model, returnErr error) {
 	handlers := channelHandlers{}
 	cmds := make(chan Cmd)
 	p.errs = make(chan error)
@@ -452,6 +1015,10 @@ func (p *Program) Run() (Model, error) {
 		defer f.Close() //nolint:errcheck
 		p.input = f
 
+		if p.startupOptions.has(withStdinMsgs) {
+			go p.readStdin(os.Stdin)
+		}
+
 	case ttyInput:
 		// Open a new TTY, by request
 		f, err := openInputTTY()
@@ -465,26 +1032,97 @@ func (p *Program) Run() (Model, error) {
 		// (There is nothing extra to do.)
 	}
 
+	// Start recording the session, if requested.
+	if err := p.startRecording(); err != nil {
+		return p.initialModel, err
+	}
+	if p.recorder != nil {
+		defer p.recorder.close() //nolint:errcheck
+	}
+
+	// Load the session to play back, if requested.
+	if p.player != nil {
+		if err := p.player.load(); err != nil {
+			return p.initialModel, err
+		}
+	}
+
+	// Restore the state saved by the previous run, if requested.
+	if err := p.restoreState(); err != nil {
+		return p.initialModel, err
+	}
+
 	// Handle signals.
 	if !p.startupOptions.has(withoutSignalHandler) {
 		handlers.add(p.handleSignals())
 	}
+	handlers.add(p.handleSubscribedSignals())
 
 	// Recover from panics.
 	if !p.startupOptions.has(withoutCatchPanics) {
 		defer func() {
 			if r := recover(); r != nil {
+				returnModel, returnErr = nil, newPanicError(r)
+				p.cancel()
+				if p.cancelReader != nil {
+					p.cancelReader.Cancel()
+				}
 				p.shutdown(true)
-				fmt.Printf("Caught panic:\n\n%s\n\nRestoring terminal...\n\n", r)
-				debug.PrintStack()
-				return
 			}
 		}()
 	}
 
-	// If no renderer is set use the standard one.
+	// If no renderer is set use the standard one, unless stdout isn't a
+	// terminal, in which case we'll only print the final frame as plain text,
+	// or the accessible one was asked for.
 	if p.renderer == nil {
-		p.renderer = newRenderer(p.output, p.startupOptions.has(withANSICompressor), p.fps)
+		if p.startupOptions.has(withAccessibleRenderer) || accessibleFromEnv(os.Getenv) {
+			p.plainOutput = true
+			p.renderer = newAccessibleRenderer(p.output)
+		} else if p.defaultOutput && !p.outputIsTerminal() {
+			p.plainOutput = true
+			p.renderer = newFinalFrameRenderer(p.output)
+		} else if r := p.legacyConsoleRenderer(); r != nil {
+			p.plainOutput = true
+			p.renderer = r
+		} else {
+			p.renderer = newRenderer(p.output, p.startupOptions.has(withANSICompressor), p.fps)
+		}
+	}
+
+	// Count the frames drawn, and pass sequences through multiplexers.
+	if r, ok := p.renderer.(*standardRenderer); ok {
+		r.onFrame = p.frameRendered
+		r.multiplexer = p.multiplexer
+		r.cellDiffing = p.startupOptions.has(withCellRenderer)
+		r.reservedHeight = p.reservedHeight
+		r.setMinFPS(p.minFPS)
+		r.maxBytesPerSecond = p.maxBytesPerSecond
+
+		// Images are drawn on terminals only, unless told otherwise.
+		if !p.startupOptions.has(withImageProtocol) && p.outputIsTerminal() {
+			p.imageProtocol = DetectImageProtocol()
+		}
+		r.imageProtocol = p.imageProtocol
+		r.overflow = p.overflow
+		if p.startupOptions.has(withTabWidth) {
+			r.tabWidth = p.tabWidth
+		}
+		r.widths = cellWidths(p.ambiguousWidth)
+
+		// TERM describes the terminal only when we're drawing to it.
+		if p.startupOptions.has(withTerm) {
+			r.caps = loadTermCaps(p.term)
+		} else if p.outputIsTerminal() {
+			r.caps = loadTermCaps(os.Getenv("TERM"))
+		}
+
+		// Remote terminals, such as SSH sessions, aren't files but are given
+		// a color profile.
+		r.paintSuffixes = p.outputIsTerminal() || p.output.Profile != termenv.Ascii
+	}
+	if p.multiplexer != NoMultiplexer {
+		p.output.Profile = p.multiplexer.colorProfile(os.Getenv, p.output.Profile)
 	}
 
 	// Check if output is a TTY before entering raw mode, hiding the cursor and
@@ -500,13 +1138,25 @@ func (p *Program) Run() (Model, error) {
 	if p.startupOptions&withoutBracketedPaste == 0 {
 		p.renderer.enableBracketedPaste()
 	}
+	if p.kittyKeyboardFlags != 0 {
+		p.renderer.enableKittyKeyboard(p.kittyKeyboardFlags)
+	}
+	if p.startupOptions.has(withModifyOtherKeys) {
+		p.renderer.enableModifyOtherKeys()
+	}
+	if p.startupOptions.has(withSynchronizedOutput) {
+		p.renderer.setSynchronizedOutput(true)
+	} else if !p.startupOptions.has(withoutSynchronizedOutput) && p.outputIsTerminal() && !p.plainOutput {
+		// Ask the terminal whether it supports synchronized output. If it
+		// does, we'll enable it once the answer arrives.
+		_, _ = p.output.WriteString(queryModeSequence(synchronizedOutputMode))
+	}
 	if p.startupOptions&withMouseCellMotion != 0 {
-		p.renderer.enableMouseCellMotion()
-		p.renderer.enableMouseSGRMode()
+		p.mouseMode = mouseModeCellMotion
 	} else if p.startupOptions&withMouseAllMotion != 0 {
-		p.renderer.enableMouseAllMotion()
-		p.renderer.enableMouseSGRMode()
+		p.mouseMode = mouseModeAllMotion
 	}
+	p.enableMouse(p.mouseMode)
 
 	// Start the renderer.
 	p.renderer.start()
@@ -528,7 +1178,7 @@ func (p *Program) Run() (Model, error) {
 	}
 
 	// Render the initial view.
-	p.renderer.write(model.View())
+	p.render(model)
 
 	// Subscribe to user input.
 	if p.input != nil {
@@ -537,20 +1187,49 @@ func (p *Program) Run() (Model, error) {
 		}
 	}
 
+	// Play back the recorded session.
+	if p.player != nil {
+		handlers.add(p.play())
+	}
+
 	// Handle resize events.
 	handlers.add(p.handleResize())
 
 	// Process commands.
-	handlers.add(p.handleCommands(cmds))
+	cmdsDone := p.handleCommands(cmds)
+	handlers.add(cmdsDone)
 
 	// Run event loop, handle updates and draw.
 	model, err := p.eventLoop(model, cmds)
+
+	// Stop accepting commands and messages, so that the commands in flight
+	// can be waited for.
+	close(p.stopped)
+	<-cmdsDone
+
+	// Subscriptions end with the program.
+	p.bus = nil
+	p.stopSubscriptions()
+
 	killed := p.ctx.Err() != nil
-	if killed {
+	if !killed && !p.waitForCommands() {
+		// We're shutting down and in-flight commands didn't finish in time.
+		// Skip the final render and tear everything down.
+		killed = true
+		err = ErrProgramKilled
+	} else if killed {
 		err = ErrProgramKilled
 	} else {
+		if code := p.ExitCode(); err == nil && code != 0 {
+			err = ExitError{Code: code}
+		}
+
 		// Ensure we rendered the final state of the model.
-		p.renderer.write(model.View())
+		p.render(model)
+
+		if saveErr := p.saveState(model); saveErr != nil && err == nil {
+			err = saveErr
+		}
 	}
 
 	// Tear down.
@@ -571,6 +1250,11 @@ func (p *Program) Run() (Model, error) {
 	// Restore terminal state.
 	p.shutdown(killed)
 
+	p.panicMtx.Lock()
+	defer p.panicMtx.Unlock()
+	if p.panicErr != nil {
+		return nil, p.panicErr
+	}
 	return model, err
 }
 
@@ -601,8 +1285,12 @@ func (p *Program) Start() error {
 // If the program has already been terminated this will be a no-op, so it's safe
 // to send messages after the program has exited.
 func (p *Program) Send(msg Msg) {
+	atomic.AddInt32(&p.queuedMsgs, 1)
+	defer atomic.AddInt32(&p.queuedMsgs, -1)
+
 	select {
 	case <-p.ctx.Done():
+	case <-p.stopped:
 	case p.msgs <- msg:
 	}
 }
@@ -625,6 +1313,92 @@ func (p *Program) Kill() {
 	p.cancel()
 }
 
+// Shutdown gracefully shuts the program down. It sends a QuitMsg, waits for
+// in-flight commands to finish, and restores the terminal. If the context is
+// done before the program has exited, because the QuitMsg couldn't be
+// delivered or commands are still running, the program is killed: the
+// renderer is stopped without a final render and the terminal is restored
+// regardless.
+//
+// Commands in flight include the ones run by Batch, Sequence, Stream, Retry,
+// WithTimeout and the like, but not watches, such as WatchFile, and
+// subscriptions, which run until the program exits. Pending timers, such as
+// the ones of Tick, Every and Cron, are stopped rather than waited for, since
+// their messages would be dropped. No new commands are run while waiting, and
+// the messages of the ones finishing are dropped.
+//
+// Shutdown returns nil if the program exited cleanly, and the context's error
+// otherwise, in which case Run returns ErrProgramKilled. This is useful for
+// servers, such as SSH hosts, that need to reap sessions deterministically:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+//	defer cancel()
+//	if err := p.Shutdown(ctx); err != nil {
+//	    log.Printf("session didn't shut down cleanly: %v", err)
+//	}
+//
+// Note that the QuitMsg passes through the program's filter like any other
+// message, so a filter that suppresses it delays the shutdown until the
+// context is done.
+func (p *Program) Shutdown(ctx context.Context) error {
+	p.shutdownMtx.Lock()
+	p.shutdownCtx = ctx
+	p.shutdownMtx.Unlock()
+
+	select {
+	case p.msgs <- QuitMsg{}:
+	case <-p.exited:
+		return nil
+	case <-p.ctx.Done():
+	case <-ctx.Done():
+		p.Kill()
+		return ctx.Err()
+	}
+
+	select {
+	case <-p.exited:
+		return ctx.Err()
+	case <-ctx.Done():
+		p.Kill()
+		return ctx.Err()
+	}
+}
+
+// waitForCommands waits for in-flight commands to finish if the program is
+// being shut down with Shutdown. It reports whether they finished before the
+// shutdown context was done. If the program is quitting any other way it
+// returns true immediately.
+func (p *Program) waitForCommands() bool {
+	p.shutdownMtx.Lock()
+	ctx := p.shutdownCtx
+	p.shutdownMtx.Unlock()
+	if ctx == nil {
+		return true
+	}
+
+	done := make(chan struct{})
+	go func() {
+		p.cmdsInFlight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// ExitCode returns the exit code the program requested with [Exit]. It's zero
+// if the program hasn't exited, or exited any other way.
+//
+// It's safe to call from any goroutine, but the exit code is only final once
+// Run has returned; until then, it may still be zero.
+func (p *Program) ExitCode() int {
+	return int(atomic.LoadInt32(&p.exitCode))
+}
+
 // Wait waits/blocks until the underlying Program finished shutting down.
 func (p *Program) Wait() {
 	<-p.finished
@@ -642,22 +1416,53 @@ func (p *Program) shutdown(kill bool) {
 	}
 
 	_ = p.restoreTerminalState()
+	p.restoreWindowTitle()
 	if p.restoreOutput != nil {
 		_ = p.restoreOutput()
 	}
+	close(p.exited)
 	p.finished <- struct{}{}
 }
 
-// ReleaseTerminal restores the original terminal state and cancels the input
-// reader. You can return control to the Program with RestoreTerminal.
+// ReleaseTerminal temporarily gives control of the terminal back: it stops
+// the renderer, stops reading input, leaves the alternate screen and restores
+// the terminal to the state it was in before the program started (i.e.
+// cooked mode). The program keeps processing messages in the meantime, but
+// nothing is drawn.
+//
+// This is useful for handing the terminal to another process, or for doing
+// I/O outside of the program, without quitting. Return control to the program
+// with RestoreTerminal:
+//
+//	if err := p.ReleaseTerminal(); err != nil {
+//	    return err
+//	}
+//	fmt.Print("Password: ")
+//	password, _ := term.ReadPassword(int(os.Stdin.Fd()))
+//	if err := p.RestoreTerminal(); err != nil {
+//	    return err
+//	}
+//
+// Note that Exec and ExecProcess take care of this for you. It's safe to call
+// ReleaseTerminal more than once; calls after the first are no-ops until the
+// terminal is restored. ReleaseTerminal and RestoreTerminal can be called from
+// any goroutine, such as from a command.
 func (p *Program) ReleaseTerminal() error {
-	atomic.StoreUint32(&p.ignoreSignals, 1)
-	p.cancelReader.Cancel()
-	p.waitForReadLoop()
+	p.terminalMtx.Lock()
+	defer p.terminalMtx.Unlock()
 
-	if p.renderer != nil {
-		p.renderer.stop()
+	if p.renderer == nil || p.terminalReleased {
+		return nil
 	}
+	p.terminalReleased = true
+
+	p.ignoreSignalsWas = atomic.SwapUint32(&p.ignoreSignals, 1)
+	if p.cancelReader != nil {
+		p.cancelReader.Cancel()
+		p.waitForReadLoop()
+	}
+
+	p.renderer.stop()
 
 	p.altScreenWasActive = p.renderer.altScreen()
 	p.bpWasActive = p.renderer.bracketedPasteActive()
@@ -666,15 +1471,26 @@ func (p *Program) ReleaseTerminal() error {
 
 // RestoreTerminal reinitializes the Program's input reader, restores the
 // terminal to the former state when the program was running, and repaints.
-// Use it to reinitialize a Program after running ReleaseTerminal.
+// Use it to reinitialize a Program after running ReleaseTerminal. If the
+// terminal hasn't been released it's a no-op.
 func (p *Program) RestoreTerminal() error {
-	atomic.StoreUint32(&p.ignoreSignals, 0)
+	p.terminalMtx.Lock()
+	defer p.terminalMtx.Unlock()
+
+	if p.renderer == nil || !p.terminalReleased {
+		return nil
+	}
+	p.terminalReleased = false
+
+	atomic.StoreUint32(&p.ignoreSignals, p.ignoreSignalsWas)
 
 	if err := p.initTerminal(); err != nil {
 		return err
 	}
-	if err := p.initCancelReader(); err != nil {
-		return err
+	if p.input != nil {
+		if err := p.initCancelReader(); err != nil {
+			return err
+		}
 	}
 	if p.altScreenWasActive {
 		p.renderer.enterAltScreen()
@@ -682,12 +1498,17 @@ func (p *Program) RestoreTerminal() error {
 		// entering alt screen already causes a repaint.
 		go p.Send(repaintMsg{})
 	}
-	if p.renderer != nil {
-		p.renderer.start()
-	}
+	p.renderer.start()
 	if p.bpWasActive {
 		p.renderer.enableBracketedPaste()
 	}
+	p.enableMouse(p.mouseMode)
+	if p.kittyKeyboardFlags != 0 {
+		p.renderer.enableKittyKeyboard(p.kittyKeyboardFlags)
+	}
+	if p.startupOptions.has(withModifyOtherKeys) {
+		p.renderer.enableModifyOtherKeys()
+	}
 
 	// If the output is a terminal, it may have been resized while another
 	// process was at the foreground, in which case we may not have received
@@ -698,6 +1519,24 @@ func (p *Program) RestoreTerminal() error {
 	return nil
 }
 
+// setMouseMode enables the given mouse mode, or disables the mouse for
+// mouseModeNone. While the terminal is released, the mode is only applied
+// once it's restored.
+func (p *Program) setMouseMode(m mouseMode) {
+	p.terminalMtx.Lock()
+	defer p.terminalMtx.Unlock()
+
+	p.mouseMode = m
+	if p.terminalReleased {
+		return
+	}
+	if m == mouseModeNone {
+		p.disableMouse()
+	} else {
+		p.enableMouse(m)
+	}
+}
+
 // Println prints above the Program. This output is unmanaged by the program
 // and will persist across renders by the Program.
 //
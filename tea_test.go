@@ -3,6 +3,9 @@ package tea
 import (
 	"bytes"
 	"context"
+	"errors"
+	"reflect"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -41,6 +44,16 @@ func (m *testModel) View() string {
 	return "success\n"
 }
 
+// initCmdModel is a testModel with an initial command.
+type initCmdModel struct {
+	*testModel
+	init Cmd
+}
+
+func (m initCmdModel) Init() Cmd {
+	return m.init
+}
+
 func TestTeaModel(t *testing.T) {
 	var buf bytes.Buffer
 	var in bytes.Buffer
@@ -56,6 +69,22 @@ func TestTeaModel(t *testing.T) {
 	}
 }
 
+func TestTeaOutputMirror(t *testing.T) {
+	var buf bytes.Buffer
+	var mirror bytes.Buffer
+	var in bytes.Buffer
+	in.Write([]byte("q"))
+
+	p := NewProgram(&testModel{}, WithInput(&in), WithOutput(&buf), WithOutputMirror(&mirror))
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.Len() == 0 || buf.String() != mirror.String() {
+		t.Fatalf("expected mirror to match output %q, got %q", buf.String(), mirror.String())
+	}
+}
+
 func TestTeaQuit(t *testing.T) {
 	var buf bytes.Buffer
 	var in bytes.Buffer
@@ -118,6 +147,41 @@ func testTeaWithFilter(t *testing.T, preventCount uint32) {
 	}
 }
 
+func TestTeaWithUpdateHook(t *testing.T) {
+	var buf bytes.Buffer
+	var in bytes.Buffer
+
+	var calls []string
+	m := &testModel{}
+	p := NewProgram(m,
+		WithInput(&in),
+		WithOutput(&buf),
+		WithUpdateHook(func(msg Msg, next func(Msg) Cmd) Cmd {
+			calls = append(calls, "outer")
+			return next(msg)
+		}),
+		WithUpdateHook(func(msg Msg, next func(Msg) Cmd) Cmd {
+			calls = append(calls, "inner")
+			if _, ok := msg.(incrementMsg); ok {
+				// Short-circuit the update.
+				return Quit
+			}
+			return next(msg)
+		}))
+
+	go p.Send(incrementMsg{})
+
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if m.counter.Load() != nil {
+		t.Error("expected the update to be short-circuited")
+	}
+	if len(calls) < 2 || calls[len(calls)-2] != "outer" || calls[len(calls)-1] != "inner" {
+		t.Errorf("expected hooks to run outermost first, got %v", calls)
+	}
+}
+
 func TestTeaKill(t *testing.T) {
 	var buf bytes.Buffer
 	var in bytes.Buffer
@@ -214,6 +278,78 @@ func TestTeaSequenceMsg(t *testing.T) {
 	}
 }
 
+func TestTeaStream(t *testing.T) {
+	var buf bytes.Buffer
+	var in bytes.Buffer
+
+	m := &testModel{}
+	p := NewProgram(m, WithInput(&in), WithOutput(&buf))
+	go p.Send(Stream(func(send func(Msg)) Msg {
+		send(incrementMsg{})
+		send(incrementMsg{})
+		return QuitMsg{}
+	})())
+
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	if m.counter.Load() != 2 {
+		t.Fatalf("counter should be 2, got %d", m.counter.Load())
+	}
+}
+
+// sequenceModel records the steps of a sequence, quitting once it's done.
+type sequenceModel struct {
+	msgs *[]Msg
+	init Cmd
+}
+
+func (m sequenceModel) Init() Cmd {
+	return m.init
+}
+
+func (m sequenceModel) Update(msg Msg) (Model, Cmd) {
+	switch msg.(type) {
+	case string, error:
+		*m.msgs = append(*m.msgs, msg)
+	case SequenceDoneMsg:
+		*m.msgs = append(*m.msgs, msg)
+		return m, Quit
+	}
+	return m, nil
+}
+
+func (m sequenceModel) View() string {
+	return ""
+}
+
+func TestTeaSequenceMsgWithStream(t *testing.T) {
+	errSomething := errors.New("something")
+	var msgs []Msg
+	m := sequenceModel{msgs: &msgs, init: SequenceAbortOnErr(
+		Stream(func(send func(Msg)) Msg {
+			send("progress")
+			time.Sleep(10 * time.Millisecond)
+			return "downloaded"
+		}),
+		func() Msg { return "next" },
+		Stream(func(send func(Msg)) Msg {
+			return errSomething
+		}),
+		func() Msg { return "skipped" },
+	)}
+	p := NewProgram(m, WithHeadlessRenderer(NewHeadlessRenderer()), WithInput(nil))
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Msg{"progress", "downloaded", "next", errSomething, SequenceDoneMsg{Aborted: true, Msg: errSomething}}
+	if !reflect.DeepEqual(msgs, expected) {
+		t.Fatalf("expected %#v, got %#v", expected, msgs)
+	}
+}
+
 func TestTeaSequenceMsgWithBatchMsg(t *testing.T) {
 	var buf bytes.Buffer
 	var in bytes.Buffer
@@ -263,3 +399,262 @@ func TestTeaNoRun(t *testing.T) {
 	m := &testModel{}
 	NewProgram(m, WithInput(&in), WithOutput(&buf))
 }
+
+func TestTeaExit(t *testing.T) {
+	var buf bytes.Buffer
+	var in bytes.Buffer
+
+	m := &testModel{}
+	p := NewProgram(m, WithInput(&in), WithOutput(&buf))
+	go p.Send(sequenceMsg{Exit(3)})
+
+	// The exit code can be read while the program runs.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				_ = p.ExitCode()
+			}
+		}
+	}()
+
+	_, err := p.Run()
+	var exitErr ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("expected an ExitError, got %v", err)
+	}
+	if exitErr.Code != 3 {
+		t.Errorf("expected exit code 3, got %d", exitErr.Code)
+	}
+	if p.ExitCode() != 3 {
+		t.Errorf("expected program exit code 3, got %d", p.ExitCode())
+	}
+}
+
+func TestTeaExitZero(t *testing.T) {
+	var buf bytes.Buffer
+	var in bytes.Buffer
+
+	m := &testModel{}
+	p := NewProgram(m, WithInput(&in), WithOutput(&buf))
+	go p.Send(sequenceMsg{Exit(0)})
+
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTeaReleaseRestoreTerminal(t *testing.T) {
+	var buf bytes.Buffer
+	var in bytes.Buffer
+
+	errs := make(chan error, 1)
+	var p *Program
+	m := initCmdModel{testModel: &testModel{}, init: func() Msg {
+		// Commands run once the program has started up.
+		if err := p.ReleaseTerminal(); err != nil {
+			errs <- err
+			return nil
+		}
+		// Releasing twice is a no-op.
+		if err := p.ReleaseTerminal(); err != nil {
+			errs <- err
+			return nil
+		}
+		errs <- p.RestoreTerminal()
+		return QuitMsg{}
+	}}
+	p = NewProgram(m, WithInput(&in), WithOutput(&buf), WithMouseCellMotion())
+
+	// Restoring a terminal that hasn't been released is a no-op.
+	if err := p.RestoreTerminal(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+
+	// The mouse should have been enabled on startup and again after
+	// restoring the terminal.
+	if n := strings.Count(buf.String(), "\x1b[?1002h\x1b[?1006h"); n != 2 {
+		t.Errorf("expected mouse to be enabled twice, got %d times in %q", n, buf.String())
+	}
+}
+
+func TestTeaReleaseRestoreTerminalConcurrently(t *testing.T) {
+	var buf bytes.Buffer
+	var in bytes.Buffer
+
+	errs := make(chan error, 1)
+	var p *Program
+	m := initCmdModel{testModel: &testModel{}, init: func() Msg {
+		// Release and restore the terminal from another goroutine while
+		// the event loop changes the mouse mode.
+		go func() {
+			for i := 0; i < 20; i++ {
+				if err := p.ReleaseTerminal(); err != nil {
+					errs <- err
+					return
+				}
+				if err := p.RestoreTerminal(); err != nil {
+					errs <- err
+					return
+				}
+			}
+			errs <- nil
+		}()
+		for i := 0; i < 20; i++ {
+			p.Send(EnableMouseAllMotion())
+			p.Send(DisableMouse())
+		}
+		if err := <-errs; err != nil {
+			return err
+		}
+		return QuitMsg{}
+	}}
+	p = NewProgram(m, WithInput(&in), WithOutput(&buf))
+
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if p.terminalReleased {
+		t.Error("expected the terminal to be restored")
+	}
+}
+
+func TestTeaShutdown(t *testing.T) {
+	t.Run("clean", func(t *testing.T) {
+		var buf bytes.Buffer
+		var in bytes.Buffer
+
+		m := &testModel{}
+		p := NewProgram(m, WithInput(&in), WithOutput(&buf))
+		started := make(chan struct{})
+		go p.Send(BatchMsg{func() Msg {
+			close(started)
+			time.Sleep(10 * time.Millisecond)
+			return nil
+		}})
+
+		errs := make(chan error, 1)
+		go func() {
+			<-started
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			errs <- p.Shutdown(ctx)
+		}()
+
+		if _, err := p.Run(); err != nil {
+			t.Fatal(err)
+		}
+		if err := <-errs; err != nil {
+			t.Fatalf("expected a clean shutdown, got %v", err)
+		}
+	})
+
+	t.Run("sequence", func(t *testing.T) {
+		var buf bytes.Buffer
+		var in bytes.Buffer
+
+		m := &testModel{}
+		p := NewProgram(m, WithInput(&in), WithOutput(&buf))
+		started := make(chan struct{})
+		var ran int32
+		go p.Send(sequenceMsg{
+			func() Msg {
+				close(started)
+				time.Sleep(10 * time.Millisecond)
+				return incrementMsg{}
+			},
+			func() Msg {
+				atomic.StoreInt32(&ran, 1)
+				return incrementMsg{}
+			},
+		})
+
+		errs := make(chan error, 1)
+		go func() {
+			<-started
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			errs <- p.Shutdown(ctx)
+		}()
+
+		if _, err := p.Run(); err != nil {
+			t.Fatal(err)
+		}
+		if err := <-errs; err != nil {
+			t.Fatalf("expected a clean shutdown, got %v", err)
+		}
+		if atomic.LoadInt32(&ran) != 1 {
+			t.Error("expected the whole sequence to run")
+		}
+	})
+
+	t.Run("tick", func(t *testing.T) {
+		var buf bytes.Buffer
+		var in bytes.Buffer
+
+		clock := manualClock{timers: make(chan instantTimer, 2)}
+		m := &testModel{}
+		p := NewProgram(m, WithInput(&in), WithOutput(&buf), WithClock(clock))
+		tick := Tick(time.Hour, func(t time.Time) Msg { return t })
+		go p.Send(BatchMsg{tick, Sequence(tick)})
+
+		errs := make(chan error, 1)
+		go func() {
+			// Shut down once both ticks are pending.
+			<-clock.timers
+			<-clock.timers
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			errs <- p.Shutdown(ctx)
+		}()
+
+		if _, err := p.Run(); err != nil {
+			t.Fatal(err)
+		}
+		if err := <-errs; err != nil {
+			t.Fatalf("expected pending ticks not to hold up shutdown, got %v", err)
+		}
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		var buf bytes.Buffer
+		var in bytes.Buffer
+
+		m := &testModel{}
+		p := NewProgram(m, WithInput(&in), WithOutput(&buf))
+		started := make(chan struct{})
+		block := make(chan struct{})
+		defer close(block)
+		go p.Send(BatchMsg{func() Msg {
+			close(started)
+			<-block
+			return nil
+		}})
+
+		errs := make(chan error, 1)
+		go func() {
+			<-started
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			errs <- p.Shutdown(ctx)
+		}()
+
+		if _, err := p.Run(); !errors.Is(err, ErrProgramKilled) {
+			t.Fatalf("expected %v, got %v", ErrProgramKilled, err)
+		}
+		if err := <-errs; !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected %v, got %v", context.DeadlineExceeded, err)
+		}
+	})
+}
@@ -0,0 +1,270 @@
+// Package teassh hosts Bubble Tea programs over SSH, running one program per
+// session.
+//
+// It doesn't depend on a particular SSH library: a Session wraps the channel
+// of an SSH session, which is all a program needs to read from and draw to,
+// and is told about the session's requests, from which it learns the type and
+// size of the client's terminal, when it's resized and which colors it
+// supports.
+// With golang.org/x/crypto/ssh, for instance:
+//
+//	ch, reqs, _ := newChannel.Accept()
+//	s := teassh.NewSession(ch)
+//	for req := range reqs {
+//	    switch {
+//	    case s.HandleRequest(req.Type, req.Payload):
+//	        req.Reply(true, nil)
+//	    case req.Type == "shell":
+//	        req.Reply(true, nil)
+//	        go func() {
+//	            defer ch.Close()
+//	            s.Run(initialModel())
+//	        }()
+//	    default:
+//	        req.Reply(false, nil)
+//	    }
+//	}
+//
+// Libraries which parse requests themselves can report them with SetPty,
+// Resize and Setenv instead.
+package teassh
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/muesli/termenv"
+)
+
+// Pty describes the terminal requested by the client with a pty-req request.
+type Pty struct {
+	// Term is the client's terminal type, e.g. xterm-256color.
+	Term string
+
+	// Width and Height are the size of the terminal in columns and rows.
+	Width  int
+	Height int
+}
+
+// Session connects a Bubble Tea program to an SSH session. It's safe for
+// concurrent use: requests can keep coming while the program runs.
+type Session struct {
+	rw io.ReadWriter
+
+	mtx     sync.Mutex
+	pty     Pty
+	hasPty  bool
+	env     []string
+	program *tea.Program
+}
+
+// NewSession returns a Session for the given SSH channel.
+func NewSession(ch io.ReadWriter) *Session {
+	return &Session{rw: ch}
+}
+
+// HandleRequest handles the session requests a program cares about: pty-req,
+// window-change and env. It reports whether the request was handled, so that
+// it can be acknowledged; other requests, and malformed ones, are left to the
+// caller.
+func (s *Session) HandleRequest(typ string, payload []byte) bool {
+	switch typ {
+	case "pty-req":
+		pty, err := ParsePtyRequest(payload)
+		if err != nil {
+			return false
+		}
+		s.SetPty(pty)
+	case "window-change":
+		width, height, err := ParseWindowChange(payload)
+		if err != nil {
+			return false
+		}
+		s.Resize(width, height)
+	case "env":
+		key, value, err := ParseEnv(payload)
+		if err != nil {
+			return false
+		}
+		s.Setenv(key, value)
+	default:
+		return false
+	}
+	return true
+}
+
+// SetPty records the terminal requested by the client.
+func (s *Session) SetPty(pty Pty) {
+	s.mtx.Lock()
+	s.pty = pty
+	s.hasPty = true
+	s.mtx.Unlock()
+
+	s.Resize(pty.Width, pty.Height)
+}
+
+// Pty returns the terminal requested by the client, and whether the client
+// requested one at all.
+func (s *Session) Pty() (Pty, bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.pty, s.hasPty
+}
+
+// Resize records a change of the size of the client's terminal, and sends it
+// to the program as a tea.WindowSizeMsg if it's running.
+func (s *Session) Resize(width, height int) {
+	s.mtx.Lock()
+	s.pty.Width, s.pty.Height = width, height
+	p := s.program
+	s.mtx.Unlock()
+
+	if p != nil {
+		p.Send(tea.WindowSizeMsg{Width: width, Height: height})
+	}
+}
+
+// Setenv records an environment variable set by the client. Variables such
+// as COLORTERM are used to figure out which colors the client supports. They
+// must be set before the program starts to be taken into account.
+func (s *Session) Setenv(key, value string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.env = append(s.env, key+"="+value)
+}
+
+// Environ returns the session's environment: the variables set by the
+// client, and TERM, from the terminal requested by the client.
+func (s *Session) Environ() []string {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	env := append([]string(nil), s.env...)
+	if s.hasPty && s.pty.Term != "" {
+		env = append(env, "TERM="+s.pty.Term)
+	}
+	return env
+}
+
+// Getenv returns the value of a variable of the session's environment.
+func (s *Session) Getenv(key string) string {
+	env := s.Environ()
+	// Later variables take precedence.
+	for i := len(env) - 1; i >= 0; i-- {
+		if k, v, ok := strings.Cut(env[i], "="); ok && k == key {
+			return v
+		}
+	}
+	return ""
+}
+
+// ColorProfile returns the color profile of the client's terminal, based on
+// the session's environment. Sessions without a terminal get no colors.
+func (s *Session) ColorProfile() termenv.Profile {
+	if _, ok := s.Pty(); !ok {
+		return termenv.Ascii
+	}
+	return termenv.NewOutput(s.rw, termenv.WithTTY(true), termenv.WithEnvironment(s)).EnvColorProfile()
+}
+
+// ProgramOptions returns the options connecting a program to the session:
+// its input and output, with the client's color profile and terminal type,
+// and no signal handling, as the server's signals aren't the client's.
+func (s *Session) ProgramOptions() []tea.ProgramOption {
+	opts := []tea.ProgramOption{
+		tea.WithInput(s.rw),
+		tea.WithOutput(termenv.NewOutput(s.rw, termenv.WithProfile(s.ColorProfile()))),
+		tea.WithoutSignalHandler(),
+	}
+	if pty, ok := s.Pty(); ok && pty.Term != "" {
+		opts = append(opts, tea.WithTerm(pty.Term))
+	}
+	return opts
+}
+
+// Attach starts sending the session's window changes to the program, along
+// with the client's current terminal size, if known. Call Detach once the
+// program has exited.
+func (s *Session) Attach(p *tea.Program) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.program = p
+	if s.hasPty {
+		size := tea.WindowSizeMsg{Width: s.pty.Width, Height: s.pty.Height}
+		go p.Send(size)
+	}
+}
+
+// Detach stops sending window changes to the program.
+func (s *Session) Detach() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.program = nil
+}
+
+// Run runs a program for the given model in the session, blocking until it
+// exits, and returns the final model. It doesn't close the channel.
+func (s *Session) Run(model tea.Model, opts ...tea.ProgramOption) (tea.Model, error) {
+	p := tea.NewProgram(model, append(opts, s.ProgramOptions()...)...)
+	s.Attach(p)
+	defer s.Detach()
+	return p.Run()
+}
+
+var errMalformedRequest = errors.New("malformed request")
+
+// ParsePtyRequest parses the payload of a pty-req request, as defined in
+// RFC 4254, section 6.2.
+func ParsePtyRequest(payload []byte) (Pty, error) {
+	term, rest, ok := parseString(payload)
+	if !ok || len(rest) < 8 { //nolint:gomnd
+		return Pty{}, errMalformedRequest
+	}
+	return Pty{
+		Term:   term,
+		Width:  int(binary.BigEndian.Uint32(rest)),
+		Height: int(binary.BigEndian.Uint32(rest[4:])),
+	}, nil
+}
+
+// ParseWindowChange parses the payload of a window-change request, as
+// defined in RFC 4254, section 6.7, returning the new size of the terminal in
+// columns and rows.
+func ParseWindowChange(payload []byte) (width, height int, err error) {
+	if len(payload) < 8 { //nolint:gomnd
+		return 0, 0, errMalformedRequest
+	}
+	return int(binary.BigEndian.Uint32(payload)), int(binary.BigEndian.Uint32(payload[4:])), nil
+}
+
+// ParseEnv parses the payload of an env request, as defined in RFC 4254,
+// section 6.4.
+func ParseEnv(payload []byte) (key, value string, err error) {
+	key, rest, ok := parseString(payload)
+	if !ok {
+		return "", "", errMalformedRequest
+	}
+	value, _, ok = parseString(rest)
+	if !ok {
+		return "", "", errMalformedRequest
+	}
+	return key, value, nil
+}
+
+// parseString parses a length-prefixed SSH string.
+func parseString(b []byte) (s string, rest []byte, ok bool) {
+	if len(b) < 4 { //nolint:gomnd
+		return "", nil, false
+	}
+	n := binary.BigEndian.Uint32(b)
+	b = b[4:]
+	if uint32(len(b)) < n {
+		return "", nil, false
+	}
+	return string(b[:n]), b[n:], true
+}
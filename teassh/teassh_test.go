@@ -0,0 +1,259 @@
+package teassh
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/muesli/termenv"
+)
+
+func appendUint32(b []byte, v uint32) []byte {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+func sshString(s string) []byte {
+	return append(appendUint32(nil, uint32(len(s))), s...)
+}
+
+func ptyRequest(term string, width, height uint32) []byte {
+	b := sshString(term)
+	b = appendUint32(b, width)
+	b = appendUint32(b, height)
+	b = appendUint32(b, 0)
+	b = appendUint32(b, 0)
+	return append(b, sshString("")...)
+}
+
+func windowChange(width, height uint32) []byte {
+	b := appendUint32(nil, width)
+	b = appendUint32(b, height)
+	b = appendUint32(b, 0)
+	return appendUint32(b, 0)
+}
+
+func TestHandleRequest(t *testing.T) {
+	s := NewSession(&bytes.Buffer{})
+
+	if s.ColorProfile() != termenv.Ascii {
+		t.Error("expected no colors without a pty")
+	}
+
+	if !s.HandleRequest("pty-req", ptyRequest("xterm-256color", 80, 24)) {
+		t.Fatal("pty-req wasn't handled")
+	}
+	if !s.HandleRequest("window-change", windowChange(120, 40)) {
+		t.Fatal("window-change wasn't handled")
+	}
+	pty, ok := s.Pty()
+	if !ok {
+		t.Fatal("expected a pty")
+	}
+	if exp := (Pty{Term: "xterm-256color", Width: 120, Height: 40}); pty != exp {
+		t.Errorf("expected %+v, got %+v", exp, pty)
+	}
+	if p := s.ColorProfile(); p != termenv.ANSI256 {
+		t.Errorf("expected 256 colors, got %v", p)
+	}
+
+	if !s.HandleRequest("env", append(sshString("COLORTERM"), sshString("truecolor")...)) {
+		t.Fatal("env wasn't handled")
+	}
+	if v := s.Getenv("COLORTERM"); v != "truecolor" {
+		t.Errorf("expected COLORTERM=truecolor, got %q", v)
+	}
+	if p := s.ColorProfile(); p != termenv.TrueColor {
+		t.Errorf("expected true color, got %v", p)
+	}
+
+	if s.HandleRequest("shell", nil) {
+		t.Error("shell shouldn't be handled")
+	}
+	if s.HandleRequest("window-change", []byte{0, 0, 0}) {
+		t.Error("malformed window-change shouldn't be handled")
+	}
+	if s.HandleRequest("pty-req", sshString("xterm")[:4]) {
+		t.Error("malformed pty-req shouldn't be handled")
+	}
+}
+
+type sizeModel struct {
+	sizes   []tea.WindowSizeMsg
+	resized chan struct{}
+}
+
+func (m sizeModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m sizeModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.WindowSizeMsg); ok {
+		m.sizes = append(m.sizes, msg)
+		if len(m.sizes) == 1 {
+			close(m.resized)
+		}
+		if len(m.sizes) == 2 {
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+func (m sizeModel) View() string {
+	return "hello"
+}
+
+func TestRun(t *testing.T) {
+	in, input := io.Pipe()
+	defer input.Close()
+	var out bytes.Buffer
+	ch := struct {
+		io.Reader
+		io.Writer
+	}{in, &out}
+
+	s := NewSession(ch)
+	s.HandleRequest("pty-req", ptyRequest("xterm", 80, 24))
+
+	// Resize the terminal once the program got its initial size.
+	resized := make(chan struct{})
+	go func() {
+		<-resized
+		s.HandleRequest("window-change", windowChange(100, 30))
+	}()
+
+	m, err := s.Run(sizeModel{resized: resized}, tea.WithoutSignals())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sizes := m.(sizeModel).sizes
+	exp := []tea.WindowSizeMsg{{Width: 80, Height: 24}, {Width: 100, Height: 30}}
+	if len(sizes) != 2 || sizes[0] != exp[0] || sizes[1] != exp[1] {
+		t.Fatalf("expected window sizes %v, got %v", exp, sizes)
+	}
+	if !bytes.Contains(out.Bytes(), []byte("hello")) {
+		t.Errorf("expected the view to be rendered, got %q", out.String())
+	}
+}
+
+// compileTerminfo builds a compiled terminfo entry, in the legacy format,
+// with the given string capabilities and no others.
+func compileTerminfo(name string, caps map[int]string) []byte {
+	n := 0
+	for i := range caps {
+		if i+1 > n {
+			n = i + 1
+		}
+	}
+	var table []byte
+	offsets := make([]int, n)
+	for i := range offsets {
+		offsets[i] = -1
+		if s, ok := caps[i]; ok {
+			offsets[i] = len(table)
+			table = append(append(table, s...), 0)
+		}
+	}
+
+	appendUint16 := func(b []byte, v int) []byte {
+		return append(b, byte(v), byte(v>>8))
+	}
+	var b []byte
+	for _, v := range []int{0o432, len(name) + 1, 0, 0, n, len(table)} {
+		b = appendUint16(b, v)
+	}
+	b = append(append(b, name...), 0)
+	if len(b)%2 == 1 {
+		b = append(b, 0)
+	}
+	for _, o := range offsets {
+		b = appendUint16(b, o)
+	}
+	return append(b, table...)
+}
+
+// syncBuffer is a buffer written to by a program and read by a test.
+type syncBuffer struct {
+	mtx sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.buf.String()
+}
+
+type linesModel string
+
+func (m linesModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m linesModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if _, ok := msg.(tea.KeyMsg); ok {
+		return linesModel("c\nd"), tea.Quit
+	}
+	return m, nil
+}
+
+func (m linesModel) View() string {
+	return string(m)
+}
+
+func TestRunTerm(t *testing.T) {
+	// A terminal type of its own, whose sequences for clearing a line and
+	// moving the cursor up stand out.
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "t"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	entry := compileTerminfo("teassh-test", map[int]string{6: "<el>", 19: "<up>"})
+	if err := os.WriteFile(filepath.Join(dir, "t", "teassh-test"), entry, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TERMINFO", dir)
+	t.Setenv("TERMINFO_DIRS", dir)
+	t.Setenv("TERM", "xterm")
+
+	in, input := io.Pipe()
+	defer input.Close()
+	out := &syncBuffer{}
+	ch := struct {
+		io.Reader
+		io.Writer
+	}{in, out}
+
+	s := NewSession(ch)
+	s.HandleRequest("pty-req", ptyRequest("teassh-test", 80, 24))
+
+	// Redraw the view once it's been drawn.
+	go func() {
+		for !strings.Contains(out.String(), "b") {
+			time.Sleep(time.Millisecond)
+		}
+		_, _ = input.Write([]byte("x"))
+	}()
+
+	if _, err := s.Run(linesModel("a\nb"), tea.WithoutSignals()); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); !strings.Contains(got, "<up>") || !strings.Contains(got, "<el>") {
+		t.Errorf("expected the sequences of the client's terminal to be used, got %q", got)
+	}
+}
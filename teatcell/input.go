@@ -0,0 +1,136 @@
+package teatcell
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/gdamore/tcell/v2"
+)
+
+// Wheel events are reported as buttons by tcell, but they are never held
+// down.
+const wheelMask = tcell.WheelUp | tcell.WheelDown | tcell.WheelLeft | tcell.WheelRight
+
+// Keys that translate to a single Bubble Tea key type.
+var keyTypes = map[tcell.Key]tea.KeyType{
+	tcell.KeyBacktab:    tea.KeyShiftTab,
+	tcell.KeyBackspace2: tea.KeyBackspace,
+	tcell.KeyDelete:     tea.KeyDelete,
+	tcell.KeyInsert:     tea.KeyInsert,
+}
+
+// Keys whose Bubble Tea key type depends on the ctrl and shift modifiers,
+// indexed by modifiers: none, shift, ctrl, then ctrl+shift.
+var modifiedKeyTypes = map[tcell.Key][4]tea.KeyType{
+	tcell.KeyUp:    {tea.KeyUp, tea.KeyShiftUp, tea.KeyCtrlUp, tea.KeyCtrlShiftUp},
+	tcell.KeyDown:  {tea.KeyDown, tea.KeyShiftDown, tea.KeyCtrlDown, tea.KeyCtrlShiftDown},
+	tcell.KeyRight: {tea.KeyRight, tea.KeyShiftRight, tea.KeyCtrlRight, tea.KeyCtrlShiftRight},
+	tcell.KeyLeft:  {tea.KeyLeft, tea.KeyShiftLeft, tea.KeyCtrlLeft, tea.KeyCtrlShiftLeft},
+	tcell.KeyHome:  {tea.KeyHome, tea.KeyShiftHome, tea.KeyCtrlHome, tea.KeyCtrlShiftHome},
+	tcell.KeyEnd:   {tea.KeyEnd, tea.KeyShiftEnd, tea.KeyCtrlEnd, tea.KeyCtrlShiftEnd},
+	tcell.KeyPgUp:  {tea.KeyPgUp, tea.KeyPgUp, tea.KeyCtrlPgUp, tea.KeyCtrlPgUp},
+	tcell.KeyPgDn:  {tea.KeyPgDown, tea.KeyPgDown, tea.KeyCtrlPgDown, tea.KeyCtrlPgDown},
+}
+
+// keyMsg translates a tcell key event into a Bubble Tea key message, and
+// reports whether the key has an equivalent.
+func keyMsg(ev *tcell.EventKey) (tea.KeyMsg, bool) {
+	mod := ev.Modifiers()
+	msg := tea.KeyMsg{Alt: mod&tcell.ModAlt != 0}
+
+	k := ev.Key()
+	if k == tcell.KeyRune {
+		r := ev.Rune()
+		msg.Type, msg.Runes = tea.KeyRunes, []rune{r}
+		if r == ' ' {
+			msg.Type = tea.KeySpace
+		}
+		return msg, true
+	}
+	if t, ok := keyTypes[k]; ok {
+		msg.Type = t
+		return msg, true
+	}
+	if types, ok := modifiedKeyTypes[k]; ok {
+		i := 0
+		if mod&tcell.ModShift != 0 {
+			i++
+		}
+		if mod&tcell.ModCtrl != 0 {
+			i += 2
+		}
+		msg.Type = types[i]
+		return msg, true
+	}
+	switch {
+	case k >= tcell.KeyF1 && k <= tcell.KeyF20:
+		// Bubble Tea's function keys count down.
+		msg.Type = tea.KeyF1 - tea.KeyType(k-tcell.KeyF1)
+	case k <= tcell.KeyCtrlUnderscore:
+		// Control characters, including enter, tab and escape, have the
+		// same codes.
+		msg.Type = tea.KeyType(k)
+	default:
+		return msg, false
+	}
+	return msg, true
+}
+
+// Buttons, in the order in which their presses and releases are reported.
+var buttons = []struct {
+	mask   tcell.ButtonMask
+	button tea.MouseButton
+}{
+	{tcell.Button1, tea.MouseButtonLeft},
+	{tcell.Button3, tea.MouseButtonMiddle},
+	{tcell.Button2, tea.MouseButtonRight},
+	{tcell.Button4, tea.MouseButtonForward},
+	{tcell.Button5, tea.MouseButtonBackward},
+	{tcell.WheelUp, tea.MouseButtonWheelUp},
+	{tcell.WheelDown, tea.MouseButtonWheelDown},
+	{tcell.WheelLeft, tea.MouseButtonWheelLeft},
+	{tcell.WheelRight, tea.MouseButtonWheelRight},
+}
+
+// mouseMsgs translates a tcell mouse event into Bubble Tea mouse messages,
+// given the buttons held down before it. tcell reports the state of the
+// buttons, while Bubble Tea reports presses, releases and motions, so a
+// single event can make several messages.
+func mouseMsgs(ev *tcell.EventMouse, x, y int, held tcell.ButtonMask) []tea.MouseMsg {
+	mod := ev.Modifiers()
+	base := tea.MouseMsg{
+		X:     x,
+		Y:     y,
+		Shift: mod&tcell.ModShift != 0,
+		Alt:   mod&tcell.ModAlt != 0,
+		Ctrl:  mod&tcell.ModCtrl != 0,
+	}
+
+	var msgs []tea.MouseMsg
+	pressed := ev.Buttons()
+	for _, b := range buttons {
+		msg := base
+		msg.Button = b.button
+		switch {
+		case pressed&b.mask != 0 && held&b.mask == 0:
+			msg.Action = tea.MouseActionPress
+		case pressed&b.mask == 0 && held&b.mask != 0:
+			msg.Action = tea.MouseActionRelease
+		default:
+			continue
+		}
+		msgs = append(msgs, msg)
+	}
+
+	if len(msgs) == 0 {
+		// Nothing was pressed or released: the mouse moved.
+		msg := base
+		msg.Action = tea.MouseActionMotion
+		for _, b := range buttons {
+			if pressed&b.mask != 0 {
+				msg.Button = b.button
+				break
+			}
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
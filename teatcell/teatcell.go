@@ -0,0 +1,475 @@
+// Package teatcell runs Bubble Tea programs on a tcell screen, so that
+// models can be embedded in existing tcell applications, or take advantage of
+// tcell's terminal handling, without changes to the tea.Model they're written
+// against.
+//
+// A standalone program takes over the whole screen:
+//
+//	s, _ := tcell.NewScreen()
+//	_ = s.Init()
+//	defer s.Fini()
+//
+//	if _, err := teatcell.Run(s, initialModel()); err != nil {
+//	    // ...
+//	}
+//
+// Applications with their own event loop create a Program, give it a region
+// of the screen with SetRegion and pass it the events meant for it with
+// HandleEvent.
+//
+// The program's views are parsed for colors and text attributes, which are
+// drawn with tcell styles, while anything else a program would write to the
+// terminal, such as its window title, is discarded.
+package teatcell
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+)
+
+// How often the screen is checked for a new frame to draw.
+const frameInterval = time.Second / 60
+
+// Program runs a Bubble Tea program on a tcell screen.
+type Program struct {
+	program  *tea.Program
+	renderer *tea.HeadlessRenderer
+	screen   tcell.Screen
+
+	mtx sync.Mutex
+
+	// the region of the screen the program is drawn in; the whole screen
+	// unless set with SetRegion
+	region              bool
+	x, y, width, height int
+
+	// what was drawn last, and whether the screen needs to be redrawn
+	drawnFrames              int
+	cursorParked             bool
+	lastCursorX, lastCursorY int
+	dirty                    bool
+
+	// the mouse buttons held down, and the pasted text being received
+	buttons tcell.ButtonMask
+	pasting bool
+	paste   []rune
+}
+
+// NewProgram returns a Program running the given model on a tcell screen,
+// which must be initialized already. Options are passed to the underlying
+// tea.Program; input and rendering are handled by the Program.
+func NewProgram(screen tcell.Screen, model tea.Model, opts ...tea.ProgramOption) *Program {
+	p := &Program{
+		renderer: tea.NewHeadlessRenderer(),
+		screen:   screen,
+		dirty:    true,
+	}
+	p.width, p.height = screen.Size()
+	p.program = tea.NewProgram(model, append(opts, tea.WithHeadlessRenderer(p.renderer), tea.WithInput(nil))...)
+	return p
+}
+
+// Run runs the program, drawing it on the screen, and blocks until it exits.
+// It returns the final model. The screen isn't finalized.
+func (p *Program) Run() (tea.Model, error) {
+	go p.program.Send(p.size())
+
+	done := make(chan struct{})
+	drawn := make(chan struct{})
+	go func() {
+		defer close(drawn)
+
+		ticker := time.NewTicker(frameInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				p.draw()
+			}
+		}
+	}()
+
+	m, err := p.program.Run()
+	close(done)
+	<-drawn
+
+	// Draw the final frame.
+	p.draw()
+	return m, err
+}
+
+// Send sends a message to the program.
+func (p *Program) Send(msg tea.Msg) {
+	p.program.Send(msg)
+}
+
+// Quit quits the program.
+func (p *Program) Quit() {
+	p.program.Quit()
+}
+
+// Kill stops the program immediately.
+func (p *Program) Kill() {
+	p.program.Kill()
+}
+
+// SetRegion confines the program to a region of the screen, reported to the
+// program as its window size. Mouse events outside of the region are left to
+// the application.
+func (p *Program) SetRegion(x, y, width, height int) {
+	p.mtx.Lock()
+	p.region = true
+	p.x, p.y, p.width, p.height = x, y, width, height
+	p.dirty = true
+	p.mtx.Unlock()
+
+	go p.program.Send(p.size())
+}
+
+// size returns the size of the program's region.
+func (p *Program) size() tea.WindowSizeMsg {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	return tea.WindowSizeMsg{Width: p.width, Height: p.height}
+}
+
+// HandleEvent passes a tcell event on to the program, and reports whether
+// the program took it. Keys, pastes and mouse events within the program's
+// region are translated into Bubble Tea messages. Resizes of the screen
+// resize the program unless it's confined to a region; they're never
+// reported as taken, so that the application can handle them too.
+func (p *Program) HandleEvent(ev tcell.Event) bool {
+	switch ev := ev.(type) {
+	case *tcell.EventResize:
+		p.mtx.Lock()
+		p.dirty = true
+		region := p.region
+		if !region {
+			p.width, p.height = ev.Size()
+		}
+		p.mtx.Unlock()
+
+		if !region {
+			p.program.Send(p.size())
+		}
+		return false
+
+	case *tcell.EventPaste:
+		p.mtx.Lock()
+		if ev.Start() {
+			p.pasting = true
+			p.paste = nil
+			p.mtx.Unlock()
+			return true
+		}
+		paste := p.paste
+		p.pasting, p.paste = false, nil
+		p.mtx.Unlock()
+
+		if len(paste) > 0 {
+			p.program.Send(tea.KeyMsg{Type: tea.KeyRunes, Runes: paste, Paste: true})
+		}
+		return true
+
+	case *tcell.EventKey:
+		p.mtx.Lock()
+		if p.pasting {
+			switch ev.Key() {
+			case tcell.KeyRune:
+				p.paste = append(p.paste, ev.Rune())
+			case tcell.KeyEnter:
+				p.paste = append(p.paste, '\n')
+			case tcell.KeyTab:
+				p.paste = append(p.paste, '\t')
+			}
+			p.mtx.Unlock()
+			return true
+		}
+		p.mtx.Unlock()
+
+		msg, ok := keyMsg(ev)
+		if ok {
+			p.program.Send(msg)
+		}
+		return ok
+
+	case *tcell.EventMouse:
+		p.mtx.Lock()
+		x, y := ev.Position()
+		x, y = x-p.x, y-p.y
+		if x < 0 || y < 0 || x >= p.width || y >= p.height {
+			p.mtx.Unlock()
+			return false
+		}
+		msgs := mouseMsgs(ev, x, y, p.buttons)
+		p.buttons = ev.Buttons() &^ wheelMask
+		p.mtx.Unlock()
+
+		for _, msg := range msgs {
+			p.program.Send(msg)
+		}
+		return true
+	}
+	return false
+}
+
+// draw draws the latest frame of the program on the screen, if it changed
+// or if the screen needs to be redrawn.
+func (p *Program) draw() {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	frames := p.renderer.Frames()
+	cursor := p.renderer.Cursor()
+	if !p.dirty && frames == p.drawnFrames && !p.cursorMoved(cursor) {
+		return
+	}
+	p.dirty = false
+	p.drawnFrames = frames
+
+	lines := strings.Split(p.renderer.LastFrame(), "\n")
+	for row := 0; row < p.height; row++ {
+		line := ""
+		if row < len(lines) {
+			line = lines[row]
+		}
+		drawLine(p.screen, p.x, p.y+row, p.width, line)
+	}
+
+	p.cursorParked = cursor != nil
+	if cursor != nil && cursor.X < p.width && cursor.Y < p.height {
+		p.lastCursorX, p.lastCursorY = cursor.X, cursor.Y
+		p.screen.ShowCursor(p.x+cursor.X, p.y+cursor.Y)
+	} else {
+		p.screen.HideCursor()
+	}
+	p.screen.Show()
+}
+
+// cursorMoved reports whether the cursor was placed, removed or moved since
+// the last time the screen was drawn.
+func (p *Program) cursorMoved(c *tea.Cursor) bool {
+	if c == nil {
+		return p.cursorParked
+	}
+	return !p.cursorParked || c.X != p.lastCursorX || c.Y != p.lastCursorY
+}
+
+// Run runs a program for the given model on the whole screen, reading the
+// screen's events until it exits, and returns the final model. The screen
+// must be initialized already, and isn't finalized. Call EnableMouse and
+// EnablePaste on the screen beforehand for the program to get mouse events
+// and pastes.
+func Run(screen tcell.Screen, model tea.Model, opts ...tea.ProgramOption) (tea.Model, error) {
+	p := NewProgram(screen, model, opts...)
+
+	// Stop reading events once the program has exited, with an interrupt
+	// event carrying this token.
+	stop := new(int)
+	polled := make(chan struct{})
+	go func() {
+		defer close(polled)
+		for {
+			switch ev := screen.PollEvent().(type) {
+			case nil:
+				// The screen was finalized.
+				return
+			case *tcell.EventInterrupt:
+				if ev.Data() == stop {
+					return
+				}
+			case *tcell.EventResize:
+				p.HandleEvent(ev)
+				screen.Sync()
+			default:
+				p.HandleEvent(ev)
+			}
+		}
+	}()
+
+	m, err := p.Run()
+	screen.PostEventWait(tcell.NewEventInterrupt(stop))
+	<-polled
+	return m, err
+}
+
+// drawLine draws a line of a view, which may contain SGR sequences, on a row
+// of the screen, padding it with blanks up to the given width.
+func drawLine(s tcell.Screen, x, y, width int, line string) {
+	var (
+		style tcell.Style
+		col   int
+		cell  rune
+		comb  []rune
+	)
+	flush := func() {
+		if cell == 0 {
+			return
+		}
+		w := runewidth.RuneWidth(cell)
+		if col+w <= width {
+			s.SetContent(x+col, y, cell, comb, style)
+		}
+		col += w
+		cell, comb = 0, nil
+	}
+
+	for i := 0; i < len(line) && col < width; {
+		if line[i] == '\x1b' {
+			flush()
+			n, params, final := parseEscape(line[i:])
+			if final == 'm' {
+				style = applySGR(style, params)
+			}
+			i += n
+			continue
+		}
+
+		r, n := utf8.DecodeRuneInString(line[i:])
+		i += n
+		if r < ' ' || r == utf8.RuneError && n == 1 {
+			continue
+		}
+		if runewidth.RuneWidth(r) == 0 && cell != 0 {
+			comb = append(comb, r)
+			continue
+		}
+		flush()
+		cell = r
+	}
+	flush()
+
+	for ; col < width; col++ {
+		s.SetContent(x+col, y, ' ', nil, tcell.StyleDefault)
+	}
+}
+
+// parseEscape parses the escape sequence at the start of s, returning its
+// length and, for CSI sequences, their parameters and final byte.
+func parseEscape(s string) (n int, params string, final byte) {
+	if len(s) < 2 { //nolint:gomnd
+		return len(s), "", 0
+	}
+	switch s[1] {
+	case '[':
+		for i := 2; i < len(s); i++ {
+			if s[i] >= 0x40 && s[i] <= 0x7e {
+				return i + 1, s[2:i], s[i]
+			}
+		}
+		return len(s), "", 0
+	case ']':
+		// OSC, up to BEL or ST.
+		for i := 2; i < len(s); i++ {
+			if s[i] == '\a' {
+				return i + 1, "", 0
+			}
+			if s[i] == '\x1b' && i+1 < len(s) && s[i+1] == '\\' {
+				return i + 2, "", 0 //nolint:gomnd
+			}
+		}
+		return len(s), "", 0
+	default:
+		return 2, "", 0 //nolint:gomnd
+	}
+}
+
+// applySGR applies the parameters of an SGR sequence to a style.
+func applySGR(style tcell.Style, params string) tcell.Style {
+	if params == "" {
+		return tcell.StyleDefault
+	}
+	ps := strings.Split(strings.ReplaceAll(params, ":", ";"), ";")
+	num := func(i int) int {
+		if i >= len(ps) {
+			return -1
+		}
+		n, err := strconv.Atoi(ps[i])
+		if err != nil {
+			return -1
+		}
+		return n
+	}
+
+	for i := 0; i < len(ps); i++ {
+		switch n := num(i); {
+		case n == 0 || ps[i] == "":
+			style = tcell.StyleDefault
+		case n == 1:
+			style = style.Bold(true)
+		case n == 2: //nolint:gomnd
+			style = style.Dim(true)
+		case n == 3: //nolint:gomnd
+			style = style.Italic(true)
+		case n == 4: //nolint:gomnd
+			style = style.Underline(true)
+		case n == 5: //nolint:gomnd
+			style = style.Blink(true)
+		case n == 7: //nolint:gomnd
+			style = style.Reverse(true)
+		case n == 9: //nolint:gomnd
+			style = style.StrikeThrough(true)
+		case n == 22: //nolint:gomnd
+			style = style.Bold(false).Dim(false)
+		case n == 23: //nolint:gomnd
+			style = style.Italic(false)
+		case n == 24: //nolint:gomnd
+			style = style.Underline(false)
+		case n == 25: //nolint:gomnd
+			style = style.Blink(false)
+		case n == 27: //nolint:gomnd
+			style = style.Reverse(false)
+		case n == 29: //nolint:gomnd
+			style = style.StrikeThrough(false)
+		case n >= 30 && n <= 37:
+			style = style.Foreground(tcell.PaletteColor(n - 30))
+		case n == 38 || n == 48:
+			c, skip := extendedColor(num, i+1)
+			i += skip
+			if n == 38 {
+				style = style.Foreground(c)
+			} else {
+				style = style.Background(c)
+			}
+		case n == 39: //nolint:gomnd
+			style = style.Foreground(tcell.ColorReset)
+		case n >= 40 && n <= 47:
+			style = style.Background(tcell.PaletteColor(n - 40))
+		case n == 49: //nolint:gomnd
+			style = style.Background(tcell.ColorReset)
+		case n >= 90 && n <= 97:
+			style = style.Foreground(tcell.PaletteColor(n - 90 + 8))
+		case n >= 100 && n <= 107:
+			style = style.Background(tcell.PaletteColor(n - 100 + 8))
+		}
+	}
+	return style
+}
+
+// extendedColor parses a 256-color or true color following 38 or 48 in an
+// SGR sequence, returning the color and the number of parameters it took.
+func extendedColor(num func(int) int, i int) (tcell.Color, int) {
+	switch num(i) {
+	case 5: //nolint:gomnd
+		if c := num(i + 1); c >= 0 {
+			return tcell.PaletteColor(c), 2 //nolint:gomnd
+		}
+		return tcell.ColorDefault, 2 //nolint:gomnd
+	case 2: //nolint:gomnd
+		r, g, b := num(i+1), num(i+2), num(i+3)
+		if r < 0 || g < 0 || b < 0 {
+			return tcell.ColorDefault, 4 //nolint:gomnd
+		}
+		return tcell.NewRGBColor(int32(r), int32(g), int32(b)), 4 //nolint:gomnd
+	}
+	return tcell.ColorDefault, 0
+}
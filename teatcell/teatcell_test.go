@@ -0,0 +1,227 @@
+package teatcell
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/gdamore/tcell/v2"
+)
+
+type testModel struct {
+	keys  []string
+	mouse []tea.MouseMsg
+	size  tea.WindowSizeMsg
+}
+
+func (m testModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m testModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if msg.String() == "q" {
+			return m, tea.Quit
+		}
+		m.keys = append(m.keys, msg.String())
+	case tea.MouseMsg:
+		m.mouse = append(m.mouse, msg)
+	case tea.WindowSizeMsg:
+		m.size = msg
+	}
+	return m, nil
+}
+
+func (m testModel) View() string {
+	return "\x1b[1;31mhello\x1b[0m\n" + strings.Join(m.keys, " ")
+}
+
+func newScreen(t *testing.T) tcell.SimulationScreen {
+	t.Helper()
+	s := tcell.NewSimulationScreen("UTF-8")
+	if err := s.Init(); err != nil {
+		t.Fatal(err)
+	}
+	s.SetSize(20, 4)
+	t.Cleanup(s.Fini)
+	return s
+}
+
+// screenLine returns a row of the screen as text, and the style of its first
+// cell.
+func screenLine(s tcell.SimulationScreen, row int) (string, tcell.Style) {
+	cells, width, _ := s.GetContents()
+	var b strings.Builder
+	for _, c := range cells[row*width : (row+1)*width] {
+		b.WriteString(string(c.Runes))
+	}
+	return strings.TrimRight(b.String(), " "), cells[row*width].Style
+}
+
+func TestRun(t *testing.T) {
+	s := newScreen(t)
+
+	type result struct {
+		m   tea.Model
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		m, err := Run(s, testModel{}, tea.WithoutSignals())
+		done <- result{m, err}
+	}()
+
+	s.InjectKey(tcell.KeyRune, 'a', tcell.ModNone)
+	s.InjectKey(tcell.KeyCtrlC, 0, tcell.ModCtrl)
+	s.InjectKey(tcell.KeyUp, 0, tcell.ModShift)
+	s.InjectMouse(3, 1, tcell.Button1, tcell.ModNone)
+	s.InjectMouse(3, 1, tcell.ButtonNone, tcell.ModNone)
+
+	// Events are handled in order, so the final frame shows the keys.
+	s.InjectKey(tcell.KeyRune, 'q', tcell.ModNone)
+
+	var r result
+	select {
+	case r = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the program to exit")
+	}
+	if r.err != nil {
+		t.Fatal(r.err)
+	}
+
+	m := r.m.(testModel)
+	if m.size.Width != 20 || m.size.Height != 4 {
+		t.Errorf("expected a 20x4 window, got %dx%d", m.size.Width, m.size.Height)
+	}
+	if len(m.mouse) != 2 || m.mouse[0].Action != tea.MouseActionPress || m.mouse[1].Action != tea.MouseActionRelease ||
+		m.mouse[0].Button != tea.MouseButtonLeft || m.mouse[0].X != 3 || m.mouse[0].Y != 1 {
+		t.Errorf("unexpected mouse messages %v", m.mouse)
+	}
+
+	line, style := screenLine(s, 0)
+	if line != "hello" {
+		t.Errorf("expected hello, got %q", line)
+	}
+	if line, _ := screenLine(s, 1); line != "a ctrl+c shift+up" {
+		t.Errorf("expected the keys to be drawn, got %q", line)
+	}
+	fg, _, attrs := style.Decompose()
+	if fg != tcell.PaletteColor(1) || attrs&tcell.AttrBold == 0 {
+		t.Errorf("expected bold red, got %v %v", fg, attrs)
+	}
+}
+
+func TestRegion(t *testing.T) {
+	s := newScreen(t)
+	p := NewProgram(s, testModel{}, tea.WithoutSignals())
+	p.SetRegion(5, 2, 10, 2)
+
+	done := make(chan tea.Model, 1)
+	go func() {
+		m, _ := p.Run()
+		done <- m
+	}()
+
+	// Events outside of the region are left to the application.
+	if p.HandleEvent(tcell.NewEventMouse(0, 0, tcell.Button1, tcell.ModNone)) {
+		t.Error("expected a click outside of the region to be left alone")
+	}
+	if !p.HandleEvent(tcell.NewEventMouse(6, 3, tcell.Button1, tcell.ModNone)) {
+		t.Error("expected a click in the region to be taken")
+	}
+	p.HandleEvent(tcell.NewEventKey(tcell.KeyRune, 'q', tcell.ModNone))
+
+	var m tea.Model
+	select {
+	case m = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the program to exit")
+	}
+
+	tm := m.(testModel)
+	if tm.size.Width != 10 || tm.size.Height != 2 {
+		t.Errorf("expected a 10x2 window, got %dx%d", tm.size.Width, tm.size.Height)
+	}
+	if len(tm.mouse) != 1 || tm.mouse[0].X != 1 || tm.mouse[0].Y != 1 {
+		t.Errorf("expected a click at 1,1 in the region, got %v", tm.mouse)
+	}
+	if line, _ := screenLine(s, 2); line != "     hello" {
+		t.Errorf("expected hello in the region, got %q", line)
+	}
+}
+
+func TestPaste(t *testing.T) {
+	s := newScreen(t)
+	p := NewProgram(s, testModel{}, tea.WithoutSignals())
+
+	done := make(chan tea.Model, 1)
+	go func() {
+		m, _ := p.Run()
+		done <- m
+	}()
+
+	p.HandleEvent(tcell.NewEventPaste(true))
+	for _, r := range "a b" {
+		p.HandleEvent(tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone))
+	}
+	p.HandleEvent(tcell.NewEventPaste(false))
+	p.HandleEvent(tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone))
+
+	p.Quit()
+	<-done
+
+	if line, _ := screenLine(s, 1); line != "[a b] x" {
+		t.Errorf("expected the paste to be drawn, got %q", line)
+	}
+}
+
+func TestKeyMsg(t *testing.T) {
+	tests := []struct {
+		ev       *tcell.EventKey
+		expected string
+	}{
+		{tcell.NewEventKey(tcell.KeyRune, 'a', tcell.ModAlt), "alt+a"},
+		{tcell.NewEventKey(tcell.KeyRune, ' ', tcell.ModNone), " "},
+		{tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone), "enter"},
+		{tcell.NewEventKey(tcell.KeyTab, 0, tcell.ModNone), "tab"},
+		{tcell.NewEventKey(tcell.KeyBacktab, 0, tcell.ModShift), "shift+tab"},
+		{tcell.NewEventKey(tcell.KeyBackspace2, 0, tcell.ModNone), "backspace"},
+		{tcell.NewEventKey(tcell.KeyEscape, 0, tcell.ModNone), "esc"},
+		{tcell.NewEventKey(tcell.KeyCtrlA, 0, tcell.ModCtrl), "ctrl+a"},
+		{tcell.NewEventKey(tcell.KeyLeft, 0, tcell.ModCtrl|tcell.ModShift), "ctrl+shift+left"},
+		{tcell.NewEventKey(tcell.KeyPgDn, 0, tcell.ModCtrl), "ctrl+pgdown"},
+		{tcell.NewEventKey(tcell.KeyF5, 0, tcell.ModNone), "f5"},
+		{tcell.NewEventKey(tcell.KeyDelete, 0, tcell.ModNone), "delete"},
+	}
+	for _, test := range tests {
+		msg, ok := keyMsg(test.ev)
+		if !ok {
+			t.Errorf("%s: expected a key message", test.ev.Name())
+			continue
+		}
+		if msg.String() != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.ev.Name(), test.expected, msg.String())
+		}
+	}
+}
+
+func TestApplySGR(t *testing.T) {
+	style := applySGR(tcell.StyleDefault, "38;2;1;2;3;48;5;200;3")
+	fg, bg, attrs := style.Decompose()
+	if fg != tcell.NewRGBColor(1, 2, 3) {
+		t.Errorf("unexpected foreground %v", fg)
+	}
+	if bg != tcell.PaletteColor(200) {
+		t.Errorf("unexpected background %v", bg)
+	}
+	if attrs&tcell.AttrItalic == 0 {
+		t.Errorf("expected italics, got %v", attrs)
+	}
+
+	if style := applySGR(style, "0"); style != tcell.StyleDefault {
+		t.Errorf("expected a reset, got %v", style)
+	}
+}
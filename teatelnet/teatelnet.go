@@ -0,0 +1,310 @@
+// Package teatelnet hosts Bubble Tea programs over telnet, or raw TCP,
+// connections, running one program per connection:
+//
+//	ln, _ := net.Listen("tcp", ":2323")
+//	for {
+//	    conn, err := ln.Accept()
+//	    if err != nil {
+//	        break
+//	    }
+//	    go func() {
+//	        defer conn.Close()
+//	        teatelnet.NewConn(conn).Run(initialModel())
+//	    }()
+//	}
+//
+// A Conn speaks just enough of the telnet protocol for programs to work:
+// it asks the client to send keys as they're typed rather than line by line,
+// leaving the echo to the server, and to report the size of its window
+// (NAWS), delivered to the program as tea.WindowSizeMsg. It strips telnet
+// commands from the input and escapes the output.
+package teatelnet
+
+import (
+	"encoding/binary"
+	"io"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/muesli/termenv"
+)
+
+// Telnet commands, see RFC 854.
+const (
+	se   = 240 // end of subnegotiation
+	ip   = 244 // interrupt process
+	sb   = 250 // start of subnegotiation
+	will = 251
+	wont = 252
+	do   = 253
+	dont = 254
+	iac  = 255 // interpret as command
+)
+
+// Telnet options.
+const (
+	optEcho = 1  // RFC 857
+	optSGA  = 3  // suppress go ahead, RFC 858
+	optNAWS = 31 // negotiate about window size, RFC 1073
+)
+
+// Parser states.
+const (
+	stateData = iota
+	stateIAC
+	stateOption // after WILL, WONT, DO or DONT
+	stateSB
+	stateSBIAC
+	stateCR
+)
+
+// Conn connects a Bubble Tea program to a telnet connection. It reads the
+// keys typed by the client, without telnet commands, and escapes what's
+// written to it.
+type Conn struct {
+	rw io.ReadWriter
+
+	// Read state, only used by Read.
+	buf   []byte
+	state int
+	verb  byte
+	sub   []byte
+
+	wmtx sync.Mutex
+
+	mtx           sync.Mutex
+	width, height int
+	program       *tea.Program
+}
+
+// NewConn returns a Conn for the given connection.
+func NewConn(conn io.ReadWriter) *Conn {
+	return &Conn{rw: conn}
+}
+
+// Negotiate asks the client to enter character mode, with the echo left to
+// the server, and to report the size of its window. Run does it already.
+func (c *Conn) Negotiate() error {
+	return c.writeRaw([]byte{
+		iac, will, optEcho,
+		iac, will, optSGA,
+		iac, do, optSGA,
+		iac, do, optNAWS,
+	})
+}
+
+// Size returns the size of the client's window, in columns and rows, or
+// zeros if it hasn't reported it yet.
+func (c *Conn) Size() (width, height int) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.width, c.height
+}
+
+// resize records a change of the size of the client's window, and sends it
+// to the program if it's running.
+func (c *Conn) resize(width, height int) {
+	c.mtx.Lock()
+	c.width, c.height = width, height
+	p := c.program
+	c.mtx.Unlock()
+
+	if p != nil {
+		p.Send(tea.WindowSizeMsg{Width: width, Height: height})
+	}
+}
+
+// Read reads the keys typed by the client. It implements io.Reader.
+func (c *Conn) Read(b []byte) (int, error) {
+	if len(b) == 0 {
+		return 0, nil
+	}
+	if cap(c.buf) < len(b) {
+		c.buf = make([]byte, len(b))
+	}
+
+	// Loop until there's some data, as the client might only have sent
+	// commands.
+	for {
+		n, err := c.rw.Read(c.buf[:len(b)])
+		m := c.parse(b, c.buf[:n])
+		if m > 0 || err != nil {
+			return m, err
+		}
+	}
+}
+
+// parse copies the data found in in to out, handling telnet commands, and
+// returns the number of bytes copied. out must be at least as long as in.
+func (c *Conn) parse(out, in []byte) int {
+	n := 0
+	for _, ch := range in {
+		switch c.state {
+		case stateData:
+			switch ch {
+			case iac:
+				c.state = stateIAC
+			case '\r':
+				out[n] = ch
+				n++
+				c.state = stateCR
+			default:
+				out[n] = ch
+				n++
+			}
+
+		case stateCR:
+			// Clients send enter as CR LF or CR NUL; programs expect a
+			// lone CR.
+			c.state = stateData
+			switch ch {
+			case '\n', 0:
+			case iac:
+				c.state = stateIAC
+			case '\r':
+				out[n] = ch
+				n++
+				c.state = stateCR
+			default:
+				out[n] = ch
+				n++
+			}
+
+		case stateIAC:
+			c.state = stateData
+			switch ch {
+			case iac:
+				// An escaped 0xff data byte.
+				out[n] = ch
+				n++
+			case ip:
+				// Clients may send ctrl+c as an interrupt.
+				out[n] = 0x03 //nolint:gomnd
+				n++
+			case will, wont, do, dont:
+				c.verb = ch
+				c.state = stateOption
+			case sb:
+				c.sub = c.sub[:0]
+				c.state = stateSB
+			}
+
+		case stateOption:
+			c.state = stateData
+			c.negotiate(c.verb, ch)
+
+		case stateSB:
+			if ch == iac {
+				c.state = stateSBIAC
+				break
+			}
+			c.sub = append(c.sub, ch)
+
+		case stateSBIAC:
+			switch ch {
+			case iac:
+				c.sub = append(c.sub, ch)
+				c.state = stateSB
+			case se:
+				c.subnegotiation(c.sub)
+				c.state = stateData
+			default:
+				// Malformed subnegotiation, give up on it.
+				c.state = stateData
+			}
+		}
+	}
+	return n
+}
+
+// negotiate answers an option request of the client. Options we asked for
+// are acknowledgements and are left unanswered, other ones are refused.
+func (c *Conn) negotiate(verb, opt byte) {
+	switch verb {
+	case do:
+		if opt != optEcho && opt != optSGA {
+			_ = c.writeRaw([]byte{iac, wont, opt})
+		}
+	case will:
+		if opt != optSGA && opt != optNAWS {
+			_ = c.writeRaw([]byte{iac, dont, opt})
+		}
+	}
+}
+
+// subnegotiation handles a subnegotiation sent by the client.
+func (c *Conn) subnegotiation(b []byte) {
+	if len(b) == 5 && b[0] == optNAWS { //nolint:gomnd
+		c.resize(int(binary.BigEndian.Uint16(b[1:])), int(binary.BigEndian.Uint16(b[3:])))
+	}
+}
+
+// Write writes output to the client, escaping 0xff bytes, which would be
+// taken as telnet commands. It implements io.Writer.
+func (c *Conn) Write(b []byte) (int, error) {
+	escaped := make([]byte, 0, len(b))
+	for _, ch := range b {
+		if ch == iac {
+			escaped = append(escaped, iac)
+		}
+		escaped = append(escaped, ch)
+	}
+	if err := c.writeRaw(escaped); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// writeRaw writes bytes to the connection as-is.
+func (c *Conn) writeRaw(b []byte) error {
+	c.wmtx.Lock()
+	defer c.wmtx.Unlock()
+	_, err := c.rw.Write(b)
+	return err
+}
+
+// ProgramOptions returns the options connecting a program to the
+// connection: its input and output, with 256 colors, which most telnet
+// clients support, and no signal handling, as the server's signals aren't
+// the client's.
+func (c *Conn) ProgramOptions() []tea.ProgramOption {
+	return []tea.ProgramOption{
+		tea.WithInput(c),
+		tea.WithOutput(termenv.NewOutput(c, termenv.WithProfile(termenv.ANSI256))),
+		tea.WithoutSignalHandler(),
+	}
+}
+
+// Attach starts sending the client's window size changes to the program,
+// along with its current size, if known. Call Detach once the program has
+// exited.
+func (c *Conn) Attach(p *tea.Program) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.program = p
+	if c.width > 0 && c.height > 0 {
+		size := tea.WindowSizeMsg{Width: c.width, Height: c.height}
+		go p.Send(size)
+	}
+}
+
+// Detach stops sending window size changes to the program.
+func (c *Conn) Detach() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.program = nil
+}
+
+// Run negotiates the telnet options, then runs a program for the given model
+// on the connection, blocking until it exits, and returns the final model.
+// It doesn't close the connection.
+func (c *Conn) Run(model tea.Model, opts ...tea.ProgramOption) (tea.Model, error) {
+	if err := c.Negotiate(); err != nil {
+		return model, err
+	}
+	p := tea.NewProgram(model, append(opts, c.ProgramOptions()...)...)
+	c.Attach(p)
+	defer c.Detach()
+	return p.Run()
+}
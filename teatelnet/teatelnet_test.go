@@ -0,0 +1,179 @@
+package teatelnet
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type testConn struct {
+	in  io.Reader
+	out bytes.Buffer
+}
+
+func (c *testConn) Read(b []byte) (int, error) {
+	return c.in.Read(b)
+}
+
+func (c *testConn) Write(b []byte) (int, error) {
+	return c.out.Write(b)
+}
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []byte
+		out  []byte
+	}{
+		{"plain", []byte("hello"), []byte("hello")},
+		{"enter", []byte("a\r\nb\r\x00c\r"), []byte("a\rb\rc\r")},
+		{"escaped iac", []byte{'a', iac, iac, 'b'}, []byte{'a', iac, 'b'}},
+		{"interrupt", []byte{iac, ip}, []byte{0x03}},
+		{"options", []byte{iac, will, optNAWS, 'a', iac, do, optEcho, 'b'}, []byte("ab")},
+		{"other commands", []byte{'a', iac, 246, 'b'}, []byte("ab")},
+		{"subnegotiation", []byte{'a', iac, sb, optNAWS, 0, 80, 0, 24, iac, se, 'b'}, []byte("ab")},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := NewConn(&testConn{in: bytes.NewReader(test.in)})
+			out, err := io.ReadAll(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(out, test.out) {
+				t.Errorf("expected %q, got %q", test.out, out)
+			}
+		})
+	}
+}
+
+func TestReadSplit(t *testing.T) {
+	// Commands may be split across reads.
+	in := []byte{'a', iac, sb, optNAWS, 0, 100, 0, 30, iac, iac, iac, se, '\r', '\n', 'b'}
+	c := NewConn(&testConn{in: bytes.NewReader(in)})
+
+	var out []byte
+	b := make([]byte, 1)
+	for {
+		n, err := c.Read(b)
+		out = append(out, b[:n]...)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if exp := []byte("a\rb"); !bytes.Equal(out, exp) {
+		t.Errorf("expected %q, got %q", exp, out)
+	}
+	// The subnegotiation is malformed: NAWS is 4 bytes long.
+	if w, h := c.Size(); w != 0 || h != 0 {
+		t.Errorf("expected no size, got %dx%d", w, h)
+	}
+}
+
+func TestNegotiation(t *testing.T) {
+	conn := &testConn{in: bytes.NewReader([]byte{
+		iac, will, optNAWS,
+		iac, sb, optNAWS, 0, 120, 0, 40, iac, se,
+		iac, do, optEcho, // acknowledgements
+		iac, do, 24, // terminal type
+		iac, will, 24,
+	})}
+	c := NewConn(conn)
+	if _, err := io.ReadAll(c); err != nil {
+		t.Fatal(err)
+	}
+
+	if w, h := c.Size(); w != 120 || h != 40 {
+		t.Errorf("expected 120x40, got %dx%d", w, h)
+	}
+	if exp := []byte{iac, wont, 24, iac, dont, 24}; !bytes.Equal(conn.out.Bytes(), exp) {
+		t.Errorf("expected %v to be answered, got %v", exp, conn.out.Bytes())
+	}
+}
+
+func TestWrite(t *testing.T) {
+	conn := &testConn{}
+	c := NewConn(conn)
+	n, err := c.Write([]byte{'a', iac, 'b'})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("expected 3 bytes written, got %d", n)
+	}
+	if exp := []byte{'a', iac, iac, 'b'}; !bytes.Equal(conn.out.Bytes(), exp) {
+		t.Errorf("expected %v, got %v", exp, conn.out.Bytes())
+	}
+}
+
+type sizeModel struct {
+	size tea.WindowSizeMsg
+}
+
+func (m sizeModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m sizeModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.WindowSizeMsg); ok {
+		m.size = msg
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m sizeModel) View() string {
+	return "hello"
+}
+
+func TestRun(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	type result struct {
+		m   tea.Model
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		m, err := NewConn(server).Run(sizeModel{}, tea.WithoutSignals())
+		done <- result{m, err}
+	}()
+
+	// Read the negotiation.
+	negotiation := make([]byte, 12)
+	if _, err := io.ReadFull(client, negotiation); err != nil {
+		t.Fatal(err)
+	}
+	if negotiation[len(negotiation)-1] != optNAWS {
+		t.Fatalf("expected NAWS to be requested, got %v", negotiation)
+	}
+
+	// Drain the output.
+	go func() {
+		_, _ = io.Copy(io.Discard, client)
+	}()
+
+	if _, err := client.Write([]byte{iac, will, optNAWS, iac, sb, optNAWS, 0, 90, 0, 20, iac, se}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case r := <-done:
+		if r.err != nil {
+			t.Fatal(r.err)
+		}
+		if size := r.m.(sizeModel).size; size.Width != 90 || size.Height != 20 {
+			t.Errorf("expected 90x20, got %dx%d", size.Width, size.Height)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the program to exit")
+	}
+}
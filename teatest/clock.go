@@ -0,0 +1,133 @@
+package teatest
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Clock is a virtual clock, which only moves forward when told to. Pass it
+// to the program under test with tea.WithClock to drive its timers, such as
+// the ones started by tea.Tick and tea.Every, without waiting for them:
+//
+//	clock := teatest.NewClock(time.Now())
+//	tm := teatest.NewTestModel(t, m,
+//	    teatest.WithProgramOptions(tea.WithClock(clock)))
+//
+//	// Wait for the model to start its timer, then fire it.
+//	clock.WaitForTimers(t, 1)
+//	clock.Advance(time.Second)
+//
+// A Clock is safe for concurrent use.
+type Clock struct {
+	mtx    sync.Mutex
+	now    time.Time
+	timers []*clockTimer
+}
+
+// NewClock returns a Clock set to the given time.
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+// Now returns the clock's current time.
+func (c *Clock) Now() time.Time {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.now
+}
+
+// NewTimer returns a timer which fires once the clock has been advanced by
+// the given duration.
+func (c *Clock) NewTimer(d time.Duration) tea.Timer {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	t := &clockTimer{
+		clock: c,
+		at:    c.now.Add(d),
+		c:     make(chan time.Time, 1),
+	}
+	if d <= 0 {
+		t.c <- c.now
+		return t
+	}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+// Advance moves the clock forward by the given duration, firing the timers
+// which are due by then.
+func (c *Clock) Advance(d time.Duration) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.now = c.now.Add(d)
+
+	var pending []*clockTimer
+	for _, t := range c.timers {
+		if t.at.After(c.now) {
+			pending = append(pending, t)
+			continue
+		}
+		// Timers send the time at which they were due.
+		t.c <- t.at
+	}
+	c.timers = pending
+}
+
+// Timers returns the number of timers waiting to fire.
+func (c *Clock) Timers() int {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return len(c.timers)
+}
+
+// WaitForTimers waits for at least n timers to be waiting to fire, failing
+// the test if that doesn't happen in time. Timers are started asynchronously
+// by the program, so wait for them before advancing the clock.
+func (c *Clock) WaitForTimers(tb testing.TB, n int, options ...WaitForOption) {
+	tb.Helper()
+
+	opts := waitForOptions{
+		duration:      defaultWaitDuration,
+		checkInterval: time.Millisecond,
+	}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	deadline := time.Now().Add(opts.duration)
+	for c.Timers() < n {
+		if time.Now().After(deadline) {
+			tb.Fatalf("timeout after %s waiting for %d timers, got %d", opts.duration, n, c.Timers())
+		}
+		time.Sleep(opts.checkInterval)
+	}
+}
+
+// clockTimer is a timer of a virtual Clock.
+type clockTimer struct {
+	clock *Clock
+	at    time.Time
+	c     chan time.Time
+}
+
+func (t *clockTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *clockTimer) Stop() bool {
+	t.clock.mtx.Lock()
+	defer t.clock.mtx.Unlock()
+
+	for i, pending := range t.clock.timers {
+		if pending == t {
+			t.clock.timers = append(t.clock.timers[:i], t.clock.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package teatest
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type tickMsg time.Time
+
+// tickModel counts ticks, quitting after three of them.
+type tickModel struct {
+	ticks int
+}
+
+func (m tickModel) tick() tea.Cmd {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg {
+		return tickMsg(t)
+	})
+}
+
+func (m tickModel) Init() tea.Cmd {
+	return m.tick()
+}
+
+func (m tickModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if _, ok := msg.(tickMsg); ok {
+		m.ticks++
+		if m.ticks == 3 {
+			return m, tea.Quit
+		}
+		return m, m.tick()
+	}
+	return m, nil
+}
+
+func (m tickModel) View() string {
+	return fmt.Sprintf("ticks: %d\n", m.ticks)
+}
+
+func TestClock(t *testing.T) {
+	clock := NewClock(time.Now())
+	tm := NewTestModel(t, tickModel{}, WithProgramOptions(tea.WithClock(clock)))
+
+	for i := 1; i <= 3; i++ {
+		clock.WaitForTimers(t, 1)
+		clock.Advance(time.Second)
+		if i < 3 {
+			want := []byte(fmt.Sprintf("ticks: %d", i))
+			WaitFor(t, tm.Output(), func(out []byte) bool {
+				return bytes.Contains(out, want)
+			})
+		}
+	}
+
+	fm := tm.FinalModel(t).(tickModel)
+	if fm.ticks != 3 {
+		t.Errorf("expected 3 ticks, got %d", fm.ticks)
+	}
+}
+
+func TestClockTimers(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewClock(start)
+
+	t1 := clock.NewTimer(time.Second)
+	t2 := clock.NewTimer(time.Minute)
+	if n := clock.Timers(); n != 2 {
+		t.Fatalf("expected 2 timers, got %d", n)
+	}
+
+	clock.Advance(2 * time.Second)
+	select {
+	case ts := <-t1.C():
+		if want := start.Add(time.Second); !ts.Equal(want) {
+			t.Errorf("expected the timer to fire at %v, got %v", want, ts)
+		}
+	default:
+		t.Fatal("expected the first timer to fire")
+	}
+	if !clock.Now().Equal(start.Add(2 * time.Second)) {
+		t.Errorf("unexpected time %v", clock.Now())
+	}
+
+	if !t2.Stop() {
+		t.Error("expected the second timer to be stopped")
+	}
+	if t1.Stop() {
+		t.Error("expected stopping a fired timer to return false")
+	}
+	clock.Advance(time.Hour)
+	select {
+	case <-t2.C():
+		t.Error("expected the stopped timer not to fire")
+	default:
+	}
+}
@@ -0,0 +1,322 @@
+// Package teatest provides helpers to test Bubble Tea programs end to end.
+//
+// A TestModel runs a program against in-memory input and output. Tests can
+// type keys and send messages to it, wait for its output to meet a
+// condition, and inspect the final model and output once it's done:
+//
+//	func TestApp(t *testing.T) {
+//	    tm := teatest.NewTestModel(t, initialModel(),
+//	        teatest.WithInitialTermSize(80, 24))
+//
+//	    teatest.WaitFor(t, tm.Output(), func(out []byte) bool {
+//	        return bytes.Contains(out, []byte("Ready"))
+//	    })
+//
+//	    tm.Type("hello")
+//	    tm.Send(tea.KeyMsg{Type: tea.KeyEnter})
+//
+//	    fm := tm.FinalModel(t).(model)
+//	    teatest.RequireEqualOutput(t, []byte(fm.View()))
+//	}
+package teatest
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var update = flag.Bool("update", false, "update .golden files")
+
+// Default durations used by the helpers in this package.
+const (
+	defaultWaitDuration  = time.Second
+	defaultCheckInterval = 50 * time.Millisecond
+	defaultFinalTimeout  = time.Second
+)
+
+// TestOption customizes a TestModel.
+type TestOption func(*testOptions)
+
+type testOptions struct {
+	width, height int
+	programOpts   []tea.ProgramOption
+}
+
+// WithInitialTermSize sets the size of the virtual terminal, which is
+// delivered to the model as a WindowSizeMsg when the program starts.
+func WithInitialTermSize(width, height int) TestOption {
+	return func(opts *testOptions) {
+		opts.width = width
+		opts.height = height
+	}
+}
+
+// WithProgramOptions adds options to the program under test. Options setting
+// the program's input and output are overridden.
+func WithProgramOptions(opts ...tea.ProgramOption) TestOption {
+	return func(o *testOptions) {
+		o.programOpts = append(o.programOpts, opts...)
+	}
+}
+
+// TestModel is a Bubble Tea program running under test.
+type TestModel struct {
+	program *tea.Program
+
+	in  *io.PipeWriter
+	out *safeBuffer
+
+	done  chan struct{}
+	model tea.Model
+	err   error
+}
+
+// NewTestModel starts a program for the given model, reading input typed
+// with TestModel.Type and writing output to a buffer available through
+// TestModel.Output.
+func NewTestModel(tb testing.TB, m tea.Model, options ...TestOption) *TestModel {
+	tb.Helper()
+
+	var opts testOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	r, w := io.Pipe()
+	tm := &TestModel{
+		in:   w,
+		out:  &safeBuffer{},
+		done: make(chan struct{}),
+	}
+
+	programOpts := append([]tea.ProgramOption{}, opts.programOpts...)
+	programOpts = append(programOpts,
+		tea.WithInput(r),
+		tea.WithOutput(tm.out),
+		tea.WithoutSignals(),
+	)
+	tm.program = tea.NewProgram(m, programOpts...)
+
+	go func() {
+		defer close(tm.done)
+		tm.model, tm.err = tm.program.Run()
+
+		// Nobody reads the input anymore; don't let Type block.
+		_ = r.Close()
+	}()
+
+	if opts.width > 0 && opts.height > 0 {
+		tm.Send(tea.WindowSizeMsg{Width: opts.width, Height: opts.height})
+	}
+	return tm
+}
+
+// Send sends a message to the program, as Program.Send does. Use it to
+// inject mouse events, resizes or any other message.
+func (tm *TestModel) Send(msg tea.Msg) {
+	tm.program.Send(msg)
+}
+
+// Type types the given text into the program's input, one key at a time,
+// as a user would.
+func (tm *TestModel) Type(s string) {
+	for _, r := range s {
+		_, _ = tm.in.Write([]byte(string(r)))
+	}
+}
+
+// Write writes raw bytes to the program's input, such as escape sequences
+// for special keys or mouse events, which are parsed as if they came from a
+// terminal.
+func (tm *TestModel) Write(b []byte) (int, error) {
+	return tm.in.Write(b)
+}
+
+// Quit quits the program.
+func (tm *TestModel) Quit() {
+	tm.program.Quit()
+}
+
+// Output returns a reader of the program's output. Reading consumes the
+// output; WaitFor reads from it until a condition is met.
+func (tm *TestModel) Output() io.Reader {
+	return tm.out
+}
+
+// FinalOpt customizes how long to wait for a program to finish.
+type FinalOpt func(*finalOptions)
+
+type finalOptions struct {
+	timeout time.Duration
+}
+
+// WithFinalTimeout sets how long to wait for the program to finish before
+// failing the test.
+func WithFinalTimeout(d time.Duration) FinalOpt {
+	return func(opts *finalOptions) {
+		opts.timeout = d
+	}
+}
+
+// WaitFinished waits for the program to finish, failing the test if it
+// doesn't in time or if it returned an error.
+func (tm *TestModel) WaitFinished(tb testing.TB, opts ...FinalOpt) {
+	tb.Helper()
+
+	o := finalOptions{timeout: defaultFinalTimeout}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	select {
+	case <-tm.done:
+	case <-time.After(o.timeout):
+		tb.Fatalf("timeout after %s waiting for the program to finish", o.timeout)
+	}
+	if tm.err != nil {
+		tb.Fatalf("program returned an error: %v", tm.err)
+	}
+}
+
+// FinalModel waits for the program to finish and returns its final model.
+func (tm *TestModel) FinalModel(tb testing.TB, opts ...FinalOpt) tea.Model {
+	tb.Helper()
+	tm.WaitFinished(tb, opts...)
+	return tm.model
+}
+
+// FinalOutput waits for the program to finish and returns the output it
+// wrote, which hasn't been read yet.
+func (tm *TestModel) FinalOutput(tb testing.TB, opts ...FinalOpt) []byte {
+	tb.Helper()
+	tm.WaitFinished(tb, opts...)
+	b, _ := io.ReadAll(tm.out)
+	return b
+}
+
+// WaitForOption customizes WaitFor.
+type WaitForOption func(*waitForOptions)
+
+type waitForOptions struct {
+	duration      time.Duration
+	checkInterval time.Duration
+}
+
+// WithDuration sets how long WaitFor waits for the condition to be met
+// before failing the test.
+func WithDuration(d time.Duration) WaitForOption {
+	return func(opts *waitForOptions) {
+		opts.duration = d
+	}
+}
+
+// WithCheckInterval sets how often WaitFor checks the condition.
+func WithCheckInterval(d time.Duration) WaitForOption {
+	return func(opts *waitForOptions) {
+		opts.checkInterval = d
+	}
+}
+
+// WaitFor keeps reading from r until the condition, which is given
+// everything read so far, returns true. It fails the test if that doesn't
+// happen in time.
+func WaitFor(tb testing.TB, r io.Reader, condition func(b []byte) bool, options ...WaitForOption) {
+	tb.Helper()
+
+	opts := waitForOptions{
+		duration:      defaultWaitDuration,
+		checkInterval: defaultCheckInterval,
+	}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	var b []byte
+	deadline := time.Now().Add(opts.duration)
+	for time.Now().Before(deadline) {
+		chunk, err := io.ReadAll(r)
+		if err != nil {
+			tb.Fatalf("error reading output: %v", err)
+		}
+		b = append(b, chunk...)
+		if condition(b) {
+			return
+		}
+		time.Sleep(opts.checkInterval)
+	}
+	tb.Fatalf("condition not met after %s; last output:\n%s", opts.duration, b)
+}
+
+// RequireEqualOutput compares the given output with the golden file for the
+// test, testdata/<test name>.golden, failing the test if they differ. Run
+// the tests with -update to create or update golden files.
+func RequireEqualOutput(tb testing.TB, out []byte) {
+	tb.Helper()
+
+	golden := filepath.Join("testdata", tb.Name()+".golden")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(golden), 0o755); err != nil { //nolint:gomnd
+			tb.Fatal(err)
+		}
+		if err := os.WriteFile(golden, out, 0o600); err != nil { //nolint:gomnd
+			tb.Fatal(err)
+		}
+	}
+
+	expected, err := os.ReadFile(golden)
+	if err != nil {
+		tb.Fatalf("error reading golden file, run with -update to create it: %v", err)
+	}
+	if !bytes.Equal(expected, out) {
+		tb.Fatalf("output doesn't match %s:\n%s", golden, diff(expected, out))
+	}
+}
+
+// diff describes the first difference between two outputs, line by line.
+func diff(expected, actual []byte) string {
+	el := strings.Split(string(expected), "\n")
+	al := strings.Split(string(actual), "\n")
+	for i := 0; i < len(el) || i < len(al); i++ {
+		var e, a string
+		if i < len(el) {
+			e = el[i]
+		}
+		if i < len(al) {
+			a = al[i]
+		}
+		if e != a {
+			return fmt.Sprintf("line %d:\nexpected: %q\n  actual: %q", i+1, e, a)
+		}
+	}
+	return ""
+}
+
+// safeBuffer is a bytes.Buffer safe for concurrent use.
+type safeBuffer struct {
+	mtx sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *safeBuffer) Write(p []byte) (int, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.buf.Write(p)
+}
+
+// Read reads what's been written so far. It returns io.EOF once everything
+// has been read, rather than blocking.
+func (b *safeBuffer) Read(p []byte) (int, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.buf.Read(p)
+}
@@ -0,0 +1,82 @@
+package teatest
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type model struct {
+	width, height int
+	typed         string
+}
+
+func (m model) Init() tea.Cmd {
+	return nil
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyEnter:
+			return m, tea.Quit
+		case tea.KeyRunes:
+			m.typed += string(msg.Runes)
+		}
+	}
+	return m, nil
+}
+
+func (m model) View() string {
+	return fmt.Sprintf("size: %dx%d\ntyped: %s\n", m.width, m.height, m.typed)
+}
+
+func TestTestModel(t *testing.T) {
+	tm := NewTestModel(t, model{}, WithInitialTermSize(70, 30))
+
+	WaitFor(t, tm.Output(), func(out []byte) bool {
+		return bytes.Contains(out, []byte("size: 70x30"))
+	})
+
+	tm.Type("hi")
+	WaitFor(t, tm.Output(), func(out []byte) bool {
+		return bytes.Contains(out, []byte("typed: hi"))
+	}, WithDuration(time.Second), WithCheckInterval(10*time.Millisecond))
+
+	tm.Send(tea.KeyMsg{Type: tea.KeyEnter})
+
+	fm, ok := tm.FinalModel(t, WithFinalTimeout(time.Second)).(model)
+	if !ok {
+		t.Fatalf("unexpected final model %T", fm)
+	}
+	if fm.typed != "hi" || fm.width != 70 || fm.height != 30 {
+		t.Errorf("unexpected final model %+v", fm)
+	}
+	RequireEqualOutput(t, []byte(fm.View()))
+}
+
+func TestTestModelQuit(t *testing.T) {
+	tm := NewTestModel(t, model{})
+	tm.Quit()
+	out := tm.FinalOutput(t)
+	if !strings.Contains(string(out), "size: 0x0") {
+		t.Errorf("expected the initial view in the output, got %q", out)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	d := diff([]byte("a\nb\nc"), []byte("a\nx\nc"))
+	if !strings.Contains(d, "line 2") {
+		t.Errorf("expected the difference to be on line 2, got %q", d)
+	}
+	if d := diff([]byte("a"), []byte("a")); d != "" {
+		t.Errorf("expected no difference, got %q", d)
+	}
+}
@@ -0,0 +1,352 @@
+package tea
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Magic numbers of compiled terminfo entries, see term(5).
+const (
+	terminfoMagic      = 0o432  // 16-bit numbers
+	terminfoMagic32Bit = 0o1036 // 32-bit numbers
+)
+
+// Indices of the string capabilities used by the renderer, in the order of
+// the terminfo database.
+const (
+	tiScrollRegion  = 3
+	tiClearScreen   = 5
+	tiClrEOL        = 6
+	tiCursorAddress = 10
+	tiCursorDown    = 11
+	tiCursorHide    = 13
+	tiCursorLeft    = 14
+	tiCursorShow    = 16
+	tiCursorRight   = 17
+	tiCursorUp      = 19
+	tiEnterCAMode   = 28
+	tiExitCAMode    = 40
+	tiInsertLine    = 53
+	tiParmDown      = 107
+	tiParmInsert    = 110
+	tiParmLeft      = 111
+	tiParmRight     = 112
+	tiParmUp        = 114
+	tiClrBOL        = 269
+)
+
+var errNoTerminfo = errors.New("no terminfo entry found")
+
+// terminfo is a compiled terminfo entry, in which only the string
+// capabilities are kept.
+type terminfo struct {
+	names   []string
+	strings map[int]string
+}
+
+// str returns a string capability, and whether the terminal has it.
+func (ti *terminfo) str(i int) (string, bool) {
+	s, ok := ti.strings[i]
+	return s, ok
+}
+
+// terminfoDirs returns the directories in which terminfo entries are looked
+// up, in the same order as ncurses.
+func terminfoDirs() []string {
+	var dirs []string
+	if dir := os.Getenv("TERMINFO"); dir != "" {
+		dirs = append(dirs, dir)
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".terminfo"))
+	}
+	defaults := []string{"/etc/terminfo", "/lib/terminfo", "/usr/share/terminfo", "/usr/lib/terminfo"}
+	if env := os.Getenv("TERMINFO_DIRS"); env != "" {
+		for _, dir := range strings.Split(env, ":") {
+			if dir == "" {
+				// An empty entry stands for the default directories.
+				dirs = append(dirs, defaults...)
+				continue
+			}
+			dirs = append(dirs, dir)
+		}
+		return dirs
+	}
+	return append(dirs, defaults...)
+}
+
+// loadTerminfo loads the terminfo entry of a terminal type.
+func loadTerminfo(term string) (*terminfo, error) {
+	if term == "" || strings.ContainsAny(term, `/\`) {
+		return nil, errNoTerminfo
+	}
+	for _, dir := range terminfoDirs() {
+		// Entries are stored in directories named after their first
+		// character, or its hexadecimal code on case-insensitive file
+		// systems.
+		for _, sub := range []string{term[:1], fmt.Sprintf("%x", term[0])} {
+			b, err := os.ReadFile(filepath.Join(dir, sub, term))
+			if err != nil {
+				continue
+			}
+			return parseTerminfo(b)
+		}
+	}
+	return nil, errNoTerminfo
+}
+
+// parseTerminfo parses a compiled terminfo entry.
+func parseTerminfo(b []byte) (*terminfo, error) {
+	const headerSize = 12
+	if len(b) < headerSize {
+		return nil, errors.New("terminfo entry too short")
+	}
+	header := make([]int, 6) //nolint:gomnd
+	for i := range header {
+		header[i] = int(int16(binary.LittleEndian.Uint16(b[i*2:])))
+	}
+	magic, namesSize, boolCount, numCount, strCount, tableSize := header[0], header[1], header[2], header[3], header[4], header[5]
+
+	numSize := 2
+	switch magic {
+	case terminfoMagic:
+	case terminfoMagic32Bit:
+		numSize = 4
+	default:
+		return nil, fmt.Errorf("bad terminfo magic number %#o", magic)
+	}
+	if namesSize < 0 || boolCount < 0 || numCount < 0 || strCount < 0 || tableSize < 0 {
+		return nil, errors.New("bad terminfo header")
+	}
+
+	off := headerSize + namesSize + boolCount
+	if off%2 == 1 {
+		// Numbers are aligned on even bytes.
+		off++
+	}
+	off += numCount * numSize
+	offsets := off
+	table := offsets + strCount*2
+	if len(b) < table+tableSize {
+		return nil, errors.New("terminfo entry truncated")
+	}
+
+	ti := &terminfo{
+		names:   strings.Split(strings.TrimRight(string(b[headerSize:headerSize+namesSize]), "\x00"), "|"),
+		strings: map[int]string{},
+	}
+	for i := 0; i < strCount; i++ {
+		// Negative offsets are absent or cancelled capabilities.
+		o := int(int16(binary.LittleEndian.Uint16(b[offsets+i*2:])))
+		if o < 0 || o >= tableSize {
+			continue
+		}
+		s := b[table+o : table+tableSize]
+		if end := strings.IndexByte(string(s), 0); end >= 0 {
+			s = s[:end]
+		}
+		ti.strings[i] = string(s)
+	}
+	return ti, nil
+}
+
+// tparm expands a parameterized terminfo string with the given parameters,
+// see terminfo(5). Delays, such as $<5>, are dropped.
+func tparm(s string, params ...int) string {
+	var (
+		out   strings.Builder
+		stack []int
+		vars  [52]int // dynamic a-z, then static A-Z
+		p     [9]int
+	)
+	copy(p[:], params)
+
+	// Parameters are all numbers, so the stack only holds numbers.
+	push := func(v int) { stack = append(stack, v) }
+	pop := func() int {
+		if len(stack) == 0 {
+			return 0
+		}
+		v := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		return v
+	}
+	bool2int := func(b bool) int {
+		if b {
+			return 1
+		}
+		return 0
+	}
+
+	// skip moves past the current conditional branch, up to the next %e or
+	// %; at the same nesting level, or to the end of the conditional only
+	// if elseToo is false.
+	skip := func(i int, elseToo bool) int {
+		depth := 0
+		for ; i < len(s); i++ {
+			if s[i] != '%' || i+1 >= len(s) {
+				continue
+			}
+			i++
+			switch s[i] {
+			case '?':
+				depth++
+			case ';':
+				if depth == 0 {
+					return i
+				}
+				depth--
+			case 'e':
+				if depth == 0 && elseToo {
+					return i
+				}
+			}
+		}
+		return i
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '$' && i+1 < len(s) && s[i+1] == '<' {
+			// Padding: $<delay>.
+			if end := strings.IndexByte(s[i:], '>'); end >= 0 {
+				i += end
+				continue
+			}
+		}
+		if c != '%' || i+1 >= len(s) {
+			out.WriteByte(c)
+			continue
+		}
+		i++
+		c = s[i]
+
+		switch c {
+		case '%':
+			out.WriteByte('%')
+		case 'c':
+			out.WriteByte(byte(pop()))
+		case 's':
+			out.WriteString(strconv.Itoa(pop()))
+		case 'p':
+			if i+1 < len(s) && s[i+1] >= '1' && s[i+1] <= '9' {
+				i++
+				push(p[s[i]-'1'])
+			}
+		case 'P', 'g':
+			if i+1 >= len(s) {
+				break
+			}
+			i++
+			var n int
+			switch v := s[i]; {
+			case v >= 'a' && v <= 'z':
+				n = int(v - 'a')
+			case v >= 'A' && v <= 'Z':
+				n = 26 + int(v-'A') //nolint:gomnd
+			default:
+				continue
+			}
+			if c == 'P' {
+				vars[n] = pop()
+			} else {
+				push(vars[n])
+			}
+		case '\'':
+			if i+2 < len(s) {
+				push(int(s[i+1]))
+				i += 2
+			}
+		case '{':
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				break
+			}
+			n, _ := strconv.Atoi(s[i+1 : i+end])
+			push(n)
+			i += end
+		case 'l':
+			push(len(strconv.Itoa(pop())))
+		case '+', '-', '*', '/', 'm', '&', '|', '^', '=', '>', '<', 'A', 'O':
+			b, a := pop(), pop()
+			switch c {
+			case '+':
+				push(a + b)
+			case '-':
+				push(a - b)
+			case '*':
+				push(a * b)
+			case '/':
+				if b != 0 {
+					push(a / b)
+				} else {
+					push(0)
+				}
+			case 'm':
+				if b != 0 {
+					push(a % b)
+				} else {
+					push(0)
+				}
+			case '&':
+				push(a & b)
+			case '|':
+				push(a | b)
+			case '^':
+				push(a ^ b)
+			case '=':
+				push(bool2int(a == b))
+			case '>':
+				push(bool2int(a > b))
+			case '<':
+				push(bool2int(a < b))
+			case 'A':
+				push(bool2int(a != 0 && b != 0))
+			case 'O':
+				push(bool2int(a != 0 || b != 0))
+			}
+		case '!':
+			push(bool2int(pop() == 0))
+		case '~':
+			push(^pop())
+		case 'i':
+			p[0]++
+			p[1]++
+		case '?', ';':
+		case 't':
+			if pop() == 0 {
+				// Jump to the else branch, or past the conditional.
+				i = skip(i+1, true)
+			}
+		case 'e':
+			// The then branch was taken: jump past the conditional.
+			i = skip(i+1, false)
+		default:
+			// %[[:]flags][width[.precision]][doxXs]
+			j := i
+			if s[j] == ':' {
+				j++
+			}
+			for j < len(s) && strings.IndexByte("-+# 0123456789.", s[j]) >= 0 {
+				j++
+			}
+			if j >= len(s) || strings.IndexByte("doxXs", s[j]) < 0 {
+				break
+			}
+			format := "%" + s[i:j] + string(s[j])
+			format = strings.Replace(format, "%:", "%", 1)
+			if s[j] == 's' {
+				fmt.Fprintf(&out, format, strconv.Itoa(pop()))
+			} else {
+				fmt.Fprintf(&out, format, pop())
+			}
+			i = j
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,134 @@
+package tea
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// compileTerminfo builds a compiled terminfo entry with the given names and
+// string capabilities, in the legacy format.
+func compileTerminfo(names string, caps map[int]string) []byte {
+	strCount := 0
+	for i := range caps {
+		if i+1 > strCount {
+			strCount = i + 1
+		}
+	}
+
+	offsets := make([]int16, strCount)
+	var table []byte
+	for i := range offsets {
+		s, ok := caps[i]
+		if !ok {
+			offsets[i] = -1
+			continue
+		}
+		offsets[i] = int16(len(table))
+		table = append(table, s...)
+		table = append(table, 0)
+	}
+
+	appendUint16 := func(b []byte, v uint16) []byte {
+		return append(b, byte(v), byte(v>>8))
+	}
+	nameBytes := append([]byte(names), 0)
+	var b []byte
+	for _, v := range []int{terminfoMagic, len(nameBytes), 1, 1, strCount, len(table)} {
+		b = appendUint16(b, uint16(v))
+	}
+	b = append(b, nameBytes...)
+	b = append(b, 1) // one boolean
+	if len(b)%2 == 1 {
+		b = append(b, 0)
+	}
+	b = appendUint16(b, 80) // one number
+	for _, o := range offsets {
+		b = appendUint16(b, uint16(o))
+	}
+	return append(b, table...)
+}
+
+func TestParseTerminfo(t *testing.T) {
+	b := compileTerminfo("test|a test terminal", map[int]string{
+		tiClrEOL:        "\x1b[K",
+		tiCursorAddress: "\x1b[%i%p1%d;%p2%dH",
+	})
+	ti, err := parseTerminfo(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ti.names) != 2 || ti.names[0] != "test" {
+		t.Errorf("unexpected names %q", ti.names)
+	}
+	if s, ok := ti.str(tiClrEOL); !ok || s != "\x1b[K" {
+		t.Errorf("unexpected el %q", s)
+	}
+	if _, ok := ti.str(tiCursorHide); ok {
+		t.Error("expected no civis")
+	}
+
+	if _, err := parseTerminfo(b[:20]); err == nil {
+		t.Error("expected an error for a truncated entry")
+	}
+	if _, err := parseTerminfo(make([]byte, 20)); err == nil {
+		t.Error("expected an error for a bad magic number")
+	}
+}
+
+func TestLoadTerminfo(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TERMINFO", dir)
+	t.Setenv("TERMINFO_DIRS", dir)
+	if err := os.MkdirAll(filepath.Join(dir, "t"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	b := compileTerminfo("test-term", map[int]string{tiClrEOL: "\x1b[K"})
+	if err := os.WriteFile(filepath.Join(dir, "t", "test-term"), b, 0o644); err != nil { //nolint:gosec
+		t.Fatal(err)
+	}
+
+	ti, err := loadTerminfo("test-term")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ti.names[0] != "test-term" {
+		t.Errorf("unexpected names %q", ti.names)
+	}
+
+	for _, term := range []string{"", "missing-term", "../t/test-term"} {
+		if _, err := loadTerminfo(term); err == nil {
+			t.Errorf("expected no entry for %q", term)
+		}
+	}
+}
+
+func TestTparm(t *testing.T) {
+	tests := []struct {
+		s        string
+		params   []int
+		expected string
+	}{
+		{"\x1b[%i%p1%d;%p2%dH", []int{4, 9}, "\x1b[5;10H"},
+		{"\x1b[%p1%dA", []int{3}, "\x1b[3A"},
+		{"\x1b[K$<3>", nil, "\x1b[K"},
+		{"100%%", nil, "100%"},
+		{"%p1%c", []int{'A'}, "A"},
+		{"%p1%02d", []int{7}, "07"},
+		{"%p1%x", []int{255}, "ff"},
+		{"%p1%{10}%+%d", []int{5}, "15"},
+		{"%'a'%c", nil, "a"},
+		{"%p1%Pa%ga%ga%*%d", []int{3}, "9"},
+		{"%?%p1%{8}%<%t3%p1%d%e%p1%{16}%<%t9%p1%{8}%-%d%e38;5;%p1%d%;", []int{2}, "32"},
+		{"%?%p1%{8}%<%t3%p1%d%e%p1%{16}%<%t9%p1%{8}%-%d%e38;5;%p1%d%;", []int{12}, "94"},
+		{"%?%p1%{8}%<%t3%p1%d%e%p1%{16}%<%t9%p1%{8}%-%d%e38;5;%p1%d%;", []int{200}, "38;5;200"},
+		{"%?%p1%t%?%p2%tboth%e1%;%e0%;", []int{1, 0}, "1"},
+		{"%p1%!%d", []int{0}, "1"},
+		{"%p1%l%d", []int{1234}, "4"},
+	}
+	for _, test := range tests {
+		if s := tparm(test.s, test.params...); s != test.expected {
+			t.Errorf("tparm(%q, %v): expected %q, got %q", test.s, test.params, test.expected, s)
+		}
+	}
+}
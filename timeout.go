@@ -0,0 +1,146 @@
+package tea
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// CmdContext is a command that stops its work once its context is done. Use
+// Cancelable to turn it into a Cmd.
+type CmdContext func(ctx context.Context) Msg
+
+// Cancelable produces a command that runs the given command with a context
+// which is done once the program exits, or once the command times out when
+// run with WithTimeout, so that work such as requests is canceled rather than
+// left running:
+//
+//	func fetch(url string) tea.Cmd {
+//	    return tea.WithTimeout(5*time.Second, tea.Cancelable(func(ctx context.Context) tea.Msg {
+//	        req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+//	        resp, err := http.DefaultClient.Do(req)
+//	        if err != nil {
+//	            return tea.ErrMsg{Err: err}
+//	        }
+//	        defer resp.Body.Close()
+//	        return statusMsg(resp.StatusCode)
+//	    }))
+//	}
+//
+// Commands made with Cancelable must be run by a program, like Tick.
+func Cancelable(cmd CmdContext) Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() Msg {
+		return contextMsg{cmd: cmd}
+	}
+}
+
+// contextMsg is an internal message used to have the program run a command
+// with a context. Its message goes through out, if set.
+type contextMsg struct {
+	cmd CmdContext
+	out func(Msg) Msg
+}
+
+// TimeoutMsg is delivered in place of the message of a command run with
+// WithTimeout which didn't complete in time. It implements the error
+// interface, so it also stops a SequenceAbortOnErr and has Retry run the
+// command again, and it matches context.DeadlineExceeded with errors.Is.
+type TimeoutMsg struct {
+	Timeout time.Duration
+}
+
+// Error implements the error interface.
+func (t TimeoutMsg) Error() string {
+	return fmt.Sprintf("command timed out after %v", t.Timeout)
+}
+
+// Unwrap returns context.DeadlineExceeded.
+func (t TimeoutMsg) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// WithTimeout produces a command that runs cmd, delivering its message if it
+// completes within d, or a TimeoutMsg otherwise. The context of commands made
+// with Cancelable is done once they time out, which cancels their work; the
+// message of other commands is dropped once they complete.
+//
+// The timeout is measured with the program's clock, so commands made with
+// WithTimeout must be run by a program, like Tick.
+func WithTimeout(d time.Duration, cmd Cmd) Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() Msg {
+		return timeoutMsg{timeout: d, cmd: cmd}
+	}
+}
+
+// timeoutMsg is an internal message used to have the program run a command
+// with a timeout. The messages delivered go through out, if set.
+type timeoutMsg struct {
+	timeout time.Duration
+	cmd     Cmd
+	out     func(Msg) Msg
+}
+
+// runTimeout runs a command with a timeout and returns its message, or a
+// TimeoutMsg if it didn't complete in time. It returns nil if the program
+// exits first.
+func (p *Program) runTimeout(t timeoutMsg) Msg {
+	ctx, cancel := context.WithCancel(p.ctx)
+	defer cancel()
+
+	done := make(chan Msg, 1)
+	go func() {
+		defer p.recoverFromGoPanic()
+		done <- p.settle(ctx, p.callOnWorker(t.cmd))
+	}()
+
+	timer := p.clock.NewTimer(t.timeout)
+	defer timer.Stop()
+
+	select {
+	case msg := <-done:
+		return through(t.out, msg)
+	case <-timer.C():
+		return through(t.out, TimeoutMsg{Timeout: t.timeout})
+	case <-p.ctx.Done():
+		return nil
+	}
+}
+
+func (c contextMsg) run(_ *Program, ctx context.Context) Msg {
+	return through(c.out, c.cmd(ctx))
+}
+
+func (c contextMsg) then(final, _ func(Msg) Msg) Msg {
+	c.out = chain(c.out, final)
+	return c
+}
+
+func (t timeoutMsg) run(p *Program, _ context.Context) Msg {
+	return p.runTimeout(t)
+}
+
+func (t timeoutMsg) then(final, _ func(Msg) Msg) Msg {
+	t.out = chain(t.out, final)
+	return t
+}
+
+// chain returns a function passing messages through out, if set, then fn.
+func chain(out, fn func(Msg) Msg) func(Msg) Msg {
+	return func(msg Msg) Msg {
+		return fn(through(out, msg))
+	}
+}
+
+// through passes a message through out, if set.
+func through(out func(Msg) Msg, msg Msg) Msg {
+	if out == nil {
+		return msg
+	}
+	return out(msg)
+}
@@ -0,0 +1,77 @@
+package tea
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWithTimeout(t *testing.T) {
+	t.Run("completes", func(t *testing.T) {
+		p := NewProgram(nil, WithClock(manualClock{timers: make(chan instantTimer, 1)}))
+		msg := p.runCmd(WithTimeout(time.Second, func() Msg { return "done" }))
+		if msg != "done" {
+			t.Errorf("expected the command's message, got %#v", msg)
+		}
+	})
+
+	t.Run("times out", func(t *testing.T) {
+		clock := manualClock{timers: make(chan instantTimer, 1)}
+		p := NewProgram(nil, WithClock(clock))
+
+		canceled := make(chan error, 1)
+		cmd := WithTimeout(time.Second, Cancelable(func(ctx context.Context) Msg {
+			<-ctx.Done()
+			canceled <- ctx.Err()
+			return "late"
+		}))
+		done := make(chan Msg)
+		go func() { done <- p.runCmd(cmd) }()
+		(<-clock.timers) <- time.Time{}
+
+		msg := <-done
+		if !reflect.DeepEqual(msg, TimeoutMsg{Timeout: time.Second}) {
+			t.Errorf("expected a timeout, got %#v", msg)
+		}
+		if err, ok := msg.(error); !ok || !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected the timeout to match context.DeadlineExceeded, got %#v", msg)
+		}
+		if err := <-canceled; err == nil {
+			t.Errorf("expected the command's context to be done")
+		}
+	})
+
+	t.Run("wrapped", func(t *testing.T) {
+		clock := manualClock{timers: make(chan instantTimer, 1)}
+		p := NewProgram(nil, WithClock(clock))
+
+		block := make(chan struct{})
+		defer close(block)
+		done := make(chan Msg)
+		go func() {
+			done <- p.runCmd(wrap("child", WithTimeout(time.Second, func() Msg {
+				<-block
+				return nil
+			})))
+		}()
+		(<-clock.timers) <- time.Time{}
+
+		if msg := <-done; !reflect.DeepEqual(msg, WrappedMsg{ID: "child", Msg: TimeoutMsg{Timeout: time.Second}}) {
+			t.Errorf("expected the timeout to be wrapped, got %#v", msg)
+		}
+	})
+}
+
+func TestCancelable(t *testing.T) {
+	p := NewProgram(nil)
+	p.cancel()
+
+	msg := p.runCmd(Cancelable(func(ctx context.Context) Msg {
+		return ctx.Err()
+	}))
+	if !errors.Is(msg.(error), context.Canceled) {
+		t.Errorf("expected the context to be done once the program exited, got %#v", msg)
+	}
+}
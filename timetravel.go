@@ -0,0 +1,194 @@
+package tea
+
+import "fmt"
+
+// Number of snapshots kept by WithTimeTravel when no limit is given.
+const defaultTimeTravelLimit = 1000
+
+// Snapshotter is implemented by models that can be recorded with
+// WithTimeTravel. Snapshot returns a copy of the model that later updates
+// can't change, which means copying the slices, maps and pointers the model
+// holds if its Update function changes what they point to.
+//
+// Models that don't implement Snapshotter are recorded as they are, which is
+// enough for models whose Update function returns a changed copy of the model
+// and leaves what it shares with it alone.
+type Snapshotter interface {
+	Snapshot() Model
+}
+
+// ToggleTimeTravel is a special command that starts or stops going through
+// the history of the model recorded with WithTimeTravel, for instance bound to
+// a key:
+//
+//	case tea.KeyMsg:
+//	    if msg.String() == "f11" {
+//	        return m, tea.ToggleTimeTravel
+//	    }
+//
+// While going through the history, the view of the model as it was after a
+// given message is shown, along with a box telling which one, and keys step
+// through the history rather than being delivered to the model: left and
+// right step one message back or forward, home and end go to the first and
+// the latest ones, and escape goes back to the present. Other messages keep
+// updating the model as usual, so the program carries on meanwhile.
+func ToggleTimeTravel() Msg {
+	return timeTravelMsg{toggle: true}
+}
+
+// TimeTravelBack is a special command that steps one message back in the
+// history of the model recorded with WithTimeTravel, starting to go through
+// it if needed. See ToggleTimeTravel.
+func TimeTravelBack() Msg {
+	return timeTravelMsg{step: -1}
+}
+
+// TimeTravelForward is a special command that steps one message forward in
+// the history of the model recorded with WithTimeTravel. Stepping forward
+// past the latest message goes back to the present. See ToggleTimeTravel.
+func TimeTravelForward() Msg {
+	return timeTravelMsg{step: 1}
+}
+
+// timeTravelMsg is an internal message that moves through the history of the
+// model: it toggles going through it, or steps through it.
+type timeTravelMsg struct {
+	toggle bool
+	step   int
+}
+
+// timeTravelEntry is a snapshot of the model, along with the type of the
+// message it was updated with.
+type timeTravelEntry struct {
+	model Model
+	msg   string
+}
+
+// timeTravel keeps the history of the model recorded with WithTimeTravel, and
+// where we are in it. It's only used from the event loop.
+type timeTravel struct {
+	limit   int
+	history []timeTravelEntry
+
+	// pos is the index of the entry shown, or -1 when the present is.
+	pos int
+}
+
+// newTimeTravel returns a history keeping up to limit snapshots.
+func newTimeTravel(limit int) *timeTravel {
+	if limit <= 0 {
+		limit = defaultTimeTravelLimit
+	}
+	return &timeTravel{limit: limit, pos: -1}
+}
+
+// record snapshots the model after it was updated with msg, or its initial
+// state if msg is nil.
+func (t *timeTravel) record(model Model, msg Msg) {
+	if t == nil {
+		return
+	}
+	if _, ok := msg.(timeTravelMsg); ok {
+		return
+	}
+
+	if s, ok := model.(Snapshotter); ok {
+		model = s.Snapshot()
+	}
+	e := timeTravelEntry{model: model, msg: "init"}
+	if msg != nil {
+		e.msg = fmt.Sprintf("%T", msg)
+	}
+	t.history = append(t.history, e)
+
+	if n := len(t.history) - t.limit; n > 0 {
+		t.history = t.history[n:]
+		if t.pos >= 0 {
+			t.pos -= n
+			if t.pos < 0 {
+				t.pos = 0
+			}
+		}
+	}
+}
+
+// traveling reports whether the history is being gone through.
+func (t *timeTravel) traveling() bool {
+	return t != nil && t.pos >= 0
+}
+
+// handle moves through the history as told by a timeTravelMsg.
+func (t *timeTravel) handle(msg timeTravelMsg) {
+	if t == nil || len(t.history) == 0 {
+		return
+	}
+	switch {
+	case msg.toggle && t.traveling():
+		t.pos = -1
+	case msg.toggle:
+		t.pos = len(t.history) - 1
+	default:
+		t.step(msg.step)
+	}
+}
+
+// step moves n messages through the history, starting from the latest one if
+// the present is shown. Stepping past the latest one goes back to the
+// present.
+func (t *timeTravel) step(n int) {
+	pos := t.pos
+	if pos < 0 {
+		pos = len(t.history) - 1
+	}
+	pos += n
+	switch {
+	case pos < 0:
+		pos = 0
+	case pos >= len(t.history):
+		pos = -1
+	}
+	t.pos = pos
+}
+
+// key handles a key pressed while going through the history, and reports
+// whether it did. Keys it doesn't handle are swallowed anyway, except for
+// ctrl+c, so that they don't change the model unbeknownst to the user.
+func (t *timeTravel) key(k KeyMsg) bool {
+	switch k.Type {
+	case KeyLeft:
+		t.step(-1)
+	case KeyRight:
+		// Don't step into the present from the latest message by mistake.
+		if t.pos < len(t.history)-1 {
+			t.step(1)
+		}
+	case KeyHome:
+		t.pos = 0
+	case KeyEnd:
+		t.pos = len(t.history) - 1
+	case KeyEscape:
+		t.pos = -1
+	case KeyCtrlC:
+		return false
+	}
+	return true
+}
+
+// shown returns the model to render: the one in the history being gone
+// through, or the current one.
+func (t *timeTravel) shown(model Model) Model {
+	if !t.traveling() {
+		return model
+	}
+	return t.history[t.pos].model
+}
+
+// overlayLines returns the lines of the box telling where we are in the
+// history.
+func (t *timeTravel) overlayLines() []string {
+	return []string{
+		fmt.Sprintf("time travel: %d/%d", t.pos+1, len(t.history)),
+		fmt.Sprintf("after: %s", t.history[t.pos].msg),
+		"←/→ step, esc back",
+	}
+}
@@ -0,0 +1,130 @@
+package tea
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type historyModel struct {
+	counts *[]int
+	init   Cmd
+}
+
+type appendMsg int
+
+func (m historyModel) Init() Cmd {
+	return m.init
+}
+
+func (m historyModel) Update(msg Msg) (Model, Cmd) {
+	switch msg := msg.(type) {
+	case appendMsg:
+		*m.counts = append(*m.counts, int(msg))
+	case KeyMsg:
+		*m.counts = append(*m.counts, -1)
+	}
+	return m, nil
+}
+
+func (m historyModel) View() string {
+	return fmt.Sprint(*m.counts)
+}
+
+func (m historyModel) Snapshot() Model {
+	counts := append([]int(nil), *m.counts...)
+	return historyModel{counts: &counts, init: m.init}
+}
+
+func TestTimeTravelHistory(t *testing.T) {
+	tt := newTimeTravel(3)
+	if tt.traveling() {
+		t.Fatal("expected to start in the present")
+	}
+	for i := 0; i < 4; i++ {
+		tt.record(counterModel{count: i}, counterMsg{})
+	}
+	tt.record(counterModel{count: 9}, timeTravelMsg{})
+	if len(tt.history) != 3 || tt.history[0].model.(counterModel).count != 1 {
+		t.Fatalf("expected the latest 3 snapshots, got %+v", tt.history)
+	}
+
+	shown := func() int {
+		return tt.shown(counterModel{count: -1}).(counterModel).count
+	}
+
+	tt.handle(timeTravelMsg{step: -1})
+	if !tt.traveling() || shown() != 2 {
+		t.Errorf("expected to step back to 2, got %d", shown())
+	}
+	tt.handle(timeTravelMsg{step: -5})
+	if shown() != 1 {
+		t.Errorf("expected to stop at the first snapshot, got %d", shown())
+	}
+
+	// Snapshots dropped past the limit move the position along.
+	tt.record(counterModel{count: 4}, counterMsg{})
+	if shown() != 2 {
+		t.Errorf("expected the first snapshot left to be shown, got %d", shown())
+	}
+
+	tt.key(KeyMsg{Type: KeyEnd})
+	tt.key(KeyMsg{Type: KeyRight})
+	if !tt.traveling() || shown() != 4 {
+		t.Errorf("expected right to stay on the latest snapshot, got %d", shown())
+	}
+	tt.key(KeyMsg{Type: KeyHome})
+	if shown() != 2 {
+		t.Errorf("expected home to go to the first snapshot, got %d", shown())
+	}
+	if tt.key(KeyMsg{Type: KeyCtrlC}) {
+		t.Error("expected ctrl+c to go through")
+	}
+	if !tt.key(KeyMsg{Type: KeyRunes, Runes: []rune("x")}) {
+		t.Error("expected other keys to be swallowed")
+	}
+	tt.key(KeyMsg{Type: KeyEscape})
+	if tt.traveling() || shown() != -1 {
+		t.Errorf("expected escape to go back to the present, got %d", shown())
+	}
+
+	tt.handle(timeTravelMsg{toggle: true})
+	if shown() != 4 {
+		t.Errorf("expected toggling to show the latest snapshot, got %d", shown())
+	}
+	tt.handle(timeTravelMsg{step: 1})
+	if tt.traveling() {
+		t.Error("expected stepping past the latest snapshot to go back to the present")
+	}
+}
+
+func TestTimeTravel(t *testing.T) {
+	var counts []int
+	r := NewHeadlessRenderer()
+	m := historyModel{counts: &counts, init: Sequence(
+		func() Msg { return appendMsg(1) },
+		func() Msg { return appendMsg(2) },
+		func() Msg { return appendMsg(3) },
+		TimeTravelBack,
+		TimeTravelBack,
+		func() Msg { return KeyMsg{Type: KeyRight} },
+		func() Msg { return KeyMsg{Type: KeyRunes, Runes: []rune("x")} },
+		func() Msg { return appendMsg(4) },
+		Quit,
+	)}
+	p := NewProgram(m, WithHeadlessRenderer(r), WithInput(nil), WithTimeTravel(0))
+	if _, err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "[1 2 3 4]"; fmt.Sprint(counts) != want {
+		t.Errorf("expected the model to keep being updated without the keys, got %v", counts)
+	}
+	frame := r.LastFrame()
+	if !strings.HasPrefix(frame, "[1 2]") {
+		t.Errorf("expected the model after the second message to be shown, got %q", frame)
+	}
+	if !strings.Contains(frame, "time travel:") || !strings.Contains(frame, "after: tea.appendMsg") {
+		t.Errorf("expected where we are in the history to be shown, got %q", frame)
+	}
+}
@@ -0,0 +1,120 @@
+package tea
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+)
+
+// TraceEntry is a message recorded in a message trace; see WithMessageTrace.
+// A trace is written as one JSON-encoded entry per line.
+type TraceEntry struct {
+	// Time is the time at which the message was received.
+	Time time.Time `json:"time"`
+
+	// Type is the Go type of the message, such as "input.KeyMsg".
+	Type string `json:"type"`
+
+	// Msg is the JSON encoding of the message. It's empty if the message
+	// couldn't be encoded, e.g. because it holds a function or a channel.
+	Msg json.RawMessage `json:"msg,omitempty"`
+}
+
+// tracer writes a message trace.
+type tracer struct {
+	enc *json.Encoder
+}
+
+func newTracer(w io.Writer) *tracer {
+	return &tracer{enc: json.NewEncoder(w)}
+}
+
+// trace records a message. Messages which can't be encoded are recorded
+// with their type only, and write errors are ignored, so tracing never gets
+// in the way of the program.
+func (t *tracer) trace(now time.Time, msg Msg) {
+	e := TraceEntry{Time: now, Type: msgType(msg)}
+	if b, err := json.Marshal(msg); err == nil {
+		e.Msg = b
+	}
+	_ = t.enc.Encode(e)
+}
+
+// msgType returns the name of the type of a message, as recorded in traces.
+func msgType(msg Msg) string {
+	return reflect.TypeOf(msg).String()
+}
+
+// ReadTrace reads a message trace written by a program started with
+// WithMessageTrace.
+func ReadTrace(r io.Reader) ([]TraceEntry, error) {
+	var entries []TraceEntry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20) //nolint:gomnd
+	for scanner.Scan() {
+		var e TraceEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return entries, fmt.Errorf("error reading trace entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return entries, fmt.Errorf("error reading trace: %w", err)
+	}
+	return entries, nil
+}
+
+// ReplayTrace replays a message trace into a model, calling its Update
+// function with each of the recorded messages, in order, and returns the
+// resulting model. Commands returned by Update are discarded: their messages
+// are part of the trace already. This makes it possible to reproduce, step by
+// step, how a program got into a given state:
+//
+//	f, _ := os.Open("trace.jsonl")
+//	m, err := tea.ReplayTrace(f, initialModel(), fetchedMsg{}, errMsg{})
+//
+// Messages are decoded from JSON, so the model's own message types must be
+// passed as examples to be recognized, in addition to Bubble Tea's key,
+// mouse, window size and clipboard messages. ReplayTrace fails on messages of
+// other types, and on messages that couldn't be encoded in the trace.
+func ReplayTrace(r io.Reader, model Model, msgTypes ...Msg) (Model, error) {
+	entries, err := ReadTrace(r)
+	if err != nil {
+		return model, err
+	}
+
+	types := map[string]reflect.Type{}
+	for _, msg := range append([]Msg{KeyMsg{}, MouseMsg{}, WindowSizeMsg{}, ClipboardMsg("")}, msgTypes...) {
+		types[msgType(msg)] = reflect.TypeOf(msg)
+	}
+
+	for i, e := range entries {
+		t, ok := types[e.Type]
+		if !ok {
+			return model, fmt.Errorf("error replaying trace entry %d: unknown message type %s", i+1, e.Type)
+		}
+		if len(e.Msg) == 0 {
+			return model, fmt.Errorf("error replaying trace entry %d: message of type %s wasn't recorded", i+1, e.Type)
+		}
+
+		var msg Msg
+		if t.Kind() == reflect.Ptr {
+			v := reflect.New(t.Elem())
+			err = json.Unmarshal(e.Msg, v.Interface())
+			msg = v.Interface()
+		} else {
+			v := reflect.New(t)
+			err = json.Unmarshal(e.Msg, v.Interface())
+			msg = v.Elem().Interface()
+		}
+		if err != nil {
+			return model, fmt.Errorf("error replaying trace entry %d: %w", i+1, err)
+		}
+
+		model, _ = model.Update(msg)
+	}
+	return model, nil
+}
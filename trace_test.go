@@ -0,0 +1,95 @@
+package tea
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+type addMsg struct {
+	N int
+}
+
+// traceModel sums addMsgs and records typed keys, quitting on enter.
+type traceModel struct {
+	sum   int
+	typed string
+}
+
+func (m traceModel) Init() Cmd {
+	return func() Msg { return addMsg{N: 2} }
+}
+
+func (m traceModel) Update(msg Msg) (Model, Cmd) {
+	switch msg := msg.(type) {
+	case addMsg:
+		m.sum += msg.N
+	case KeyMsg:
+		if msg.Type == KeyEnter {
+			return m, Quit
+		}
+		m.typed += msg.String()
+	}
+	return m, nil
+}
+
+func (m traceModel) View() string {
+	return ""
+}
+
+func TestMessageTrace(t *testing.T) {
+	var buf, trace bytes.Buffer
+	in := strings.NewReader("ab\r")
+
+	p := NewProgram(traceModel{}, WithInput(in), WithOutput(&buf), WithMessageTrace(&trace))
+	final, err := p.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := ReadTrace(bytes.NewReader(trace.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var types []string
+	var keys int
+	for _, e := range entries {
+		types = append(types, e.Type)
+		if e.Type == "input.KeyMsg" {
+			keys++
+		}
+	}
+	if keys == 0 {
+		t.Errorf("expected keys to be traced, got %v", types)
+	}
+
+	replayed, err := ReplayTrace(bytes.NewReader(trace.Bytes()), traceModel{}, addMsg{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayed != final {
+		t.Errorf("expected the replayed model %+v to match %+v", replayed, final)
+	}
+
+	if _, err := ReplayTrace(bytes.NewReader(trace.Bytes()), traceModel{}); err == nil {
+		t.Error("expected an error replaying an unknown message type")
+	}
+}
+
+func TestMessageTraceUnencodable(t *testing.T) {
+	var trace bytes.Buffer
+	tr := newTracer(&trace)
+	tr.trace(time.Now(), func() {})
+
+	entries, err := ReadTrace(bytes.NewReader(trace.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Type != "func()" || len(entries[0].Msg) != 0 {
+		t.Fatalf("expected an entry with the type only, got %+v", entries)
+	}
+	if _, err := ReplayTrace(&trace, traceModel{}, func() {}); err == nil {
+		t.Error("expected an error replaying a message that wasn't recorded")
+	}
+}
@@ -25,6 +25,8 @@ func (p *Program) initTerminal() error {
 func (p *Program) restoreTerminalState() error {
 	if p.renderer != nil {
 		p.renderer.disableBracketedPaste()
+		p.renderer.disableKittyKeyboard()
+		p.renderer.disableModifyOtherKeys()
 		p.renderer.showCursor()
 		p.disableMouse()
 
@@ -51,10 +53,20 @@ func (p *Program) restoreInput() error {
 
 // initCancelReader (re)commences reading inputs.
 func (p *Program) initCancelReader() error {
-	var err error
-	p.cancelReader, err = newInputReader(p.input)
-	if err != nil {
-		return fmt.Errorf("error creating cancelreader: %w", err)
+	if _, ok := p.input.(*os.File); !ok {
+		// Inputs other than files, such as network connections, can't be
+		// cancelled in the middle of a read, so read them in the
+		// background instead.
+		if p.streamInput == nil {
+			p.streamInput = newStreamInput(p.input)
+		}
+		p.cancelReader = p.streamInput.newReader()
+	} else {
+		var err error
+		p.cancelReader, err = newInputReader(p.input)
+		if err != nil {
+			return fmt.Errorf("error creating cancelreader: %w", err)
+		}
 	}
 
 	p.readLoopDone = make(chan struct{})
@@ -66,8 +78,28 @@ func (p *Program) initCancelReader() error {
 func (p *Program) readLoop() {
 	defer close(p.readLoopDone)
 
-	err := readInputs(p.ctx, p.msgs, p.cancelReader)
-	if !errors.Is(err, io.EOF) && !errors.Is(err, cancelreader.ErrCanceled) {
+	var input io.Reader = p.cancelReader
+	if p.recorder != nil && !isConsoleInput(p.cancelReader) {
+		input = recordingReader{r: input, rec: p.recorder}
+	}
+
+	msgs := p.msgs
+	if p.startupOptions.has(withMotionCoalescing) {
+		in := make(chan Msg)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			p.coalesceMotion(in)
+		}()
+		defer func() {
+			close(in)
+			<-done
+		}()
+		msgs = in
+	}
+
+	err := readInputs(p.ctx, msgs, input, p.inputParser)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, cancelreader.ErrCanceled) {
 		select {
 		case <-p.ctx.Done():
 		case p.errs <- err:
@@ -86,6 +118,12 @@ func (p *Program) waitForReadLoop() {
 	}
 }
 
+// outputIsTerminal reports whether the program's output is a terminal.
+func (p *Program) outputIsTerminal() bool {
+	f, ok := p.output.TTY().(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
 // checkResize detects the current size of the output and informs the program
 // via a WindowSizeMsg.
 func (p *Program) checkResize() {
@@ -0,0 +1,27 @@
+//go:build js
+// +build js
+
+package tea
+
+import (
+	"errors"
+	"os"
+)
+
+// initInput does nothing in the browser: there's no terminal to put in raw
+// mode. Programs get their input through an adapter, such as the one of the
+// xterm package.
+func (p *Program) initInput() error {
+	return nil
+}
+
+// openInputTTY fails in the browser, which has no TTY.
+func openInputTTY() (*os.File, error) {
+	return nil, errors.New("no TTY available in the browser")
+}
+
+// listenForResize does nothing in the browser: resizes are reported by the
+// adapter connecting the program to the page.
+func (p *Program) listenForResize(done chan struct{}) {
+	close(done)
+}
@@ -0,0 +1,70 @@
+package tea
+
+// Cmd2 is a command that only produces messages of type M, so that components
+// can tell which messages their commands deliver at compile time:
+//
+//	type fetchedMsg struct{ body string }
+//
+//	func fetch(url string) tea.Cmd2[fetchedMsg] {
+//	    return tea.Typed(func() fetchedMsg {
+//	        return fetchedMsg{body: get(url)}
+//	    })
+//	}
+//
+// Typed commands are combined with Batch2, Sequence2 and Map2, which keep
+// track of the type of the messages, and returned from Update with Cmd:
+//
+//	return m, tea.Batch2(fetch(a), fetch(b)).Cmd()
+//
+// The zero Cmd2 does nothing.
+type Cmd2[M Msg] struct {
+	cmd Cmd
+}
+
+// Typed produces a typed command producing the message fn returns.
+func Typed[M Msg](fn func() M) Cmd2[M] {
+	if fn == nil {
+		return Cmd2[M]{}
+	}
+	return Cmd2[M]{cmd: func() Msg {
+		return fn()
+	}}
+}
+
+// Cmd returns the command, for Update to return, or to be combined with
+// untyped commands.
+func (c Cmd2[M]) Cmd() Cmd {
+	return c.cmd
+}
+
+// Batch2 is like Batch, for typed commands producing messages of the same
+// type.
+func Batch2[M Msg](cmds ...Cmd2[M]) Cmd2[M] {
+	return Cmd2[M]{cmd: Batch(untyped(cmds)...)}
+}
+
+// Sequence2 is like Sequence, for typed commands producing messages of the
+// same type.
+func Sequence2[M Msg](cmds ...Cmd2[M]) Cmd2[M] {
+	return Cmd2[M]{cmd: Sequence(untyped(cmds)...)}
+}
+
+// Map2 is like Map, for typed commands: it produces a command delivering the
+// messages of cmd as transformed by fn.
+func Map2[A, B Msg](cmd Cmd2[A], fn func(A) B) Cmd2[B] {
+	return Cmd2[B]{cmd: Map(cmd.cmd, func(msg Msg) Msg {
+		if a, ok := msg.(A); ok {
+			return fn(a)
+		}
+		return msg
+	})}
+}
+
+// untyped returns the commands of typed commands.
+func untyped[M Msg](cmds []Cmd2[M]) []Cmd {
+	out := make([]Cmd, len(cmds))
+	for i, c := range cmds {
+		out[i] = c.cmd
+	}
+	return out
+}
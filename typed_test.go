@@ -0,0 +1,81 @@
+package tea
+
+import "testing"
+
+type typedMsg int
+
+func TestTyped(t *testing.T) {
+	if cmd := Typed[typedMsg](nil).Cmd(); cmd != nil {
+		t.Error("expected no command")
+	}
+	if cmd := (Cmd2[typedMsg]{}).Cmd(); cmd != nil {
+		t.Error("expected the zero Cmd2 to have no command")
+	}
+
+	cmd := Typed(func() typedMsg { return 1 })
+	if msg := cmd.Cmd()(); msg != typedMsg(1) {
+		t.Errorf("expected 1, got %#v", msg)
+	}
+}
+
+func TestBatch2(t *testing.T) {
+	one := Typed(func() typedMsg { return 1 })
+	two := Typed(func() typedMsg { return 2 })
+
+	if cmd := Batch2[typedMsg]().Cmd(); cmd != nil {
+		t.Error("expected no command")
+	}
+	if msg := Batch2(one, Cmd2[typedMsg]{}).Cmd()(); msg != typedMsg(1) {
+		t.Errorf("expected the only command to be returned, got %#v", msg)
+	}
+
+	batch, ok := Batch2(one, two).Cmd()().(BatchMsg)
+	if !ok || len(batch) != 2 {
+		t.Fatalf("expected a batch of 2 commands, got %#v", batch)
+	}
+	for i, cmd := range batch {
+		if msg := cmd(); msg != typedMsg(i+1) {
+			t.Errorf("expected %d, got %#v", i+1, msg)
+		}
+	}
+}
+
+func TestSequence2(t *testing.T) {
+	one := Typed(func() typedMsg { return 1 })
+	two := Typed(func() typedMsg { return 2 })
+
+	seq, ok := Sequence2(one, two).Cmd()().(sequenceMsg)
+	if !ok || len(seq) != 2 {
+		t.Fatalf("expected a sequence of 2 commands, got %#v", seq)
+	}
+	for i, cmd := range seq {
+		if msg := cmd(); msg != typedMsg(i+1) {
+			t.Errorf("expected %d, got %#v", i+1, msg)
+		}
+	}
+}
+
+func TestMap2(t *testing.T) {
+	type doubledMsg int
+	double := func(msg typedMsg) doubledMsg { return doubledMsg(msg * 2) }
+
+	if cmd := Map2(Cmd2[typedMsg]{}, double).Cmd(); cmd != nil {
+		t.Error("expected no command")
+	}
+
+	one := Typed(func() typedMsg { return 1 })
+	if msg := run(Map2(one, double).Cmd()); msg != doubledMsg(2) {
+		t.Errorf("expected 2, got %#v", msg)
+	}
+
+	two := Typed(func() typedMsg { return 2 })
+	batch, ok := Map2(Batch2(one, two), double).Cmd()().(BatchMsg)
+	if !ok || len(batch) != 2 {
+		t.Fatalf("expected a batch of 2 commands, got %#v", batch)
+	}
+	for i, cmd := range batch {
+		if msg := run(cmd); msg != doubledMsg(2*(i+1)) {
+			t.Errorf("expected %d, got %#v", 2*(i+1), msg)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package tea
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// FileOp is a change to a file reported by WatchFile and WatchDir. Several
+// may be reported at once.
+type FileOp uint32
+
+// File changes.
+const (
+	// FileCreated reports that the file was created, or moved in place.
+	FileCreated FileOp = 1 << iota
+
+	// FileWritten reports that the file was written to.
+	FileWritten
+
+	// FileRemoved reports that the file was removed.
+	FileRemoved
+
+	// FileRenamed reports that the file was moved away. Editors often save
+	// files by writing a new file and moving it in place of the old one,
+	// which is reported as FileCreated.
+	FileRenamed
+
+	// FileChmod reports that the attributes of the file changed.
+	FileChmod
+)
+
+// Has reports whether the changes include op.
+func (o FileOp) Has(op FileOp) bool {
+	return o&op != 0
+}
+
+// FileEvent is a change to a file watched with WatchFile or WatchDir, or an
+// error watching it.
+type FileEvent struct {
+	// Path is the path of the file that changed.
+	Path string
+
+	// Op is how it changed.
+	Op FileOp
+
+	// Err is the error watching the file failed with. Path and Op are
+	// unset then.
+	Err error
+}
+
+// WatchFile produces a command that watches a file for changes until the
+// program exits, delivering the message fn returns for each change, such as
+// for a log viewer to show new lines, or a program to reload its
+// configuration. Messages fn returns nil for are dropped.
+//
+//	func (m model) Init() tea.Cmd {
+//	    return tea.WatchFile(m.configPath, func(e tea.FileEvent) tea.Msg {
+//	        if e.Err != nil || e.Op.Has(tea.FileWritten|tea.FileCreated) {
+//	            return reloadConfigMsg{}
+//	        }
+//	        return nil
+//	    })
+//	}
+//
+// The file's directory is watched rather than the file itself, so that the
+// file is still watched after being replaced, as editors do when saving, or
+// removed and created again. An error watching it is delivered as a
+// FileEvent, after which it's no longer watched.
+func WatchFile(path string, fn func(FileEvent) Msg) Cmd {
+	return func() Msg {
+		return watchMsg{path: filepath.Clean(path), file: true, fn: fn}
+	}
+}
+
+// WatchDir produces a command that watches the files of a directory for
+// changes until the program exits, as WatchFile does. Subdirectories aren't
+// watched: changes to them are reported, but not changes to the files in
+// them.
+func WatchDir(path string, fn func(FileEvent) Msg) Cmd {
+	return func() Msg {
+		return watchMsg{path: filepath.Clean(path), fn: fn}
+	}
+}
+
+// watchMsg is an internal message used to have the program watch a file, or
+// the files of a directory.
+type watchMsg struct {
+	path string
+	file bool
+	fn   func(FileEvent) Msg
+}
+
+func (w watchMsg) run(p *Program, _ context.Context) Msg {
+	p.watch(w)
+	return nil
+}
+
+func (w watchMsg) then(final, _ func(Msg) Msg) Msg {
+	event := w.fn
+	w.fn = func(e FileEvent) Msg {
+		return final(event(e))
+	}
+	return w
+}
+
+// watch watches a file or directory in the background until the program
+// exits, sending the messages for its changes.
+func (p *Program) watch(w watchMsg) {
+	p.watchUntil(p.ctx, w, p.Send)
+}
+
+// watchUntil watches a file or directory in the background until ctx is done,
+// sending the messages for its changes with sendMsg.
+func (p *Program) watchUntil(ctx context.Context, w watchMsg, sendMsg func(Msg)) {
+	send := func(e FileEvent) {
+		if msg := w.fn(e); msg != nil {
+			sendMsg(msg)
+		}
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		go send(FileEvent{Err: err})
+		return
+	}
+	dir := w.path
+	if w.file {
+		dir = filepath.Dir(w.path)
+	}
+	if err := watcher.Add(dir); err != nil {
+		_ = watcher.Close()
+		go send(FileEvent{Err: err})
+		return
+	}
+
+	go func() {
+		defer p.recoverFromGoPanic()
+		defer watcher.Close() //nolint:errcheck
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case e, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if w.file && filepath.Clean(e.Name) != w.path {
+					continue
+				}
+				send(FileEvent{Path: e.Name, Op: fileOp(e.Op)})
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				send(FileEvent{Err: err})
+				return
+			}
+		}
+	}()
+}
+
+// fileOp converts fsnotify operations to file changes.
+func fileOp(op fsnotify.Op) FileOp {
+	var o FileOp
+	for _, c := range []struct {
+		from fsnotify.Op
+		to   FileOp
+	}{
+		{fsnotify.Create, FileCreated},
+		{fsnotify.Write, FileWritten},
+		{fsnotify.Remove, FileRemoved},
+		{fsnotify.Rename, FileRenamed},
+		{fsnotify.Chmod, FileChmod},
+	} {
+		if op.Has(c.from) {
+			o |= c.to
+		}
+	}
+	return o
+}
@@ -0,0 +1,73 @@
+package tea
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config")
+	if err := os.WriteFile(path, []byte("a"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewProgram(nil)
+	defer p.cancel()
+	p.watch(WatchFile(path, func(e FileEvent) Msg { return e })().(watchMsg))
+
+	next := func() FileEvent {
+		t.Helper()
+		select {
+		case msg := <-p.msgs:
+			return msg.(FileEvent)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a file event")
+			return FileEvent{}
+		}
+	}
+
+	// Changes to other files in the directory aren't reported.
+	if err := os.WriteFile(filepath.Join(dir, "other"), []byte("b"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("c"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if e := next(); e.Path != path || !e.Op.Has(FileWritten) {
+		t.Errorf("expected the file to be written to, got %+v", e)
+	}
+
+	// Files replaced by others are still watched.
+	tmp := filepath.Join(dir, "config.tmp")
+	if err := os.WriteFile(tmp, []byte("d"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+	for e := next(); !e.Op.Has(FileCreated); e = next() {
+		if e.Path != path {
+			t.Errorf("unexpected event for another file: %+v", e)
+		}
+	}
+}
+
+func TestWatchDirError(t *testing.T) {
+	p := NewProgram(nil)
+	defer p.cancel()
+	p.watch(WatchDir(filepath.Join(t.TempDir(), "missing"), func(e FileEvent) Msg { return e })().(watchMsg))
+
+	if e := (<-p.msgs).(FileEvent); e.Err == nil {
+		t.Errorf("expected an error watching a missing directory, got %+v", e)
+	}
+}
+
+func TestFileOp(t *testing.T) {
+	op := FileCreated | FileChmod
+	if !op.Has(FileCreated) || !op.Has(FileChmod) || op.Has(FileWritten) {
+		t.Errorf("unexpected changes %b", op)
+	}
+}
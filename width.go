@@ -0,0 +1,112 @@
+package tea
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/rivo/uniseg"
+)
+
+// StringWidth returns the width of a string in cells, as terminals draw it.
+// Escape sequences, hyperlinks included, take up no cells, and widths are
+// measured by grapheme cluster rather than by rune: an emoji sequence joined
+// with zero-width joiners, a character followed by a variation selector or by
+// combining marks, or a pair of regional indicators making up a flag, each
+// take up the cells of a single character.
+//
+// Components should measure text with it, so that borders and columns stay
+// aligned around emoji and CJK text.
+func StringWidth(s string) int {
+	return cellWidths(0).stringWidth(s)
+}
+
+// cellWidths measures text in cells, taking East Asian ambiguous characters
+// to be 2 cells wide if it's 2, and 1 cell wide otherwise. Each program
+// measures its frames with its own, set by WithEastAsianAmbiguousWidth. The
+// zero value measures text as StringWidth does.
+type cellWidths int
+
+func (m cellWidths) stringWidth(s string) int {
+	w := 0
+	for i := 0; i < len(s); {
+		if s[i] == '\x1b' {
+			i, _ = sequenceEnd(s, i)
+			continue
+		}
+		cluster, cw := m.firstGrapheme(s[i:])
+		w += cw
+		i += len(cluster)
+	}
+	return w
+}
+
+// firstGrapheme returns the grapheme cluster at the start of s and its width
+// in cells.
+func (m cellWidths) firstGrapheme(s string) (string, int) {
+	cluster, w := firstGrapheme(s)
+	if m == 2 && w == 1 {
+		if r, _ := utf8.DecodeRuneInString(cluster); runewidth.IsAmbiguousWidth(r) {
+			w = 2
+		}
+	}
+	return cluster, w
+}
+
+// firstGrapheme returns the grapheme cluster at the start of s and its width
+// in cells. Clusters never take in escape sequences, as they start with a
+// control character.
+func firstGrapheme(s string) (string, int) {
+	cluster, _, width, _ := uniseg.FirstGraphemeClusterInString(s, -1)
+	return cluster, width
+}
+
+// graphemeStart returns the offset of the start of the grapheme cluster of s
+// the offset n falls into, which is n itself if a cluster starts there.
+func graphemeStart(s string, n int) int {
+	pos, state := 0, -1
+	for rest := s; pos < n && rest != ""; {
+		var cluster string
+		cluster, rest, _, state = uniseg.FirstGraphemeClusterInString(rest, state)
+		if pos+len(cluster) > n {
+			return pos
+		}
+		pos += len(cluster)
+	}
+	return n
+}
+
+// defaultTabWidth is the width of tab stops tabs are expanded to, as set on
+// terminals by default.
+const defaultTabWidth = 8
+
+// expandTabs replaces the tabs of a line with spaces up to the next tab stop,
+// every tabWidth cells. Escape sequences take up no cells.
+func expandTabs(s string, tabWidth int) string {
+	return cellWidths(0).expandTabs(s, tabWidth)
+}
+
+func (m cellWidths) expandTabs(s string, tabWidth int) string {
+	var b strings.Builder
+	b.Grow(len(s) + tabWidth)
+	col := 0
+	for i := 0; i < len(s); {
+		switch {
+		case s[i] == '\x1b':
+			end, _ := sequenceEnd(s, i)
+			b.WriteString(s[i:end])
+			i = end
+		case s[i] == '\t':
+			n := tabWidth - col%tabWidth
+			b.WriteString(strings.Repeat(" ", n))
+			col += n
+			i++
+		default:
+			cluster, w := m.firstGrapheme(s[i:])
+			b.WriteString(cluster)
+			col += w
+			i += len(cluster)
+		}
+	}
+	return b.String()
+}
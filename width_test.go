@@ -0,0 +1,109 @@
+package tea
+
+import "testing"
+
+func TestStringWidth(t *testing.T) {
+	tests := []struct {
+		name  string
+		s     string
+		width int
+	}{
+		{"plain", "abc", 3},
+		{"styled", "\x1b[31mabc\x1b[0m", 3},
+		{"hyperlink", Hyperlink("https://example.com", "link"), 4},
+		{"hyperlink with bel", "\x1b]8;;https://example.com\alink\x1b]8;;\a", 4},
+		{"cjk", "日本", 4},
+		{"combining marks", "é̂", 1},
+		{"zero-width joiners", "👨‍👩‍👧", 2},
+		{"variation selector", "❤️", 2},
+		{"flag", "🇫🇷", 2},
+		{"split by a sequence", "e\x1b[1ḿ", 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := StringWidth(test.s); got != test.width {
+				t.Errorf("expected %q to be %d cells wide, got %d", test.s, test.width, got)
+			}
+		})
+	}
+}
+
+func TestGraphemeStart(t *testing.T) {
+	family := "👨‍👩‍👧"
+	s := "ab" + family + "c"
+	tests := []struct {
+		n, expected int
+	}{
+		{0, 0},
+		{2, 2},
+		{3, 2},
+		{2 + len("👨‍"), 2},
+		{2 + len(family), 2 + len(family)},
+		{len(s), len(s)},
+	}
+	for _, test := range tests {
+		if got := graphemeStart(s, test.n); got != test.expected {
+			t.Errorf("expected graphemeStart(%d) to be %d, got %d", test.n, test.expected, got)
+		}
+	}
+}
+
+func TestCommonPrefix(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     string
+		expected int
+	}{
+		{"plain", "abc", "abd", 2},
+		{"sequence", "a\x1b[1mb", "a\x1b[1mc", 1},
+		{"combining mark", "ae", "ae\u0301", 1},
+		{"joined emoji", "a👨‍👩", "a👨‍👧", 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := commonPrefix(test.a, test.b); got != test.expected {
+				t.Errorf("expected %d, got %d", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestEastAsianAmbiguousWidth(t *testing.T) {
+	wide := cellWidths(2)
+	if w := wide.stringWidth("α"); w != 2 {
+		t.Errorf("expected an ambiguous character to be 2 cells wide, got %d", w)
+	}
+	if got := wide.truncateLine("αβγ", 5); got != "αβ" {
+		t.Errorf("expected the line to be cut after 2 ambiguous characters, got %q", got)
+	}
+
+	// Measuring wide doesn't change how anything else measures them.
+	if w := StringWidth("α"); w != 1 {
+		t.Errorf("expected an ambiguous character to be 1 cell wide, got %d", w)
+	}
+	if w := cellWidths(0).stringWidth("α"); w != 1 {
+		t.Errorf("expected an ambiguous character to be 1 cell wide, got %d", w)
+	}
+}
+
+func TestExpandTabs(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		tabWidth int
+		expected string
+	}{
+		{"start", "\ta", 4, "    a"},
+		{"tab stops", "ab\tc\td", 4, "ab  c   d"},
+		{"full stop", "abcd\te", 4, "abcd    e"},
+		{"styled", "\x1b[1ma\x1b[0m\tb", 4, "\x1b[1ma\x1b[0m   b"},
+		{"wide", "世\ta", 4, "世  a"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := expandTabs(test.s, test.tabWidth); got != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, got)
+			}
+		})
+	}
+}
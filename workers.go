@@ -0,0 +1,199 @@
+package tea
+
+import (
+	"sync"
+	"time"
+)
+
+// WorkerMetrics is an optional interface a Metrics implementation can
+// satisfy to also receive measurements of the worker pool running commands,
+// when it's limited with WithCommandWorkers. A growing queue is a sign that
+// the pool is too small for the program's workload.
+type WorkerMetrics interface {
+	Metrics
+
+	// CommandQueueDepth is called each time a command is queued or picked
+	// up by a worker, with the number of commands waiting for a worker.
+	CommandQueueDepth(n int)
+
+	// CommandWait is called each time a worker picks up a command, with how
+	// long the command waited for it.
+	CommandWait(d time.Duration)
+}
+
+// cmdQueue is an unbounded queue of commands waiting for a worker. It's
+// unbounded so that queueing a command never blocks the event loop.
+type cmdQueue struct {
+	mtx    sync.Mutex
+	cond   *sync.Cond
+	cmds   []queuedCmd
+	closed bool
+}
+
+type queuedCmd struct {
+	cmd Cmd
+	at  time.Time
+
+	// reply, if set, receives the message of the command in place of the
+	// program, for the commands the program runs itself, which wait for it.
+	reply chan<- Msg
+}
+
+func newCmdQueue() *cmdQueue {
+	q := &cmdQueue{}
+	q.cond = sync.NewCond(&q.mtx)
+	return q
+}
+
+// push queues a command and returns the number of commands waiting. It
+// returns false if the queue is closed, in which case the command is dropped.
+func (q *cmdQueue) push(cmd Cmd, reply chan<- Msg) (int, bool) {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	if q.closed {
+		return 0, false
+	}
+	q.cmds = append(q.cmds, queuedCmd{cmd: cmd, at: time.Now(), reply: reply})
+	q.cond.Signal()
+	return len(q.cmds), true
+}
+
+// pop waits for a command and returns it, with the number of commands still
+// waiting. It returns false once the queue is closed.
+func (q *cmdQueue) pop() (queuedCmd, int, bool) {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	for len(q.cmds) == 0 && !q.closed {
+		q.cond.Wait()
+	}
+	if q.closed {
+		return queuedCmd{}, 0, false
+	}
+	c := q.cmds[0]
+	q.cmds[0] = queuedCmd{}
+	q.cmds = q.cmds[1:]
+	return c, len(q.cmds), true
+}
+
+// close closes the queue, dropping the commands still waiting, and returns
+// how many there were.
+func (q *cmdQueue) close() int {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	q.closed = true
+	q.cond.Broadcast()
+	n := len(q.cmds)
+	q.cmds = nil
+	return n
+}
+
+// handleCommandsWithWorkers runs commands on a fixed number of workers,
+// queueing them until a worker is free.
+func (p *Program) handleCommandsWithWorkers(cmds chan Cmd) chan struct{} {
+	ch := make(chan struct{})
+	q := newCmdQueue()
+	p.cmdQueue = q
+	metrics, _ := p.metrics.(WorkerMetrics)
+
+	for i := 0; i < p.cmdWorkers; i++ {
+		go func() {
+			for {
+				c, n, ok := q.pop()
+				if !ok {
+					return
+				}
+				if metrics != nil {
+					metrics.CommandQueueDepth(n)
+					metrics.CommandWait(time.Since(c.at))
+				}
+				if c.reply != nil {
+					p.execReply(c)
+				} else {
+					p.execCmd(c.cmd)
+				}
+			}
+		}()
+	}
+
+	// Queued commands will never run once the program is torn down. They
+	// still run once it stops accepting commands, since they're in flight.
+	go func() {
+		<-p.ctx.Done()
+		for n := q.close(); n > 0; n-- {
+			p.cmdsInFlight.Done()
+		}
+	}()
+
+	go func() {
+		defer close(ch)
+
+		for {
+			select {
+			case <-p.ctx.Done():
+				return
+
+			case <-p.stopped:
+				return
+
+			case cmd := <-cmds:
+				if cmd == nil {
+					continue
+				}
+
+				p.cmdsInFlight.Add(1)
+				n, ok := q.push(cmd, nil)
+				if !ok {
+					p.cmdsInFlight.Done()
+				} else if metrics != nil {
+					metrics.CommandQueueDepth(n)
+				}
+			}
+		}
+	}()
+
+	return ch
+}
+
+// callOnWorker calls a command on the worker pool, if the program has one,
+// and returns its message, for the commands the program runs itself, such as
+// the steps of sequences, to be bounded by the pool too. It returns nil if
+// the program exits before a worker is free.
+func (p *Program) callOnWorker(cmd Cmd) Msg {
+	q := p.cmdQueue
+	if q == nil {
+		return p.callCmd(cmd)
+	}
+
+	reply := make(chan Msg, 1)
+	p.cmdsInFlight.Add(1)
+	n, ok := q.push(cmd, reply)
+	if !ok {
+		p.cmdsInFlight.Done()
+		return nil
+	}
+	if metrics, ok := p.metrics.(WorkerMetrics); ok {
+		metrics.CommandQueueDepth(n)
+	}
+
+	select {
+	case msg := <-reply:
+		return msg
+	case <-p.ctx.Done():
+		return nil
+	}
+}
+
+// execReply runs a command queued by callOnWorker, counted in cmdsInFlight,
+// and replies with its message.
+func (p *Program) execReply(c queuedCmd) {
+	defer p.recoverFromGoPanic()
+
+	msg := func() Msg {
+		defer p.cmdsInFlight.Done()
+		return p.callCmd(c.cmd)
+	}()
+	c.reply <- msg
+}
@@ -0,0 +1,151 @@
+package tea
+
+import (
+	"bytes"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type doneMsg struct{}
+
+// countModel quits once it has received n doneMsgs.
+type countModel struct {
+	n, done int
+	init    Cmd
+}
+
+func (m countModel) Init() Cmd {
+	return m.init
+}
+
+func (m countModel) Update(msg Msg) (Model, Cmd) {
+	if _, ok := msg.(doneMsg); ok {
+		m.done++
+		if m.done == m.n {
+			return m, Quit
+		}
+	}
+	return m, nil
+}
+
+func (m countModel) View() string {
+	return ""
+}
+
+type workerMetrics struct {
+	testMetrics
+
+	mtx      sync.Mutex
+	maxDepth int
+	waits    int
+}
+
+func (m *workerMetrics) CommandQueueDepth(n int) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	if n > m.maxDepth {
+		m.maxDepth = n
+	}
+}
+
+func (m *workerMetrics) CommandWait(time.Duration) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.waits++
+}
+
+// trackedCmds returns n commands recording the most of them running at once
+// in maxRunning.
+func trackedCmds(n int, maxRunning *int32) []Cmd {
+	var running int32
+	cmds := make([]Cmd, n)
+	for i := range cmds {
+		cmds[i] = func() Msg {
+			r := atomic.AddInt32(&running, 1)
+			defer atomic.AddInt32(&running, -1)
+			for {
+				prev := atomic.LoadInt32(maxRunning)
+				if r <= prev || atomic.CompareAndSwapInt32(maxRunning, prev, r) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			return doneMsg{}
+		}
+	}
+	return cmds
+}
+
+func TestCommandWorkers(t *testing.T) {
+	const n = 20
+	var maxRunning int32
+	cmds := trackedCmds(n, &maxRunning)
+
+	var buf bytes.Buffer
+	metrics := &workerMetrics{}
+	m := countModel{n: n, init: Batch(cmds...)}
+	p := NewProgram(m, WithInput(nil), WithOutput(&buf),
+		WithCommandWorkers(2), WithMetrics(metrics))
+	final, err := p.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if done := final.(countModel).done; done != n {
+		t.Errorf("expected %d commands to run, got %d", n, done)
+	}
+	if most := atomic.LoadInt32(&maxRunning); most > 2 {
+		t.Errorf("expected at most 2 commands to run at once, got %d", most)
+	}
+
+	metrics.mtx.Lock()
+	defer metrics.mtx.Unlock()
+	if metrics.waits < n {
+		t.Errorf("expected the wait of %d commands to be reported, got %d", n, metrics.waits)
+	}
+	if metrics.maxDepth == 0 {
+		t.Error("expected commands to be queued")
+	}
+}
+
+func TestCommandWorkersSequence(t *testing.T) {
+	const n = 20
+	var maxRunning int32
+	cmds := trackedCmds(n, &maxRunning)
+
+	var buf bytes.Buffer
+	m := countModel{n: n, init: Sequence(
+		Batch(cmds[:n/2]...),
+		cmds[n/2],
+		Batch(cmds[n/2+1:]...),
+	)}
+	p := NewProgram(m, WithInput(nil), WithOutput(&buf), WithCommandWorkers(2))
+	final, err := p.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if done := final.(countModel).done; done != n {
+		t.Errorf("expected %d commands to run, got %d", n, done)
+	}
+	if most := atomic.LoadInt32(&maxRunning); most > 2 {
+		t.Errorf("expected at most 2 commands to run at once, got %d", most)
+	}
+}
+
+func TestCmdQueueClose(t *testing.T) {
+	q := newCmdQueue()
+	q.push(func() Msg { return nil }, nil)
+	q.push(func() Msg { return nil }, nil)
+	if c, n, ok := q.pop(); !ok || c.cmd == nil || n != 1 {
+		t.Fatalf("expected a command with 1 left, got %v, %d, %v", c, n, ok)
+	}
+	if n := q.close(); n != 1 {
+		t.Errorf("expected 1 dropped command, got %d", n)
+	}
+	if _, _, ok := q.pop(); ok {
+		t.Error("expected no command from a closed queue")
+	}
+}
@@ -0,0 +1,35 @@
+// Package xterm runs Bubble Tea programs in the browser, compiled to
+// WebAssembly, on top of an xterm.js terminal.
+//
+// Build the program with GOOS=js GOARCH=wasm and load it with the
+// wasm_exec.js support script shipped with Go. Create the terminal on the
+// JavaScript side and hand it over to the program, for instance through a
+// global:
+//
+//	// index.js
+//	const term = new Terminal();
+//	term.open(document.getElementById("terminal"));
+//	globalThis.term = term;
+//
+//	const go = new Go();
+//	WebAssembly.instantiateStreaming(fetch("app.wasm"), go.importObject)
+//	    .then((result) => go.run(result.instance));
+//
+//	// main.go
+//	func main() {
+//	    term := js.Global().Get("term")
+//	    if _, err := xterm.Run(term, initialModel()); err != nil {
+//	        fmt.Println(err)
+//	    }
+//	}
+//
+// The program draws to the terminal with escape sequences, as it would to a
+// real one, reads the keys typed in it and receives a tea.WindowSizeMsg
+// whenever it's resized, e.g. by the xterm.js fit addon.
+//
+// Text composed with input methods is reported as tea.CompositionMsg while
+// it's composed. xterm.js shows it over the cursor as well, which programs
+// showing it themselves can hide with CSS:
+//
+//	.xterm .composition-view { display: none !important; }
+package xterm
@@ -0,0 +1,180 @@
+//go:build js && wasm
+// +build js,wasm
+
+package xterm
+
+import (
+	"io"
+	"sync"
+	"syscall/js"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/muesli/termenv"
+)
+
+// Terminal connects Bubble Tea programs to an xterm.js Terminal object.
+type Terminal struct {
+	term js.Value
+
+	// Keys typed in the terminal are written to in, which the program
+	// reads from.
+	in  *io.PipeReader
+	out *io.PipeWriter
+
+	mtx       sync.Mutex
+	callbacks []js.Func
+	disposers []func()
+
+	// Events are handled in the order they happened by a single goroutine,
+	// which handlers queue them to.
+	events chan func()
+}
+
+// eventBuffer is the number of events queued before the handlers wait for
+// the program to catch up.
+const eventBuffer = 256
+
+// New returns a Terminal for the given xterm.js Terminal object.
+func New(term js.Value) *Terminal {
+	r, w := io.Pipe()
+	return &Terminal{term: term, in: r, out: w}
+}
+
+// Size returns the size of the terminal, in columns and rows.
+func (t *Terminal) Size() (width, height int) {
+	return t.term.Get("cols").Int(), t.term.Get("rows").Int()
+}
+
+// Write writes output to the terminal. It implements io.Writer.
+func (t *Terminal) Write(b []byte) (int, error) {
+	// Pass the output as bytes so that xterm.js decodes it as UTF-8 itself,
+	// even when a character is split across writes.
+	data := js.Global().Get("Uint8Array").New(len(b))
+	js.CopyBytesToJS(data, b)
+	t.term.Call("write", data)
+	return len(b), nil
+}
+
+// Read reads the input typed in the terminal. It implements io.Reader.
+func (t *Terminal) Read(b []byte) (int, error) {
+	return t.in.Read(b)
+}
+
+// ProgramOptions returns the options connecting a program to the terminal:
+// its input and output, with true colors, which xterm.js supports, and no
+// signal handling, which doesn't apply to the browser.
+func (t *Terminal) ProgramOptions() []tea.ProgramOption {
+	return []tea.ProgramOption{
+		tea.WithInput(t),
+		tea.WithOutput(termenv.NewOutput(t, termenv.WithProfile(termenv.TrueColor))),
+		tea.WithoutSignals(),
+	}
+}
+
+// Attach starts forwarding the terminal's input and resizes to the program,
+// along with the text composed with input methods, as tea.CompositionMsg and
+// tea.CompositionEndMsg, and sends it the terminal's current size. Call
+// Detach once the program has exited to release the event handlers.
+func (t *Terminal) Attach(p *tea.Program) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	t.events = make(chan func(), eventBuffer)
+	go func(events <-chan func()) {
+		for handle := range events {
+			handle()
+		}
+	}(t.events)
+
+	t.on("onData", func(args []js.Value) {
+		_, _ = io.WriteString(t.out, args[0].String())
+	})
+	t.on("onBinary", func(args []js.Value) {
+		// Binary data comes as a string of one character per byte, as sent
+		// by some mouse reports.
+		s := args[0].String()
+		b := make([]byte, 0, len(s))
+		for _, c := range s {
+			b = append(b, byte(c))
+		}
+		_, _ = t.out.Write(b)
+	})
+	t.on("onResize", func(args []js.Value) {
+		p.Send(tea.WindowSizeMsg{
+			Width:  args[0].Get("cols").Int(),
+			Height: args[0].Get("rows").Int(),
+		})
+	})
+
+	// xterm.js composes text in its hidden textarea, showing it over the
+	// cursor, and sends it as data once it's committed.
+	if textarea := t.term.Get("textarea"); textarea.Truthy() {
+		t.listen(textarea, "compositionupdate", func(args []js.Value) {
+			p.Send(tea.CompositionMsg{Text: args[0].Get("data").String()})
+		})
+		t.listen(textarea, "compositionend", func(args []js.Value) {
+			p.Send(tea.CompositionEndMsg{Committed: args[0].Get("data").String()})
+		})
+	}
+
+	w, h := t.Size()
+	t.events <- func() { p.Send(tea.WindowSizeMsg{Width: w, Height: h}) }
+}
+
+// queue returns a callback queueing an event to be handled, so as not to
+// block the JavaScript event loop while the program is busy. t.mtx must be
+// held.
+func (t *Terminal) queue(fn func(args []js.Value)) js.Func {
+	events := t.events
+	return js.FuncOf(func(_ js.Value, args []js.Value) interface{} {
+		events <- func() { fn(args) }
+		return nil
+	})
+}
+
+// on registers an event handler on the terminal. t.mtx must be held.
+func (t *Terminal) on(event string, fn func(args []js.Value)) {
+	cb := t.queue(fn)
+	t.callbacks = append(t.callbacks, cb)
+	d := t.term.Call(event, cb)
+	t.disposers = append(t.disposers, func() { d.Call("dispose") })
+}
+
+// listen registers a DOM event listener on an element of the terminal. t.mtx
+// must be held.
+func (t *Terminal) listen(target js.Value, event string, fn func(args []js.Value)) {
+	cb := t.queue(fn)
+	t.callbacks = append(t.callbacks, cb)
+	target.Call("addEventListener", event, cb)
+	t.disposers = append(t.disposers, func() { target.Call("removeEventListener", event, cb) })
+}
+
+// Detach stops forwarding events to the program and releases the event
+// handlers. The terminal can't be used by another program once detached.
+func (t *Terminal) Detach() {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	for _, dispose := range t.disposers {
+		dispose()
+	}
+	for _, cb := range t.callbacks {
+		cb.Release()
+	}
+	t.disposers, t.callbacks = nil, nil
+	if t.events != nil {
+		close(t.events)
+		t.events = nil
+	}
+	_ = t.out.Close()
+}
+
+// Run runs a program for the given model in an xterm.js terminal, blocking
+// until it exits, and returns the final model.
+func Run(term js.Value, model tea.Model, opts ...tea.ProgramOption) (tea.Model, error) {
+	t := New(term)
+	p := tea.NewProgram(model, append(opts, t.ProgramOptions()...)...)
+	t.Attach(p)
+	defer t.Detach()
+	return p.Run()
+}
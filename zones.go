@@ -0,0 +1,216 @@
+package tea
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/bubbletea/input"
+)
+
+// Zone marks text of a view as a zone, identified by id, so that mouse events
+// over it report it with MouseEvent.InZone. This spares components working
+// out from coordinates what the mouse is over:
+//
+//	func (m model) View() string {
+//	    return tea.Zone("ok", "[ OK ]") + " " + tea.Zone("cancel", "[ Cancel ]")
+//	}
+//
+//	case tea.MouseMsg:
+//	    if msg.Action == tea.MouseActionPress && msg.InZone("ok") {
+//	        ...
+//	    }
+//
+// The markers Zone adds take up no cells, so views are laid out the same as
+// without them, and are removed by the program before rendering. A zone takes
+// up the smallest rectangle holding its text, which can span several lines.
+// Zones can be nested, and an id can be used more than once, by all of the
+// items of a list for instance.
+//
+// Zones are placed where they're rendered on the screen, which is where the
+// mouse reports them in the alt screen. Outside of it, the screen doesn't
+// start at the top of the view, so rows don't match.
+func Zone(id, s string) string {
+	n := zoneIDs.number(id)
+	return zoneMarker(zoneStart, n) + s + zoneMarker(zoneEnd, n)
+}
+
+// Zone markers are CSI sequences, which components measuring text leave out
+// like any other escape sequence:
+//
+//	CSI kind ; number z
+//
+// where kind is the start or the end of the zone and number identifies it.
+const (
+	zoneStart = 1
+	zoneEnd   = 2
+)
+
+func zoneMarker(kind, n int) string {
+	return "\x1b[" + strconv.Itoa(kind) + ";" + strconv.Itoa(n) + "z"
+}
+
+// zoneIDs numbers zone ids for their markers. Numbers are never reused, as
+// views made before a number was given out may still be rendered.
+var zoneIDs zoneNumbers
+
+type zoneNumbers struct {
+	mtx     sync.RWMutex
+	numbers map[string]int
+	ids     []string
+}
+
+// number returns the number of a zone id, giving one out if needed.
+func (z *zoneNumbers) number(id string) int {
+	z.mtx.RLock()
+	n, ok := z.numbers[id]
+	z.mtx.RUnlock()
+	if ok {
+		return n
+	}
+
+	z.mtx.Lock()
+	defer z.mtx.Unlock()
+	if n, ok := z.numbers[id]; ok {
+		return n
+	}
+	if z.numbers == nil {
+		z.numbers = map[string]int{}
+	}
+	n = len(z.ids)
+	z.numbers[id] = n
+	z.ids = append(z.ids, id)
+	return n
+}
+
+// id returns the zone id given a number, if any.
+func (z *zoneNumbers) id(n int) (string, bool) {
+	z.mtx.RLock()
+	defer z.mtx.RUnlock()
+	if n < 0 || n >= len(z.ids) {
+		return "", false
+	}
+	return z.ids[n], true
+}
+
+// used reports whether any zone was ever marked.
+func (z *zoneNumbers) used() bool {
+	z.mtx.RLock()
+	defer z.mtx.RUnlock()
+	return len(z.ids) > 0
+}
+
+// zoneRect is the rectangle a zone takes up in a frame, in cells, bounds
+// included.
+type zoneRect struct {
+	id             string
+	x0, y0, x1, y1 int
+}
+
+func (r zoneRect) contains(x, y int) bool {
+	return x >= r.x0 && x <= r.x1 && y >= r.y0 && y <= r.y1
+}
+
+// zoneMap is the zones of a frame.
+type zoneMap []zoneRect
+
+// scanZones removes the zone markers from a frame, and returns the frame
+// along with the zones marked in it. Zones left open at the end of the frame
+// end there.
+func scanZones(frame string) (string, zoneMap) {
+	if !zoneIDs.used() || !strings.Contains(frame, "z") {
+		return frame, nil
+	}
+
+	var (
+		b      strings.Builder
+		zm     zoneMap
+		open   = map[int]int{} // zone numbers to their index in zm
+		x, y   int
+		marked bool
+	)
+	b.Grow(len(frame))
+	for i := 0; i < len(frame); {
+		switch c := frame[i]; {
+		case c == '\x1b':
+			end, _ := sequenceEnd(frame, i)
+			if kind, n, ok := parseZoneMarker(frame[i:end]); ok {
+				marked = true
+				if kind == zoneStart {
+					if id, ok := zoneIDs.id(n); ok {
+						open[n] = len(zm)
+						zm = append(zm, zoneRect{id: id, x0: -1, y0: y, x1: -1, y1: y})
+					}
+				} else {
+					delete(open, n)
+				}
+			} else {
+				b.WriteString(frame[i:end])
+			}
+			i = end
+		case c == '\n':
+			b.WriteByte(c)
+			x, y = 0, y+1
+			i++
+		default:
+			cluster, w := firstGrapheme(frame[i:])
+			b.WriteString(cluster)
+			for _, j := range open {
+				r := &zm[j]
+				if r.x0 < 0 || x < r.x0 {
+					r.x0 = x
+				}
+				if x+w-1 > r.x1 {
+					r.x1 = x + w - 1
+				}
+				r.y1 = y
+			}
+			x += w
+			i += len(cluster)
+		}
+	}
+	if !marked {
+		return frame, nil
+	}
+
+	// Zones without any text take up no cells.
+	rects := zm[:0]
+	for _, r := range zm {
+		if r.x0 >= 0 {
+			rects = append(rects, r)
+		}
+	}
+	return b.String(), rects
+}
+
+// parseZoneMarker parses a zone marker, reporting whether the sequence is
+// one.
+func parseZoneMarker(seq string) (kind, n int, ok bool) {
+	if !strings.HasPrefix(seq, "\x1b[") || !strings.HasSuffix(seq, "z") {
+		return 0, 0, false
+	}
+	params := strings.SplitN(seq[2:len(seq)-1], ";", 2)
+	if len(params) != 2 {
+		return 0, 0, false
+	}
+	kind, err := strconv.Atoi(params[0])
+	if err != nil || (kind != zoneStart && kind != zoneEnd) {
+		return 0, 0, false
+	}
+	n, err = strconv.Atoi(params[1])
+	if err != nil {
+		return 0, 0, false
+	}
+	return kind, n, true
+}
+
+// hits returns the zones of a frame a position is in, innermost first.
+func (zm zoneMap) hits(x, y int) []input.Zone {
+	var hits []input.Zone
+	for i := len(zm) - 1; i >= 0; i-- {
+		if zm[i].contains(x, y) {
+			hits = append(hits, input.Zone{ID: zm[i].id, X: zm[i].x0, Y: zm[i].y0})
+		}
+	}
+	return hits
+}
@@ -0,0 +1,59 @@
+package tea
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestZoneWidth(t *testing.T) {
+	if w := StringWidth(Zone("a", "xy")); w != 2 {
+		t.Errorf("expected zone markers to take up no cells, got a width of %d", w)
+	}
+}
+
+func TestScanZones(t *testing.T) {
+	view := "ab " + Zone("ok", "[OK]") + "\n" +
+		Zone("list", "\x1b[1mone\x1b[0m\n"+Zone("item", "two")+"\nthree") + "\n" +
+		Zone("empty", "") + zoneMarker(zoneStart, zoneIDs.number("open")) + "日本"
+
+	frame, zm := scanZones(view)
+	if want := "ab [OK]\n\x1b[1mone\x1b[0m\ntwo\nthree\n日本"; frame != want {
+		t.Errorf("expected zone markers to be removed, got %q", frame)
+	}
+	want := zoneMap{
+		{id: "ok", x0: 3, y0: 0, x1: 6, y1: 0},
+		{id: "list", x0: 0, y0: 1, x1: 4, y1: 3},
+		{id: "item", x0: 0, y0: 2, x1: 2, y1: 2},
+		{id: "open", x0: 0, y0: 4, x1: 3, y1: 4},
+	}
+	if !reflect.DeepEqual(zm, want) {
+		t.Errorf("expected zones %+v, got %+v", want, zm)
+	}
+
+	if frame, zm := scanZones("plain\x1b[1mtext"); frame != "plain\x1b[1mtext" || zm != nil {
+		t.Errorf("expected frames without zones to be left alone, got %q and %+v", frame, zm)
+	}
+}
+
+func TestMouseEventZones(t *testing.T) {
+	_, zm := scanZones(Zone("list", "one\n"+Zone("item", "two")))
+
+	hits := zm.hits(1, 1)
+	m := MouseEvent{X: 1, Y: 1}.WithZones(hits)
+	if got := m.Zones(); !reflect.DeepEqual(got, []string{"item", "list"}) {
+		t.Errorf("expected the innermost zone first, got %v", got)
+	}
+	if !m.InZone("list") || m.InZone("other") {
+		t.Error("expected the mouse to be in the list only")
+	}
+	if x, y, ok := m.ZonePos("list"); !ok || x != 1 || y != 1 {
+		t.Errorf("expected the mouse at 1,1 in the list, got %d,%d", x, y)
+	}
+	if x, y, ok := m.ZonePos("item"); !ok || x != 1 || y != 0 {
+		t.Errorf("expected the mouse at 1,0 in the item, got %d,%d", x, y)
+	}
+
+	if hits := zm.hits(5, 0); len(hits) != 0 {
+		t.Errorf("expected no zones to be hit, got %+v", hits)
+	}
+}